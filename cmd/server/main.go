@@ -9,12 +9,21 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Embed the timezone database so user timezones resolve without system tzdata
+	_ "time/tzdata"
 
 	"github.com/boetro/odot/cmd/docs"
 	"github.com/boetro/odot/internal/api"
+	"github.com/boetro/odot/internal/busytime"
 	"github.com/boetro/odot/internal/config"
 	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/digest"
+	"github.com/boetro/odot/internal/jobs"
 	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/mailer"
+	"github.com/boetro/odot/internal/planning"
+	"github.com/boetro/odot/internal/reminders"
+	"github.com/boetro/odot/internal/safehttp"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -65,6 +74,57 @@ func main() {
 		}
 	}()
 
+	// Email goes out over SMTP when it is configured, and is otherwise
+	// dropped into a directory or only logged
+	var mail mailer.Mailer
+	switch {
+	case cfg.SMTPHost != "":
+		mail = mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+	case cfg.MailDropDir != "":
+		mail = mailer.NewFileDrop(cfg.MailDropDir, "odot@localhost")
+	default:
+		mail = mailer.NewLog(logger)
+	}
+
+	// Start the background job workers
+	workers := jobs.NewWorkerPool(pool, cfg.JobConcurrency, logger)
+	if err := jobs.RegisterCleanup(workers, queries); err != nil {
+		logger.Fatal("Failed to register jobs", "error", err)
+	}
+	digests := digest.NewSender(pool, mail, cfg.JWTSecret, cfg.PublicURL, logger)
+	if err := digests.Register(workers); err != nil {
+		logger.Fatal("Failed to register jobs", "error", err)
+	}
+	if err := planning.RegisterJobs(workers, pool, logger); err != nil {
+		logger.Fatal("Failed to register jobs", "error", err)
+	}
+	guard := safehttp.NewGuard(cfg.OutboundAllowlist)
+	if err := busytime.NewImporter(pool, guard, logger).Register(workers); err != nil {
+		logger.Fatal("Failed to register jobs", "error", err)
+	}
+	if err := workers.Start(ctx); err != nil {
+		logger.Fatal("Failed to start job workers", "error", err)
+	}
+
+	// Start the reminder scheduler
+	scheduler := reminders.NewScheduler(pool, cfg.ReminderInterval, logger,
+		reminders.NewInAppChannel(queries),
+		reminders.NewEmailChannel(mail),
+		reminders.NewWebhookChannel(guard),
+	)
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Run(schedulerCtx)
+	}()
+
 	// Wait for interrupt signal to gracefully shut down the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -72,6 +132,9 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	stopScheduler()
+	<-schedulerDone
+
 	// Create context with timeout for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -80,5 +143,13 @@ func main() {
 		logger.Fatal("Server forced to shutdown", "error", err)
 	}
 
+	// Let running jobs finish; any still running after the timeout are
+	// cancelled and retried later
+	jobsCtx, cancelJobs := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelJobs()
+	if err := workers.Shutdown(jobsCtx); err != nil {
+		logger.Error("Job workers did not drain in time", "error", err)
+	}
+
 	logger.Info("Server exited properly")
 }
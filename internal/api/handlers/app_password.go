@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/auth"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxAppPasswordName is the length of app_passwords.name
+const maxAppPasswordName = 255
+
+type AppPasswordHandler struct {
+	querier   db.Querier
+	publicURL string
+	logger    logger.Logger
+}
+
+// NewAppPasswordHandler returns an AppPasswordHandler. The CalDAV server URL
+// shown with new passwords points at publicURL.
+func NewAppPasswordHandler(querier db.Querier, publicURL string, logger logger.Logger) *AppPasswordHandler {
+	return &AppPasswordHandler{
+		querier:   querier,
+		publicURL: publicURL,
+		logger:    logger,
+	}
+}
+
+type CreateAppPasswordRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type AppPasswordResponse struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Username, Password and ServerURL are only returned when the password
+	// is created, since only its hash is kept
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	ServerURL string `json:"server_url,omitempty"`
+}
+
+func NewAppPasswordResponse(appPassword *db.AppPassword) *AppPasswordResponse {
+	return &AppPasswordResponse{
+		ID:         appPassword.AppPasswordID,
+		Name:       appPassword.Name,
+		LastUsedAt: timePtr(appPassword.LastUsedAt),
+		CreatedAt:  appPassword.CreatedAt.Time,
+	}
+}
+
+// ListAppPasswords lists the user's app passwords, without the passwords
+func (h *AppPasswordHandler) ListAppPasswords(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	appPasswords, err := db.QuerierFromContext(c.Request.Context(), h.querier).ListAppPasswords(c, userID)
+	if err != nil {
+		h.logger.Error("Failed to list app passwords", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	responses := make([]*AppPasswordResponse, len(appPasswords))
+	for i := range appPasswords {
+		responses[i] = NewAppPasswordResponse(&appPasswords[i])
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateAppPassword creates a password for a CalDAV client and returns it,
+// along with the username and server URL to use. This is the only time the
+// password is shown.
+func (h *AppPasswordHandler) CreateAppPassword(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CreateAppPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if len([]rune(name)) > maxAppPasswordName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at most 255 characters"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	user, err := querier.GetUser(ctx, userID)
+	if err != nil {
+		h.appPasswordError(c, err)
+		return
+	}
+	password, hash, err := auth.GenerateAppPassword()
+	if err != nil {
+		h.appPasswordError(c, err)
+		return
+	}
+	appPassword, err := querier.CreateAppPassword(ctx, db.CreateAppPasswordParams{
+		UserID:       userID,
+		Name:         name,
+		PasswordHash: hash,
+	})
+	if err != nil {
+		h.appPasswordError(c, err)
+		return
+	}
+
+	response := NewAppPasswordResponse(&appPassword)
+	response.Username = user.Email
+	response.Password = password
+	response.ServerURL = strings.TrimRight(h.publicURL, "/") + DAVPrefix + "/"
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeleteAppPassword revokes an app password
+func (h *AppPasswordHandler) DeleteAppPassword(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid app password ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	appPassword, err := querier.GetAppPassword(ctx, int32(id))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		h.appPasswordError(c, err)
+		return
+	}
+	if err != nil || appPassword.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "App password not found"})
+		return
+	}
+
+	if err := querier.DeleteAppPassword(ctx, appPassword.AppPasswordID); err != nil {
+		h.appPasswordError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AppPasswordHandler) appPasswordError(c *gin.Context, err error) {
+	h.logger.Error("App password request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
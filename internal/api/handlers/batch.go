@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	MaxBatchSize = 50
+	batchPath    = "/api/batch"
+	// authPath endpoints set cookies on the batch's own response, so they
+	// can't run as operations
+	authPath = "/api/auth"
+)
+
+type BatchHandler struct {
+	router *gin.Engine
+	pool   *pgxpool.Pool
+	logger logger.Logger
+}
+
+func NewBatchHandler(router *gin.Engine, pool *pgxpool.Pool, logger logger.Logger) *BatchHandler {
+	return &BatchHandler{
+		router: router,
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+type BatchOperation struct {
+	Method string          `json:"method" binding:"required"`
+	Path   string          `json:"path" binding:"required"`
+	Body   json.RawMessage `json:"body"`
+}
+
+type BatchRequest struct {
+	// Atomic runs every operation in one transaction and rolls all of them
+	// back as soon as one fails.
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations" binding:"required"`
+}
+
+type BatchOperationResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+type BatchResponse struct {
+	RolledBack bool                      `json:"rolled_back"`
+	Responses  []*BatchOperationResponse `json:"responses"`
+}
+
+var batchMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+func (h *BatchHandler) ExecuteBatch(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one operation is required"})
+		return
+	}
+	if len(req.Operations) > MaxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A batch may contain at most %d operations", MaxBatchSize)})
+		return
+	}
+
+	for i := range req.Operations {
+		op := &req.Operations[i]
+		op.Method = strings.ToUpper(op.Method)
+		if !batchMethods[op.Method] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("operations[%d]: unsupported method %s", i, op.Method)})
+			return
+		}
+		target, ok := batchTarget(op.Path)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("operations[%d]: path must be an API endpoint other than %s and %s", i, batchPath, authPath)})
+			return
+		}
+		op.Path = target
+	}
+
+	if !req.Atomic {
+		responses := make([]*BatchOperationResponse, len(req.Operations))
+		for i, op := range req.Operations {
+			responses[i] = h.dispatch(c.Request.Context(), c, op)
+		}
+		c.JSON(http.StatusOK, BatchResponse{Responses: responses})
+		return
+	}
+
+	tx, err := h.pool.Begin(c)
+	if err != nil {
+		h.logger.Error("Failed to begin batch transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	ctx := db.ContextWithTx(c.Request.Context(), tx)
+	responses := make([]*BatchOperationResponse, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		response := h.dispatch(ctx, c, op)
+		responses = append(responses, response)
+		if response.Status >= http.StatusBadRequest {
+			c.JSON(http.StatusOK, BatchResponse{RolledBack: true, Responses: responses})
+			return
+		}
+	}
+
+	if err := tx.Commit(c); err != nil {
+		h.logger.Error("Failed to commit batch transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, BatchResponse{Responses: responses})
+}
+
+// batchTarget cleans an operation's path, returning false if it isn't an API
+// endpoint that may be batched. Only the query is kept besides the path, so
+// "/api//batch" or "/api/./auth/logout" can't slip past the checks.
+func batchTarget(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Opaque != "" || u.Fragment != "" || !strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+	cleaned := path.Clean(u.Path)
+	if !strings.HasPrefix(cleaned, "/api/") {
+		return "", false
+	}
+	for _, denied := range []string{batchPath, authPath} {
+		if cleaned == denied || strings.HasPrefix(cleaned, denied+"/") {
+			return "", false
+		}
+	}
+	return (&url.URL{Path: cleaned, RawQuery: u.RawQuery}).String(), true
+}
+
+// dispatch runs a single operation through the router with the caller's
+// credentials so it is authenticated and authorized like a normal request.
+func (h *BatchHandler) dispatch(ctx context.Context, c *gin.Context, op BatchOperation) *BatchOperationResponse {
+	sub, err := http.NewRequestWithContext(ctx, op.Method, op.Path, bytes.NewReader(op.Body))
+	if err != nil {
+		return &BatchOperationResponse{
+			Status: http.StatusBadRequest,
+			Body:   errorBody(err.Error()),
+		}
+	}
+
+	sub.Header.Set("Content-Type", "application/json")
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		sub.Header.Set("Authorization", authHeader)
+	}
+	for _, cookie := range c.Request.Cookies() {
+		sub.AddCookie(cookie)
+	}
+	sub.RemoteAddr = c.Request.RemoteAddr
+
+	recorder := newBatchRecorder()
+	h.router.ServeHTTP(recorder, sub)
+
+	body := recorder.body.Bytes()
+	if len(body) > 0 && !json.Valid(body) {
+		body, _ = json.Marshal(string(body))
+	}
+
+	return &BatchOperationResponse{
+		Status: recorder.status,
+		Body:   body,
+	}
+}
+
+func errorBody(message string) json.RawMessage {
+	body, _ := json.Marshal(gin.H{"error": message})
+	return body
+}
+
+// batchRecorder is an in-memory http.ResponseWriter for sub-requests
+type batchRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{
+		header: http.Header{},
+		status: http.StatusOK,
+	}
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	r.status = status
+}
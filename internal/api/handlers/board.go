@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Status categories. Todos in a done status are completed, and todos in the
+// others are open.
+const (
+	StatusCategoryTodo  = "todo"
+	StatusCategoryDoing = "doing"
+	StatusCategoryDone  = "done"
+)
+
+const (
+	// maxStatusName is the length of project_statuses.name
+	maxStatusName = 100
+	// MaxProjectStatuses caps the statuses of one project
+	MaxProjectStatuses = 50
+	// maxBoardColumnTodos caps the todos listed in one board column
+	maxBoardColumnTodos = 200
+)
+
+type BoardHandler struct {
+	querier db.Querier
+	pool    *pgxpool.Pool
+	logger  logger.Logger
+}
+
+func NewBoardHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *BoardHandler {
+	return &BoardHandler{
+		querier: querier,
+		pool:    pool,
+		logger:  logger,
+	}
+}
+
+type ProjectStatusRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Category is todo, doing or done
+	Category string `json:"category" binding:"required"`
+	// WIPLimit caps how many todos the status holds. Leave it out, or set it
+	// to 0, for no limit.
+	WIPLimit *int32 `json:"wip_limit"`
+}
+
+type ReorderProjectStatusesRequest struct {
+	// StatusIDs lists every status of the project in its new order
+	StatusIDs []int32 `json:"status_ids" binding:"required"`
+}
+
+type ProjectStatusResponse struct {
+	ID        int32     `json:"id"`
+	ProjectID int32     `json:"project_id"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`
+	Position  int32     `json:"position"`
+	WIPLimit  *int32    `json:"wip_limit"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewProjectStatusResponse(status *db.ProjectStatus) *ProjectStatusResponse {
+	return &ProjectStatusResponse{
+		ID:        status.StatusID,
+		ProjectID: status.ProjectID,
+		Name:      status.Name,
+		Category:  status.Category,
+		Position:  status.Position,
+		WIPLimit:  int4Ptr(status.WipLimit),
+		CreatedAt: status.CreatedAt.Time,
+		UpdatedAt: status.UpdatedAt.Time,
+	}
+}
+
+// BoardColumn is a status with the todos in it
+type BoardColumn struct {
+	*ProjectStatusResponse
+	// Count is how many todos are in the status, which can be more than are
+	// listed
+	Count     int             `json:"count"`
+	OverLimit bool            `json:"over_limit"`
+	Todos     []*TodoResponse `json:"todos"`
+}
+
+type BoardResponse struct {
+	ProjectID int32          `json:"project_id"`
+	Columns   []*BoardColumn `json:"columns"`
+	// Unsorted are the todos without a status, because the project has no
+	// done status for completed todos or no other status for open ones
+	Unsorted []*TodoResponse `json:"unsorted"`
+}
+
+// ListProjectStatuses lists a project's statuses in board order
+func (h *BoardHandler) ListProjectStatuses(c *gin.Context) {
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		return projectStatuses(ctx, querier, project.ProjectID)
+	})
+}
+
+// CreateProjectStatus adds a status to the end of a project's board. Todos
+// that had no status because the project had none of the right category
+// move into it.
+func (h *BoardHandler) CreateProjectStatus(c *gin.Context) {
+	var req ProjectStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		params, err := validateProjectStatus(&req)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := querier.ListProjectStatuses(ctx, project.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) >= MaxProjectStatuses {
+			return nil, &requestError{http.StatusBadRequest, "A project can have at most " + strconv.Itoa(MaxProjectStatuses) + " statuses"}
+		}
+
+		status, err := querier.CreateProjectStatus(ctx, db.CreateProjectStatusParams{
+			ProjectID: project.ProjectID,
+			UserID:    project.UserID,
+			Name:      params.Name,
+			Category:  params.Category,
+			WipLimit:  params.WipLimit,
+		})
+		if err != nil {
+			return nil, statusNameError(err)
+		}
+		if _, err := querier.ResyncProjectTodoStatuses(ctx, pgtype.Int4{Int32: project.ProjectID, Valid: true}); err != nil {
+			return nil, err
+		}
+		return NewProjectStatusResponse(&status), nil
+	})
+}
+
+// UpdateProjectStatus renames a status or changes its category or WIP limit.
+// A status holding todos can't change between done and not done, since that
+// would complete or reopen them.
+func (h *BoardHandler) UpdateProjectStatus(c *gin.Context) {
+	var req ProjectStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		status, err := projectStatus(ctx, querier, project, c.Param("status_id"))
+		if err != nil {
+			return nil, err
+		}
+		params, err := validateProjectStatus(&req)
+		if err != nil {
+			return nil, err
+		}
+		if (params.Category == StatusCategoryDone) != (status.Category == StatusCategoryDone) {
+			count, err := querier.CountStatusTodos(ctx, pgtype.Int4{Int32: status.StatusID, Valid: true})
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, &requestError{http.StatusConflict, "Move the todos out of the status before changing whether it is done"}
+			}
+		}
+
+		params.StatusID = status.StatusID
+		updated, err := querier.UpdateProjectStatus(ctx, *params)
+		if err != nil {
+			return nil, statusNameError(err)
+		}
+		if _, err := querier.ResyncProjectTodoStatuses(ctx, pgtype.Int4{Int32: project.ProjectID, Valid: true}); err != nil {
+			return nil, err
+		}
+		return NewProjectStatusResponse(&updated), nil
+	})
+}
+
+// ReorderProjectStatuses sets the order of a project's board columns
+func (h *BoardHandler) ReorderProjectStatuses(c *gin.Context) {
+	var req ReorderProjectStatusesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		statuses, err := querier.ListProjectStatuses(ctx, project.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		ids := uniqueIDs(req.StatusIDs)
+		known := make(map[int32]bool, len(statuses))
+		for _, status := range statuses {
+			known[status.StatusID] = true
+		}
+		valid := len(ids) == len(req.StatusIDs) && len(ids) == len(statuses)
+		for _, id := range ids {
+			valid = valid && known[id]
+		}
+		if !valid {
+			return nil, &requestError{http.StatusBadRequest, "status_ids must list each of the project's statuses once"}
+		}
+
+		for i, id := range ids {
+			err := querier.SetProjectStatusPosition(ctx, db.SetProjectStatusPositionParams{
+				StatusID: id,
+				Position: int32(i),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return projectStatuses(ctx, querier, project.ProjectID)
+	})
+}
+
+// DeleteProjectStatus removes a status. Its todos move to the status in the
+// move_to query parameter, which must be done if the deleted one was and not
+// done if it wasn't, or otherwise to the first status that fits.
+func (h *BoardHandler) DeleteProjectStatus(c *gin.Context) {
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		status, err := projectStatus(ctx, querier, project, c.Param("status_id"))
+		if err != nil {
+			return nil, err
+		}
+		if param := c.Query("move_to"); param != "" {
+			target, err := projectStatus(ctx, querier, project, param)
+			if err != nil {
+				return nil, err
+			}
+			if target.StatusID == status.StatusID {
+				return nil, &requestError{http.StatusBadRequest, "move_to must be another status"}
+			}
+			if (target.Category == StatusCategoryDone) != (status.Category == StatusCategoryDone) {
+				return nil, &requestError{http.StatusBadRequest, "move_to must be done if the deleted status is, and not done if it isn't"}
+			}
+			_, err = querier.MoveStatusTodos(ctx, db.MoveStatusTodosParams{
+				ToStatusID:   pgtype.Int4{Int32: target.StatusID, Valid: true},
+				FromStatusID: pgtype.Int4{Int32: status.StatusID, Valid: true},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The todos left in the status lose it, and the sync_todos_status
+		// trigger gives them the first status that fits
+		if err := querier.DeleteProjectStatus(ctx, status.StatusID); err != nil {
+			return nil, err
+		}
+		return projectStatuses(ctx, querier, project.ProjectID)
+	})
+}
+
+// GetBoard returns a project's todos grouped by status, in board order. Open
+// columns list todos in their manual order, and done columns the most
+// recently completed first.
+func (h *BoardHandler) GetBoard(c *gin.Context) {
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		statuses, err := querier.ListProjectStatuses(ctx, project.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		todos, err := querier.ListProjectTodos(ctx, pgtype.Int4{Int32: project.ProjectID, Valid: true})
+		if err != nil {
+			return nil, err
+		}
+
+		response := &BoardResponse{
+			ProjectID: project.ProjectID,
+			Columns:   make([]*BoardColumn, len(statuses)),
+			Unsorted:  []*TodoResponse{},
+		}
+		columns := make(map[int32]*BoardColumn, len(statuses))
+		for i := range statuses {
+			response.Columns[i] = &BoardColumn{
+				ProjectStatusResponse: NewProjectStatusResponse(&statuses[i]),
+				Todos:                 []*TodoResponse{},
+			}
+			columns[statuses[i].StatusID] = response.Columns[i]
+		}
+		var listed []*TodoResponse
+		for i := range todos {
+			todo := NewTodoResponse(&todos[i])
+			column, ok := columns[todos[i].StatusID.Int32]
+			if !todos[i].StatusID.Valid || !ok {
+				response.Unsorted = append(response.Unsorted, todo)
+				listed = append(listed, todo)
+				continue
+			}
+			column.Count++
+			column.Todos = append(column.Todos, todo)
+		}
+		for _, column := range response.Columns {
+			if column.Category == StatusCategoryDone {
+				sort.SliceStable(column.Todos, func(i, j int) bool {
+					return column.Todos[i].CompletedAt != nil && column.Todos[j].CompletedAt != nil &&
+						column.Todos[i].CompletedAt.After(*column.Todos[j].CompletedAt)
+				})
+			}
+			if len(column.Todos) > maxBoardColumnTodos {
+				column.Todos = column.Todos[:maxBoardColumnTodos]
+			}
+			column.OverLimit = column.WIPLimit != nil && column.Count > int(*column.WIPLimit)
+			listed = append(listed, column.Todos...)
+		}
+		if err := fillDependencies(ctx, querier, listed); err != nil {
+			return nil, err
+		}
+		return response, nil
+	})
+}
+
+// withProject loads the project named by the :id parameter and runs fn on it
+// in a transaction, responding with what fn returns
+func (h *BoardHandler) withProject(c *gin.Context, fn func(ctx context.Context, querier db.Querier, project *db.Project) (any, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.boardError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	var response any
+	project, err := ownedProject(ctx, querier, userID, int32(projectID))
+	if err == nil {
+		response, err = fn(ctx, querier, project)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.boardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *BoardHandler) boardError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Board request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+// ownedProject loads a project, returning a 404 requestError if it doesn't
+// exist or belongs to someone else
+func ownedProject(ctx context.Context, querier db.Querier, userID int32, projectID int32) (*db.Project, error) {
+	project, err := querier.GetProject(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &requestError{http.StatusNotFound, "Project not found"}
+		}
+		return nil, err
+	}
+	if project.UserID != userID {
+		return nil, &requestError{http.StatusNotFound, "Project not found"}
+	}
+	return &project, nil
+}
+
+// projectStatus loads the status with the ID in param, which must belong to
+// project
+func projectStatus(ctx context.Context, querier db.Querier, project *db.Project, param string) (*db.ProjectStatus, error) {
+	id, err := strconv.ParseInt(param, 10, 32)
+	if err != nil {
+		return nil, &requestError{http.StatusBadRequest, "Invalid status ID"}
+	}
+	status, err := querier.GetProjectStatus(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &requestError{http.StatusNotFound, "Status not found"}
+		}
+		return nil, err
+	}
+	if status.ProjectID != project.ProjectID {
+		return nil, &requestError{http.StatusNotFound, "Status not found"}
+	}
+	return &status, nil
+}
+
+func projectStatuses(ctx context.Context, querier db.Querier, projectID int32) ([]*ProjectStatusResponse, error) {
+	statuses, err := querier.ListProjectStatuses(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*ProjectStatusResponse, len(statuses))
+	for i := range statuses {
+		responses[i] = NewProjectStatusResponse(&statuses[i])
+	}
+	return responses, nil
+}
+
+// validateProjectStatus checks req, returning it as the parameters of
+// UpdateProjectStatus
+func validateProjectStatus(req *ProjectStatusRequest) (*db.UpdateProjectStatusParams, error) {
+	params := &db.UpdateProjectStatusParams{
+		Name:     strings.TrimSpace(req.Name),
+		Category: req.Category,
+	}
+	switch {
+	case params.Name == "":
+		return nil, &requestError{http.StatusBadRequest, "name is required"}
+	case len([]rune(params.Name)) > maxStatusName:
+		return nil, &requestError{http.StatusBadRequest, "name must be at most 100 characters"}
+	}
+	switch req.Category {
+	case StatusCategoryTodo, StatusCategoryDoing, StatusCategoryDone:
+	default:
+		return nil, &requestError{http.StatusBadRequest, "category must be todo, doing or done"}
+	}
+	if req.WIPLimit != nil {
+		if *req.WIPLimit < 0 {
+			return nil, &requestError{http.StatusBadRequest, "wip_limit can't be negative"}
+		}
+		params.WipLimit = pgtype.Int4{Int32: *req.WIPLimit, Valid: *req.WIPLimit > 0}
+	}
+	return params, nil
+}
+
+// statusNameError turns a clash with another status's name into a 409
+func statusNameError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return &requestError{http.StatusConflict, "The project already has a status with that name"}
+	}
+	return err
+}
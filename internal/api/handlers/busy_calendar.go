@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/busytime"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/safehttp"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxBusyCalendarName is the length of busy_calendars.name
+const maxBusyCalendarName = 255
+
+type BusyCalendarHandler struct {
+	querier  db.Querier
+	pool     *pgxpool.Pool
+	importer *busytime.Importer
+	logger   logger.Logger
+}
+
+func NewBusyCalendarHandler(querier db.Querier, pool *pgxpool.Pool, importer *busytime.Importer, logger logger.Logger) *BusyCalendarHandler {
+	return &BusyCalendarHandler{
+		querier:  querier,
+		pool:     pool,
+		importer: importer,
+		logger:   logger,
+	}
+}
+
+// CreateBusyCalendarRequest subscribes to the ICS file at URL. Name defaults
+// to the calendar's own name.
+type CreateBusyCalendarRequest struct {
+	Name string `json:"name"`
+	URL  string `json:"url" binding:"required"`
+}
+
+type UpdateBusyCalendarRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type BusyCalendarResponse struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	// URL is null for uploaded files
+	URL        *string `json:"url"`
+	EventCount int32   `json:"event_count"`
+	// SkippedCount is how many events couldn't be read, such as ones that
+	// repeat in ways that aren't supported
+	SkippedCount int32      `json:"skipped_count"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    *string    `json:"last_error"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func NewBusyCalendarResponse(calendar *db.BusyCalendar) *BusyCalendarResponse {
+	response := &BusyCalendarResponse{
+		ID:           calendar.CalendarID,
+		Name:         calendar.Name,
+		EventCount:   calendar.EventCount,
+		SkippedCount: calendar.SkippedCount,
+		LastSyncedAt: timePtr(calendar.LastSyncedAt),
+		CreatedAt:    calendar.CreatedAt.Time,
+	}
+	if calendar.Url.Valid {
+		response.URL = &calendar.Url.String
+	}
+	if calendar.LastError.Valid {
+		response.LastError = &calendar.LastError.String
+	}
+	return response
+}
+
+// ListBusyCalendars lists the user's busy calendars
+func (h *BusyCalendarHandler) ListBusyCalendars(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	calendars, err := db.QuerierFromContext(c.Request.Context(), h.querier).ListBusyCalendars(c, userID)
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+
+	responses := make([]*BusyCalendarResponse, len(calendars))
+	for i := range calendars {
+		responses[i] = NewBusyCalendarResponse(&calendars[i])
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateBusyCalendar subscribes to an ICS URL. The calendar is downloaded
+// straight away, and again every hour.
+func (h *BusyCalendarHandler) CreateBusyCalendar(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CreateBusyCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	calendarURL, err := busytime.NormalizeURL(req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	download, err := h.importer.Fetch(ctx, calendarURL)
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+	name := req.Name
+	if strings.TrimSpace(name) == "" {
+		var host string
+		if u, err := url.Parse(calendarURL); err == nil {
+			host = u.Hostname()
+		}
+		name = defaultBusyCalendarName([]byte(download.Data), host)
+	}
+
+	calendar, err := h.create(ctx, userID, name, pgtype.Text{String: calendarURL, Valid: true}, download)
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, NewBusyCalendarResponse(calendar))
+}
+
+// UploadBusyCalendar imports an ICS file sent as the multipart form field
+// "file", with an optional "name" field
+func (h *BusyCalendarHandler) UploadBusyCalendar(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An ICS file is required in the file field"})
+		return
+	}
+	if header.Size > busytime.MaxCalendarSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "The file is larger than 5 MB"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, busytime.MaxCalendarSize))
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+
+	name := c.PostForm("name")
+	if strings.TrimSpace(name) == "" {
+		name = defaultBusyCalendarName(data, strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename)))
+	}
+
+	calendar, err := h.create(c.Request.Context(), userID, name, pgtype.Text{}, &busytime.Download{Data: string(data)})
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, NewBusyCalendarResponse(calendar))
+}
+
+// UpdateBusyCalendar renames a busy calendar
+func (h *BusyCalendarHandler) UpdateBusyCalendar(c *gin.Context) {
+	var req UpdateBusyCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, err := busyCalendarName(req.Name)
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+
+	calendar, ok := h.ownedCalendar(c)
+	if !ok {
+		return
+	}
+	renamed, err := db.QuerierFromContext(c.Request.Context(), h.querier).RenameBusyCalendar(c, db.RenameBusyCalendarParams{
+		CalendarID: calendar.CalendarID,
+		Name:       name,
+	})
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, NewBusyCalendarResponse(&renamed))
+}
+
+// RefreshBusyCalendar downloads a calendar URL again, or expands an uploaded
+// file again, without waiting for the next hourly refresh
+func (h *BusyCalendarHandler) RefreshBusyCalendar(c *gin.Context) {
+	calendar, ok := h.ownedCalendar(c)
+	if !ok {
+		return
+	}
+
+	synced, err := h.importer.Sync(c.Request.Context(), calendar.CalendarID, time.Now())
+	if err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, NewBusyCalendarResponse(synced))
+}
+
+// DeleteBusyCalendar removes a calendar along with its busy blocks
+func (h *BusyCalendarHandler) DeleteBusyCalendar(c *gin.Context) {
+	calendar, ok := h.ownedCalendar(c)
+	if !ok {
+		return
+	}
+
+	if err := db.QuerierFromContext(c.Request.Context(), h.querier).DeleteBusyCalendar(c, calendar.CalendarID); err != nil {
+		h.busyCalendarError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// create adds a calendar holding download and stores its busy blocks, in one
+// transaction so a file that can't be read leaves nothing behind
+func (h *BusyCalendarHandler) create(ctx context.Context, userID int32, name string, calendarURL pgtype.Text, download *busytime.Download) (*db.BusyCalendar, error) {
+	name, err := busyCalendarName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	calendar, err := querier.CreateBusyCalendar(ctx, db.CreateBusyCalendarParams{
+		UserID: userID,
+		Name:   name,
+		Url:    calendarURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	synced, err := busytime.Store(ctx, querier, &calendar, download, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return synced, nil
+}
+
+// ownedCalendar loads the calendar named by the :id parameter, responding
+// with an error if it doesn't exist or belongs to someone else
+func (h *BusyCalendarHandler) ownedCalendar(c *gin.Context) (*db.BusyCalendar, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar ID"})
+		return nil, false
+	}
+
+	calendar, err := db.QuerierFromContext(c.Request.Context(), h.querier).GetBusyCalendar(c, int32(id))
+	if err == nil && calendar.UserID != userID {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+		} else {
+			h.busyCalendarError(c, err)
+		}
+		return nil, false
+	}
+	return &calendar, true
+}
+
+// defaultBusyCalendarName is the name a calendar gives itself, or fallback,
+// cut to fit
+func defaultBusyCalendarName(data []byte, fallback string) string {
+	name := busytime.CalendarName(data)
+	if name == "" {
+		name = strings.TrimSpace(fallback)
+	}
+	if runes := []rune(name); len(runes) > maxBusyCalendarName {
+		name = string(runes[:maxBusyCalendarName])
+	}
+	return name
+}
+
+// busyCalendarName trims a calendar name and checks its length
+func busyCalendarName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", &requestError{http.StatusBadRequest, "name is required"}
+	case len([]rune(name)) > maxBusyCalendarName:
+		return "", &requestError{http.StatusBadRequest, "name must be at most 255 characters"}
+	}
+	return name, nil
+}
+
+func (h *BusyCalendarHandler) busyCalendarError(c *gin.Context, err error) {
+	var reqErr *requestError
+	var fetchErr *busytime.FetchError
+	switch {
+	case errors.As(err, &reqErr):
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+	case errors.Is(err, safehttp.ErrForbiddenAddress):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must point at a public host"})
+	case errors.As(err, &fetchErr):
+		// The reason stays in the logs, so the server can't be used to
+		// probe what it can reach
+		h.logger.Debug("Busy calendar download failed", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Couldn't fetch the calendar"})
+	case errors.Is(err, busytime.ErrInvalidCalendar):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Busy calendar request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+	}
+}
@@ -0,0 +1,1186 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/caldav"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/ical"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DAVPrefix is where the CalDAV server is mounted
+	DAVPrefix = "/dav"
+	// DAVAllow lists the methods the CalDAV server supports
+	DAVAllow = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, PROPPATCH, REPORT"
+	// inboxCollection is the calendar of todos without a project
+	inboxCollection = "inbox"
+	davContentType  = "text/calendar; charset=utf-8; component=VTODO"
+	// maxDAVBody bounds the size of a PUT or REPORT body
+	maxDAVBody = 1 << 20
+	// maxTagNameLength is the length of tags.name
+	maxTagNameLength = 100
+	// maxParentDepth bounds the walk up a todo's parents looking for a cycle
+	maxParentDepth = 1000
+)
+
+var davDefaultName = regexp.MustCompile(`^todo-(\d+)\.ics$`)
+
+var (
+	davRootHref      = DAVPrefix + "/"
+	davPrincipalHref = DAVPrefix + "/principal/"
+	davHomeHref      = DAVPrefix + "/calendars/"
+)
+
+// CalDAVHandler serves projects as calendars of VTODOs, plus an inbox
+// calendar for todos without a project, to clients such as Apple Reminders,
+// Thunderbird and DAVx5. Each todo is a resource named todo-<id>.ics, or
+// whatever name the client that created it chose.
+type CalDAVHandler struct {
+	querier   db.Querier
+	pool      *pgxpool.Pool
+	publicURL string
+	logger    logger.Logger
+}
+
+// NewCalDAVHandler returns a CalDAVHandler. The UIDs of todos are derived
+// from publicURL.
+func NewCalDAVHandler(querier db.Querier, pool *pgxpool.Pool, publicURL string, logger logger.Logger) *CalDAVHandler {
+	return &CalDAVHandler{
+		querier:   querier,
+		pool:      pool,
+		publicURL: publicURL,
+		logger:    logger,
+	}
+}
+
+type davKind int
+
+const (
+	kindRoot davKind = iota
+	kindPrincipal
+	kindHome
+	kindCalendar
+	kindObject
+)
+
+// davResource is what a path below DAVPrefix names
+type davResource struct {
+	kind       davKind
+	collection *davCollection
+	// name is the resource name of an object
+	name string
+}
+
+// davCollection is a calendar, either a project or the inbox
+type davCollection struct {
+	segment   string
+	projectID pgtype.Int4
+	name      string
+	color     pgtype.Text
+}
+
+func (c *davCollection) href() string {
+	return davHomeHref + c.segment + "/"
+}
+
+func (c *davCollection) objectHref(name string) string {
+	return c.href() + url.PathEscape(name)
+}
+
+func (c *davCollection) contains(todo *db.Todo) bool {
+	return todo.ProjectID == c.projectID
+}
+
+// davObject is a todo rendered as a calendar object resource
+type davObject struct {
+	todo *db.Todo
+	name string
+	uid  string
+	data []byte
+	etag string
+}
+
+// davPrecondition is a failed WebDAV or CalDAV precondition, reported in a
+// DAV:error body
+type davPrecondition struct {
+	status    int
+	condition xml.Name
+	inner     string
+}
+
+func (e *davPrecondition) Error() string {
+	return e.condition.Local
+}
+
+// Options advertises CalDAV support. It doesn't need authentication, since
+// clients use it to find out what the server is.
+func (h *CalDAVHandler) Options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", DAVAllow)
+	c.Status(http.StatusOK)
+}
+
+// WellKnown points clients looking up /.well-known/caldav at the principal
+func (h *CalDAVHandler) WellKnown(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, davPrincipalHref)
+}
+
+// Propfind lists the properties of a resource, and at depth 1 those of its
+// children
+func (h *CalDAVHandler) Propfind(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	root, err := caldav.ParseXML(io.LimitReader(c.Request.Body, maxDAVBody))
+	if err != nil || (root != nil && root.Name != caldav.DAV("propfind")) {
+		c.String(http.StatusBadRequest, "Invalid PROPFIND body")
+		return
+	}
+	req := caldav.ParsePropRequest(root)
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	res, err := h.resolve(ctx, querier, userID, c.Param("path"))
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+
+	depth := caldav.Depth(c.Request)
+	ms := &caldav.Multistatus{}
+	switch res.kind {
+	case kindRoot:
+		ms.Responses = append(ms.Responses, caldav.NewResponse(davRootHref, req, caldav.Props{
+			caldav.DAV("resourcetype"):           caldav.Elem(caldav.DAV("collection"), ""),
+			caldav.DAV("current-user-principal"): caldav.Href(davPrincipalHref),
+		}))
+	case kindPrincipal:
+		user, err := querier.GetUser(ctx, userID)
+		if err != nil {
+			h.davError(c, err)
+			return
+		}
+		ms.Responses = append(ms.Responses, caldav.NewResponse(davPrincipalHref, req, principalProps(&user)))
+	case kindHome:
+		ms.Responses = append(ms.Responses, caldav.NewResponse(davHomeHref, req, caldav.Props{
+			caldav.DAV("resourcetype"):           caldav.Elem(caldav.DAV("collection"), ""),
+			caldav.DAV("current-user-principal"): caldav.Href(davPrincipalHref),
+			caldav.DAV("owner"):                  caldav.Href(davPrincipalHref),
+		}))
+		if depth > 0 {
+			collections, err := h.collections(ctx, querier, userID)
+			if err != nil {
+				h.davError(c, err)
+				return
+			}
+			for _, collection := range collections {
+				props, err := collectionProps(ctx, querier, userID, collection)
+				if err != nil {
+					h.davError(c, err)
+					return
+				}
+				ms.Responses = append(ms.Responses, caldav.NewResponse(collection.href(), req, props))
+			}
+		}
+	case kindCalendar:
+		props, err := collectionProps(ctx, querier, userID, res.collection)
+		if err != nil {
+			h.davError(c, err)
+			return
+		}
+		ms.Responses = append(ms.Responses, caldav.NewResponse(res.collection.href(), req, props))
+		if depth > 0 {
+			todos, err := querier.ListCollectionTodos(ctx, db.ListCollectionTodosParams{
+				UserID:    userID,
+				ProjectID: res.collection.projectID,
+			})
+			if err != nil {
+				h.davError(c, err)
+				return
+			}
+			objects, err := h.objects(ctx, querier, userID, todos)
+			if err != nil {
+				h.davError(c, err)
+				return
+			}
+			for _, object := range objects {
+				ms.Responses = append(ms.Responses, objectResponse(res.collection, object, req))
+			}
+		}
+	case kindObject:
+		object, err := h.findObject(ctx, querier, userID, res.collection, res.name)
+		if err != nil {
+			h.davError(c, err)
+			return
+		}
+		ms.Responses = append(ms.Responses, objectResponse(res.collection, object, req))
+	}
+	ms.Write(c.Writer)
+}
+
+// Proppatch refuses to change properties, which are all derived from
+// projects and todos
+func (h *CalDAVHandler) Proppatch(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	root, err := caldav.ParseXML(io.LimitReader(c.Request.Body, maxDAVBody))
+	if err != nil || root == nil || root.Name != caldav.DAV("propertyupdate") {
+		c.String(http.StatusBadRequest, "Invalid PROPPATCH body")
+		return
+	}
+
+	ctx := c.Request.Context()
+	res, err := h.resolve(ctx, db.QuerierFromContext(ctx, h.querier), userID, c.Param("path"))
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+
+	response := &caldav.Response{Href: DAVPrefix + c.Param("path")}
+	if res.collection != nil && res.kind == kindCalendar {
+		response.Href = res.collection.href()
+	}
+	for _, update := range root.Children {
+		for _, prop := range update.Children {
+			if prop.Name != caldav.DAV("prop") {
+				continue
+			}
+			for _, name := range prop.Children {
+				response.Denied = append(response.Denied, name.Name)
+			}
+		}
+	}
+	(&caldav.Multistatus{Responses: []*caldav.Response{response}}).Write(c.Writer)
+}
+
+// Report answers the calendar-multiget, calendar-query and sync-collection
+// reports on a calendar
+func (h *CalDAVHandler) Report(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	root, err := caldav.ParseXML(io.LimitReader(c.Request.Body, maxDAVBody))
+	if err != nil || root == nil {
+		c.String(http.StatusBadRequest, "Invalid REPORT body")
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	res, err := h.resolve(ctx, querier, userID, c.Param("path"))
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	if res.kind != kindCalendar {
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.DAV("supported-report"), "")
+		return
+	}
+
+	var ms *caldav.Multistatus
+	switch root.Name {
+	case caldav.CalDAV("calendar-multiget"):
+		ms, err = h.multiget(ctx, querier, userID, res.collection, root)
+	case caldav.CalDAV("calendar-query"):
+		ms, err = h.calendarQuery(ctx, querier, userID, res.collection, root)
+	case caldav.DAV("sync-collection"):
+		ms, err = h.syncCollection(ctx, querier, userID, res.collection, root)
+	default:
+		err = &davPrecondition{http.StatusForbidden, caldav.DAV("supported-report"), ""}
+	}
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	ms.Write(c.Writer)
+}
+
+// Get returns a todo as iCalendar data
+func (h *CalDAVHandler) Get(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	res, err := h.resolve(ctx, querier, userID, c.Param("path"))
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	if res.kind != kindObject {
+		c.Header("Allow", DAVAllow)
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+
+	object, err := h.findObject(ctx, querier, userID, res.collection, res.name)
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	c.Header("ETag", object.etag)
+	c.Header("Last-Modified", object.todo.UpdatedAt.Time.UTC().Format(http.TimeFormat))
+	c.Data(http.StatusOK, davContentType, object.data)
+}
+
+// Put creates or replaces a todo from a VTODO. Other components, and
+// overrides of single occurrences, are ignored.
+func (h *CalDAVHandler) Put(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDAVBody+1))
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	if len(body) > maxDAVBody {
+		c.Status(http.StatusRequestEntityTooLarge)
+		return
+	}
+	cal, err := ical.Parse(body)
+	if err != nil || cal.Name != "VCALENDAR" {
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.CalDAV("valid-calendar-data"), "")
+		return
+	}
+	var vtodo *ical.Component
+	for _, component := range cal.Children("VTODO") {
+		if component.Get("RECURRENCE-ID") == nil {
+			vtodo = component
+			break
+		}
+	}
+	if vtodo == nil {
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.CalDAV("supported-calendar-component"), "")
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	status, err := h.put(ctx, querier, userID, c.Param("path"), c.Request, vtodo)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	c.Status(status)
+}
+
+// Delete deletes a todo, along with its subtasks
+func (h *CalDAVHandler) Delete(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	res, err := h.resolve(ctx, querier, userID, c.Param("path"))
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	if res.kind != kindObject {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	object, err := h.findObject(ctx, querier, userID, res.collection, res.name)
+	if err != nil {
+		h.davError(c, err)
+		return
+	}
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !etagMatches(ifMatch, object.etag) {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+	if err := querier.DeleteTodo(ctx, object.todo.TodoID); err != nil {
+		h.davError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// put applies a PUT of vtodo inside a transaction, returning 201 if it
+// created a todo and 204 if it updated one
+func (h *CalDAVHandler) put(ctx context.Context, querier db.Querier, userID int32, path string, r *http.Request, vtodo *ical.Component) (int, error) {
+	if err := querier.LockUser(ctx, userID); err != nil {
+		return 0, err
+	}
+	res, err := h.resolve(ctx, querier, userID, path)
+	if err != nil {
+		return 0, err
+	}
+	if res.kind != kindObject {
+		return 0, &requestError{http.StatusMethodNotAllowed, "Only todos can be written"}
+	}
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		return 0, err
+	}
+	data, err := ical.ParseTodo(vtodo, location)
+	if err != nil {
+		return 0, &davPrecondition{http.StatusForbidden, caldav.CalDAV("valid-calendar-data"), ""}
+	}
+
+	existing, err := h.findObject(ctx, querier, userID, res.collection, res.name)
+	var reqErr *requestError
+	if errors.As(err, &reqErr) && reqErr.status == http.StatusNotFound {
+		existing, err = nil, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && (existing == nil || !etagMatches(ifMatch, existing.etag)) {
+		return 0, &requestError{http.StatusPreconditionFailed, "The todo has changed"}
+	}
+	if r.Header.Get("If-None-Match") == "*" && existing != nil {
+		return 0, &requestError{http.StatusPreconditionFailed, "The todo already exists"}
+	}
+
+	todo, err := h.findByUID(ctx, querier, userID, data.UID)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case todo == nil || (existing != nil && todo.TodoID == existing.todo.TodoID):
+		todo = nil
+		if existing != nil {
+			todo = existing.todo
+		}
+	case existing == nil && !res.collection.contains(todo):
+		// Clients move a todo to another calendar by creating it there
+		// before deleting it from the old one, whose name stops resolving
+		// once the todo is renamed below
+	default:
+		objects, err := h.objects(ctx, querier, userID, []db.Todo{*todo})
+		if err != nil {
+			return 0, err
+		}
+		href := davHomeHref
+		if len(objects) > 0 {
+			href = res.collection.objectHref(objects[0].name)
+		}
+		return 0, &davPrecondition{http.StatusForbidden, caldav.CalDAV("no-uid-conflict"), caldav.Href(href)}
+	}
+
+	status := http.StatusNoContent
+	if todo == nil {
+		status = http.StatusCreated
+	}
+	todo, err = h.saveTodo(ctx, querier, userID, res.collection, todo, data, location)
+	if err != nil {
+		return 0, err
+	}
+	if err := setTodoCategories(ctx, querier, userID, todo.TodoID, data.Categories); err != nil {
+		return 0, err
+	}
+
+	if res.name != fmt.Sprintf("todo-%d.ics", todo.TodoID) || data.UID != ical.TodoUID(todo.TodoID, icalDomain(h.publicURL)) {
+		named, err := querier.GetCaldavObjectByName(ctx, db.GetCaldavObjectByNameParams{UserID: userID, Name: res.name})
+		if err == nil && named.TodoID != todo.TodoID {
+			return 0, &requestError{http.StatusConflict, "Another todo already has this name"}
+		}
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return 0, err
+		}
+		if err := querier.UpsertCaldavObject(ctx, db.UpsertCaldavObjectParams{
+			TodoID: todo.TodoID,
+			UserID: userID,
+			Name:   res.name,
+			Uid:    data.UID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return status, nil
+}
+
+// saveTodo creates a todo in collection from data, or updates todo with it,
+// including its recurrence and completion
+func (h *CalDAVHandler) saveTodo(ctx context.Context, querier db.Querier, userID int32, collection *davCollection, todo *db.Todo, data *ical.TodoData, location *time.Location) (*db.Todo, error) {
+	title := data.Summary
+	if title == "" {
+		title = "Untitled"
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = string([]rune(title)[:maxTitleLength])
+	}
+	var description *string
+	if data.Description != "" {
+		description = &data.Description
+	}
+	var parentID *int32
+	if data.ParentUID != "" {
+		parent, err := h.findByUID(ctx, querier, userID, data.ParentUID)
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil && (todo == nil || !isDescendant(ctx, querier, parent, todo.TodoID)) {
+			parentID = &parent.TodoID
+		}
+	}
+
+	var previousRule string
+	if todo == nil {
+		created, err := createTodo(ctx, querier, userID, &CreateTodoRequest{
+			Title:        title,
+			Description:  description,
+			ProjectID:    int4Ptr(collection.projectID),
+			ParentTodoID: parentID,
+			AssignedDate: data.Date,
+			DurationMin:  data.DurationMin,
+			Priority:     data.Priority,
+		})
+		if err != nil {
+			return nil, err
+		}
+		todo = created
+	} else {
+		// The rule as the client last saw it, to tell whether it changed
+		if rule, err := ical.TodoRule(todo); err == nil && rule != nil {
+			component, _, err := ical.TodoComponent(todo, location, ical.TodoOptions{TodosOnly: true})
+			if err != nil {
+				return nil, err
+			}
+			if p := component.Get("RRULE"); p != nil {
+				previousRule = p.Value
+			}
+		}
+		updated, err := querier.UpdateTodo(ctx, db.UpdateTodoParams{
+			TodoID:       todo.TodoID,
+			ProjectID:    collection.projectID,
+			ParentTodoID: pgInt4(parentID),
+			Title:        title,
+			Description:  pgText(description),
+			AssignedDate: pgTimestamptz(data.Date),
+			DurationMin:  pgInt4(data.DurationMin),
+			Priority:     pgtype.Int4{Int32: data.Priority, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		todo = &updated
+	}
+
+	if data.Rule != previousRule {
+		switch {
+		case data.Rule == "" && todo.RecurrenceMode == RecurrenceFromSchedule && todo.RecurrenceRule.Valid:
+			cleared, err := querier.ClearTodoRecurrence(ctx, todo.TodoID)
+			if err != nil {
+				return nil, err
+			}
+			todo = &cleared
+		case data.Rule != "" && todo.AssignedDate.Valid:
+			updated, err := setRecurrence(ctx, querier, todo, &RecurrenceRequest{Rule: data.Rule, Mode: RecurrenceFromSchedule}, location)
+			if err != nil {
+				return nil, err
+			}
+			todo = updated
+		}
+	}
+
+	completed := todo.IsCompleted.Valid && todo.IsCompleted.Bool
+	switch {
+	case data.Completed && !completed:
+		// Sync clients don't know about dependencies, so they can't
+		// complete a todo that is still waiting on others either
+		blockers, err := openBlockers(ctx, querier, []int32{todo.TodoID})
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 {
+			return nil, &blockedError{blockers}
+		}
+		// A recurring todo moves on to its next occurrence instead
+		if _, err := completeTodo(ctx, querier, todo, time.Now()); err != nil {
+			return nil, err
+		}
+		completedTodo, err := querier.GetTodo(ctx, todo.TodoID)
+		if err != nil {
+			return nil, err
+		}
+		todo = &completedTodo
+	case !data.Completed && completed:
+		updated, err := querier.UncompleteTodo(ctx, todo.TodoID)
+		if err != nil {
+			return nil, err
+		}
+		todo = &updated
+	}
+	return todo, nil
+}
+
+// setTodoCategories makes a todo's tags the named ones, creating tags that
+// don't exist yet
+func setTodoCategories(ctx context.Context, querier db.Querier, userID int32, todoID int32, categories []string) error {
+	var names []string
+	for _, name := range categories {
+		if utf8.RuneCountInString(name) > maxTagNameLength {
+			name = string([]rune(name)[:maxTagNameLength])
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	current, err := querier.ListTodoTagNames(ctx, []int32{todoID})
+	if err != nil {
+		return err
+	}
+	currentNames := make([]string, len(current))
+	for i, tag := range current {
+		currentNames[i] = tag.Name
+	}
+	slices.Sort(names)
+	if slices.Equal(names, currentNames) {
+		return nil
+	}
+
+	if err := querier.DeleteAllTodoTags(ctx, todoID); err != nil {
+		return err
+	}
+	for _, name := range names {
+		tag, err := querier.GetTagByName(ctx, db.GetTagByNameParams{UserID: userID, Name: name})
+		if errors.Is(err, pgx.ErrNoRows) {
+			tag, err = querier.CreateTag(ctx, db.CreateTagParams{
+				UserID: userID,
+				Name:   name,
+				Color:  pgtype.Text{String: defaultTagColor, Valid: true},
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if err := querier.CreateTodoTag(ctx, db.CreateTodoTagParams{TodoID: todoID, TagID: tag.TagID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isDescendant reports whether todo is todoID or one of its subtasks, in
+// which case it can't become todoID's parent
+func isDescendant(ctx context.Context, querier db.Querier, todo *db.Todo, todoID int32) bool {
+	current := todo
+	for range maxParentDepth {
+		if current.TodoID == todoID {
+			return true
+		}
+		if !current.ParentTodoID.Valid {
+			return false
+		}
+		parent, err := querier.GetTodo(ctx, current.ParentTodoID.Int32)
+		if err != nil {
+			return true
+		}
+		current = &parent
+	}
+	return true
+}
+
+// multiget answers a calendar-multiget report with the objects it names
+func (h *CalDAVHandler) multiget(ctx context.Context, querier db.Querier, userID int32, collection *davCollection, root *caldav.Element) (*caldav.Multistatus, error) {
+	req := caldav.ParsePropRequest(root)
+	ms := &caldav.Multistatus{}
+	for _, child := range root.Children {
+		if child.Name != caldav.DAV("href") {
+			continue
+		}
+		href := strings.TrimSpace(child.Text)
+		name := ""
+		if u, err := url.Parse(href); err == nil {
+			if rest, ok := strings.CutPrefix(u.Path, collection.href()); ok && !strings.Contains(rest, "/") {
+				name = rest
+			}
+		}
+		object, err := h.findObject(ctx, querier, userID, collection, name)
+		var reqErr *requestError
+		if errors.As(err, &reqErr) && reqErr.status == http.StatusNotFound {
+			ms.Responses = append(ms.Responses, &caldav.Response{Href: href, Status: http.StatusNotFound})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		response := objectResponse(collection, object, req)
+		response.Href = href
+		ms.Responses = append(ms.Responses, response)
+	}
+	return ms, nil
+}
+
+// calendarQuery answers a calendar-query report. Only the component filter
+// is applied, since clients filter by time themselves and a calendar only
+// holds todos anyway.
+func (h *CalDAVHandler) calendarQuery(ctx context.Context, querier db.Querier, userID int32, collection *davCollection, root *caldav.Element) (*caldav.Multistatus, error) {
+	req := caldav.ParsePropRequest(root)
+	ms := &caldav.Multistatus{}
+	if filter := root.Child(caldav.CalDAV("filter")); filter != nil {
+		if calendar := filter.Child(caldav.CalDAV("comp-filter")); calendar != nil {
+			if component := calendar.Child(caldav.CalDAV("comp-filter")); component != nil && component.Attr("name") != "VTODO" {
+				return ms, nil
+			}
+		}
+	}
+
+	todos, err := querier.ListCollectionTodos(ctx, db.ListCollectionTodosParams{
+		UserID:    userID,
+		ProjectID: collection.projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	objects, err := h.objects(ctx, querier, userID, todos)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		ms.Responses = append(ms.Responses, objectResponse(collection, object, req))
+	}
+	return ms, nil
+}
+
+// syncCollection answers a sync-collection report with what changed since
+// its token, or everything if it has none
+func (h *CalDAVHandler) syncCollection(ctx context.Context, querier db.Querier, userID int32, collection *davCollection, root *caldav.Element) (*caldav.Multistatus, error) {
+	req := caldav.ParsePropRequest(root)
+	var token string
+	if element := root.Child(caldav.DAV("sync-token")); element != nil {
+		token = element.Text
+	}
+	after, err := caldav.ParseSyncToken(token)
+	invalid := &davPrecondition{http.StatusForbidden, caldav.DAV("valid-sync-token"), ""}
+	if err != nil {
+		return nil, invalid
+	}
+	current, err := querier.GetCollectionSyncToken(ctx, db.GetCollectionSyncTokenParams{
+		UserID:    userID,
+		ProjectID: collection.projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if after > current {
+		return nil, invalid
+	}
+
+	ms := &caldav.Multistatus{SyncToken: caldav.SyncToken(current)}
+	var todos []db.Todo
+	if after == 0 {
+		todos, err = querier.ListCollectionTodos(ctx, db.ListCollectionTodosParams{
+			UserID:    userID,
+			ProjectID: collection.projectID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		changes, err := querier.ListCollectionChanges(ctx, db.ListCollectionChangesParams{
+			UserID:    userID,
+			ProjectID: collection.projectID,
+			After:     after,
+		})
+		if err != nil {
+			return nil, err
+		}
+		latest := after
+		var changed []int32
+		for _, change := range changes {
+			latest = max(latest, change.ChangeID)
+			if change.Deleted {
+				ms.Responses = append(ms.Responses, &caldav.Response{Href: collection.objectHref(change.Name), Status: http.StatusNotFound})
+			} else {
+				changed = append(changed, change.TodoID)
+			}
+		}
+		ms.SyncToken = caldav.SyncToken(latest)
+		if len(changed) > 0 {
+			todos, err = querier.ListTodosByIDs(ctx, db.ListTodosByIDsParams{UserID: userID, TodoIds: changed})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	objects, err := h.objects(ctx, querier, userID, todos)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		if collection.contains(object.todo) {
+			ms.Responses = append(ms.Responses, objectResponse(collection, object, req))
+		}
+	}
+	return ms, nil
+}
+
+// resolve parses a path below DAVPrefix, checking that the calendar it
+// names belongs to the user
+func (h *CalDAVHandler) resolve(ctx context.Context, querier db.Querier, userID int32, path string) (*davResource, error) {
+	trimmed := strings.Trim(path, "/")
+	var parts []string
+	if trimmed != "" {
+		parts = strings.Split(trimmed, "/")
+	}
+	switch {
+	case len(parts) == 0:
+		return &davResource{kind: kindRoot}, nil
+	case len(parts) == 1 && parts[0] == "principal":
+		return &davResource{kind: kindPrincipal}, nil
+	case parts[0] != "calendars" || len(parts) > 3:
+		return nil, &requestError{http.StatusNotFound, "Not found"}
+	case len(parts) == 1:
+		return &davResource{kind: kindHome}, nil
+	}
+
+	collection, err := h.collection(ctx, querier, userID, parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 2 {
+		return &davResource{kind: kindCalendar, collection: collection}, nil
+	}
+	return &davResource{kind: kindObject, collection: collection, name: parts[2]}, nil
+}
+
+// collection loads the calendar named by segment, a project ID or the inbox
+func (h *CalDAVHandler) collection(ctx context.Context, querier db.Querier, userID int32, segment string) (*davCollection, error) {
+	if segment == inboxCollection {
+		return &davCollection{segment: inboxCollection, name: "Inbox"}, nil
+	}
+	id, err := strconv.ParseInt(segment, 10, 32)
+	if err != nil {
+		return nil, &requestError{http.StatusNotFound, "Calendar not found"}
+	}
+	project, err := querier.GetProject(ctx, int32(id))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil || project.UserID != userID {
+		return nil, &requestError{http.StatusNotFound, "Calendar not found"}
+	}
+	return projectCollection(&project), nil
+}
+
+// collections lists the inbox and a calendar for each of the user's projects
+func (h *CalDAVHandler) collections(ctx context.Context, querier db.Querier, userID int32) ([]*davCollection, error) {
+	projects, err := querier.ListProjects(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	collections := []*davCollection{{segment: inboxCollection, name: "Inbox"}}
+	for i := range projects {
+		collections = append(collections, projectCollection(&projects[i]))
+	}
+	return collections, nil
+}
+
+func projectCollection(project *db.Project) *davCollection {
+	return &davCollection{
+		segment:   strconv.Itoa(int(project.ProjectID)),
+		projectID: pgtype.Int4{Int32: project.ProjectID, Valid: true},
+		name:      project.Name,
+		color:     project.Color,
+	}
+}
+
+// findObject loads the todo named name in collection, returning a 404
+// requestError if there's none
+func (h *CalDAVHandler) findObject(ctx context.Context, querier db.Querier, userID int32, collection *davCollection, name string) (*davObject, error) {
+	notFound := &requestError{http.StatusNotFound, "Todo not found"}
+	var todoID int32
+	named, err := querier.GetCaldavObjectByName(ctx, db.GetCaldavObjectByNameParams{UserID: userID, Name: name})
+	switch {
+	case err == nil:
+		todoID = named.TodoID
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	default:
+		match := davDefaultName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, notFound
+		}
+		id, err := strconv.ParseInt(match[1], 10, 32)
+		if err != nil {
+			return nil, notFound
+		}
+		todoID = int32(id)
+	}
+
+	todo, err := querier.GetTodo(ctx, todoID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && (todo.UserID != userID || !collection.contains(&todo))) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	objects, err := h.objects(ctx, querier, userID, []db.Todo{todo})
+	if err != nil {
+		return nil, err
+	}
+	// A todo a client named isn't also reachable by its default name
+	if len(objects) == 0 || objects[0].name != name {
+		return nil, notFound
+	}
+	return objects[0], nil
+}
+
+// findByUID loads the user's todo with the given UID, or nil if there's none
+func (h *CalDAVHandler) findByUID(ctx context.Context, querier db.Querier, userID int32, uid string) (*db.Todo, error) {
+	var todoID int32
+	object, err := querier.GetCaldavObjectByUID(ctx, db.GetCaldavObjectByUIDParams{UserID: userID, Uid: uid})
+	switch {
+	case err == nil:
+		todoID = object.TodoID
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	default:
+		rest, ok := strings.CutPrefix(uid, "todo-")
+		if !ok {
+			return nil, nil
+		}
+		rest, ok = strings.CutSuffix(rest, "@"+icalDomain(h.publicURL))
+		if !ok {
+			return nil, nil
+		}
+		id, err := strconv.ParseInt(rest, 10, 32)
+		if err != nil {
+			return nil, nil
+		}
+		todoID = int32(id)
+	}
+
+	todo, err := querier.GetTodo(ctx, todoID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && todo.UserID != userID) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if object.TodoID == 0 {
+		// A todo a client gave its own UID no longer has the derived one
+		objects, err := querier.ListCaldavObjects(ctx, db.ListCaldavObjectsParams{UserID: userID, TodoIds: []int32{todoID}})
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) > 0 {
+			return nil, nil
+		}
+	}
+	return &todo, nil
+}
+
+// objects renders todos as calendar object resources, with their names,
+// UIDs, tags and parents' UIDs
+func (h *CalDAVHandler) objects(ctx context.Context, querier db.Querier, userID int32, todos []db.Todo) ([]*davObject, error) {
+	if len(todos) == 0 {
+		return nil, nil
+	}
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int32, 0, 2*len(todos))
+	todoIDs := make([]int32, len(todos))
+	for i := range todos {
+		todoIDs[i] = todos[i].TodoID
+		ids = append(ids, todos[i].TodoID)
+		if todos[i].ParentTodoID.Valid {
+			ids = append(ids, todos[i].ParentTodoID.Int32)
+		}
+	}
+	named, err := querier.ListCaldavObjects(ctx, db.ListCaldavObjectsParams{UserID: userID, TodoIds: uniqueIDs(ids)})
+	if err != nil {
+		return nil, err
+	}
+	byTodo := make(map[int32]db.CaldavObject, len(named))
+	for _, object := range named {
+		byTodo[object.TodoID] = object
+	}
+	tags, err := querier.ListTodoTagNames(ctx, todoIDs)
+	if err != nil {
+		return nil, err
+	}
+	categories := make(map[int32][]string)
+	for _, tag := range tags {
+		categories[tag.TodoID] = append(categories[tag.TodoID], tag.Name)
+	}
+
+	domain := icalDomain(h.publicURL)
+	objects := make([]*davObject, 0, len(todos))
+	for i := range todos {
+		todo := &todos[i]
+		object := &davObject{
+			todo: todo,
+			name: fmt.Sprintf("todo-%d.ics", todo.TodoID),
+			uid:  ical.TodoUID(todo.TodoID, domain),
+		}
+		if named, ok := byTodo[todo.TodoID]; ok {
+			object.name, object.uid = named.Name, named.Uid
+		}
+		opts := ical.TodoOptions{
+			Domain:     domain,
+			UID:        object.uid,
+			Categories: categories[todo.TodoID],
+			TodosOnly:  true,
+		}
+		if parent, ok := byTodo[todo.ParentTodoID.Int32]; ok && todo.ParentTodoID.Valid {
+			opts.ParentUID = parent.Uid
+		}
+		component, usesZone, err := ical.TodoComponent(todo, location, opts)
+		if err != nil {
+			h.logger.Error("Failed to map todo to iCalendar", "todo_id", todo.TodoID, "error", err)
+			continue
+		}
+		cal := ical.NewResource()
+		if usesZone {
+			cal.AddComponent(ical.Timezone(location, todo.AssignedDate.Time, todo.AssignedDate.Time))
+		}
+		cal.AddComponent(component)
+		object.data = cal.Encode()
+		sum := sha256.Sum256(object.data)
+		object.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func principalProps(user *db.User) caldav.Props {
+	return caldav.Props{
+		caldav.DAV("resourcetype"):                 caldav.Elem(caldav.DAV("principal"), ""),
+		caldav.DAV("displayname"):                  caldav.Text(user.Email),
+		caldav.DAV("principal-URL"):                caldav.Href(davPrincipalHref),
+		caldav.DAV("current-user-principal"):       caldav.Href(davPrincipalHref),
+		caldav.CalDAV("calendar-home-set"):         caldav.Href(davHomeHref),
+		caldav.CalDAV("calendar-user-address-set"): caldav.Href("mailto:" + user.Email),
+	}
+}
+
+func collectionProps(ctx context.Context, querier db.Querier, userID int32, collection *davCollection) (caldav.Props, error) {
+	token, err := querier.GetCollectionSyncToken(ctx, db.GetCollectionSyncTokenParams{
+		UserID:    userID,
+		ProjectID: collection.projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var reports strings.Builder
+	for _, report := range []xml.Name{caldav.CalDAV("calendar-multiget"), caldav.CalDAV("calendar-query"), caldav.DAV("sync-collection")} {
+		reports.WriteString(caldav.Elem(caldav.DAV("supported-report"), caldav.Elem(caldav.DAV("report"), caldav.Elem(report, ""))))
+	}
+	var privileges strings.Builder
+	for _, privilege := range []string{"read", "write", "write-content", "bind", "unbind", "read-current-user-privilege-set"} {
+		privileges.WriteString(caldav.Elem(caldav.DAV("privilege"), caldav.Elem(caldav.DAV(privilege), "")))
+	}
+
+	props := caldav.Props{
+		caldav.DAV("resourcetype"):                                caldav.Elem(caldav.DAV("collection"), "") + caldav.Elem(caldav.CalDAV("calendar"), ""),
+		caldav.DAV("displayname"):                                 caldav.Text(collection.name),
+		caldav.DAV("current-user-principal"):                      caldav.Href(davPrincipalHref),
+		caldav.DAV("owner"):                                       caldav.Href(davPrincipalHref),
+		caldav.DAV("sync-token"):                                  caldav.Text(caldav.SyncToken(token)),
+		caldav.DAV("supported-report-set"):                        reports.String(),
+		caldav.DAV("current-user-privilege-set"):                  privileges.String(),
+		caldav.CalDAV("supported-calendar-component-set"):         `<c:comp name="VTODO"/>`,
+		{Space: caldav.NamespaceCalendarServer, Local: "getctag"}: caldav.Text(caldav.SyncToken(token)),
+	}
+	if collection.color.Valid {
+		props[xml.Name{Space: caldav.NamespaceApple, Local: "calendar-color"}] = caldav.Text(collection.color.String)
+	}
+	return props, nil
+}
+
+// objectResponse answers req for an object. calendar-data only comes when
+// asked for by name.
+func objectResponse(collection *davCollection, object *davObject, req *caldav.PropRequest) *caldav.Response {
+	return caldav.NewResponse(collection.objectHref(object.name), req, caldav.Props{
+		caldav.DAV("resourcetype"):     "",
+		caldav.DAV("getetag"):          caldav.Text(object.etag),
+		caldav.DAV("getcontenttype"):   caldav.Text(davContentType),
+		caldav.DAV("getcontentlength"): strconv.Itoa(len(object.data)),
+		caldav.DAV("getlastmodified"):  caldav.Text(object.todo.UpdatedAt.Time.UTC().Format(http.TimeFormat)),
+		caldav.CalDAV("calendar-data"): caldav.Text(string(object.data)),
+	}, caldav.CalDAV("calendar-data"))
+}
+
+// etagMatches reports whether an If-Match header lists etag
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// davError responds to a failed CalDAV request. Clients show the status
+// rather than a body, so errors are plain text.
+func (h *CalDAVHandler) davError(c *gin.Context, err error) {
+	var precondition *davPrecondition
+	if errors.As(err, &precondition) {
+		caldav.WriteError(c.Writer, precondition.status, precondition.condition, precondition.inner)
+		return
+	}
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.String(reqErr.status, reqErr.message)
+		return
+	}
+	var blocked *blockedError
+	if errors.As(err, &blocked) {
+		c.String(http.StatusConflict, blocked.Error())
+		return
+	}
+	h.logger.Error("CalDAV request failed", "error", err)
+	c.String(http.StatusInternalServerError, "Internal error")
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/recurrence"
+	"github.com/boetro/odot/internal/timeblock"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// MaxCalendarRange caps the span of a calendar request
+	MaxCalendarRange = 62 * 24 * time.Hour
+
+	// maxCalendarTodos caps the non-recurring todos in a calendar response
+	maxCalendarTodos = 2000
+	// maxOccurrencesPerTodo caps how many times one recurring todo is
+	// expanded into a calendar response
+	maxOccurrencesPerTodo = 200
+)
+
+// Kinds of calendar entries
+const (
+	CalendarEntryTodo       = "todo"
+	CalendarEntryOccurrence = "occurrence"
+	CalendarEntryBlock      = "block"
+	// CalendarEntryBusy is an event from one of the user's busy calendars
+	CalendarEntryBusy = "busy"
+)
+
+type CalendarHandler struct {
+	querier db.Querier
+	logger  logger.Logger
+}
+
+func NewCalendarHandler(querier db.Querier, logger logger.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		querier: querier,
+		logger:  logger,
+	}
+}
+
+// CalendarEntry is a todo, an occurrence of a recurring todo, a scheduled
+// block or busy time. Entries without a time of day are all day and have no
+// end, except for busy time, which ends on the day after its last.
+type CalendarEntry struct {
+	Kind string `json:"kind"`
+	// TodoID is left out of busy time
+	TodoID         int32      `json:"todo_id,omitempty"`
+	BusyCalendarID *int32     `json:"busy_calendar_id"`
+	BlockID        *int32     `json:"block_id"`
+	BlockStatus    *string    `json:"block_status"`
+	Title          string     `json:"title"`
+	StartsAt       time.Time  `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	AllDay         bool       `json:"all_day"`
+	DurationMin    *int32     `json:"duration_min"`
+	Priority       int32      `json:"priority"`
+	IsCompleted    bool       `json:"is_completed"`
+	// Overlapping is true when the entry shares time with another one
+	Overlapping bool `json:"overlapping"`
+}
+
+type CalendarDay struct {
+	Date string `json:"date"`
+	// WorkingMinutes is how long the user works on the day
+	WorkingMinutes int32 `json:"working_minutes"`
+	// BusyMinutes is how much of the working time busy time takes up
+	BusyMinutes int32 `json:"busy_minutes"`
+	// PlannedMinutes adds up duration_min of the day's open entries
+	PlannedMinutes int32 `json:"planned_minutes"`
+	// OverCapacity is true when more is planned than fits in the working
+	// time left around busy time
+	OverCapacity bool             `json:"over_capacity"`
+	Entries      []*CalendarEntry `json:"entries"`
+}
+
+type CalendarResponse struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Timezone string         `json:"timezone"`
+	Days     []*CalendarDay `json:"days"`
+}
+
+// GetCalendar returns the todos, scheduled blocks and busy time between the
+// from and to RFC 3339 query parameters, grouped by day in the user's
+// timezone. Recurring todos are expanded into their occurrences.
+func (h *CalendarHandler) GetCalendar(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 time"})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC 3339 time"})
+		return
+	}
+	if !from.Before(to) || to.Sub(from) > MaxCalendarRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from and at most 62 days later"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := buildCalendar(ctx, db.QuerierFromContext(ctx, h.querier), userID, from, to)
+	if err != nil {
+		h.calendarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CalendarHandler) calendarError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Calendar request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+// buildCalendar gathers everything on the user's calendar in [from, to)
+func buildCalendar(ctx context.Context, querier db.Querier, userID int32, from time.Time, to time.Time) (*CalendarResponse, error) {
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+	hours, _, err := loadWorkingHours(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+	from, to = from.In(location), to.In(location)
+	starts := pgtype.Timestamptz{Time: from, Valid: true}
+	ends := pgtype.Timestamptz{Time: to, Valid: true}
+
+	var entries []*CalendarEntry
+	blocks, err := querier.ListScheduleBlocks(ctx, db.ListScheduleBlocksParams{UserID: userID, StartsAt: starts, EndsAt: ends})
+	if err != nil {
+		return nil, err
+	}
+	// An accepted block stands in for its todo, which was moved to the same time
+	accepted := make(map[int32]bool)
+	for i := range blocks {
+		block := &blocks[i]
+		minutes := int32(block.EndsAt.Time.Sub(block.StartsAt.Time) / time.Minute)
+		entries = append(entries, &CalendarEntry{
+			Kind:        CalendarEntryBlock,
+			TodoID:      block.TodoID,
+			BlockID:     &block.BlockID,
+			BlockStatus: &block.Status,
+			Title:       block.TodoTitle,
+			StartsAt:    block.StartsAt.Time,
+			EndsAt:      &block.EndsAt.Time,
+			DurationMin: &minutes,
+			IsCompleted: block.TodoCompleted,
+		})
+		if block.Status == "accepted" {
+			accepted[block.TodoID] = true
+		}
+	}
+
+	todos, err := querier.ListTodosAssignedBetween(ctx, db.ListTodosAssignedBetweenParams{
+		UserID:     userID,
+		StartsAt:   starts,
+		EndsAt:     ends,
+		MaxResults: maxCalendarTodos,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range todos {
+		if !accepted[todos[i].TodoID] {
+			entries = append(entries, newCalendarEntry(CalendarEntryTodo, &todos[i], todos[i].AssignedDate.Time, location))
+		}
+	}
+
+	occurrences, err := recurringEntries(ctx, querier, userID, from, to, location)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, occurrences...)
+
+	meetings, err := querier.ListBusyBlocksBetween(ctx, db.ListBusyBlocksBetweenParams{UserID: userID, StartsAt: starts, EndsAt: ends})
+	if err != nil {
+		return nil, err
+	}
+	for i := range meetings {
+		meeting := &meetings[i]
+		title := meeting.Summary
+		if title == "" {
+			title = meeting.CalendarName
+		}
+		entries = append(entries, &CalendarEntry{
+			Kind:           CalendarEntryBusy,
+			BusyCalendarID: &meeting.CalendarID,
+			Title:          title,
+			StartsAt:       meeting.StartsAt.Time.In(location),
+			EndsAt:         &meeting.EndsAt.Time,
+			AllDay:         meeting.AllDay,
+		})
+	}
+
+	markOverlaps(entries)
+	return groupByDay(entries, hours, from, to, location), nil
+}
+
+// recurringEntries returns the completed occurrences of recurring todos in
+// [from, to) along with the ones still to come
+func recurringEntries(ctx context.Context, querier db.Querier, userID int32, from time.Time, to time.Time, location *time.Location) ([]*CalendarEntry, error) {
+	var entries []*CalendarEntry
+	done, err := querier.ListTodoOccurrencesBetween(ctx, db.ListTodoOccurrencesBetweenParams{
+		UserID:   userID,
+		StartsAt: pgtype.Timestamptz{Time: from, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, occurrence := range done {
+		todo := db.Todo{
+			TodoID:      occurrence.TodoID,
+			Title:       occurrence.TodoTitle,
+			DurationMin: occurrence.DurationMin,
+			Priority:    occurrence.Priority,
+			IsCompleted: pgtype.Bool{Bool: true, Valid: true},
+		}
+		entries = append(entries, newCalendarEntry(CalendarEntryOccurrence, &todo, occurrence.OccurrenceDate.Time, location))
+	}
+
+	todos, err := querier.ListRecurringTodosStartedBefore(ctx, db.ListRecurringTodosStartedBeforeParams{
+		UserID: userID,
+		EndsAt: pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range todos {
+		todo := &todos[i]
+		if !todo.AssignedDate.Valid {
+			continue
+		}
+		// The assigned date is the next occurrence due. Later ones are only
+		// known for todos that repeat on a schedule, since the others restart
+		// from whenever they're completed.
+		current := todo.AssignedDate.Time.In(location)
+		dates := []time.Time{current}
+		if todo.RecurrenceMode == RecurrenceFromSchedule {
+			rule, err := recurrence.Parse(todo.RecurrenceRule.String)
+			if err != nil {
+				return nil, err
+			}
+			start := from
+			if current.After(start) {
+				start = current
+			}
+			later := rule.Between(todo.RecurrenceStart.Time.In(location), start, to, maxOccurrencesPerTodo)
+			if len(later) > 0 && later[0].Equal(current) {
+				later = later[1:]
+			}
+			dates = append(dates, later...)
+		}
+		for _, date := range dates {
+			if !date.Before(from) && date.Before(to) {
+				entries = append(entries, newCalendarEntry(CalendarEntryOccurrence, todo, date, location))
+			}
+		}
+	}
+	return entries, nil
+}
+
+func newCalendarEntry(kind string, todo *db.Todo, startsAt time.Time, location *time.Location) *CalendarEntry {
+	startsAt = startsAt.In(location)
+	entry := &CalendarEntry{
+		Kind:        kind,
+		TodoID:      todo.TodoID,
+		Title:       todo.Title,
+		StartsAt:    startsAt,
+		AllDay:      startsAt.Equal(startOfDay(startsAt, 0)),
+		DurationMin: int4Ptr(todo.DurationMin),
+		Priority:    todo.Priority.Int32,
+		IsCompleted: todo.IsCompleted.Bool,
+	}
+	if !entry.AllDay && todo.DurationMin.Valid {
+		end := startsAt.Add(time.Duration(todo.DurationMin.Int32) * time.Minute)
+		entry.EndsAt = &end
+	}
+	return entry
+}
+
+// markOverlaps flags the timed entries with an end that share time with
+// another
+func markOverlaps(entries []*CalendarEntry) {
+	var timed []*CalendarEntry
+	for _, entry := range entries {
+		if entry.EndsAt != nil && !entry.AllDay {
+			timed = append(timed, entry)
+		}
+	}
+	sort.Slice(timed, func(i, j int) bool { return timed[i].StartsAt.Before(timed[j].StartsAt) })
+
+	// latest is the entry reaching furthest among those started so far
+	var latest *CalendarEntry
+	for _, entry := range timed {
+		if latest != nil && entry.StartsAt.Before(*latest.EndsAt) {
+			entry.Overlapping = true
+			latest.Overlapping = true
+		}
+		if latest == nil || entry.EndsAt.After(*latest.EndsAt) {
+			latest = entry
+		}
+	}
+}
+
+// groupByDay lays the entries out over every day from the one from falls on
+// until to, working out each day's capacity from the working hours and busy
+// time
+func groupByDay(entries []*CalendarEntry, hours []timeblock.Window, from time.Time, to time.Time, location *time.Location) *CalendarResponse {
+	var busy []timeblock.Interval
+	for _, entry := range entries {
+		if entry.Kind == CalendarEntryBusy {
+			busy = append(busy, timeblock.Interval{Start: entry.StartsAt, End: *entry.EndsAt})
+		}
+	}
+
+	response := &CalendarResponse{From: from, To: to, Timezone: location.String()}
+	index := make(map[string]*CalendarDay)
+	for day := startOfDay(from, 0); day.Before(to); day = startOfDay(day, 1) {
+		next := startOfDay(day, 1)
+		var working, free time.Duration
+		for _, span := range timeblock.WorkingTime(hours, location, day, next) {
+			working += span.Duration()
+		}
+		for _, span := range timeblock.FreeSlots(hours, location, day, next, busy) {
+			free += span.Duration()
+		}
+		date := day.Format(time.DateOnly)
+		index[date] = &CalendarDay{
+			Date:           date,
+			WorkingMinutes: int32(working / time.Minute),
+			BusyMinutes:    int32((working - free) / time.Minute),
+			Entries:        []*CalendarEntry{},
+		}
+		response.Days = append(response.Days, index[date])
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].AllDay != entries[j].AllDay {
+			return entries[i].AllDay
+		}
+		return entries[i].StartsAt.Before(entries[j].StartsAt)
+	})
+	// A todo with a proposed block is counted once on each day, through
+	// whichever entry comes first
+	counted := make(map[string]map[int32]bool)
+	for _, entry := range entries {
+		// Busy time that began before from is shown on the first day
+		startsAt := entry.StartsAt
+		if entry.Kind == CalendarEntryBusy && startsAt.Before(from) {
+			startsAt = from
+		}
+		date := startsAt.In(location).Format(time.DateOnly)
+		day, ok := index[date]
+		if !ok {
+			continue
+		}
+		day.Entries = append(day.Entries, entry)
+		if entry.IsCompleted || entry.DurationMin == nil {
+			continue
+		}
+		if counted[date] == nil {
+			counted[date] = make(map[int32]bool)
+		}
+		if entry.Kind != CalendarEntryOccurrence && counted[date][entry.TodoID] {
+			continue
+		}
+		counted[date][entry.TodoID] = true
+		day.PlannedMinutes += *entry.DurationMin
+	}
+	for _, day := range response.Days {
+		day.OverCapacity = day.PlannedMinutes > day.WorkingMinutes-day.BusyMinutes
+	}
+	return response
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/auth"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/filter"
+	"github.com/boetro/odot/internal/ical"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxFeedTodos bounds how many todos a feed lists
+	maxFeedTodos = 2000
+	// feedCompletedDays is how long completed todos stay in a feed
+	feedCompletedDays = 30
+	defaultFeedName   = "odot"
+	// maxFeedName is the length of calendar_feeds.name
+	maxFeedName = 255
+)
+
+type CalendarFeedHandler struct {
+	querier   db.Querier
+	pool      *pgxpool.Pool
+	publicURL string
+	logger    logger.Logger
+}
+
+// NewCalendarFeedHandler returns a CalendarFeedHandler. Feed URLs point at
+// publicURL.
+func NewCalendarFeedHandler(querier db.Querier, pool *pgxpool.Pool, publicURL string, logger logger.Logger) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		querier:   querier,
+		pool:      pool,
+		publicURL: publicURL,
+		logger:    logger,
+	}
+}
+
+// CreateCalendarFeedRequest creates a feed of all the user's todos, or only
+// those of a project or matching a saved filter
+type CreateCalendarFeedRequest struct {
+	Name          string `json:"name"`
+	ProjectID     *int32 `json:"project_id"`
+	SavedFilterID *int32 `json:"saved_filter_id"`
+}
+
+type CalendarFeedResponse struct {
+	ID             int32      `json:"id"`
+	Name           string     `json:"name"`
+	ProjectID      *int32     `json:"project_id"`
+	SavedFilterID  *int32     `json:"saved_filter_id"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	// URL is only returned when the token is created, since only its hash
+	// is kept
+	URL string `json:"url,omitempty"`
+}
+
+func NewCalendarFeedResponse(feed *db.CalendarFeed) *CalendarFeedResponse {
+	return &CalendarFeedResponse{
+		ID:             feed.FeedID,
+		Name:           feed.Name,
+		ProjectID:      int4Ptr(feed.ProjectID),
+		SavedFilterID:  int4Ptr(feed.SavedFilterID),
+		LastAccessedAt: timePtr(feed.LastAccessedAt),
+		CreatedAt:      feed.CreatedAt.Time,
+	}
+}
+
+// ListCalendarFeeds lists the user's feeds, without their URLs
+func (h *CalendarFeedHandler) ListCalendarFeeds(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	feeds, err := db.QuerierFromContext(c.Request.Context(), h.querier).ListCalendarFeeds(c, userID)
+	if err != nil {
+		h.logger.Error("Failed to list calendar feeds", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	responses := make([]*CalendarFeedResponse, len(feeds))
+	for i := range feeds {
+		responses[i] = NewCalendarFeedResponse(&feeds[i])
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateCalendarFeed creates a feed and returns its URL. This is the only
+// time the URL is shown.
+func (h *CalendarFeedHandler) CreateCalendarFeed(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CreateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ProjectID != nil && req.SavedFilterID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A feed can't be limited to both a project and a saved filter"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	name := strings.TrimSpace(req.Name)
+	if len([]rune(name)) > maxFeedName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at most 255 characters"})
+		return
+	}
+	if req.ProjectID != nil {
+		project, err := querier.GetProject(ctx, *req.ProjectID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			h.feedError(c, err)
+			return
+		}
+		if err != nil || project.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		if name == "" {
+			name = project.Name
+		}
+	}
+	if req.SavedFilterID != nil {
+		savedFilter, err := querier.GetSavedFilter(ctx, *req.SavedFilterID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			h.feedError(c, err)
+			return
+		}
+		if err != nil || savedFilter.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved filter not found"})
+			return
+		}
+		if name == "" {
+			name = savedFilter.Name
+		}
+	}
+	if name == "" {
+		name = defaultFeedName
+	}
+
+	token, hash, err := auth.GenerateFeedToken()
+	if err != nil {
+		h.feedError(c, err)
+		return
+	}
+	feed, err := querier.CreateCalendarFeed(ctx, db.CreateCalendarFeedParams{
+		UserID:        userID,
+		Name:          name,
+		TokenHash:     hash,
+		ProjectID:     pgInt4(req.ProjectID),
+		SavedFilterID: pgInt4(req.SavedFilterID),
+	})
+	if err != nil {
+		h.feedError(c, err)
+		return
+	}
+
+	response := NewCalendarFeedResponse(&feed)
+	response.URL = h.feedURL(token)
+	c.JSON(http.StatusCreated, response)
+}
+
+// RotateCalendarFeed replaces a feed's token, so its old URL stops working
+func (h *CalendarFeedHandler) RotateCalendarFeed(c *gin.Context) {
+	feed, ok := h.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	token, hash, err := auth.GenerateFeedToken()
+	if err != nil {
+		h.feedError(c, err)
+		return
+	}
+	rotated, err := db.QuerierFromContext(c.Request.Context(), h.querier).RotateCalendarFeedToken(c, db.RotateCalendarFeedTokenParams{
+		FeedID:    feed.FeedID,
+		TokenHash: hash,
+	})
+	if err != nil {
+		h.feedError(c, err)
+		return
+	}
+
+	response := NewCalendarFeedResponse(&rotated)
+	response.URL = h.feedURL(token)
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteCalendarFeed revokes a feed
+func (h *CalendarFeedHandler) DeleteCalendarFeed(c *gin.Context) {
+	feed, ok := h.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	if err := db.QuerierFromContext(c.Request.Context(), h.querier).DeleteCalendarFeed(c, feed.FeedID); err != nil {
+		h.feedError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetFeed serves the iCalendar data of the feed whose token is in the URL.
+// It needs no other authentication so calendar apps can subscribe to it.
+func (h *CalendarFeedHandler) GetFeed(c *gin.Context) {
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	feed, err := querier.GetCalendarFeedByTokenHash(ctx, auth.HashFeedToken(c.Param("token")))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+			return
+		}
+		h.feedError(c, err)
+		return
+	}
+
+	location, err := userLocation(ctx, querier, feed.UserID)
+	if err != nil {
+		h.feedError(c, err)
+		return
+	}
+	now := time.Now()
+	todos, err := h.feedTodos(ctx, querier, &feed, now, location)
+	if err != nil {
+		h.feedError(c, err)
+		return
+	}
+
+	cal := ical.NewCalendar(feed.Name, location)
+	domain := icalDomain(h.publicURL)
+	var components []*ical.Component
+	var zoneFrom, zoneTo time.Time
+	for i := range todos {
+		component, usesZone, err := ical.TodoComponent(&todos[i], location, ical.TodoOptions{Domain: domain})
+		if err != nil {
+			// A rule that no longer parses shouldn't take the whole feed
+			// down
+			h.logger.Error("Failed to map todo to iCalendar", "todo_id", todos[i].TodoID, "error", err)
+			continue
+		}
+		if usesZone {
+			start := todos[i].AssignedDate.Time
+			if zoneFrom.IsZero() || start.Before(zoneFrom) {
+				zoneFrom = start
+			}
+			if start.After(zoneTo) {
+				zoneTo = start
+			}
+		}
+		components = append(components, component)
+	}
+	if !zoneFrom.IsZero() {
+		cal.AddComponent(ical.Timezone(location, zoneFrom, zoneTo))
+	}
+	for _, component := range components {
+		cal.AddComponent(component)
+	}
+
+	if err := querier.TouchCalendarFeed(ctx, feed.FeedID); err != nil {
+		h.logger.Error("Failed to record calendar feed access", "feed_id", feed.FeedID, "error", err)
+	}
+	c.Header("Content-Disposition", `inline; filename="odot.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", cal.Encode())
+}
+
+// feedTodos loads the todos in a feed: those with a date that are open or
+// were completed recently, limited to the feed's project or saved filter
+func (h *CalendarFeedHandler) feedTodos(ctx context.Context, querier db.Querier, feed *db.CalendarFeed, now time.Time, location *time.Location) ([]db.Todo, error) {
+	b := filter.NewBuilder()
+	b.Where("t.user_id = %s", feed.UserID)
+	b.Where("t.assigned_date IS NOT NULL")
+	b.Where("(NOT COALESCE(t.is_completed, FALSE) OR t.completed_at >= %s)", now.AddDate(0, 0, -feedCompletedDays))
+	if feed.ProjectID.Valid {
+		b.Where("t.project_id = %s", feed.ProjectID.Int32)
+	}
+	if feed.SavedFilterID.Valid {
+		savedFilter, err := querier.GetSavedFilter(ctx, feed.SavedFilterID.Int32)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := filter.Parse(savedFilter.Query)
+		if err != nil {
+			return nil, err
+		}
+		expr.Apply(b, filter.Options{UserID: feed.UserID, Now: now, Location: location})
+	}
+
+	sql := "SELECT t.* FROM todos t WHERE " + b.Conditions() +
+		" ORDER BY t.assigned_date, t.todo_id LIMIT " + b.Arg(maxFeedTodos)
+	rows, err := db.DBFromContext(ctx, h.pool).Query(ctx, sql, b.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[db.Todo])
+}
+
+// ownedFeed loads the feed named by the :id parameter, responding with an
+// error if it doesn't exist or belongs to someone else
+func (h *CalendarFeedHandler) ownedFeed(c *gin.Context) (*db.CalendarFeed, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid feed ID"})
+		return nil, false
+	}
+
+	feed, err := db.QuerierFromContext(c.Request.Context(), h.querier).GetCalendarFeed(c, int32(id))
+	if err == nil && feed.UserID != userID {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		} else {
+			h.feedError(c, err)
+		}
+		return nil, false
+	}
+	return &feed, true
+}
+
+func (h *CalendarFeedHandler) feedURL(token string) string {
+	return strings.TrimRight(h.publicURL, "/") + "/api/ical/" + url.PathEscape(token) + "/odot.ics"
+}
+
+// icalDomain is the right hand side of the UIDs of todos served from
+// publicURL
+func icalDomain(publicURL string) string {
+	if u, err := url.Parse(publicURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return defaultFeedName
+}
+
+func (h *CalendarFeedHandler) feedError(c *gin.Context, err error) {
+	h.logger.Error("Calendar feed request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/depgraph"
+	"github.com/boetro/odot/internal/timeblock"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxTimelineDays caps how far ahead working hours are laid out for a
+// critical path. Work beyond it is counted in plain hours.
+const maxTimelineDays = 3 * 366
+
+// CriticalPathTodo is an open todo of a project with the earliest it can be
+// done, working through the user's working hours from now
+type CriticalPathTodo struct {
+	*TodoResponse
+	EarliestStart  time.Time `json:"earliest_start"`
+	EarliestFinish time.Time `json:"earliest_finish"`
+	// SlackMin is how many minutes of work the todo can slip without
+	// delaying the project
+	SlackMin int32 `json:"slack_min"`
+	Critical bool  `json:"critical"`
+}
+
+type CriticalPathResponse struct {
+	ProjectID int32  `json:"project_id"`
+	Timezone  string `json:"timezone"`
+	// CriticalPath lists the todos, first to last, that decide when the
+	// project can be finished
+	CriticalPath []int32 `json:"critical_path"`
+	// TotalMin is how many minutes of work the critical path takes
+	TotalMin       int32     `json:"total_min"`
+	EarliestFinish time.Time `json:"earliest_finish"`
+	// Unestimated are the todos without duration_min, which are counted as
+	// taking no time
+	Unestimated []int32 `json:"unestimated"`
+	// Todos are the project's open todos, each after the ones it depends on
+	Todos []*CriticalPathTodo `json:"todos"`
+}
+
+// GetCriticalPath works out the earliest each open todo of a project can be
+// done from duration_min and the dependencies between them, and the chain of
+// todos that decides when the project is finished. Dependencies on todos in
+// other projects are left out.
+func (h *ProjectHandler) GetCriticalPath(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	project, err := ownedProject(ctx, querier, userID, int32(projectID))
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+	hours, _, err := loadWorkingHours(ctx, querier, userID)
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+	todos, err := querier.ListProjectOpenTodos(ctx, pgtype.Int4{Int32: project.ProjectID, Valid: true})
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+	ids := make([]int32, len(todos))
+	for i := range todos {
+		ids[i] = todos[i].TodoID
+	}
+	edges, err := querier.ListOpenDependencyEdges(ctx, ids)
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+
+	response := &CriticalPathResponse{
+		ProjectID:    project.ProjectID,
+		Timezone:     location.String(),
+		CriticalPath: []int32{},
+		Unestimated:  []int32{},
+		Todos:        make([]*CriticalPathTodo, 0, len(todos)),
+	}
+	prerequisites := make(map[int32][]int32)
+	for _, edge := range edges {
+		prerequisites[edge.TodoID] = append(prerequisites[edge.TodoID], edge.DependsOnID)
+	}
+	byID := make(map[int32]*TodoResponse, len(todos))
+	tasks := make([]depgraph.Task, len(todos))
+	for i := range todos {
+		todo := &todos[i]
+		byID[todo.TodoID] = NewTodoResponse(todo)
+		if !todo.DurationMin.Valid {
+			response.Unestimated = append(response.Unestimated, todo.TodoID)
+		}
+		tasks[i] = depgraph.Task{
+			ID:        todo.TodoID,
+			Duration:  time.Duration(max(todo.DurationMin.Int32, 0)) * time.Minute,
+			DependsOn: prerequisites[todo.TodoID],
+		}
+	}
+	responses := make([]*TodoResponse, 0, len(byID))
+	for _, todo := range byID {
+		responses = append(responses, todo)
+	}
+	if err := fillDependencies(ctx, querier, responses); err != nil {
+		h.projectError(c, err)
+		return
+	}
+
+	result, err := depgraph.CriticalPath(tasks)
+	if err != nil {
+		// Cycles are rejected when dependencies are added
+		h.projectError(c, err)
+		return
+	}
+	at := workingTimeline(hours, location, time.Now(), result.Length)
+	for _, timing := range result.Timings {
+		response.Todos = append(response.Todos, &CriticalPathTodo{
+			TodoResponse:   byID[timing.ID],
+			EarliestStart:  at(timing.EarliestStart, false),
+			EarliestFinish: at(timing.EarliestFinish, true),
+			SlackMin:       int32(timing.Slack / time.Minute),
+			Critical:       timing.Critical,
+		})
+	}
+	response.CriticalPath = append(response.CriticalPath, result.CriticalPath...)
+	response.TotalMin = int32(result.Length / time.Minute)
+	response.EarliestFinish = at(result.Length, true)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ProjectHandler) projectError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Project request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+}
+
+// workingTimeline lays length of work out over the working hours from now,
+// returning when a given amount of it is done. Work that starts when a
+// working span ends starts in the next span instead, while work that
+// finishes then finishes at the end of the span.
+func workingTimeline(hours []timeblock.Window, location *time.Location, now time.Time, length time.Duration) func(offset time.Duration, finish bool) time.Time {
+	var spans []timeblock.Interval
+	var total time.Duration
+	limit := startOfDay(now.In(location), maxTimelineDays)
+	for day := now.In(location); total < length && day.Before(limit); day = startOfDay(day, 1) {
+		for _, span := range timeblock.WorkingTime(hours, location, day, startOfDay(day, 1)) {
+			spans = append(spans, span)
+			total += span.Duration()
+		}
+	}
+
+	return func(offset time.Duration, finish bool) time.Time {
+		end := now
+		for _, span := range spans {
+			d := span.Duration()
+			if offset < d || (finish && offset == d) {
+				return span.Start.Add(offset)
+			}
+			offset -= d
+			end = span.End
+		}
+		return end.Add(offset)
+	}
+}
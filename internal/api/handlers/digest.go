@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/digest"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultDigestHour    = 7
+	defaultDigestWeekday = time.Monday
+)
+
+// unsubscribePage asks before turning a digest off, since mail scanners and
+// link previews follow the GET link in the email
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body style="font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;padding:48px;text-align:center;color:#111827;">
+<form method="post" action="?token={{.Token}}">
+<p>Stop getting the {{.Kind}} digest?</p>
+<input type="hidden" name="confirm" value="true">
+<button type="submit">Unsubscribe</button>
+</form>
+</body></html>
+`))
+
+var unsubscribedPage = template.Must(template.New("unsubscribed").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribed</title></head>
+<body style="font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;padding:48px;text-align:center;color:#111827;">
+<p>You won't get the {{.}} digest anymore. You can turn it back on in your settings.</p>
+</body></html>
+`))
+
+type DigestHandler struct {
+	querier   db.Querier
+	secret    string
+	publicURL string
+	logger    logger.Logger
+}
+
+// NewDigestHandler returns a DigestHandler. secret signs unsubscribe links,
+// which point at publicURL.
+func NewDigestHandler(querier db.Querier, secret string, publicURL string, logger logger.Logger) *DigestHandler {
+	return &DigestHandler{
+		querier:   querier,
+		secret:    secret,
+		publicURL: publicURL,
+		logger:    logger,
+	}
+}
+
+// DigestRequest subscribes to a digest or changes when it is sent. Fields
+// that aren't set keep their current value.
+type DigestRequest struct {
+	Enabled *bool `json:"enabled"`
+	// SendHour is the hour of the day, 0-23, in the user's timezone
+	SendHour *int32 `json:"send_hour"`
+	// SendWeekday is the day the weekly digest is sent, 0 for Sunday
+	SendWeekday *int32 `json:"send_weekday"`
+}
+
+type DigestResponse struct {
+	Kind        string     `json:"kind"`
+	Enabled     bool       `json:"enabled"`
+	SendHour    int32      `json:"send_hour"`
+	SendWeekday int32      `json:"send_weekday"`
+	NextSendAt  *time.Time `json:"next_send_at"`
+	LastSentAt  *time.Time `json:"last_sent_at"`
+}
+
+func NewDigestResponse(sub *db.DigestSubscription) *DigestResponse {
+	return &DigestResponse{
+		Kind:        sub.Kind,
+		Enabled:     sub.Enabled,
+		SendHour:    sub.SendHour,
+		SendWeekday: sub.SendWeekday,
+		NextSendAt:  timePtr(sub.NextSendAt),
+		LastSentAt:  timePtr(sub.LastSentAt),
+	}
+}
+
+// ListDigests returns the user's settings for every digest, including the
+// ones they haven't subscribed to
+func (h *DigestHandler) ListDigests(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	subs, err := db.QuerierFromContext(c.Request.Context(), h.querier).ListDigestSubscriptions(c, userID)
+	if err != nil {
+		h.digestError(c, err)
+		return
+	}
+	byKind := make(map[string]*db.DigestSubscription, len(subs))
+	for i := range subs {
+		byKind[subs[i].Kind] = &subs[i]
+	}
+
+	responses := make([]*DigestResponse, 0, 2)
+	for _, kind := range []string{digest.KindDaily, digest.KindWeekly} {
+		sub, ok := byKind[kind]
+		if !ok {
+			sub = defaultDigestSubscription(userID, kind)
+		}
+		responses = append(responses, NewDigestResponse(sub))
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// UpdateDigest subscribes to, unsubscribes from or reschedules a digest
+func (h *DigestHandler) UpdateDigest(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	kind, ok := digestKind(c)
+	if !ok {
+		return
+	}
+
+	var req DigestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	sub, err := querier.GetDigestSubscription(ctx, db.GetDigestSubscriptionParams{UserID: userID, Kind: kind})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		sub = *defaultDigestSubscription(userID, kind)
+		// Asking for a digest's settings to change is asking for the digest
+		sub.Enabled = true
+	case err != nil:
+		h.digestError(c, err)
+		return
+	}
+
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+	if req.SendHour != nil {
+		if *req.SendHour < 0 || *req.SendHour > 23 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "send_hour must be between 0 and 23"})
+			return
+		}
+		sub.SendHour = *req.SendHour
+	}
+	if req.SendWeekday != nil {
+		if *req.SendWeekday < 0 || *req.SendWeekday > 6 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "send_weekday must be between 0 (Sunday) and 6 (Saturday)"})
+			return
+		}
+		sub.SendWeekday = *req.SendWeekday
+	}
+
+	var nextSend pgtype.Timestamptz
+	if sub.Enabled {
+		location, err := userLocation(ctx, querier, userID)
+		if err != nil {
+			h.digestError(c, err)
+			return
+		}
+		next := digest.NextSend(kind, int(sub.SendHour), time.Weekday(sub.SendWeekday), location, time.Now())
+		nextSend = pgtype.Timestamptz{Time: next, Valid: true}
+	}
+
+	updated, err := querier.UpsertDigestSubscription(ctx, db.UpsertDigestSubscriptionParams{
+		UserID:      userID,
+		Kind:        kind,
+		Enabled:     sub.Enabled,
+		SendHour:    sub.SendHour,
+		SendWeekday: sub.SendWeekday,
+		NextSendAt:  nextSend,
+	})
+	if err != nil {
+		h.digestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewDigestResponse(&updated))
+}
+
+// PreviewDigest renders the user's digest as it would be sent right now, as
+// HTML or, with format=text, plain text
+func (h *DigestHandler) PreviewDigest(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	kind, ok := digestKind(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	unsubscribeURL := digest.UnsubscribeURL(h.publicURL, h.secret, userID, kind)
+	msg, err := digest.Preview(ctx, db.QuerierFromContext(ctx, h.querier), userID, kind, time.Now(), unsubscribeURL)
+	if err != nil {
+		h.digestError(c, err)
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(msg.Text))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.HTML))
+}
+
+// UnsubscribePage shows the signed link in the email, asking the user to
+// confirm with a POST to Unsubscribe. It needs no login.
+func (h *DigestHandler) UnsubscribePage(c *gin.Context) {
+	token := c.Query("token")
+	_, kind, err := digest.ParseUnsubscribeToken(h.secret, token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	data := struct{ Token, Kind string }{token, kind}
+	if err := unsubscribePage.Execute(c.Writer, data); err != nil {
+		h.logger.Error("Failed to render unsubscribe page", "error", err)
+	}
+}
+
+// Unsubscribe turns a digest off from the signed link in the email. It needs
+// no login. Mail clients POST to it for one-click unsubscribe (RFC 8058) and
+// get no content back, while the confirmation form gets a page.
+func (h *DigestHandler) Unsubscribe(c *gin.Context) {
+	userID, kind, err := digest.ParseUnsubscribeToken(h.secret, c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	_, err = db.QuerierFromContext(ctx, h.querier).DisableDigestSubscription(ctx, db.DisableDigestSubscriptionParams{
+		UserID: userID,
+		Kind:   kind,
+	})
+	if err != nil {
+		h.digestError(c, err)
+		return
+	}
+
+	if c.PostForm("confirm") != "true" {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := unsubscribedPage.Execute(c.Writer, kind); err != nil {
+		h.logger.Error("Failed to render unsubscribe page", "error", err)
+	}
+}
+
+func (h *DigestHandler) digestError(c *gin.Context, err error) {
+	h.logger.Error("Digest request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+// digestKind reads the :kind parameter, responding with an error if it isn't
+// a digest
+func digestKind(c *gin.Context) (string, bool) {
+	kind := c.Param("kind")
+	if !digest.IsKind(kind) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown digest " + strconv.Quote(kind)})
+		return "", false
+	}
+	return kind, true
+}
+
+func defaultDigestSubscription(userID int32, kind string) *db.DigestSubscription {
+	return &db.DigestSubscription{
+		UserID:      userID,
+		Kind:        kind,
+		SendHour:    defaultDigestHour,
+		SendWeekday: int32(defaultDigestWeekday),
+	}
+}
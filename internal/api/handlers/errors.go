@@ -0,0 +1,22 @@
+package handlers
+
+// requestError is returned from the parts of a handler that run inside a
+// transaction to reject the request with a specific status instead of a 500
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// blockedError rejects completing todos that still wait on the open todos in
+// blockers
+type blockedError struct {
+	blockers []int32
+}
+
+func (e *blockedError) Error() string {
+	return "Blocked by open todos that must be completed first"
+}
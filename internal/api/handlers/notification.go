@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/filter"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/notifications"
+	"github.com/boetro/odot/internal/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var notificationSortKeys = map[string]pagination.SortKey{
+	"created": {Column: "n.created_at", Kind: pagination.KindTime},
+}
+
+type NotificationHandler struct {
+	querier db.Querier
+	pool    *pgxpool.Pool
+	logger  logger.Logger
+}
+
+func NewNotificationHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		querier: querier,
+		pool:    pool,
+		logger:  logger,
+	}
+}
+
+type NotificationResponse struct {
+	ID          int32      `json:"id"`
+	Event       string     `json:"event"`
+	Title       string     `json:"title"`
+	Body        *string    `json:"body"`
+	TodoID      *int32     `json:"todo_id"`
+	ActorUserID *int32     `json:"actor_user_id"`
+	Read        bool       `json:"read"`
+	ReadAt      *time.Time `json:"read_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func NewNotificationResponse(notification *db.Notification) *NotificationResponse {
+	return &NotificationResponse{
+		ID:          notification.NotificationID,
+		Event:       notification.Event,
+		Title:       notification.Title,
+		Body:        textPtr(notification.Body),
+		TodoID:      int4Ptr(notification.TodoID),
+		ActorUserID: int4Ptr(notification.ActorUserID),
+		Read:        notification.ReadAt.Valid,
+		ReadAt:      timePtr(notification.ReadAt),
+		CreatedAt:   notification.CreatedAt.Time,
+	}
+}
+
+// NotificationListFilter narrows the notifications list
+type NotificationListFilter struct {
+	Unread bool   `form:"unread"`
+	Event  string `form:"event"`
+}
+
+type UnreadCountResponse struct {
+	Total   int64            `json:"total"`
+	ByEvent map[string]int64 `json:"by_event"`
+}
+
+// NotificationPreference turns one event on or off for one channel
+type NotificationPreference struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// QuietHours are wall clock times such as "22:00" in the user's timezone.
+// A window that ends before it starts runs overnight.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type NotificationPreferencesResponse struct {
+	Preferences []NotificationPreference `json:"preferences"`
+	QuietHours  *QuietHours              `json:"quiet_hours"`
+	Timezone    string                   `json:"timezone"`
+}
+
+// UpdateNotificationPreferencesRequest changes the listed preferences and
+// leaves the rest alone. Quiet hours are only changed when QuietHours is set;
+// send {"enabled": false} to turn them off.
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences"`
+	QuietHours  *QuietHoursRequest       `json:"quiet_hours"`
+}
+
+type QuietHoursRequest struct {
+	Enabled bool   `json:"enabled"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+// ListNotifications returns a page of the user's notifications, newest first
+// unless order=asc. unread=true and event narrow the list.
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var listFilter NotificationListFilter
+	if err := c.ShouldBindQuery(&listFilter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if listFilter.Event != "" && !notifications.IsEvent(listFilter.Event) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event " + strconv.Quote(listFilter.Event)})
+		return
+	}
+
+	var pageRequest pagination.Request
+	if err := c.ShouldBindQuery(&pageRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if pageRequest.Order == "" {
+		pageRequest.Order = "desc"
+	}
+	query, err := pageRequest.Resolve(notificationSortKeys, "created", "n.notification_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	b := filter.NewBuilder()
+	b.Where("n.user_id = %s", userID)
+	if listFilter.Unread {
+		b.WhereSQL("n.read_at IS NULL")
+	}
+	if listFilter.Event != "" {
+		b.Where("n.event = %s", listFilter.Event)
+	}
+	query.Apply(b)
+
+	sql := "SELECT n.* FROM notifications n WHERE " + b.Conditions() +
+		" ORDER BY " + query.OrderBy() +
+		" LIMIT " + b.Arg(query.LimitArg())
+
+	rows, err := db.DBFromContext(c.Request.Context(), h.pool).Query(c, sql, b.Args()...)
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByName[db.Notification])
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+
+	responses := make([]*NotificationResponse, len(list))
+	for i := range list {
+		responses[i] = NewNotificationResponse(&list[i])
+	}
+	page, err := pagination.NewPage(query, responses, func(notification *NotificationResponse) (any, int32) {
+		return notification.CreatedAt, notification.ID
+	})
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUnreadCount returns how many notifications are unread, in total and per
+// event, for badges
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	counts, err := db.QuerierFromContext(c.Request.Context(), h.querier).CountUnreadNotifications(c, userID)
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+
+	response := UnreadCountResponse{ByEvent: make(map[string]int64, len(notifications.Events))}
+	for _, event := range notifications.Events {
+		response.ByEvent[event] = 0
+	}
+	for _, count := range counts {
+		response.ByEvent[count.Event] = count.Count
+		response.Total += count.Count
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	h.markNotification(c, func(ctx context.Context, querier db.Querier, id int32) (db.Notification, error) {
+		return querier.MarkNotificationRead(ctx, id)
+	})
+}
+
+func (h *NotificationHandler) MarkNotificationUnread(c *gin.Context) {
+	h.markNotification(c, func(ctx context.Context, querier db.Querier, id int32) (db.Notification, error) {
+		return querier.MarkNotificationUnread(ctx, id)
+	})
+}
+
+// MarkAllNotificationsRead marks every unread notification read
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	updated, err := db.QuerierFromContext(c.Request.Context(), h.querier).MarkAllNotificationsRead(c, userID)
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// GetNotificationPreferences returns every event and channel with whether it
+// is enabled, along with the user's quiet hours
+func (h *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	prefs, err := notifications.LoadPreferences(c.Request.Context(), db.QuerierFromContext(c.Request.Context(), h.querier), userID)
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newNotificationPreferencesResponse(prefs))
+}
+
+func (h *NotificationHandler) UpdateNotificationPreferences(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, pref := range req.Preferences {
+		if !notifications.IsEvent(pref.Event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event " + strconv.Quote(pref.Event)})
+			return
+		}
+		if !notifications.IsChannel(pref.Channel) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown channel " + strconv.Quote(pref.Channel)})
+			return
+		}
+	}
+	var quietStart, quietEnd pgtype.Time
+	if req.QuietHours != nil && req.QuietHours.Enabled {
+		var err error
+		if quietStart, err = parseClock(req.QuietHours.Start); err == nil {
+			quietEnd, err = parseClock(req.QuietHours.End)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quiet_hours start and end must be times such as 22:00"})
+			return
+		}
+		if quietStart.Microseconds == quietEnd.Microseconds {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quiet_hours must not start and end at the same time"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	for _, pref := range req.Preferences {
+		err := querier.UpsertNotificationPreference(ctx, db.UpsertNotificationPreferenceParams{
+			UserID:  userID,
+			Event:   pref.Event,
+			Channel: pref.Channel,
+			Enabled: pref.Enabled,
+		})
+		if err != nil {
+			h.notificationError(c, err)
+			return
+		}
+	}
+	if req.QuietHours != nil {
+		_, err := querier.UpsertNotificationSettings(ctx, db.UpsertNotificationSettingsParams{
+			UserID:          userID,
+			QuietHoursStart: quietStart,
+			QuietHoursEnd:   quietEnd,
+		})
+		if err != nil {
+			h.notificationError(c, err)
+			return
+		}
+	}
+
+	prefs, err := notifications.LoadPreferences(ctx, querier, userID)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.notificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newNotificationPreferencesResponse(prefs))
+}
+
+// markNotification applies mark to the notification named by the :id
+// parameter and responds with the result
+func (h *NotificationHandler) markNotification(c *gin.Context, mark func(ctx context.Context, querier db.Querier, id int32) (db.Notification, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	notification, err := querier.GetNotification(ctx, int32(id))
+	if err == nil && notification.UserID != userID {
+		err = pgx.ErrNoRows
+	}
+	if err == nil {
+		notification, err = mark(ctx, querier, notification.NotificationID)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &requestError{http.StatusNotFound, "Notification not found"}
+		}
+		h.notificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewNotificationResponse(&notification))
+}
+
+func (h *NotificationHandler) notificationError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Notification request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+func newNotificationPreferencesResponse(prefs *notifications.Preferences) *NotificationPreferencesResponse {
+	response := &NotificationPreferencesResponse{Timezone: prefs.Location.String()}
+	for _, event := range notifications.Events {
+		for _, channel := range notifications.Channels {
+			response.Preferences = append(response.Preferences, NotificationPreference{
+				Event:   event,
+				Channel: channel,
+				Enabled: prefs.Allows(event, channel),
+			})
+		}
+	}
+	if prefs.QuietStart != nil && prefs.QuietEnd != nil {
+		response.QuietHours = &QuietHours{Start: formatClock(*prefs.QuietStart), End: formatClock(*prefs.QuietEnd)}
+	}
+	return response
+}
+
+// parseClock parses a 24 hour "15:04" time of day
+func parseClock(value string) (pgtype.Time, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return pgtype.Time{}, err
+	}
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return pgtype.Time{Microseconds: offset.Microseconds(), Valid: true}, nil
+}
+
+func formatClock(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)
+}
@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/planning"
+	"github.com/boetro/odot/internal/timeblock"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultDailyPlans is how many past plans are listed when no limit is
+	// given
+	DefaultDailyPlans = 14
+	// MaxDailyPlans caps how many past plans are listed at once
+	MaxDailyPlans = 90
+
+	// maxPlanningCandidates caps the todos considered for a day's plan
+	maxPlanningCandidates = 500
+)
+
+type PlanningHandler struct {
+	querier db.Querier
+	pool    *pgxpool.Pool
+	logger  logger.Logger
+}
+
+func NewPlanningHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *PlanningHandler {
+	return &PlanningHandler{
+		querier: querier,
+		pool:    pool,
+		logger:  logger,
+	}
+}
+
+// PlanningSettingsRequest sets what happens to unfinished todos when their
+// day is over: move, flag or off
+type PlanningSettingsRequest struct {
+	RolloverMode string `json:"rollover_mode" binding:"required"`
+}
+
+type PlanningSettingsResponse struct {
+	RolloverMode   string     `json:"rollover_mode"`
+	NextRolloverAt *time.Time `json:"next_rollover_at"`
+}
+
+// PlanProposalResponse suggests what to work on for a day. Proposed fits into
+// the available minutes; Others are the rest of the candidates, ranked the
+// same way.
+type PlanProposalResponse struct {
+	Date             string          `json:"date"`
+	Timezone         string          `json:"timezone"`
+	AvailableMinutes int32           `json:"available_minutes"`
+	ProposedMinutes  int32           `json:"proposed_minutes"`
+	Proposed         []*TodoResponse `json:"proposed"`
+	Others           []*TodoResponse `json:"others"`
+}
+
+// CommitPlanRequest commits to the todos in TodoIDs, in order, for the day.
+// Committing again replaces the earlier plan.
+type CommitPlanRequest struct {
+	TodoIDs []int32 `json:"todo_ids"`
+}
+
+type DailyPlanItemResponse struct {
+	TodoID         int32      `json:"todo_id"`
+	Title          string     `json:"title"`
+	Position       int32      `json:"position"`
+	PlannedMinutes *int32     `json:"planned_minutes"`
+	AssignedDate   *time.Time `json:"assigned_date"`
+	Done           bool       `json:"done"`
+}
+
+// DailyPlanResponse is a committed plan along with how it went
+type DailyPlanResponse struct {
+	Date             string                   `json:"date"`
+	CommittedAt      time.Time                `json:"committed_at"`
+	AvailableMinutes int32                    `json:"available_minutes"`
+	PlannedMinutes   int32                    `json:"planned_minutes"`
+	CompletedMinutes int32                    `json:"completed_minutes"`
+	CompletedCount   int                      `json:"completed_count"`
+	Items            []*DailyPlanItemResponse `json:"items"`
+}
+
+func (h *PlanningHandler) GetPlanningSettings(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	settings, err := db.QuerierFromContext(ctx, h.querier).GetPlanningSettings(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The rollover job hasn't seen the user yet
+		settings, err = db.PlanningSetting{UserID: userID, RolloverMode: planning.RolloverFlag}, nil
+	}
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPlanningSettingsResponse(&settings))
+}
+
+func (h *PlanningHandler) UpdatePlanningSettings(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req PlanningSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !planning.IsRolloverMode(req.RolloverMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rollover_mode must be move, flag or off"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	settings, err := db.QuerierFromContext(ctx, h.querier).UpsertPlanningSettings(ctx, db.UpsertPlanningSettingsParams{
+		UserID:       userID,
+		RolloverMode: req.RolloverMode,
+	})
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPlanningSettingsResponse(&settings))
+}
+
+// ProposePlan suggests todos to work on for the :date parameter, a date or
+// "today". Open todos assigned to the day or earlier, or not at all, are
+// ranked by priority and age, and picked while their duration_min fits into
+// the working hours left that day. Todos that already have a time that day
+// are left out and their time isn't available.
+func (h *PlanningHandler) ProposePlan(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	now := time.Now()
+	day, location, err := planDay(ctx, querier, userID, c.Param("date"), now)
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+	available, fixed, err := dayAvailability(ctx, querier, userID, day, location, now)
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+
+	todos, err := querier.ListPlanningCandidates(ctx, db.ListPlanningCandidatesParams{
+		UserID:     userID,
+		EndsAt:     pgtype.Timestamptz{Time: startOfDay(day, 1), Valid: true},
+		MaxResults: maxPlanningCandidates,
+	})
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+	byID := make(map[int32]*db.Todo, len(todos))
+	candidates := make([]planning.Candidate, 0, len(todos))
+	for i := range todos {
+		todo := &todos[i]
+		if fixed[todo.TodoID] {
+			continue
+		}
+		byID[todo.TodoID] = todo
+		candidates = append(candidates, planning.Candidate{
+			ID:            todo.TodoID,
+			Minutes:       todo.DurationMin.Int32,
+			Priority:      todo.Priority.Int32,
+			RolloverCount: todo.RolloverCount,
+			CreatedAt:     todo.CreatedAt.Time,
+		})
+	}
+	planning.Rank(candidates)
+	proposed := planning.Propose(candidates, available)
+
+	response := &PlanProposalResponse{
+		Date:             day.Format(time.DateOnly),
+		Timezone:         location.String(),
+		AvailableMinutes: available,
+		Proposed:         make([]*TodoResponse, 0, len(proposed)),
+		Others:           make([]*TodoResponse, 0, len(candidates)-len(proposed)),
+	}
+	picked := make(map[int32]bool, len(proposed))
+	for _, candidate := range proposed {
+		picked[candidate.ID] = true
+		response.ProposedMinutes += candidate.Minutes
+		response.Proposed = append(response.Proposed, NewTodoResponse(byID[candidate.ID]))
+	}
+	for _, candidate := range candidates {
+		if !picked[candidate.ID] {
+			response.Others = append(response.Others, NewTodoResponse(byID[candidate.ID]))
+		}
+	}
+	if err := fillDependencies(ctx, querier, append(append([]*TodoResponse{}, response.Proposed...), response.Others...)); err != nil {
+		h.planningError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CommitPlan records the todos the user commits to for the :date parameter,
+// which can't be in the past, and assigns them to that day. Todos already
+// assigned to the day keep their time, and recurring todos stay on their
+// schedule.
+func (h *PlanningHandler) CommitPlan(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CommitPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	response, err := commitPlan(ctx, db.New(tx), userID, c.Param("date"), uniqueIDs(req.TodoIDs), time.Now())
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDailyPlan returns the plan committed for the :date parameter and how
+// much of it got done
+func (h *PlanningHandler) GetDailyPlan(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	day, _, err := planDay(ctx, querier, userID, c.Param("date"), time.Now())
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+	plan, err := querier.GetDailyPlan(ctx, db.GetDailyPlanParams{UserID: userID, PlanDate: pgDate(day)})
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = &requestError{http.StatusNotFound, "No plan was committed for " + day.Format(time.DateOnly)}
+	}
+	var response *DailyPlanResponse
+	if err == nil {
+		response, err = dailyPlanResponse(ctx, querier, &plan)
+	}
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListDailyPlans returns the most recent committed plans, newest first
+func (h *PlanningHandler) ListDailyPlans(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	limit := DefaultDailyPlans
+	if param := c.Query("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > MaxDailyPlans {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(MaxDailyPlans)})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	plans, err := querier.ListDailyPlans(ctx, db.ListDailyPlansParams{UserID: userID, Limit: int32(limit)})
+	if err != nil {
+		h.planningError(c, err)
+		return
+	}
+	responses := make([]*DailyPlanResponse, len(plans))
+	for i := range plans {
+		if responses[i], err = dailyPlanResponse(ctx, querier, &plans[i]); err != nil {
+			h.planningError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+func (h *PlanningHandler) planningError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Planning request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+func commitPlan(ctx context.Context, querier db.Querier, userID int32, date string, todoIDs []int32, now time.Time) (*DailyPlanResponse, error) {
+	day, location, err := planDay(ctx, querier, userID, date, now)
+	if err != nil {
+		return nil, err
+	}
+	if day.Before(startOfDay(now.In(location), 0)) {
+		return nil, &requestError{http.StatusBadRequest, "Plans can't be committed for past days"}
+	}
+
+	todos := make([]*db.Todo, len(todoIDs))
+	for i, todoID := range todoIDs {
+		if todos[i], err = ownedTodo(ctx, querier, userID, todoID); err != nil {
+			return nil, err
+		}
+		if todos[i].IsCompleted.Bool {
+			return nil, &requestError{http.StatusBadRequest, "Todo " + strconv.Itoa(int(todoID)) + " is already completed"}
+		}
+	}
+
+	available, _, err := dayAvailability(ctx, querier, userID, day, location, now)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := querier.UpsertDailyPlan(ctx, db.UpsertDailyPlanParams{
+		UserID:           userID,
+		PlanDate:         pgDate(day),
+		AvailableMinutes: available,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := querier.DeleteDailyPlanItems(ctx, plan.DailyPlanID); err != nil {
+		return nil, err
+	}
+
+	for i, todo := range todos {
+		err := querier.CreateDailyPlanItem(ctx, db.CreateDailyPlanItemParams{
+			DailyPlanID:    plan.DailyPlanID,
+			TodoID:         todo.TodoID,
+			Position:       int32(i),
+			PlannedMinutes: todo.DurationMin,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		onDay := todo.AssignedDate.Valid && startOfDay(todo.AssignedDate.Time.In(location), 0).Equal(day)
+		if onDay || todo.RecurrenceRule.Valid {
+			continue
+		}
+		_, err = querier.SetTodoAssignedDate(ctx, db.SetTodoAssignedDateParams{
+			AssignedDate: pgtype.Timestamptz{Time: day, Valid: true},
+			TodoID:       todo.TodoID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dailyPlanResponse(ctx, querier, &plan)
+}
+
+// planDay parses a "today" or YYYY-MM-DD date parameter into the start of
+// that day in the user's timezone
+func planDay(ctx context.Context, querier db.Querier, userID int32, date string, now time.Time) (time.Time, *time.Location, error) {
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if date == "today" {
+		return startOfDay(now.In(location), 0), location, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, date, location)
+	if err != nil {
+		return time.Time{}, nil, &requestError{http.StatusBadRequest, "date must be today or a date such as 2025-01-31"}
+	}
+	return day, location, nil
+}
+
+// dayAvailability works out how many minutes of working time are left on day
+// after now, and which todos already have a time that day. Those todos, and
+// accepted or pinned schedule blocks, take up the time they're set for.
+func dayAvailability(ctx context.Context, querier db.Querier, userID int32, day time.Time, location *time.Location, now time.Time) (int32, map[int32]bool, error) {
+	hours, _, err := loadWorkingHours(ctx, querier, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	end := startOfDay(day, 1)
+	starts := pgtype.Timestamptz{Time: day, Valid: true}
+	ends := pgtype.Timestamptz{Time: end, Valid: true}
+
+	fixed := make(map[int32]bool)
+	var busy []timeblock.Interval
+	todos, err := querier.ListOpenTodosBetween(ctx, db.ListOpenTodosBetweenParams{
+		UserID:     userID,
+		StartsAt:   starts,
+		EndsAt:     ends,
+		MaxResults: maxPlanningCandidates,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, todo := range todos {
+		assigned := todo.AssignedDate.Time.In(location)
+		if assigned.Equal(startOfDay(assigned, 0)) {
+			continue
+		}
+		fixed[todo.TodoID] = true
+		if todo.DurationMin.Valid {
+			busy = append(busy, timeblock.Interval{
+				Start: assigned,
+				End:   assigned.Add(time.Duration(todo.DurationMin.Int32) * time.Minute),
+			})
+		}
+	}
+	blocks, err := querier.ListScheduleBlocks(ctx, db.ListScheduleBlocksParams{UserID: userID, StartsAt: starts, EndsAt: ends})
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, block := range blocks {
+		if block.Status == "accepted" || block.Pinned {
+			fixed[block.TodoID] = true
+			busy = append(busy, timeblock.Interval{Start: block.StartsAt.Time, End: block.EndsAt.Time})
+		}
+	}
+
+	from := day
+	if now.After(from) {
+		from = now
+	}
+	var free time.Duration
+	if from.Before(end) {
+		for _, slot := range timeblock.FreeSlots(hours, location, from, end, busy) {
+			free += slot.Duration()
+		}
+	}
+	return int32(free / time.Minute), fixed, nil
+}
+
+func dailyPlanResponse(ctx context.Context, querier db.Querier, plan *db.DailyPlan) (*DailyPlanResponse, error) {
+	items, err := querier.ListDailyPlanItems(ctx, plan.DailyPlanID)
+	if err != nil {
+		return nil, err
+	}
+	response := &DailyPlanResponse{
+		Date:             plan.PlanDate.Time.Format(time.DateOnly),
+		CommittedAt:      plan.CommittedAt.Time,
+		AvailableMinutes: plan.AvailableMinutes,
+		Items:            make([]*DailyPlanItemResponse, len(items)),
+	}
+	for i, item := range items {
+		response.Items[i] = &DailyPlanItemResponse{
+			TodoID:         item.TodoID,
+			Title:          item.Title,
+			Position:       item.Position,
+			PlannedMinutes: int4Ptr(item.PlannedMinutes),
+			AssignedDate:   timePtr(item.AssignedDate),
+			Done:           item.Done,
+		}
+		response.PlannedMinutes += item.PlannedMinutes.Int32
+		if item.Done {
+			response.CompletedCount++
+			response.CompletedMinutes += item.PlannedMinutes.Int32
+		}
+	}
+	return response, nil
+}
+
+func newPlanningSettingsResponse(settings *db.PlanningSetting) *PlanningSettingsResponse {
+	return &PlanningSettingsResponse{
+		RolloverMode:   settings.RolloverMode,
+		NextRolloverAt: timePtr(settings.NextRolloverAt),
+	}
+}
+
+// pgDate converts the start of a local day to a DATE
+func pgDate(day time.Time) pgtype.Date {
+	return pgtype.Date{Time: time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC), Valid: true}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/boetro/odot/internal/rank"
+)
+
+// MoveRequest places an item of a manually ordered list next to other items
+// of the same list. With only after_id the item goes right after it, and
+// with only before_id right before it.
+type MoveRequest struct {
+	// AfterID is the item the moved one should follow
+	AfterID *int32 `json:"after_id"`
+	// BeforeID is the item the moved one should precede
+	BeforeID *int32 `json:"before_id"`
+}
+
+// sibling is an item of a manually ordered list
+type sibling struct {
+	ID       int32
+	Position string
+}
+
+// firstPosition returns the position that puts a new item at the start of a
+// list whose lowest position is first, or which is empty when first is. ok
+// is false when the list is due a rebalance, done with placeFirst once the
+// item exists.
+func firstPosition(first string) (position string, ok bool) {
+	position, err := rank.Between("", first)
+	if err != nil {
+		return first, false
+	}
+	return position, len(position) <= rank.MaxLength
+}
+
+// lastPosition is firstPosition for the end of a list whose highest
+// position is last, due a rebalance with placeLast when ok is false
+func lastPosition(last string) (position string, ok bool) {
+	position, err := rank.Between(last, "")
+	if err != nil {
+		return last, false
+	}
+	return position, len(position) <= rank.MaxLength
+}
+
+// placeLast rebalances a list, putting the item id at its end
+func placeLast(ctx context.Context, siblings []sibling, id int32, setPosition func(ctx context.Context, id int32, position string) error) error {
+	list := make([]sibling, 0, len(siblings)+1)
+	for _, s := range siblings {
+		if s.ID != id {
+			list = append(list, s)
+		}
+	}
+	return rebalance(ctx, append(list, sibling{ID: id}), setPosition)
+}
+
+// placeFirst rebalances a list, putting the item id at its start
+func placeFirst(ctx context.Context, siblings []sibling, id int32, setPosition func(ctx context.Context, id int32, position string) error) error {
+	list := make([]sibling, 1, len(siblings)+1)
+	list[0] = sibling{ID: id}
+	for _, s := range siblings {
+		if s.ID != id {
+			list = append(list, s)
+		}
+	}
+	return rebalance(ctx, list, setPosition)
+}
+
+// moveBetween gives the item id a position among siblings, which are in
+// order and may include the item itself, that puts it between the neighbours
+// in req. Only the item's own key is written unless the neighbours have no
+// room left between them, when the whole list is rebalanced.
+func moveBetween(ctx context.Context, siblings []sibling, id int32, req *MoveRequest, setPosition func(ctx context.Context, id int32, position string) error) error {
+	if req.AfterID == nil && req.BeforeID == nil {
+		return &requestError{http.StatusBadRequest, "after_id or before_id is required"}
+	}
+	others := make([]sibling, 0, len(siblings))
+	for _, s := range siblings {
+		if s.ID != id {
+			others = append(others, s)
+		}
+	}
+	index := func(neighbourID int32) (int, error) {
+		for i, s := range others {
+			if s.ID == neighbourID {
+				return i, nil
+			}
+		}
+		return 0, &requestError{http.StatusBadRequest, "Neighbours must be other items of the same list"}
+	}
+
+	// at is where the item goes among the others. A client showing part of
+	// the list can name neighbours that aren't next to each other, and the
+	// item then goes right after after_id.
+	var at int
+	if req.BeforeID != nil {
+		i, err := index(*req.BeforeID)
+		if err != nil {
+			return err
+		}
+		at = i
+	}
+	if req.AfterID != nil {
+		i, err := index(*req.AfterID)
+		if err != nil {
+			return err
+		}
+		if req.BeforeID != nil && i >= at {
+			return &requestError{http.StatusBadRequest, "after_id must come before before_id"}
+		}
+		at = i + 1
+	}
+
+	var lo, hi string
+	if at > 0 {
+		lo = others[at-1].Position
+	}
+	if at < len(others) {
+		hi = others[at].Position
+	}
+	if position, err := rank.Between(lo, hi); err == nil && len(position) <= rank.MaxLength {
+		return setPosition(ctx, id, position)
+	}
+
+	list := make([]sibling, 0, len(others)+1)
+	list = append(append(append(list, others[:at]...), sibling{ID: id}), others[at:]...)
+	return rebalance(ctx, list, setPosition)
+}
+
+// rebalance spreads the positions of a list evenly, keeping its order, and
+// writes those that changed
+func rebalance(ctx context.Context, list []sibling, setPosition func(ctx context.Context, id int32, position string) error) error {
+	positions, err := rank.Spread("", "", len(list))
+	if err != nil {
+		return err
+	}
+	for i, s := range list {
+		if s.Position == positions[i] {
+			continue
+		}
+		if err := setPosition(ctx, s.ID, positions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,22 +2,30 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/boetro/odot/internal/api/middleware"
 	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/filter"
 	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/pagination"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ProjectHandler struct {
 	querier db.Querier
+	pool    *pgxpool.Pool
 	logger  logger.Logger
 }
 
-func NewProjectHandler(querier db.Querier, logger logger.Logger) *ProjectHandler {
+func NewProjectHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *ProjectHandler {
 	return &ProjectHandler{
 		querier: querier,
+		pool:    pool,
 		logger:  logger,
 	}
 }
@@ -35,6 +43,10 @@ type ProjectResponse struct {
 	Description     *string `json:"description"`
 	Color           string  `json:"color"`
 	ParentProjectID *int    `json:"parent_project_id"`
+	// Position orders the project among those with the same parent
+	Position  string    `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func NewProjectResponse(project *db.Project) *ProjectResponse {
@@ -50,9 +62,20 @@ func NewProjectResponse(project *db.Project) *ProjectResponse {
 			}
 			return nil
 		}(),
+		Position:  project.Position,
+		CreatedAt: project.CreatedAt.Time,
+		UpdatedAt: project.UpdatedAt.Time,
 	}
 }
 
+// projectSortKeys are the orderings supported by ListProjects
+var projectSortKeys = map[string]pagination.SortKey{
+	"created":  {Column: "p.created_at", Kind: pagination.KindTime},
+	"updated":  {Column: "p.updated_at", Kind: pagination.KindTime},
+	"name":     {Column: "p.name", Kind: pagination.KindText},
+	"position": {Column: "p.position", Kind: pagination.KindText},
+}
+
 func (h *ProjectHandler) CreateProject(c *gin.Context) {
 	userId, ok := middleware.GetUserID(c)
 
@@ -61,6 +84,8 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		return
 	}
 
+	querier := db.QuerierFromContext(c.Request.Context(), h.querier)
+
 	var req CreateProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -81,7 +106,7 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 
 	var parentProjectID pgtype.Int4
 	if req.ParentProjectID != 0 {
-		parentProject, err := h.querier.GetProject(c, req.ParentProjectID)
+		parentProject, err := querier.GetProject(c, req.ParentProjectID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 			return
@@ -100,7 +125,18 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		}
 	}
 
-	project, err := h.querier.CreateProject(c, db.CreateProjectParams{
+	// New projects go to the top of their list
+	first, err := querier.FirstProjectPosition(c, db.FirstProjectPositionParams{
+		UserID:          userId,
+		ParentProjectID: parentProjectID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+	position, ok := firstPosition(first)
+
+	project, err := querier.CreateProject(c, db.CreateProjectParams{
 		Name:        req.Name,
 		UserID:      userId,
 		Description: description,
@@ -109,6 +145,7 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 			Valid:  true,
 		},
 		ParentProjectID: parentProjectID,
+		Position:        position,
 	})
 
 	if err != nil {
@@ -116,6 +153,20 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		return
 	}
 
+	if !ok {
+		siblings, err := projectSiblings(c, querier, &project)
+		if err == nil {
+			err = placeFirst(c, siblings, project.ProjectID, setProjectPosition(querier))
+		}
+		if err == nil {
+			project, err = querier.GetProject(c, project.ProjectID)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+	}
+
 	response := NewProjectResponse(&project)
 
 	c.JSON(http.StatusCreated, response)
@@ -131,8 +182,41 @@ func (h *ProjectHandler) ListProjects(c *gin.Context) {
 		return
 	}
 
-	projects, err := h.querier.ListProjects(c, userId)
+	var projectFilter filter.ProjectFilter
+	if err := c.ShouldBindQuery(&projectFilter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var pageRequest pagination.Request
+	if err := c.ShouldBindQuery(&pageRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if pageRequest.Sort == "" && pageRequest.Order == "" {
+		pageRequest.Order = "asc"
+	}
+	query, err := pageRequest.Resolve(projectSortKeys, "position", "p.project_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	b := filter.NewBuilder()
+	b.Where("p.user_id = %s", userId)
+	projectFilter.Apply(b)
+	query.Apply(b)
 
+	sql := "SELECT p.* FROM projects p WHERE " + b.Conditions() +
+		" ORDER BY " + query.OrderBy() +
+		" LIMIT " + b.Arg(query.LimitArg())
+
+	rows, err := db.DBFromContext(c.Request.Context(), h.pool).Query(c, sql, b.Args()...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+	projects, err := pgx.CollectRows(rows, pgx.RowToStructByName[db.Project])
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 		return
@@ -144,7 +228,122 @@ func (h *ProjectHandler) ListProjects(c *gin.Context) {
 		responses[i] = NewProjectResponse(&project)
 	}
 
-	c.JSON(http.StatusOK, responses)
+	page, err := pagination.NewPage(query, responses, func(project *ProjectResponse) (any, int32) {
+		switch query.Sort() {
+		case "updated":
+			return project.UpdatedAt, int32(project.ID)
+		case "name":
+			return project.Name, int32(project.ID)
+		case "position":
+			return project.Position, int32(project.ID)
+		}
+		return project.CreatedAt, int32(project.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
 
 	return
 }
+
+// ProjectSectionTodos is a section with its todos
+type ProjectSectionTodos struct {
+	*ProjectSectionResponse
+	Todos []*TodoResponse `json:"todos"`
+}
+
+type ProjectDetailResponse struct {
+	*ProjectResponse
+	// Todos are the todos outside any section
+	Todos    []*TodoResponse        `json:"todos"`
+	Sections []*ProjectSectionTodos `json:"sections"`
+}
+
+// GetProject returns a project with its sections and their todos, each list
+// in manual order. Subtasks are listed in their parent's section, and
+// completed todos are left out unless completed=true.
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	withCompleted := c.Query("completed") == "true"
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	project, err := ownedProject(ctx, querier, userID, int32(projectID))
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+	sections, err := querier.ListProjectSections(ctx, project.ProjectID)
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+	todos, err := querier.ListProjectTodos(ctx, pgtype.Int4{Int32: project.ProjectID, Valid: true})
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+
+	response := &ProjectDetailResponse{
+		ProjectResponse: NewProjectResponse(project),
+		Todos:           []*TodoResponse{},
+		Sections:        make([]*ProjectSectionTodos, len(sections)),
+	}
+	bySection := make(map[int32]*ProjectSectionTodos, len(sections))
+	for i := range sections {
+		response.Sections[i] = &ProjectSectionTodos{
+			ProjectSectionResponse: NewProjectSectionResponse(&sections[i]),
+			Todos:                  []*TodoResponse{},
+		}
+		bySection[sections[i].SectionID] = response.Sections[i]
+	}
+
+	byID := make(map[int32]*db.Todo, len(todos))
+	for i := range todos {
+		byID[todos[i].TodoID] = &todos[i]
+	}
+	// sectionOf follows a todo up to its top-level ancestor in the project
+	sectionOf := func(todo *db.Todo) pgtype.Int4 {
+		for depth := 0; todo.ParentTodoID.Valid && depth < len(todos); depth++ {
+			parent, ok := byID[todo.ParentTodoID.Int32]
+			if !ok {
+				break
+			}
+			todo = parent
+		}
+		return todo.SectionID
+	}
+	var listed []*TodoResponse
+	for i := range todos {
+		todo := &todos[i]
+		if todo.IsCompleted.Bool && !withCompleted {
+			continue
+		}
+		item := NewTodoResponse(todo)
+		listed = append(listed, item)
+		if section, ok := bySection[sectionOf(todo).Int32]; ok {
+			section.Todos = append(section.Todos, item)
+		} else {
+			response.Todos = append(response.Todos, item)
+		}
+	}
+	if err := fillDependencies(ctx, querier, listed); err != nil {
+		h.projectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// MoveProject reorders a project among the projects with the same parent
+func (h *ProjectHandler) MoveProject(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req MoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	project, err := ownedProject(ctx, querier, userID, int32(projectID))
+	if err == nil {
+		// Concurrent moves would otherwise each place their project against
+		// keys the other is rewriting
+		err = querier.LockUser(ctx, userID)
+	}
+	var siblings []sibling
+	if err == nil {
+		siblings, err = projectSiblings(ctx, querier, project)
+	}
+	if err == nil {
+		err = moveBetween(ctx, siblings, project.ProjectID, &req, setProjectPosition(querier))
+	}
+	var moved db.Project
+	if err == nil {
+		moved, err = querier.GetProject(ctx, project.ProjectID)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.projectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewProjectResponse(&moved))
+}
+
+// projectSiblings lists the projects sharing project's parent, in order
+func projectSiblings(ctx context.Context, querier db.Querier, project *db.Project) ([]sibling, error) {
+	rows, err := querier.ListProjectSiblings(ctx, db.ListProjectSiblingsParams{
+		UserID:          project.UserID,
+		ParentProjectID: project.ParentProjectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	siblings := make([]sibling, len(rows))
+	for i, row := range rows {
+		siblings[i] = sibling{ID: row.ProjectID, Position: row.Position}
+	}
+	return siblings, nil
+}
+
+func setProjectPosition(querier db.Querier) func(ctx context.Context, id int32, position string) error {
+	return func(ctx context.Context, id int32, position string) error {
+		return querier.SetProjectPosition(ctx, db.SetProjectPositionParams{Position: position, ProjectID: id})
+	}
+}
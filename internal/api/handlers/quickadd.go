@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/quickadd"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const defaultTagColor = "#6B7280"
+
+type QuickAddRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// QuickAddName is a project or tag named in the text. ID is only set when
+// the user already has one with that name.
+type QuickAddName struct {
+	ID     *int32 `json:"id"`
+	Name   string `json:"name"`
+	Exists bool   `json:"exists"`
+}
+
+// QuickAddPreview is what a quick-add line will create
+type QuickAddPreview struct {
+	Title        string           `json:"title"`
+	AssignedDate *time.Time       `json:"assigned_date"`
+	HasTime      bool             `json:"has_time"`
+	DurationMin  *int32           `json:"duration_min"`
+	Priority     int32            `json:"priority"`
+	Project      *QuickAddName    `json:"project"`
+	Tags         []*QuickAddName  `json:"tags"`
+	Matches      []quickadd.Match `json:"matches"`
+	Timezone     string           `json:"timezone"`
+}
+
+// PreviewQuickAdd parses a quick-add line without creating anything so the
+// client can show what was recognized as the user types
+func (h *TodoHandler) PreviewQuickAdd(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req QuickAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.parseQuickAdd(c.Request.Context(), db.QuerierFromContext(c.Request.Context(), h.querier), userID, req.Text)
+	if err != nil {
+		h.todoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// QuickAdd parses a quick-add line and creates the todo. Tags that don't
+// exist yet are created; an unknown project is rejected.
+func (h *TodoHandler) QuickAdd(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req QuickAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.logger.Error("Failed to begin transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	todo, err := h.quickAdd(ctx, querier, userID, req.Text)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.todoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.withDuplicates(ctx, todo))
+}
+
+func (h *TodoHandler) quickAdd(ctx context.Context, querier db.Querier, userID int32, text string) (*db.Todo, error) {
+	preview, err := h.parseQuickAdd(ctx, querier, userID, text)
+	if err != nil {
+		return nil, err
+	}
+	if preview.Title == "" {
+		return nil, &requestError{http.StatusBadRequest, "The todo needs a title"}
+	}
+
+	req := &CreateTodoRequest{
+		Title:        preview.Title,
+		AssignedDate: preview.AssignedDate,
+		DurationMin:  preview.DurationMin,
+		Priority:     preview.Priority,
+	}
+	if preview.Project != nil {
+		if !preview.Project.Exists {
+			return nil, &requestError{http.StatusNotFound, "Project #" + preview.Project.Name + " not found"}
+		}
+		req.ProjectID = preview.Project.ID
+	}
+	for _, tag := range preview.Tags {
+		if !tag.Exists {
+			created, err := querier.CreateTag(ctx, db.CreateTagParams{
+				UserID: userID,
+				Name:   tag.Name,
+				Color:  pgtype.Text{String: defaultTagColor, Valid: true},
+			})
+			if err != nil {
+				return nil, err
+			}
+			tag.ID = &created.TagID
+		}
+		req.TagIDs = append(req.TagIDs, *tag.ID)
+	}
+
+	return createTodo(ctx, querier, userID, req)
+}
+
+// parseQuickAdd parses text in the user's timezone and resolves the project
+// and tag names it mentions
+func (h *TodoHandler) parseQuickAdd(ctx context.Context, querier db.Querier, userID int32, text string) (*QuickAddPreview, error) {
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := quickadd.Parse(text, time.Now(), location)
+
+	preview := &QuickAddPreview{
+		Title:        result.Title,
+		AssignedDate: result.AssignedDate,
+		HasTime:      result.HasTime,
+		DurationMin:  result.DurationMin,
+		Priority:     result.Priority,
+		Tags:         []*QuickAddName{},
+		Matches:      result.Matches,
+		Timezone:     location.String(),
+	}
+	if preview.Matches == nil {
+		preview.Matches = []quickadd.Match{}
+	}
+
+	if result.Project != "" {
+		preview.Project = &QuickAddName{Name: result.Project}
+		project, err := querier.GetProjectByName(ctx, db.GetProjectByNameParams{UserID: userID, Name: result.Project})
+		switch {
+		case err == nil:
+			preview.Project.ID = &project.ProjectID
+			preview.Project.Name = project.Name
+			preview.Project.Exists = true
+		case !errors.Is(err, pgx.ErrNoRows):
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool, len(result.Tags))
+	for _, name := range result.Tags {
+		// Tags match by name regardless of case, as projects do
+		key := strings.ToLower(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		tag := &QuickAddName{Name: name}
+		existing, err := querier.GetTagByName(ctx, db.GetTagByNameParams{UserID: userID, Name: name})
+		switch {
+		case err == nil:
+			tag.ID = &existing.TagID
+			tag.Exists = true
+		case !errors.Is(err, pgx.ErrNoRows):
+			return nil, err
+		}
+		preview.Tags = append(preview.Tags, tag)
+	}
+
+	return preview, nil
+}
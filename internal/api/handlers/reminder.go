@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/reminders"
+	"github.com/boetro/odot/internal/safehttp"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxReminderOffset is how long before a todo a relative reminder can fire
+const maxReminderOffset = 4 * 7 * 24 * 60
+
+type ReminderHandler struct {
+	querier db.Querier
+	guard   *safehttp.Guard
+	logger  logger.Logger
+}
+
+// NewReminderHandler returns a ReminderHandler. guard decides which hosts
+// webhook URLs may point at.
+func NewReminderHandler(querier db.Querier, guard *safehttp.Guard, logger logger.Logger) *ReminderHandler {
+	return &ReminderHandler{
+		querier: querier,
+		guard:   guard,
+		logger:  logger,
+	}
+}
+
+// ReminderRequest creates or updates a reminder. A reminder fires either at
+// RemindAt or OffsetMinutes before the todo's assigned date; setting one on
+// update clears the other. On update only the fields that are set are
+// changed, and the todo can't be changed.
+type ReminderRequest struct {
+	TodoID        *int32     `json:"todo_id"`
+	RemindAt      *time.Time `json:"remind_at"`
+	OffsetMinutes *int32     `json:"offset_minutes"`
+	Channel       *string    `json:"channel"`
+	WebhookURL    *string    `json:"webhook_url"`
+}
+
+type ReminderResponse struct {
+	ID            int32      `json:"id"`
+	TodoID        int32      `json:"todo_id"`
+	RemindAt      *time.Time `json:"remind_at"`
+	OffsetMinutes *int32     `json:"offset_minutes"`
+	Channel       string     `json:"channel"`
+	WebhookURL    *string    `json:"webhook_url"`
+	LastFiredAt   *time.Time `json:"last_fired_at"`
+	LastError     *string    `json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func NewReminderResponse(reminder *db.Reminder) *ReminderResponse {
+	return &ReminderResponse{
+		ID:            reminder.ReminderID,
+		TodoID:        reminder.TodoID,
+		RemindAt:      timePtr(reminder.RemindAt),
+		OffsetMinutes: int4Ptr(reminder.OffsetMinutes),
+		Channel:       reminder.Channel,
+		WebhookURL:    textPtr(reminder.WebhookUrl),
+		LastFiredAt:   timePtr(reminder.LastFiredAt),
+		LastError:     textPtr(reminder.LastError),
+		CreatedAt:     reminder.CreatedAt.Time,
+		UpdatedAt:     reminder.UpdatedAt.Time,
+	}
+}
+
+// ListReminders returns the user's reminders, or only those of one todo when
+// todo_id is given
+func (h *ReminderHandler) ListReminders(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+
+	var list []db.Reminder
+	var err error
+	if todoParam := c.Query("todo_id"); todoParam != "" {
+		todoID, parseErr := strconv.ParseInt(todoParam, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+			return
+		}
+		if _, err = ownedTodo(ctx, querier, userID, int32(todoID)); err == nil {
+			list, err = querier.ListRemindersByTodo(ctx, int32(todoID))
+		}
+	} else {
+		list, err = querier.ListReminders(ctx, userID)
+	}
+	if err != nil {
+		h.reminderError(c, err)
+		return
+	}
+
+	responses := make([]*ReminderResponse, len(list))
+	for i := range list {
+		responses[i] = NewReminderResponse(&list[i])
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+func (h *ReminderHandler) GetReminder(c *gin.Context) {
+	reminder, ok := h.ownedReminder(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, NewReminderResponse(reminder))
+}
+
+func (h *ReminderHandler) CreateReminder(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TodoID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "todo_id is required"})
+		return
+	}
+	if req.RemindAt == nil && req.OffsetMinutes == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "remind_at or offset_minutes is required"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	if _, err := ownedTodo(ctx, querier, userID, *req.TodoID); err != nil {
+		h.reminderError(c, err)
+		return
+	}
+
+	reminder := db.Reminder{
+		UserID:  userID,
+		TodoID:  *req.TodoID,
+		Channel: reminders.ChannelInApp,
+	}
+	if err := h.applyReminderRequest(ctx, &reminder, &req); err != nil {
+		h.reminderError(c, err)
+		return
+	}
+
+	created, err := querier.CreateReminder(ctx, db.CreateReminderParams{
+		UserID:        reminder.UserID,
+		TodoID:        reminder.TodoID,
+		RemindAt:      reminder.RemindAt,
+		OffsetMinutes: reminder.OffsetMinutes,
+		Channel:       reminder.Channel,
+		WebhookUrl:    reminder.WebhookUrl,
+	})
+	if err != nil {
+		h.reminderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, NewReminderResponse(&created))
+}
+
+// UpdateReminder changes when or how a reminder fires. A reminder that
+// already fired will fire again at its new time.
+func (h *ReminderHandler) UpdateReminder(c *gin.Context) {
+	reminder, ok := h.ownedReminder(c)
+	if !ok {
+		return
+	}
+
+	var req ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TodoID != nil && *req.TodoID != reminder.TodoID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reminder can't be moved to another todo"})
+		return
+	}
+	ctx := c.Request.Context()
+	if err := h.applyReminderRequest(ctx, reminder, &req); err != nil {
+		h.reminderError(c, err)
+		return
+	}
+
+	updated, err := db.QuerierFromContext(ctx, h.querier).UpdateReminder(ctx, db.UpdateReminderParams{
+		ReminderID:    reminder.ReminderID,
+		RemindAt:      reminder.RemindAt,
+		OffsetMinutes: reminder.OffsetMinutes,
+		Channel:       reminder.Channel,
+		WebhookUrl:    reminder.WebhookUrl,
+	})
+	if err != nil {
+		h.reminderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewReminderResponse(&updated))
+}
+
+func (h *ReminderHandler) DeleteReminder(c *gin.Context) {
+	reminder, ok := h.ownedReminder(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := db.QuerierFromContext(ctx, h.querier).DeleteReminder(ctx, reminder.ReminderID); err != nil {
+		h.reminderError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ownedReminder loads the reminder named by the :id parameter, responding
+// with an error if it doesn't exist or belongs to someone else
+func (h *ReminderHandler) ownedReminder(c *gin.Context) (*db.Reminder, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	reminder, err := db.QuerierFromContext(ctx, h.querier).GetReminder(ctx, int32(id))
+	if err == nil && reminder.UserID != userID {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &requestError{http.StatusNotFound, "Reminder not found"}
+		}
+		h.reminderError(c, err)
+		return nil, false
+	}
+
+	return &reminder, true
+}
+
+// applyReminderRequest validates the set fields of req and copies them onto
+// reminder
+func (h *ReminderHandler) applyReminderRequest(ctx context.Context, reminder *db.Reminder, req *ReminderRequest) error {
+	if req.RemindAt != nil && req.OffsetMinutes != nil {
+		return &requestError{http.StatusBadRequest, "Only one of remind_at and offset_minutes can be set"}
+	}
+	if req.RemindAt != nil {
+		reminder.RemindAt = pgTimestamptz(req.RemindAt)
+		reminder.OffsetMinutes = pgInt4(nil)
+	}
+	if req.OffsetMinutes != nil {
+		if *req.OffsetMinutes < 0 || *req.OffsetMinutes > maxReminderOffset {
+			return &requestError{http.StatusBadRequest, "offset_minutes must be between 0 and " + strconv.Itoa(maxReminderOffset)}
+		}
+		reminder.OffsetMinutes = pgInt4(req.OffsetMinutes)
+		reminder.RemindAt = pgTimestamptz(nil)
+	}
+
+	if req.Channel != nil {
+		switch *req.Channel {
+		case reminders.ChannelInApp, reminders.ChannelEmail, reminders.ChannelWebhook:
+		default:
+			return &requestError{http.StatusBadRequest, "channel must be in_app, email or webhook"}
+		}
+		reminder.Channel = *req.Channel
+	}
+	if req.WebhookURL != nil {
+		target, err := url.Parse(*req.WebhookURL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return &requestError{http.StatusBadRequest, "webhook_url must be an http or https URL"}
+		}
+		if err := h.guard.CheckURL(ctx, *req.WebhookURL); err != nil {
+			return &requestError{http.StatusBadRequest, "webhook_url must point at a public host"}
+		}
+		reminder.WebhookUrl = pgText(req.WebhookURL)
+	}
+
+	if reminder.Channel != reminders.ChannelWebhook {
+		reminder.WebhookUrl = pgText(nil)
+	} else if !reminder.WebhookUrl.Valid {
+		return &requestError{http.StatusBadRequest, "webhook_url is required for the webhook channel"}
+	}
+	return nil
+}
+
+func (h *ReminderHandler) reminderError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Reminder request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/filter"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	defaultSavedFilterColor = "#3B82F6"
+	// maxSavedFilterName is the length of saved_filters.name
+	maxSavedFilterName = 255
+)
+
+// savedFilterGroupings are the ways a client may group the todos of a view
+var savedFilterGroupings = map[string]bool{
+	"none":     true,
+	"project":  true,
+	"priority": true,
+	"date":     true,
+	"tag":      true,
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type SavedFilterHandler struct {
+	querier db.Querier
+	todos   *TodoHandler
+	logger  logger.Logger
+}
+
+func NewSavedFilterHandler(querier db.Querier, todos *TodoHandler, logger logger.Logger) *SavedFilterHandler {
+	return &SavedFilterHandler{
+		querier: querier,
+		todos:   todos,
+		logger:  logger,
+	}
+}
+
+// SavedFilterRequest creates or updates a saved filter. On update only the
+// fields that are set are changed.
+type SavedFilterRequest struct {
+	Name      *string `json:"name"`
+	Color     *string `json:"color"`
+	Query     *string `json:"query"`
+	SortBy    *string `json:"sort_by"`
+	SortOrder *string `json:"sort_order"`
+	GroupBy   *string `json:"group_by"`
+	IsPinned  *bool   `json:"is_pinned"`
+}
+
+type SavedFilterResponse struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	Query     string    `json:"query"`
+	SortBy    string    `json:"sort_by"`
+	SortOrder string    `json:"sort_order"`
+	GroupBy   string    `json:"group_by"`
+	IsPinned  bool      `json:"is_pinned"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewSavedFilterResponse(savedFilter *db.SavedFilter) *SavedFilterResponse {
+	return &SavedFilterResponse{
+		ID:        savedFilter.SavedFilterID,
+		Name:      savedFilter.Name,
+		Color:     savedFilter.Color,
+		Query:     savedFilter.Query,
+		SortBy:    savedFilter.SortBy,
+		SortOrder: savedFilter.SortOrder,
+		GroupBy:   savedFilter.GroupBy,
+		IsPinned:  savedFilter.IsPinned,
+		CreatedAt: savedFilter.CreatedAt.Time,
+		UpdatedAt: savedFilter.UpdatedAt.Time,
+	}
+}
+
+// SavedFilterTodosResponse is a page of todos matching a saved filter along
+// with the filter itself, so clients know how to group the items
+type SavedFilterTodosResponse struct {
+	Filter *SavedFilterResponse `json:"filter"`
+	*pagination.Page[*TodoResponse]
+}
+
+// ListSavedFilters returns all of the user's saved filters, pinned first
+func (h *SavedFilterHandler) ListSavedFilters(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	savedFilters, err := db.QuerierFromContext(c.Request.Context(), h.querier).ListSavedFilters(c, userID)
+	if err != nil {
+		h.logger.Error("Failed to list saved filters", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	responses := make([]*SavedFilterResponse, len(savedFilters))
+	for i := range savedFilters {
+		responses[i] = NewSavedFilterResponse(&savedFilters[i])
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+func (h *SavedFilterHandler) GetSavedFilter(c *gin.Context) {
+	savedFilter, ok := h.ownedSavedFilter(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, NewSavedFilterResponse(savedFilter))
+}
+
+func (h *SavedFilterHandler) CreateSavedFilter(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req SavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == nil || req.Query == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and query are required"})
+		return
+	}
+
+	savedFilter := db.SavedFilter{
+		UserID:    userID,
+		Color:     defaultSavedFilterColor,
+		SortBy:    "created",
+		SortOrder: "desc",
+		GroupBy:   "none",
+	}
+	if !h.applyRequest(c, &savedFilter, &req) {
+		return
+	}
+
+	created, err := db.QuerierFromContext(c.Request.Context(), h.querier).CreateSavedFilter(c, db.CreateSavedFilterParams{
+		UserID:    savedFilter.UserID,
+		Name:      savedFilter.Name,
+		Color:     savedFilter.Color,
+		Query:     savedFilter.Query,
+		SortBy:    savedFilter.SortBy,
+		SortOrder: savedFilter.SortOrder,
+		GroupBy:   savedFilter.GroupBy,
+		IsPinned:  savedFilter.IsPinned,
+	})
+	if err != nil {
+		h.saveError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, NewSavedFilterResponse(&created))
+}
+
+func (h *SavedFilterHandler) UpdateSavedFilter(c *gin.Context) {
+	savedFilter, ok := h.ownedSavedFilter(c)
+	if !ok {
+		return
+	}
+
+	var req SavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.applyRequest(c, savedFilter, &req) {
+		return
+	}
+
+	updated, err := db.QuerierFromContext(c.Request.Context(), h.querier).UpdateSavedFilter(c, db.UpdateSavedFilterParams{
+		SavedFilterID: savedFilter.SavedFilterID,
+		Name:          savedFilter.Name,
+		Color:         savedFilter.Color,
+		Query:         savedFilter.Query,
+		SortBy:        savedFilter.SortBy,
+		SortOrder:     savedFilter.SortOrder,
+		GroupBy:       savedFilter.GroupBy,
+		IsPinned:      savedFilter.IsPinned,
+	})
+	if err != nil {
+		h.saveError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewSavedFilterResponse(&updated))
+}
+
+func (h *SavedFilterHandler) DeleteSavedFilter(c *gin.Context) {
+	savedFilter, ok := h.ownedSavedFilter(c)
+	if !ok {
+		return
+	}
+
+	err := db.QuerierFromContext(c.Request.Context(), h.querier).DeleteSavedFilter(c, savedFilter.SavedFilterID)
+	if err != nil {
+		h.logger.Error("Failed to delete saved filter", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListSavedFilterTodos runs a saved filter and returns a page of matching
+// todos. The saved sort applies unless the request overrides it.
+func (h *SavedFilterHandler) ListSavedFilterTodos(c *gin.Context) {
+	savedFilter, ok := h.ownedSavedFilter(c)
+	if !ok {
+		return
+	}
+
+	expr, err := filter.Parse(savedFilter.Query)
+	if err != nil {
+		filterError(c, err)
+		return
+	}
+
+	var pageRequest pagination.Request
+	if err := c.ShouldBindQuery(&pageRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if pageRequest.Sort == "" {
+		pageRequest.Sort = savedFilter.SortBy
+		if pageRequest.Order == "" {
+			pageRequest.Order = savedFilter.SortOrder
+		}
+	}
+	query, err := pageRequest.Resolve(todoSortKeys, savedFilter.SortBy, "t.todo_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.todos.todoPage(c.Request.Context(), savedFilter.UserID, query, func(b *filter.Builder, opts filter.Options) {
+		expr.Apply(b, opts)
+	})
+	if err != nil {
+		h.logger.Error("Failed to run saved filter", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SavedFilterTodosResponse{
+		Filter: NewSavedFilterResponse(savedFilter),
+		Page:   page,
+	})
+}
+
+// ownedSavedFilter loads the saved filter named by the :id parameter,
+// responding with an error if it doesn't exist or belongs to someone else
+func (h *SavedFilterHandler) ownedSavedFilter(c *gin.Context) (*db.SavedFilter, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved filter ID"})
+		return nil, false
+	}
+
+	savedFilter, err := db.QuerierFromContext(c.Request.Context(), h.querier).GetSavedFilter(c, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved filter not found"})
+			return nil, false
+		}
+		h.logger.Error("Failed to get saved filter", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return nil, false
+	}
+	if savedFilter.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved filter not found"})
+		return nil, false
+	}
+
+	return &savedFilter, true
+}
+
+// applyRequest validates the set fields of req and copies them onto
+// savedFilter, responding with an error if any is invalid
+func (h *SavedFilterHandler) applyRequest(c *gin.Context, savedFilter *db.SavedFilter, req *SavedFilterRequest) bool {
+	if req.Name != nil {
+		if *req.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+			return false
+		}
+		if len([]rune(*req.Name)) > maxSavedFilterName {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at most 255 characters"})
+			return false
+		}
+		savedFilter.Name = *req.Name
+	}
+	if req.Color != nil {
+		if !colorPattern.MatchString(*req.Color) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "color must be a hex color such as #3B82F6"})
+			return false
+		}
+		savedFilter.Color = *req.Color
+	}
+	if req.Query != nil {
+		if _, err := filter.Parse(*req.Query); err != nil {
+			filterError(c, err)
+			return false
+		}
+		savedFilter.Query = *req.Query
+	}
+	if req.SortBy != nil {
+		if _, ok := todoSortKeys[*req.SortBy]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported sort_by " + strconv.Quote(*req.SortBy)})
+			return false
+		}
+		savedFilter.SortBy = *req.SortBy
+	}
+	if req.SortOrder != nil {
+		if *req.SortOrder != "asc" && *req.SortOrder != "desc" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort_order must be asc or desc"})
+			return false
+		}
+		savedFilter.SortOrder = *req.SortOrder
+	}
+	if req.GroupBy != nil {
+		if !savedFilterGroupings[*req.GroupBy] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be none, project, priority, date or tag"})
+			return false
+		}
+		savedFilter.GroupBy = *req.GroupBy
+	}
+	if req.IsPinned != nil {
+		savedFilter.IsPinned = *req.IsPinned
+	}
+	return true
+}
+
+func (h *SavedFilterHandler) saveError(c *gin.Context, err error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		c.JSON(http.StatusConflict, gin.H{"error": "A saved filter with this name already exists"})
+		return
+	}
+	h.logger.Error("Failed to save saved filter", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
@@ -0,0 +1,711 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/timeblock"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultPlanDays is how far ahead the schedule is planned and listed
+	// when no days parameter is given
+	DefaultPlanDays = 7
+	// MaxPlanDays caps how far ahead the schedule can be planned
+	MaxPlanDays = 28
+
+	// planGranularity is what blocks start on a multiple of
+	planGranularity = 5 * time.Minute
+	// maxBlockLength keeps a block that is moved by hand within a day
+	maxBlockLength = 24 * time.Hour
+)
+
+type ScheduleHandler struct {
+	querier db.Querier
+	pool    *pgxpool.Pool
+	logger  logger.Logger
+}
+
+func NewScheduleHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		querier: querier,
+		pool:    pool,
+		logger:  logger,
+	}
+}
+
+// WorkingHours is a window of working time on a day of the week, with 0 for
+// Sunday, as 24 hour "15:04" times in the user's timezone
+type WorkingHours struct {
+	Weekday int32  `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+type WorkingHoursRequest struct {
+	Hours []WorkingHours `json:"hours" binding:"required"`
+}
+
+type WorkingHoursResponse struct {
+	Hours    []WorkingHours `json:"hours"`
+	Timezone string         `json:"timezone"`
+	// Default is true while the user hasn't set their own working hours
+	Default bool `json:"default"`
+}
+
+type ScheduleBlockResponse struct {
+	ID            int32     `json:"id"`
+	TodoID        int32     `json:"todo_id"`
+	Title         string    `json:"title"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	Status        string    `json:"status"`
+	Pinned        bool      `json:"pinned"`
+	TodoCompleted bool      `json:"todo_completed"`
+}
+
+func NewScheduleBlockResponse(block *db.ScheduleBlock, todo *db.Todo) *ScheduleBlockResponse {
+	return &ScheduleBlockResponse{
+		ID:            block.BlockID,
+		TodoID:        block.TodoID,
+		Title:         todo.Title,
+		StartsAt:      block.StartsAt.Time,
+		EndsAt:        block.EndsAt.Time,
+		Status:        block.Status,
+		Pinned:        block.Pinned,
+		TodoCompleted: todo.IsCompleted.Bool,
+	}
+}
+
+type ScheduleResponse struct {
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	Timezone string                   `json:"timezone"`
+	Blocks   []*ScheduleBlockResponse `json:"blocks"`
+}
+
+// PlanRequest re-runs the plan over the next Days days, starting now
+type PlanRequest struct {
+	Days int `json:"days"`
+}
+
+// UnscheduledTodo is a todo the plan couldn't fit. Reason is one of no_room,
+// deadline, blocked or cycle.
+type UnscheduledTodo struct {
+	TodoID int32  `json:"todo_id"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+type PlanResponse struct {
+	*ScheduleResponse
+	Unscheduled []*UnscheduledTodo `json:"unscheduled"`
+}
+
+// UpdateScheduleBlockRequest moves or pins a block. Moving a block pins it
+// unless pinned is false.
+type UpdateScheduleBlockRequest struct {
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	Pinned   *bool      `json:"pinned"`
+}
+
+// AcceptScheduleRequest accepts the proposed blocks in BlockIDs, or all of
+// them if it's empty
+type AcceptScheduleRequest struct {
+	BlockIDs []int32 `json:"block_ids"`
+}
+
+// GetWorkingHours returns the hours the scheduler places todos in
+func (h *ScheduleHandler) GetWorkingHours(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := workingHoursResponse(ctx, db.QuerierFromContext(ctx, h.querier), userID)
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateWorkingHours replaces the user's working hours
+func (h *ScheduleHandler) UpdateWorkingHours(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req WorkingHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Hours) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hours must include at least one window"})
+		return
+	}
+	params := make([]db.CreateWorkingHoursParams, len(req.Hours))
+	windows := make([]timeblock.Interval, len(req.Hours))
+	for i, hours := range req.Hours {
+		if hours.Weekday < 0 || hours.Weekday > 6 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weekday must be between 0 and 6"})
+			return
+		}
+		start, err := parseClock(hours.Start)
+		var end pgtype.Time
+		if err == nil {
+			end, err = parseClock(hours.End)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start and end must be times such as 09:00"})
+			return
+		}
+		if start.Microseconds >= end.Microseconds {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Working hours must end after they start"})
+			return
+		}
+		// Windows are compared on the same made up week so overlaps between
+		// any two of them are easy to spot
+		day := time.Duration(hours.Weekday) * 24 * time.Hour
+		windows[i] = timeblock.Interval{
+			Start: time.Time{}.Add(day + clockOffset(start)),
+			End:   time.Time{}.Add(day + clockOffset(end)),
+		}
+		for _, other := range windows[:i] {
+			if windows[i].Overlaps(other) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Working hours must not overlap"})
+				return
+			}
+		}
+		params[i] = db.CreateWorkingHoursParams{UserID: userID, Weekday: hours.Weekday, StartTime: start, EndTime: end}
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	err = querier.DeleteWorkingHours(ctx, userID)
+	for i := 0; err == nil && i < len(params); i++ {
+		err = querier.CreateWorkingHours(ctx, params[i])
+	}
+	var response *WorkingHoursResponse
+	if err == nil {
+		response, err = workingHoursResponse(ctx, querier, userID)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSchedule returns the blocks over the next days, starting today
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	days := DefaultPlanDays
+	if param := c.Query("days"); param != "" {
+		var err error
+		days, err = strconv.Atoi(param)
+		if err != nil || days < 1 || days > MaxPlanDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(MaxPlanDays)})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+	today := startOfDay(time.Now().In(location), 0)
+	response, err := scheduleResponse(ctx, querier, userID, today, startOfDay(today, days), location)
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PlanSchedule fits the user's open todos into their working hours over the
+// next days. Blocks proposed by an earlier run are replaced unless they were
+// pinned or accepted, which are kept and planned around along with todos
+// that already have a time.
+func (h *ScheduleHandler) PlanSchedule(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req PlanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Days == 0 {
+		req.Days = DefaultPlanDays
+	}
+	if req.Days < 1 || req.Days > MaxPlanDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(MaxPlanDays)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	response, err := planSchedule(ctx, db.New(tx), userID, time.Now(), req.Days)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AcceptSchedule accepts proposed blocks, moving each todo's assigned_date to
+// the start of its block
+func (h *ScheduleHandler) AcceptSchedule(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req AcceptScheduleRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	blocks, err := querier.AcceptScheduleBlocks(ctx, db.AcceptScheduleBlocksParams{
+		UserID:   userID,
+		BlockIds: uniqueIDs(req.BlockIDs),
+	})
+	responses := make([]*ScheduleBlockResponse, 0, len(blocks))
+	for i := 0; err == nil && i < len(blocks); i++ {
+		var todo db.Todo
+		todo, err = querier.SetTodoAssignedDate(ctx, db.SetTodoAssignedDateParams{
+			AssignedDate: blocks[i].StartsAt,
+			TodoID:       blocks[i].TodoID,
+		})
+		responses = append(responses, NewScheduleBlockResponse(&blocks[i], &todo))
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// UpdateScheduleBlock moves, pins or unpins a block. Moving an accepted block
+// moves its todo's assigned_date along with it.
+func (h *ScheduleHandler) UpdateScheduleBlock(c *gin.Context) {
+	var req UpdateScheduleBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withBlock(c, func(ctx context.Context, querier db.Querier, block *db.ScheduleBlock) (any, error) {
+		params := db.UpdateScheduleBlockParams{
+			BlockID:  block.BlockID,
+			StartsAt: block.StartsAt,
+			EndsAt:   block.EndsAt,
+			Pinned:   block.Pinned,
+		}
+		if req.StartsAt != nil || req.EndsAt != nil {
+			// Keep the length of the block when only one end moves
+			length := block.EndsAt.Time.Sub(block.StartsAt.Time)
+			switch {
+			case req.StartsAt != nil && req.EndsAt != nil:
+				params.StartsAt = pgtype.Timestamptz{Time: *req.StartsAt, Valid: true}
+				params.EndsAt = pgtype.Timestamptz{Time: *req.EndsAt, Valid: true}
+			case req.StartsAt != nil:
+				params.StartsAt = pgtype.Timestamptz{Time: *req.StartsAt, Valid: true}
+				params.EndsAt = pgtype.Timestamptz{Time: req.StartsAt.Add(length), Valid: true}
+			default:
+				params.StartsAt = pgtype.Timestamptz{Time: req.EndsAt.Add(-length), Valid: true}
+				params.EndsAt = pgtype.Timestamptz{Time: *req.EndsAt, Valid: true}
+			}
+			if length := params.EndsAt.Time.Sub(params.StartsAt.Time); length <= 0 || length > maxBlockLength {
+				return nil, &requestError{http.StatusBadRequest, "A block must end after it starts and last at most a day"}
+			}
+			params.Pinned = true
+		}
+		if req.Pinned != nil {
+			params.Pinned = *req.Pinned
+		}
+
+		updated, err := querier.UpdateScheduleBlock(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		var todo db.Todo
+		if updated.Status == "accepted" && !updated.StartsAt.Time.Equal(block.StartsAt.Time) {
+			todo, err = querier.SetTodoAssignedDate(ctx, db.SetTodoAssignedDateParams{
+				AssignedDate: updated.StartsAt,
+				TodoID:       updated.TodoID,
+			})
+		} else {
+			todo, err = querier.GetTodo(ctx, updated.TodoID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return NewScheduleBlockResponse(&updated, &todo), nil
+	})
+}
+
+// DeleteScheduleBlock removes a block. The todo keeps its assigned_date, and
+// is planned again on the next run unless that gave it a time.
+func (h *ScheduleHandler) DeleteScheduleBlock(c *gin.Context) {
+	h.withBlock(c, func(ctx context.Context, querier db.Querier, block *db.ScheduleBlock) (any, error) {
+		return nil, querier.DeleteScheduleBlock(ctx, block.BlockID)
+	})
+}
+
+// withBlock loads the block named by the :id parameter and runs fn on it in a
+// transaction, responding with what fn returns, or no content if that's nil
+func (h *ScheduleHandler) withBlock(c *gin.Context, fn func(ctx context.Context, querier db.Querier, block *db.ScheduleBlock) (any, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	blockID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid block ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	block, err := querier.GetScheduleBlock(ctx, int32(blockID))
+	if err == nil && block.UserID != userID {
+		err = pgx.ErrNoRows
+	}
+	var response any
+	if err == nil {
+		response, err = fn(ctx, querier, &block)
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		err = &requestError{http.StatusNotFound, "Block not found"}
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.scheduleError(c, err)
+		return
+	}
+
+	if response == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ScheduleHandler) scheduleError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Schedule request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+// planSchedule replaces the user's proposed blocks with a new plan covering
+// now until the end of the given number of days
+func planSchedule(ctx context.Context, querier db.Querier, userID int32, now time.Time, days int) (*PlanResponse, error) {
+	// Runs for the same user would otherwise both try to place each todo
+	if err := querier.LockUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+	hours, _, err := loadWorkingHours(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := querier.DeleteProposedScheduleBlocks(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	now = now.In(location)
+	from := now.Add(planGranularity - 1).Truncate(planGranularity)
+	to := startOfDay(now, days)
+
+	// Pinned and accepted blocks stay where they are
+	fixed, err := querier.ListFixedScheduleBlocks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var busy []timeblock.Interval
+	placed := make(map[int32]time.Time, len(fixed))
+	for _, block := range fixed {
+		busy = append(busy, timeblock.Interval{Start: block.StartsAt.Time, End: block.EndsAt.Time})
+		placed[block.TodoID] = block.EndsAt.Time
+	}
+	// So do meetings from the user's busy calendars
+	meetings, err := querier.ListBusyBlocksBetween(ctx, db.ListBusyBlocksBetweenParams{
+		UserID:   userID,
+		StartsAt: pgtype.Timestamptz{Time: from, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, meeting := range meetings {
+		busy = append(busy, timeblock.Interval{Start: meeting.StartsAt.Time, End: meeting.EndsAt.Time})
+	}
+
+	todos, err := querier.ListPendingTodos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[int32]string, len(todos))
+	subtasks := make(map[int32][]int32)
+	for _, todo := range todos {
+		titles[todo.TodoID] = todo.Title
+		// Subtasks without a duration take no planned time, so they don't
+		// hold up their parent
+		if todo.ParentTodoID.Valid && todo.DurationMin.Valid {
+			subtasks[todo.ParentTodoID.Int32] = append(subtasks[todo.ParentTodoID.Int32], todo.TodoID)
+		}
+	}
+
+	dependencies, err := querier.ListUserOpenDependencies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prerequisites := make(map[int32][]int32)
+	for _, dependency := range dependencies {
+		prerequisites[dependency.TodoID] = append(prerequisites[dependency.TodoID], dependency.DependsOnID)
+	}
+
+	var tasks []timeblock.Task
+	for _, todo := range todos {
+		if _, ok := placed[todo.TodoID]; ok || !todo.DurationMin.Valid || todo.DurationMin.Int32 <= 0 {
+			continue
+		}
+		duration := time.Duration(todo.DurationMin.Int32) * time.Minute
+		if todo.AssignedDate.Valid {
+			assigned := todo.AssignedDate.Time.In(location)
+			// A todo assigned to a time of day, rather than just a day, is an
+			// appointment to plan around
+			if !assigned.Equal(startOfDay(assigned, 0)) {
+				busy = append(busy, timeblock.Interval{Start: assigned, End: assigned.Add(duration)})
+				placed[todo.TodoID] = assigned.Add(duration)
+				continue
+			}
+		}
+		if todo.RecurrenceRule.Valid {
+			continue
+		}
+
+		task := timeblock.Task{
+			ID:        todo.TodoID,
+			Duration:  duration,
+			Priority:  todo.Priority.Int32,
+			DependsOn: subtasks[todo.TodoID],
+			CreatedAt: todo.CreatedAt.Time,
+		}
+		if todo.AssignedDate.Valid {
+			task.NotBefore = todo.AssignedDate.Time
+		}
+		if todo.Deadline.Valid {
+			task.Deadline = todo.Deadline.Time
+		}
+		tasks = append(tasks, task)
+	}
+	// A todo waits on the todos it depends on that take planned time, as it
+	// does on its subtasks
+	planned := make(map[int32]bool, len(tasks))
+	for _, task := range tasks {
+		planned[task.ID] = true
+	}
+	for i := range tasks {
+		for _, id := range prerequisites[tasks[i].ID] {
+			if _, ok := placed[id]; ok || planned[id] {
+				tasks[i].DependsOn = append(tasks[i].DependsOn, id)
+			}
+		}
+	}
+
+	free := timeblock.FreeSlots(hours, location, from, to, busy)
+	plan := timeblock.Schedule(free, tasks, placed)
+	for _, block := range plan.Blocks {
+		_, err := querier.CreateScheduleBlock(ctx, db.CreateScheduleBlockParams{
+			UserID:   userID,
+			TodoID:   block.TaskID,
+			StartsAt: pgtype.Timestamptz{Time: block.Start, Valid: true},
+			EndsAt:   pgtype.Timestamptz{Time: block.End, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	schedule, err := scheduleResponse(ctx, querier, userID, from, to, location)
+	if err != nil {
+		return nil, err
+	}
+	response := &PlanResponse{ScheduleResponse: schedule, Unscheduled: []*UnscheduledTodo{}}
+	for _, skipped := range plan.Unscheduled {
+		response.Unscheduled = append(response.Unscheduled, &UnscheduledTodo{
+			TodoID: skipped.TaskID,
+			Title:  titles[skipped.TaskID],
+			Reason: string(skipped.Reason),
+		})
+	}
+	return response, nil
+}
+
+func scheduleResponse(ctx context.Context, querier db.Querier, userID int32, from time.Time, to time.Time, location *time.Location) (*ScheduleResponse, error) {
+	rows, err := querier.ListScheduleBlocks(ctx, db.ListScheduleBlocksParams{
+		UserID:   userID,
+		StartsAt: pgtype.Timestamptz{Time: from, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := &ScheduleResponse{
+		From:     from,
+		To:       to,
+		Timezone: location.String(),
+		Blocks:   make([]*ScheduleBlockResponse, len(rows)),
+	}
+	for i, row := range rows {
+		response.Blocks[i] = &ScheduleBlockResponse{
+			ID:            row.BlockID,
+			TodoID:        row.TodoID,
+			Title:         row.TodoTitle,
+			StartsAt:      row.StartsAt.Time,
+			EndsAt:        row.EndsAt.Time,
+			Status:        row.Status,
+			Pinned:        row.Pinned,
+			TodoCompleted: row.TodoCompleted,
+		}
+	}
+	return response, nil
+}
+
+// loadWorkingHours returns the user's working hours, or the defaults and true
+// if they haven't set any
+func loadWorkingHours(ctx context.Context, querier db.Querier, userID int32) ([]timeblock.Window, bool, error) {
+	rows, err := querier.ListWorkingHours(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rows) == 0 {
+		return timeblock.DefaultHours, true, nil
+	}
+	hours := make([]timeblock.Window, len(rows))
+	for i, row := range rows {
+		hours[i] = timeblock.Window{
+			Weekday: time.Weekday(row.Weekday),
+			Start:   clockOffset(row.StartTime),
+			End:     clockOffset(row.EndTime),
+		}
+	}
+	return hours, false, nil
+}
+
+func workingHoursResponse(ctx context.Context, querier db.Querier, userID int32) (*WorkingHoursResponse, error) {
+	location, err := userLocation(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+	hours, isDefault, err := loadWorkingHours(ctx, querier, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := &WorkingHoursResponse{
+		Hours:    make([]WorkingHours, len(hours)),
+		Timezone: location.String(),
+		Default:  isDefault,
+	}
+	for i, window := range hours {
+		response.Hours[i] = WorkingHours{
+			Weekday: int32(window.Weekday),
+			Start:   formatClock(window.Start),
+			End:     formatClock(window.End),
+		}
+	}
+	return response, nil
+}
+
+func clockOffset(t pgtype.Time) time.Duration {
+	return time.Duration(t.Microseconds) * time.Microsecond
+}
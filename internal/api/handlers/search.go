@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/search"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+type SearchHandler struct {
+	querier db.Querier
+	pool    *pgxpool.Pool
+	logger  logger.Logger
+}
+
+func NewSearchHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *SearchHandler {
+	return &SearchHandler{
+		querier: querier,
+		pool:    pool,
+		logger:  logger,
+	}
+}
+
+type SearchRequest struct {
+	Query     string `form:"q"`
+	ProjectID *int32 `form:"project_id"`
+	TagID     *int32 `form:"tag_id"`
+	// Types is a comma separated list of todo, comment and project
+	Types  string `form:"types"`
+	Limit  int32  `form:"limit"`
+	Offset int32  `form:"offset"`
+}
+
+type SearchResponse struct {
+	Results []*search.Result `json:"results"`
+}
+
+// Search runs a full-text search over the user's todos, comments and
+// projects. Snippets are HTML escaped with matches wrapped in <mark>.
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req SearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if search.TSQuery(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must contain at least one word"})
+		return
+	}
+
+	types := []search.Type{search.TypeTodo, search.TypeComment, search.TypeProject}
+	if req.Types != "" {
+		types = nil
+		for _, name := range strings.Split(req.Types, ",") {
+			switch typ := search.Type(strings.TrimSpace(name)); typ {
+			case search.TypeTodo, search.TypeComment, search.TypeProject:
+				types = append(types, typ)
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "types must be a comma separated list of todo, comment and project"})
+				return
+			}
+		}
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = DefaultSearchLimit
+	}
+	if req.Limit > MaxSearchLimit {
+		req.Limit = MaxSearchLimit
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	ctx := c.Request.Context()
+	querier := db.QuerierFromContext(ctx, h.querier)
+	if req.ProjectID != nil {
+		project, err := querier.GetProject(ctx, *req.ProjectID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			h.logger.Error("Failed to get project", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+			return
+		}
+		if err != nil || project.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+	}
+	if req.TagID != nil {
+		tag, err := querier.GetTag(ctx, *req.TagID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			h.logger.Error("Failed to get tag", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+			return
+		}
+		if err != nil || tag.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+	}
+
+	sql, args := search.Build(search.Options{
+		UserID:    userID,
+		Text:      req.Query,
+		ProjectID: req.ProjectID,
+		TagID:     req.TagID,
+		Types:     types,
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+	})
+	if sql == "" {
+		// Only projects were requested but the scope excludes them
+		c.JSON(http.StatusOK, SearchResponse{Results: []*search.Result{}})
+		return
+	}
+
+	rows, err := db.DBFromContext(ctx, h.pool).Query(ctx, sql, args...)
+	if err != nil {
+		h.logger.Error("Failed to search", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	results, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[search.Result])
+	if err != nil {
+		h.logger.Error("Failed to search", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	for _, result := range results {
+		result.Snippet = search.Highlight(result.Snippet)
+	}
+	c.JSON(http.StatusOK, SearchResponse{Results: results})
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxSectionName is the length of project_sections.name
+	maxSectionName = 255
+	// MaxProjectSections caps the sections of one project
+	MaxProjectSections = 100
+)
+
+type SectionHandler struct {
+	querier db.Querier
+	pool    *pgxpool.Pool
+	logger  logger.Logger
+}
+
+func NewSectionHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *SectionHandler {
+	return &SectionHandler{
+		querier: querier,
+		pool:    pool,
+		logger:  logger,
+	}
+}
+
+type CreateProjectSectionRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateProjectSectionRequest changes the fields that are set
+type UpdateProjectSectionRequest struct {
+	Name        *string `json:"name"`
+	IsCollapsed *bool   `json:"is_collapsed"`
+}
+
+type TransferProjectSectionRequest struct {
+	// ProjectID is the project the section and its todos move to
+	ProjectID int32 `json:"project_id" binding:"required"`
+}
+
+type ProjectSectionResponse struct {
+	ID        int32  `json:"id"`
+	ProjectID int32  `json:"project_id"`
+	Name      string `json:"name"`
+	// Position orders the section among the project's sections
+	Position    string    `json:"position"`
+	IsCollapsed bool      `json:"is_collapsed"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func NewProjectSectionResponse(section *db.ProjectSection) *ProjectSectionResponse {
+	return &ProjectSectionResponse{
+		ID:          section.SectionID,
+		ProjectID:   section.ProjectID,
+		Name:        section.Name,
+		Position:    section.Position,
+		IsCollapsed: section.IsCollapsed,
+		CreatedAt:   section.CreatedAt.Time,
+		UpdatedAt:   section.UpdatedAt.Time,
+	}
+}
+
+// ListProjectSections lists a project's sections in order
+func (h *SectionHandler) ListProjectSections(c *gin.Context) {
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		return projectSections(ctx, querier, project.ProjectID)
+	})
+}
+
+// CreateProjectSection adds a section to the end of a project
+func (h *SectionHandler) CreateProjectSection(c *gin.Context) {
+	var req CreateProjectSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		name, err := validateSectionName(req.Name)
+		if err != nil {
+			return nil, err
+		}
+		siblings, err := sectionSiblings(ctx, querier, project.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if len(siblings) >= MaxProjectSections {
+			return nil, &requestError{http.StatusBadRequest, "A project can have at most " + strconv.Itoa(MaxProjectSections) + " sections"}
+		}
+		var last string
+		if len(siblings) > 0 {
+			last = siblings[len(siblings)-1].Position
+		}
+		position, ok := lastPosition(last)
+
+		section, err := querier.CreateProjectSection(ctx, db.CreateProjectSectionParams{
+			ProjectID: project.ProjectID,
+			UserID:    project.UserID,
+			Name:      name,
+			Position:  position,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if err := placeLast(ctx, siblings, section.SectionID, setSectionPosition(querier)); err != nil {
+				return nil, err
+			}
+			if section, err = querier.GetProjectSection(ctx, section.SectionID); err != nil {
+				return nil, err
+			}
+		}
+		return NewProjectSectionResponse(&section), nil
+	})
+}
+
+// UpdateProjectSection renames a section or collapses or expands it
+func (h *SectionHandler) UpdateProjectSection(c *gin.Context) {
+	var req UpdateProjectSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withSection(c, func(ctx context.Context, querier db.Querier, project *db.Project, section *db.ProjectSection) (any, error) {
+		params := db.UpdateProjectSectionParams{
+			Name:        section.Name,
+			IsCollapsed: section.IsCollapsed,
+			SectionID:   section.SectionID,
+		}
+		if req.Name != nil {
+			name, err := validateSectionName(*req.Name)
+			if err != nil {
+				return nil, err
+			}
+			params.Name = name
+		}
+		if req.IsCollapsed != nil {
+			params.IsCollapsed = *req.IsCollapsed
+		}
+		updated, err := querier.UpdateProjectSection(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return NewProjectSectionResponse(&updated), nil
+	})
+}
+
+// MoveProjectSection reorders a section among its project's sections
+func (h *SectionHandler) MoveProjectSection(c *gin.Context) {
+	var req MoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withSection(c, func(ctx context.Context, querier db.Querier, project *db.Project, section *db.ProjectSection) (any, error) {
+		siblings, err := sectionSiblings(ctx, querier, project.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if err := moveBetween(ctx, siblings, section.SectionID, &req, setSectionPosition(querier)); err != nil {
+			return nil, err
+		}
+		moved, err := querier.GetProjectSection(ctx, section.SectionID)
+		if err != nil {
+			return nil, err
+		}
+		return NewProjectSectionResponse(&moved), nil
+	})
+}
+
+// TransferProjectSection moves a section, with its todos and their subtasks,
+// to the end of another project. The todos take on statuses of the new
+// project.
+func (h *SectionHandler) TransferProjectSection(c *gin.Context) {
+	var req TransferProjectSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withSection(c, func(ctx context.Context, querier db.Querier, project *db.Project, section *db.ProjectSection) (any, error) {
+		target, err := ownedProject(ctx, querier, project.UserID, req.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if target.ProjectID == project.ProjectID {
+			return NewProjectSectionResponse(section), nil
+		}
+		siblings, err := sectionSiblings(ctx, querier, target.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if len(siblings) >= MaxProjectSections {
+			return nil, &requestError{http.StatusBadRequest, "A project can have at most " + strconv.Itoa(MaxProjectSections) + " sections"}
+		}
+		var last string
+		if len(siblings) > 0 {
+			last = siblings[len(siblings)-1].Position
+		}
+		position, ok := lastPosition(last)
+
+		// The section moves first, so its todos stay in it when they follow
+		moved, err := querier.MoveProjectSection(ctx, db.MoveProjectSectionParams{
+			ProjectID: target.ProjectID,
+			Position:  position,
+			SectionID: section.SectionID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := querier.MoveSectionTodos(ctx, db.MoveSectionTodosParams{
+			SectionID: section.SectionID,
+			ProjectID: target.ProjectID,
+		}); err != nil {
+			return nil, err
+		}
+		if !ok {
+			if err := placeLast(ctx, siblings, section.SectionID, setSectionPosition(querier)); err != nil {
+				return nil, err
+			}
+			if moved, err = querier.GetProjectSection(ctx, section.SectionID); err != nil {
+				return nil, err
+			}
+		}
+		return NewProjectSectionResponse(&moved), nil
+	})
+}
+
+// DeleteProjectSection deletes a section and returns the sections left. Its
+// todos stay in the project, outside any section.
+func (h *SectionHandler) DeleteProjectSection(c *gin.Context) {
+	h.withSection(c, func(ctx context.Context, querier db.Querier, project *db.Project, section *db.ProjectSection) (any, error) {
+		if err := querier.DeleteProjectSection(ctx, section.SectionID); err != nil {
+			return nil, err
+		}
+		return projectSections(ctx, querier, project.ProjectID)
+	})
+}
+
+// withProject runs fn in a transaction for the user's project in the :id
+// parameter. Sections are reordered by rewriting their keys, so requests for
+// the same user are serialized.
+func (h *SectionHandler) withProject(c *gin.Context, fn func(ctx context.Context, querier db.Querier, project *db.Project) (any, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.sectionError(c, err)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	var response any
+	project, err := ownedProject(ctx, querier, userID, int32(projectID))
+	if err == nil {
+		err = querier.LockUser(ctx, userID)
+	}
+	if err == nil {
+		response, err = fn(ctx, querier, project)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.sectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// withSection is withProject for the project's section in the :section_id
+// parameter
+func (h *SectionHandler) withSection(c *gin.Context, fn func(ctx context.Context, querier db.Querier, project *db.Project, section *db.ProjectSection) (any, error)) {
+	h.withProject(c, func(ctx context.Context, querier db.Querier, project *db.Project) (any, error) {
+		sectionID, err := strconv.ParseInt(c.Param("section_id"), 10, 32)
+		if err != nil {
+			return nil, &requestError{http.StatusBadRequest, "Invalid section ID"}
+		}
+		section, err := ownedSection(ctx, querier, project.UserID, int32(sectionID))
+		if err != nil {
+			return nil, err
+		}
+		if section.ProjectID != project.ProjectID {
+			return nil, &requestError{http.StatusNotFound, "Section not found"}
+		}
+		return fn(ctx, querier, project, section)
+	})
+}
+
+func (h *SectionHandler) sectionError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	h.logger.Error("Section request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+// ownedSection loads a section, returning a 404 requestError if it doesn't
+// exist or belongs to someone else
+func ownedSection(ctx context.Context, querier db.Querier, userID int32, sectionID int32) (*db.ProjectSection, error) {
+	section, err := querier.GetProjectSection(ctx, sectionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &requestError{http.StatusNotFound, "Section not found"}
+		}
+		return nil, err
+	}
+	if section.UserID != userID {
+		return nil, &requestError{http.StatusNotFound, "Section not found"}
+	}
+	return &section, nil
+}
+
+func projectSections(ctx context.Context, querier db.Querier, projectID int32) ([]*ProjectSectionResponse, error) {
+	sections, err := querier.ListProjectSections(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*ProjectSectionResponse, len(sections))
+	for i := range sections {
+		responses[i] = NewProjectSectionResponse(&sections[i])
+	}
+	return responses, nil
+}
+
+// sectionSiblings lists a project's sections, in order
+func sectionSiblings(ctx context.Context, querier db.Querier, projectID int32) ([]sibling, error) {
+	sections, err := querier.ListProjectSections(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	siblings := make([]sibling, len(sections))
+	for i, section := range sections {
+		siblings[i] = sibling{ID: section.SectionID, Position: section.Position}
+	}
+	return siblings, nil
+}
+
+func setSectionPosition(querier db.Querier) func(ctx context.Context, id int32, position string) error {
+	return func(ctx context.Context, id int32, position string) error {
+		return querier.SetProjectSectionPosition(ctx, db.SetProjectSectionPositionParams{Position: position, SectionID: id})
+	}
+}
+
+func validateSectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", &requestError{http.StatusBadRequest, "name is required"}
+	case len([]rune(name)) > maxSectionName:
+		return "", &requestError{http.StatusBadRequest, "name must be at most 255 characters"}
+	}
+	return name, nil
+}
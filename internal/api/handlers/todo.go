@@ -0,0 +1,592 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/filter"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TodoHandler struct {
+	querier db.Querier
+	pool    *pgxpool.Pool
+	logger  logger.Logger
+}
+
+func NewTodoHandler(querier db.Querier, pool *pgxpool.Pool, logger logger.Logger) *TodoHandler {
+	return &TodoHandler{
+		querier: querier,
+		pool:    pool,
+		logger:  logger,
+	}
+}
+
+type TodoResponse struct {
+	ID           int32  `json:"id"`
+	ProjectID    *int32 `json:"project_id"`
+	ParentTodoID *int32 `json:"parent_todo_id"`
+	// StatusID is the todo's column on its project's board
+	StatusID *int32 `json:"status_id"`
+	// SectionID is the todo's section of its project. Subtasks show under
+	// their parent's section.
+	SectionID *int32 `json:"section_id"`
+	// Position orders the todo among those with the same project, parent and
+	// section
+	Position     string     `json:"position"`
+	Title        string     `json:"title"`
+	Description  *string    `json:"description"`
+	IsCompleted  bool       `json:"is_completed"`
+	AssignedDate *time.Time `json:"assigned_date"`
+	DurationMin  *int32     `json:"duration_min"`
+	// Priority is 0 for none, otherwise 1 to 4 with 4 the most urgent. Filters
+	// and quick-add write it the other way round, Todoist style: p1 is 4 and
+	// p4 is 1.
+	Priority int32      `json:"priority"`
+	Deadline *time.Time `json:"deadline"`
+	// RolloverCount is how many days the todo was left unfinished on the day
+	// it was assigned to
+	RolloverCount int32      `json:"rollover_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	// Recurrence is nil for todos that don't repeat
+	Recurrence *RecurrenceResponse `json:"recurrence"`
+	// BlockedBy are the open todos this one waits on, and Blocking the open
+	// todos waiting on it
+	BlockedBy []int32 `json:"blocked_by"`
+	Blocking  []int32 `json:"blocking"`
+}
+
+func NewTodoResponse(todo *db.Todo) *TodoResponse {
+	return &TodoResponse{
+		ID:            todo.TodoID,
+		ProjectID:     int4Ptr(todo.ProjectID),
+		ParentTodoID:  int4Ptr(todo.ParentTodoID),
+		StatusID:      int4Ptr(todo.StatusID),
+		SectionID:     int4Ptr(todo.SectionID),
+		Position:      todo.Position,
+		Title:         todo.Title,
+		Description:   textPtr(todo.Description),
+		IsCompleted:   todo.IsCompleted.Bool,
+		AssignedDate:  timePtr(todo.AssignedDate),
+		DurationMin:   int4Ptr(todo.DurationMin),
+		Priority:      todo.Priority.Int32,
+		Deadline:      timePtr(todo.Deadline),
+		RolloverCount: todo.RolloverCount,
+		CreatedAt:     todo.CreatedAt.Time,
+		UpdatedAt:     todo.UpdatedAt.Time,
+		CompletedAt:   timePtr(todo.CompletedAt),
+		Recurrence:    NewRecurrenceResponse(todo),
+		BlockedBy:     []int32{},
+		Blocking:      []int32{},
+	}
+}
+
+// MaxDuplicateSuggestions caps how many likely duplicates are returned when
+// a todo is created
+const MaxDuplicateSuggestions = 5
+
+// maxTitleLength is the length of todos.title
+const maxTitleLength = 500
+
+type CreateTodoRequest struct {
+	Title        string  `json:"title" binding:"required"`
+	Description  *string `json:"description"`
+	ProjectID    *int32  `json:"project_id"`
+	ParentTodoID *int32  `json:"parent_todo_id"`
+	// SectionID puts a top-level todo in a section. project_id defaults to
+	// the section's project.
+	SectionID    *int32     `json:"section_id"`
+	AssignedDate *time.Time `json:"assigned_date"`
+	DurationMin  *int32     `json:"duration_min"`
+	Priority     int32      `json:"priority"`
+	// Deadline is when the todo must be finished by. The auto-scheduler won't
+	// place it in a slot that ends later.
+	Deadline *time.Time `json:"deadline"`
+	TagIDs   []int32    `json:"tag_ids"`
+	// Recurrence makes the todo repeat, starting from assigned_date
+	Recurrence *RecurrenceRequest `json:"recurrence"`
+}
+
+// DuplicateTodo is an open todo whose title is similar to a new one
+type DuplicateTodo struct {
+	*TodoResponse
+	Similarity float32 `json:"similarity"`
+}
+
+type CreateTodoResponse struct {
+	*TodoResponse
+	PossibleDuplicates []*DuplicateTodo `json:"possible_duplicates"`
+}
+
+// todoSortKeys are the orderings supported by todo list endpoints
+var todoSortKeys = map[string]pagination.SortKey{
+	"created":   {Column: "t.created_at", Kind: pagination.KindTime},
+	"updated":   {Column: "t.updated_at", Kind: pagination.KindTime},
+	"completed": {Column: "t.completed_at", Kind: pagination.KindTime, Nullable: true},
+	"priority":  {Column: "COALESCE(t.priority, 0)", Kind: pagination.KindInt},
+	"assigned":  {Column: "t.assigned_date", Kind: pagination.KindTime, Nullable: true},
+	"title":     {Column: "t.title", Kind: pagination.KindText},
+	"position":  {Column: "t.position", Kind: pagination.KindText},
+}
+
+func todoSortValue(sort string) func(*TodoResponse) (any, int32) {
+	return func(todo *TodoResponse) (any, int32) {
+		switch sort {
+		case "updated":
+			return todo.UpdatedAt, todo.ID
+		case "completed":
+			return todo.CompletedAt, todo.ID
+		case "priority":
+			return todo.Priority, todo.ID
+		case "assigned":
+			return todo.AssignedDate, todo.ID
+		case "title":
+			return todo.Title, todo.ID
+		case "position":
+			return todo.Position, todo.ID
+		}
+		return todo.CreatedAt, todo.ID
+	}
+}
+
+// CreateTodo creates a todo and returns it along with open todos that look
+// like duplicates of it
+func (h *TodoHandler) CreateTodo(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CreateTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.logger.Error("Failed to begin transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	todo, err := createTodo(ctx, db.New(tx), userID, &req)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.todoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.withDuplicates(ctx, todo))
+}
+
+// createTodo validates and creates a todo along with its tags
+func createTodo(ctx context.Context, querier db.Querier, userID int32, req *CreateTodoRequest) (*db.Todo, error) {
+	if utf8.RuneCountInString(req.Title) > maxTitleLength {
+		return nil, &requestError{http.StatusBadRequest, "title must be at most " + strconv.Itoa(maxTitleLength) + " characters"}
+	}
+	if req.Priority < 0 || req.Priority > 4 {
+		return nil, &requestError{http.StatusBadRequest, "priority must be between 0 and 4"}
+	}
+	if req.DurationMin != nil && *req.DurationMin <= 0 {
+		return nil, &requestError{http.StatusBadRequest, "duration_min must be positive"}
+	}
+
+	projectID := req.ProjectID
+	if req.SectionID != nil {
+		if req.ParentTodoID != nil {
+			return nil, &requestError{http.StatusBadRequest, "Subtasks show under their parent's section"}
+		}
+		section, err := ownedSection(ctx, querier, userID, *req.SectionID)
+		if err != nil {
+			return nil, err
+		}
+		if projectID == nil {
+			projectID = &section.ProjectID
+		} else if *projectID != section.ProjectID {
+			return nil, &requestError{http.StatusBadRequest, "The section is in another project"}
+		}
+	}
+	if projectID != nil {
+		project, err := querier.GetProject(ctx, *projectID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		if err != nil || project.UserID != userID {
+			return nil, &requestError{http.StatusNotFound, "Project not found"}
+		}
+	}
+	if req.ParentTodoID != nil {
+		if _, err := ownedTodo(ctx, querier, userID, *req.ParentTodoID); err != nil {
+			return nil, err
+		}
+	}
+	tagIDs := uniqueIDs(req.TagIDs)
+	for _, tagID := range tagIDs {
+		if _, err := ownedTagID(ctx, querier, userID, &tagID); err != nil {
+			return nil, err
+		}
+	}
+
+	// New todos go to the top of their list
+	first, err := querier.FirstTodoPosition(ctx, db.FirstTodoPositionParams{
+		UserID:       userID,
+		ProjectID:    pgInt4(projectID),
+		ParentTodoID: pgInt4(req.ParentTodoID),
+		SectionID:    pgInt4(req.SectionID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	position, ok := firstPosition(first)
+
+	todo, err := querier.CreateTodo(ctx, db.CreateTodoParams{
+		UserID:       userID,
+		ProjectID:    pgInt4(projectID),
+		ParentTodoID: pgInt4(req.ParentTodoID),
+		Title:        req.Title,
+		Description:  pgText(req.Description),
+		AssignedDate: pgTimestamptz(req.AssignedDate),
+		DurationMin:  pgInt4(req.DurationMin),
+		Priority:     pgtype.Int4{Int32: req.Priority, Valid: true},
+		Deadline:     pgTimestamptz(req.Deadline),
+		Position:     position,
+		SectionID:    pgInt4(req.SectionID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		siblings, err := todoSiblings(ctx, querier, &todo)
+		if err != nil {
+			return nil, err
+		}
+		if err := placeFirst(ctx, siblings, todo.TodoID, setTodoPosition(querier)); err != nil {
+			return nil, err
+		}
+		if todo, err = querier.GetTodo(ctx, todo.TodoID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tagID := range tagIDs {
+		if err := querier.CreateTodoTag(ctx, db.CreateTodoTagParams{TodoID: todo.TodoID, TagID: tagID}); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Recurrence != nil {
+		location, err := userLocation(ctx, querier, userID)
+		if err != nil {
+			return nil, err
+		}
+		return setRecurrence(ctx, querier, &todo, req.Recurrence, location)
+	}
+
+	return &todo, nil
+}
+
+// withDuplicates builds the response for a newly created todo. The todo
+// already exists at this point, so a failed duplicate lookup is logged rather
+// than failing the request and inviting a retry that creates it twice.
+func (h *TodoHandler) withDuplicates(ctx context.Context, todo *db.Todo) *CreateTodoResponse {
+	response := &CreateTodoResponse{
+		TodoResponse:       NewTodoResponse(todo),
+		PossibleDuplicates: []*DuplicateTodo{},
+	}
+
+	similar, err := db.QuerierFromContext(ctx, h.querier).FindSimilarTodos(ctx, db.FindSimilarTodosParams{
+		Title:         todo.Title,
+		UserID:        todo.UserID,
+		ExcludeTodoID: todo.TodoID,
+		MaxResults:    MaxDuplicateSuggestions,
+	})
+	if err != nil {
+		h.logger.Error("Failed to find similar todos", "error", err)
+		return response
+	}
+	for _, row := range similar {
+		match := db.Todo{
+			TodoID:       row.TodoID,
+			UserID:       row.UserID,
+			ProjectID:    row.ProjectID,
+			ParentTodoID: row.ParentTodoID,
+			Title:        row.Title,
+			Description:  row.Description,
+			IsCompleted:  row.IsCompleted,
+			AssignedDate: row.AssignedDate,
+			DurationMin:  row.DurationMin,
+			Priority:     row.Priority,
+			CreatedAt:    row.CreatedAt,
+			UpdatedAt:    row.UpdatedAt,
+			CompletedAt:  row.CompletedAt,
+
+			RecurrenceRule:  row.RecurrenceRule,
+			RecurrenceMode:  row.RecurrenceMode,
+			RecurrenceStart: row.RecurrenceStart,
+			RecurrenceCount: row.RecurrenceCount,
+
+			Deadline:         row.Deadline,
+			RolloverCount:    row.RolloverCount,
+			LastRolledOverAt: row.LastRolledOverAt,
+			StatusID:         row.StatusID,
+			Position:         row.Position,
+			SectionID:        row.SectionID,
+		}
+		response.PossibleDuplicates = append(response.PossibleDuplicates, &DuplicateTodo{
+			TodoResponse: NewTodoResponse(&match),
+			Similarity:   row.Similarity,
+		})
+	}
+	return response
+}
+
+// ListTodos returns a page of the user's todos matching the query filters, in
+// their manual order by default
+func (h *TodoHandler) ListTodos(c *gin.Context) {
+	h.listTodos(c, nil, "position", "asc")
+}
+
+// ListCompletedTodos returns a page of completed todos, most recently
+// completed first by default
+func (h *TodoHandler) ListCompletedTodos(c *gin.Context) {
+	completed := true
+	h.listTodos(c, &completed, "completed", "")
+}
+
+func (h *TodoHandler) listTodos(c *gin.Context, completed *bool, defaultSort string, defaultOrder string) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var todoFilter filter.TodoFilter
+	if err := c.ShouldBindQuery(&todoFilter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if completed != nil {
+		todoFilter.Completed = completed
+	}
+
+	var expr *filter.Expr
+	if query := c.Query("query"); query != "" {
+		var err error
+		expr, err = filter.Parse(query)
+		if err != nil {
+			filterError(c, err)
+			return
+		}
+	}
+
+	var pageRequest pagination.Request
+	if err := c.ShouldBindQuery(&pageRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if pageRequest.Sort == "" && pageRequest.Order == "" {
+		pageRequest.Order = defaultOrder
+	}
+	query, err := pageRequest.Resolve(todoSortKeys, defaultSort, "t.todo_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.todoPage(c.Request.Context(), userID, query, func(b *filter.Builder, opts filter.Options) {
+		todoFilter.Apply(b)
+		if expr != nil {
+			expr.Apply(b, opts)
+		}
+	})
+	if err != nil {
+		h.logger.Error("Failed to list todos", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// todoScope adds the conditions selecting the todos a list shows. opts carries
+// the user's timezone for date based conditions.
+type todoScope func(b *filter.Builder, opts filter.Options)
+
+// todoPage fetches one page of the user's todos within scope
+func (h *TodoHandler) todoPage(ctx context.Context, userID int32, query *pagination.Query, scope todoScope) (*pagination.Page[*TodoResponse], error) {
+	opts, err := h.filterOptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b := filter.NewBuilder()
+	b.Where("t.user_id = %s", userID)
+	scope(b, opts)
+	query.Apply(b)
+
+	todos, err := h.queryTodos(ctx, b, query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(query, todos, todoSortValue(query.Sort()))
+}
+
+// filterOptions evaluates filters as of now in the user's timezone
+func (h *TodoHandler) filterOptions(ctx context.Context, userID int32) (filter.Options, error) {
+	location, err := userLocation(ctx, h.querier, userID)
+	if err != nil {
+		return filter.Options{}, err
+	}
+	return filter.Options{
+		UserID:   userID,
+		Now:      time.Now(),
+		Location: location,
+	}, nil
+}
+
+// queryTodos runs a filtered, ordered and limited SELECT over todos aliased as t
+func (h *TodoHandler) queryTodos(ctx context.Context, b *filter.Builder, query *pagination.Query) ([]*TodoResponse, error) {
+	sql := "SELECT t.* FROM todos t WHERE " + b.Conditions() +
+		" ORDER BY " + query.OrderBy() +
+		" LIMIT " + b.Arg(query.LimitArg())
+	return h.selectTodos(ctx, sql, b.Args())
+}
+
+// selectTodos runs a SELECT returning whole todo rows
+func (h *TodoHandler) selectTodos(ctx context.Context, sql string, args []any) ([]*TodoResponse, error) {
+	rows, err := db.DBFromContext(ctx, h.pool).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := pgx.CollectRows(rows, pgx.RowToStructByName[db.Todo])
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*TodoResponse, len(todos))
+	for i := range todos {
+		responses[i] = NewTodoResponse(&todos[i])
+	}
+	if err := fillDependencies(ctx, db.QuerierFromContext(ctx, h.querier), responses); err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// todoResponse returns todo with its blocked_by and blocking lists
+func todoResponse(ctx context.Context, querier db.Querier, todo *db.Todo) (*TodoResponse, error) {
+	response := NewTodoResponse(todo)
+	if err := fillDependencies(ctx, querier, []*TodoResponse{response}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ownedTodo loads a todo, returning a 404 requestError if it doesn't exist or
+// belongs to someone else
+func ownedTodo(ctx context.Context, querier db.Querier, userID int32, todoID int32) (*db.Todo, error) {
+	todo, err := querier.GetTodo(ctx, todoID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &requestError{http.StatusNotFound, "Todo not found"}
+		}
+		return nil, err
+	}
+	if todo.UserID != userID {
+		return nil, &requestError{http.StatusNotFound, "Todo not found"}
+	}
+	return &todo, nil
+}
+
+// todoError responds with the status of a requestError, a 409 listing the
+// blockers of a blockedError, or a 500 for anything else
+func (h *TodoHandler) todoError(c *gin.Context, err error) {
+	var reqErr *requestError
+	var blocked *blockedError
+	switch {
+	case errors.As(err, &reqErr):
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	case errors.As(err, &blocked):
+		c.JSON(http.StatusConflict, gin.H{"error": blocked.Error(), "blocked_by": blocked.blockers})
+		return
+	}
+	h.logger.Error("Todo request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+// filterError responds to an invalid filter expression, pointing at the
+// offending part of it
+func filterError(c *gin.Context, err error) {
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    filterErr.Error(),
+			"position": filterErr.Pos,
+			"end":      filterErr.End,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+func int4Ptr(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+func textPtr(v pgtype.Text) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func timePtr(v pgtype.Timestamptz) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
+func pgInt4(v *int32) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *v, Valid: true}
+}
+
+func pgText(v *string) pgtype.Text {
+	if v == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *v, Valid: true}
+}
+
+func pgTimestamptz(v *time.Time) pgtype.Timestamptz {
+	if v == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *v, Valid: true}
+}
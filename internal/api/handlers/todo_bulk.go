@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/filter"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const MaxBulkTodoIDs = 1000
+
+// BulkTodoRequest selects todos by ID, by filter, by filter expression or a
+// combination (filters then narrow the given IDs). The remaining fields are
+// per operation.
+type BulkTodoRequest struct {
+	TodoIDs []int32            `json:"todo_ids"`
+	Filter  *filter.TodoFilter `json:"filter"`
+	Query   string             `json:"query"`
+
+	ProjectID     *int32 `json:"project_id"`
+	ParentTodoID  *int32 `json:"parent_todo_id"`
+	TagID         *int32 `json:"tag_id"`
+	OffsetDays    int32  `json:"offset_days"`
+	OffsetMinutes int64  `json:"offset_minutes"`
+	Priority      *int32 `json:"priority"`
+	// Force completes todos that wait on open todos outside the selection
+	Force bool `json:"force"`
+
+	expr *filter.Expr
+}
+
+type BulkTodoResponse struct {
+	Operation string  `json:"operation"`
+	Matched   int     `json:"matched"`
+	Affected  int64   `json:"affected"`
+	TodoIDs   []int32 `json:"todo_ids"`
+}
+
+type bulkOperation func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error)
+
+// BulkCompleteTodos completes todos. Recurring todos move on to their next
+// occurrence, as they do when completed one at a time. Todos waiting on open
+// todos that aren't being completed with them are only completed with force.
+func (h *TodoHandler) BulkCompleteTodos(c *gin.Context) {
+	h.runBulk(c, "complete", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		if !req.Force {
+			blockers, err := openBlockers(ctx, querier, todoIDs)
+			if err != nil {
+				return 0, err
+			}
+			if len(blockers) > 0 {
+				return 0, &blockedError{blockers}
+			}
+		}
+
+		recurring, err := querier.ListRecurringTodosForUpdate(ctx, db.ListRecurringTodosForUpdateParams{UserID: userID, TodoIds: todoIDs})
+		if err != nil {
+			return 0, err
+		}
+
+		now := time.Now()
+		handled := make(map[int32]bool, len(recurring))
+		for i := range recurring {
+			if _, err := completeTodo(ctx, querier, &recurring[i], now); err != nil {
+				return 0, err
+			}
+			handled[recurring[i].TodoID] = true
+		}
+
+		remaining := make([]int32, 0, len(todoIDs))
+		for _, id := range todoIDs {
+			if !handled[id] {
+				remaining = append(remaining, id)
+			}
+		}
+		affected, err := querier.BulkCompleteTodos(ctx, db.BulkCompleteTodosParams{UserID: userID, TodoIds: remaining})
+		return affected + int64(len(recurring)), err
+	})
+}
+
+func (h *TodoHandler) BulkUncompleteTodos(c *gin.Context) {
+	h.runBulk(c, "uncomplete", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		return querier.BulkUncompleteTodos(ctx, db.BulkUncompleteTodosParams{UserID: userID, TodoIds: todoIDs})
+	})
+}
+
+func (h *TodoHandler) BulkDeleteTodos(c *gin.Context) {
+	h.runBulk(c, "delete", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		return querier.BulkDeleteTodos(ctx, db.BulkDeleteTodosParams{UserID: userID, TodoIds: todoIDs})
+	})
+}
+
+// BulkMoveTodos moves todos to a project and/or under a parent todo, taking
+// the parent's project when only a parent is given. Leaving both empty moves
+// them to the inbox as top-level todos. Subtasks move to the same project.
+func (h *TodoHandler) BulkMoveTodos(c *gin.Context) {
+	h.runBulk(c, "move", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		params := db.BulkMoveTodosParams{UserID: userID, TodoIds: todoIDs}
+
+		if req.ProjectID != nil {
+			project, err := querier.GetProject(ctx, *req.ProjectID)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return 0, &requestError{http.StatusNotFound, "Project not found"}
+				}
+				return 0, err
+			}
+			if project.UserID != userID {
+				return 0, &requestError{http.StatusNotFound, "Project not found"}
+			}
+			params.ProjectID = pgtype.Int4{Int32: project.ProjectID, Valid: true}
+		}
+
+		if req.ParentTodoID != nil {
+			parent, err := querier.GetTodo(ctx, *req.ParentTodoID)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return 0, &requestError{http.StatusNotFound, "Parent todo not found"}
+				}
+				return 0, err
+			}
+			if parent.UserID != userID {
+				return 0, &requestError{http.StatusNotFound, "Parent todo not found"}
+			}
+			// The new parent can't be one of the moved todos or one of their descendants
+			cycles, err := querier.CountTodoAncestorsInSet(ctx, db.CountTodoAncestorsInSetParams{
+				TodoID:  parent.TodoID,
+				TodoIds: todoIDs,
+			})
+			if err != nil {
+				return 0, err
+			}
+			if cycles > 0 {
+				return 0, &requestError{http.StatusBadRequest, "A todo can't be moved under itself or one of its subtasks"}
+			}
+			params.ParentTodoID = pgtype.Int4{Int32: parent.TodoID, Valid: true}
+
+			// Subtasks live in their parent's project
+			if req.ProjectID == nil {
+				params.ProjectID = parent.ProjectID
+			} else if params.ProjectID != parent.ProjectID {
+				return 0, &requestError{http.StatusBadRequest, "The parent todo is in another project"}
+			}
+		}
+
+		moved, err := querier.BulkMoveTodos(ctx, params)
+		if err != nil {
+			return 0, err
+		}
+		// Subtasks follow the moved todos into their new project
+		err = querier.SetDescendantProjects(ctx, db.SetDescendantProjectsParams{
+			TodoIds:   todoIDs,
+			ProjectID: params.ProjectID,
+		})
+		return moved, err
+	})
+}
+
+func (h *TodoHandler) BulkAddTodoTag(c *gin.Context) {
+	h.runBulk(c, "add_tag", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		tagID, err := ownedTagID(ctx, querier, userID, req.TagID)
+		if err != nil {
+			return 0, err
+		}
+		return querier.BulkCreateTodoTags(ctx, db.BulkCreateTodoTagsParams{TodoIds: todoIDs, TagID: tagID})
+	})
+}
+
+func (h *TodoHandler) BulkRemoveTodoTag(c *gin.Context) {
+	h.runBulk(c, "remove_tag", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		tagID, err := ownedTagID(ctx, querier, userID, req.TagID)
+		if err != nil {
+			return 0, err
+		}
+		return querier.BulkDeleteTodoTags(ctx, db.BulkDeleteTodoTagsParams{TagID: tagID, TodoIds: todoIDs})
+	})
+}
+
+// BulkRescheduleTodos shifts assigned_date by offset_days and offset_minutes.
+// Todos without an assigned date are left alone.
+func (h *TodoHandler) BulkRescheduleTodos(c *gin.Context) {
+	h.runBulk(c, "reschedule", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		if req.OffsetDays == 0 && req.OffsetMinutes == 0 {
+			return 0, &requestError{http.StatusBadRequest, "offset_days or offset_minutes is required"}
+		}
+		return querier.BulkShiftTodoAssignedDates(ctx, db.BulkShiftTodoAssignedDatesParams{
+			Shift: pgtype.Interval{
+				Days:         req.OffsetDays,
+				Microseconds: req.OffsetMinutes * int64(time.Minute/time.Microsecond),
+				Valid:        true,
+			},
+			UserID:  userID,
+			TodoIds: todoIDs,
+		})
+	})
+}
+
+func (h *TodoHandler) BulkSetTodoPriority(c *gin.Context) {
+	h.runBulk(c, "set_priority", func(ctx context.Context, querier db.Querier, userID int32, todoIDs []int32, req *BulkTodoRequest) (int64, error) {
+		if req.Priority == nil || *req.Priority < 0 || *req.Priority > 4 {
+			return 0, &requestError{http.StatusBadRequest, "priority must be between 0 and 4"}
+		}
+		return querier.BulkSetTodoPriority(ctx, db.BulkSetTodoPriorityParams{
+			Priority: *req.Priority,
+			UserID:   userID,
+			TodoIds:  todoIDs,
+		})
+	})
+}
+
+// runBulk resolves the selected todos and applies the operation to them in a
+// single transaction
+func (h *TodoHandler) runBulk(c *gin.Context, name string, operation bulkOperation) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req BulkTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.TodoIDs) == 0 && req.Filter == nil && req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "todo_ids, filter or query is required"})
+		return
+	}
+	if req.Query != "" {
+		expr, err := filter.Parse(req.Query)
+		if err != nil {
+			filterError(c, err)
+			return
+		}
+		req.expr = expr
+	}
+	if len(req.TodoIDs) > MaxBulkTodoIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many todo_ids"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.logger.Error("Failed to begin bulk transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	response, err := applyBulk(ctx, tx, userID, name, &req, operation)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err == nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var reqErr *requestError
+	var blocked *blockedError
+	switch {
+	case errors.As(err, &reqErr):
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	case errors.As(err, &blocked):
+		c.JSON(http.StatusConflict, gin.H{"error": blocked.Error(), "blocked_by": blocked.blockers})
+		return
+	}
+	h.logger.Error("Bulk todo operation failed", "operation", name, "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
+
+func applyBulk(ctx context.Context, tx pgx.Tx, userID int32, name string, req *BulkTodoRequest, operation bulkOperation) (*BulkTodoResponse, error) {
+	querier := db.New(tx)
+
+	todoIDs, err := resolveBulkTodoIDs(ctx, tx, querier, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := operation(ctx, querier, userID, todoIDs, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BulkTodoResponse{
+		Operation: name,
+		Matched:   len(todoIDs),
+		Affected:  affected,
+		TodoIDs:   todoIDs,
+	}, nil
+}
+
+// resolveBulkTodoIDs returns the IDs of the todos a bulk request applies to,
+// locking them for the rest of the transaction. Explicit IDs must all belong
+// to the user.
+func resolveBulkTodoIDs(ctx context.Context, conn db.DBTX, querier db.Querier, userID int32, req *BulkTodoRequest) ([]int32, error) {
+	if len(req.TodoIDs) > 0 {
+		owned, err := querier.ListOwnedTodoIDs(ctx, db.ListOwnedTodoIDsParams{
+			UserID:  userID,
+			TodoIds: req.TodoIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(owned) != len(uniqueIDs(req.TodoIDs)) {
+			return nil, &requestError{http.StatusNotFound, "One or more todos were not found"}
+		}
+		if req.Filter == nil && req.expr == nil {
+			return owned, nil
+		}
+	}
+
+	b := filter.NewBuilder()
+	b.Where("t.user_id = %s", userID)
+	if len(req.TodoIDs) > 0 {
+		b.Where("t.todo_id = ANY(%s)", req.TodoIDs)
+	}
+	req.Filter.Apply(b)
+	if req.expr != nil {
+		location, err := userLocation(ctx, querier, userID)
+		if err != nil {
+			return nil, err
+		}
+		req.expr.Apply(b, filter.Options{UserID: userID, Now: time.Now(), Location: location})
+	}
+
+	rows, err := conn.Query(ctx, "SELECT t.todo_id FROM todos t WHERE "+b.Conditions()+" ORDER BY t.todo_id FOR UPDATE", b.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+	if err != nil {
+		return nil, err
+	}
+	return todoIDs, nil
+}
+
+func ownedTagID(ctx context.Context, querier db.Querier, userID int32, tagID *int32) (int32, error) {
+	if tagID == nil {
+		return 0, &requestError{http.StatusBadRequest, "tag_id is required"}
+	}
+	tag, err := querier.GetTag(ctx, *tagID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, &requestError{http.StatusNotFound, "Tag not found"}
+		}
+		return 0, err
+	}
+	if tag.UserID != userID {
+		return 0, &requestError{http.StatusNotFound, "Tag not found"}
+	}
+	return tag.TagID, nil
+}
+
+func uniqueIDs(ids []int32) []int32 {
+	seen := make(map[int32]bool, len(ids))
+	unique := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+type AddTodoDependencyRequest struct {
+	// DependsOnID is the todo that must be completed first
+	DependsOnID int32 `json:"depends_on_id" binding:"required"`
+}
+
+type TodoDependenciesResponse struct {
+	// DependsOn are the todos that must be completed first, open or not
+	DependsOn []*TodoResponse `json:"depends_on"`
+	// Dependents are the todos waiting on this one
+	Dependents []*TodoResponse `json:"dependents"`
+}
+
+// ListTodoDependencies returns the todos a todo depends on and the todos
+// that depend on it
+func (h *TodoHandler) ListTodoDependencies(c *gin.Context) {
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		return todoDependencies(ctx, querier, todo.TodoID)
+	})
+}
+
+// AddTodoDependency makes a todo wait on another one. Dependencies that would
+// make a todo wait on itself, directly or through other todos, are rejected.
+func (h *TodoHandler) AddTodoDependency(c *gin.Context) {
+	var req AddTodoDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		if err := addDependency(ctx, querier, todo, req.DependsOnID); err != nil {
+			return nil, err
+		}
+		return todoDependencies(ctx, querier, todo.TodoID)
+	})
+}
+
+// RemoveTodoDependency stops a todo waiting on the one in the :depends_on_id
+// parameter
+func (h *TodoHandler) RemoveTodoDependency(c *gin.Context) {
+	dependsOnID, err := strconv.ParseInt(c.Param("depends_on_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		removed, err := querier.DeleteTodoDependency(ctx, db.DeleteTodoDependencyParams{
+			TodoID:      todo.TodoID,
+			DependsOnID: int32(dependsOnID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if removed == 0 {
+			return nil, &requestError{http.StatusNotFound, "Dependency not found"}
+		}
+		return todoDependencies(ctx, querier, todo.TodoID)
+	})
+}
+
+// addDependency makes todo wait on the user's todo dependsOnID, unless that
+// would form a cycle. Adding a dependency that exists already does nothing.
+func addDependency(ctx context.Context, querier db.Querier, todo *db.Todo, dependsOnID int32) error {
+	if dependsOnID == todo.TodoID {
+		return &requestError{http.StatusBadRequest, "A todo can't depend on itself"}
+	}
+	if _, err := ownedTodo(ctx, querier, todo.UserID, dependsOnID); err != nil {
+		return err
+	}
+	// Two requests adding opposite dependencies would otherwise both pass the
+	// cycle check
+	if err := querier.LockUser(ctx, todo.UserID); err != nil {
+		return err
+	}
+	cycle, err := querier.TodoDependsOn(ctx, db.TodoDependsOnParams{
+		TodoID:      dependsOnID,
+		DependsOnID: todo.TodoID,
+	})
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return &requestError{http.StatusBadRequest, "The dependency would make the todo wait on itself"}
+	}
+	_, err = querier.CreateTodoDependency(ctx, db.CreateTodoDependencyParams{
+		TodoID:      todo.TodoID,
+		DependsOnID: dependsOnID,
+		UserID:      todo.UserID,
+	})
+	return err
+}
+
+func todoDependencies(ctx context.Context, querier db.Querier, todoID int32) (*TodoDependenciesResponse, error) {
+	prerequisites, err := querier.ListTodoPrerequisites(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	dependents, err := querier.ListTodoDependents(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TodoDependenciesResponse{
+		DependsOn:  make([]*TodoResponse, len(prerequisites)),
+		Dependents: make([]*TodoResponse, len(dependents)),
+	}
+	for i := range prerequisites {
+		response.DependsOn[i] = NewTodoResponse(&prerequisites[i])
+	}
+	for i := range dependents {
+		response.Dependents[i] = NewTodoResponse(&dependents[i])
+	}
+	todos := append(append([]*TodoResponse{}, response.DependsOn...), response.Dependents...)
+	if err := fillDependencies(ctx, querier, todos); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// fillDependencies sets the blocked_by and blocking lists of todos
+func fillDependencies(ctx context.Context, querier db.Querier, todos []*TodoResponse) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	byID := make(map[int32]*TodoResponse, len(todos))
+	ids := make([]int32, 0, len(todos))
+	for _, todo := range todos {
+		if _, ok := byID[todo.ID]; !ok {
+			ids = append(ids, todo.ID)
+		}
+		byID[todo.ID] = todo
+	}
+
+	edges, err := querier.ListOpenDependencyEdges(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, edge := range edges {
+		if todo, ok := byID[edge.TodoID]; ok {
+			todo.BlockedBy = append(todo.BlockedBy, edge.DependsOnID)
+		}
+		if todo, ok := byID[edge.DependsOnID]; ok {
+			todo.Blocking = append(todo.Blocking, edge.TodoID)
+		}
+	}
+	return nil
+}
+
+// openBlockers returns the open todos that the open todos in todoIDs wait
+// on, leaving out those that are in todoIDs themselves
+func openBlockers(ctx context.Context, querier db.Querier, todoIDs []int32) ([]int32, error) {
+	edges, err := querier.ListOpenDependencyEdges(ctx, todoIDs)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[int32]bool, len(todoIDs))
+	for _, id := range todoIDs {
+		selected[id] = true
+	}
+	var blockers []int32
+	for _, edge := range edges {
+		if selected[edge.TodoID] && !selected[edge.DependsOnID] {
+			blockers = append(blockers, edge.DependsOnID)
+		}
+	}
+	return uniqueIDs(blockers), nil
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type MergeTodosRequest struct {
+	// SourceTodoID is merged into the todo in the URL and then deleted
+	SourceTodoID int32 `json:"source_todo_id" binding:"required"`
+}
+
+type MergeTodosResponse struct {
+	Todo          *TodoResponse `json:"todo"`
+	MovedComments int64         `json:"moved_comments"`
+	MovedSubtasks int64         `json:"moved_subtasks"`
+}
+
+// MergeTodos merges a duplicate into the todo in the URL. The survivor gains
+// the duplicate's tags, comments and subtasks, and its description is
+// appended so nothing written on the duplicate is lost.
+func (h *TodoHandler) MergeTodos(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	targetID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req MergeTodosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SourceTodoID == int32(targetID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A todo can't be merged into itself"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.logger.Error("Failed to begin merge transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	response, err := mergeTodos(ctx, db.New(tx), userID, int32(targetID), req.SourceTodoID)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.todoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func mergeTodos(ctx context.Context, querier db.Querier, userID int32, targetID int32, sourceID int32) (*MergeTodosResponse, error) {
+	target, err := ownedTodo(ctx, querier, userID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := ownedTodo(ctx, querier, userID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The survivor adopts the duplicate's subtasks, so it can't be one of them
+	nested, err := querier.CountTodoAncestorsInSet(ctx, db.CountTodoAncestorsInSetParams{
+		TodoID:  target.TodoID,
+		TodoIds: []int32{source.TodoID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if nested > 0 {
+		return nil, &requestError{http.StatusBadRequest, "A todo can't be merged into one of its own subtasks"}
+	}
+
+	merge := db.CopyTodoTagsParams{TargetTodoID: target.TodoID, SourceTodoID: source.TodoID}
+	if err := querier.CopyTodoTags(ctx, merge); err != nil {
+		return nil, err
+	}
+	movedComments, err := querier.MoveTodoComments(ctx, db.MoveTodoCommentsParams(merge))
+	if err != nil {
+		return nil, err
+	}
+	movedSubtasks, err := querier.MoveSubtasks(ctx, db.MoveSubtasksParams(merge))
+	if err != nil {
+		return nil, err
+	}
+	// The adopted subtasks, and everything below them, join the target's
+	// project
+	err = querier.SetDescendantProjects(ctx, db.SetDescendantProjectsParams{
+		TodoIds:   []int32{target.TodoID},
+		ProjectID: target.ProjectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := mergeDependencies(ctx, querier, target, source); err != nil {
+		return nil, err
+	}
+
+	survivor := *target
+	if source.Description.Valid && source.Description.String != "" {
+		description := source.Description.String
+		if target.Description.Valid && target.Description.String != "" {
+			description = target.Description.String + "\n\n" + description
+		}
+		survivor, err = querier.UpdateTodo(ctx, db.UpdateTodoParams{
+			TodoID:       target.TodoID,
+			ProjectID:    target.ProjectID,
+			ParentTodoID: target.ParentTodoID,
+			Title:        target.Title,
+			Description:  pgtype.Text{String: description, Valid: true},
+			AssignedDate: target.AssignedDate,
+			DurationMin:  target.DurationMin,
+			Priority:     target.Priority,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := querier.DeleteTodo(ctx, source.TodoID); err != nil {
+		return nil, err
+	}
+	response, err := todoResponse(ctx, querier, &survivor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MergeTodosResponse{
+		Todo:          response,
+		MovedComments: movedComments,
+		MovedSubtasks: movedSubtasks,
+	}, nil
+}
+
+// mergeDependencies gives target the dependencies of source, in both
+// directions. Ones between the two, or that would form a cycle, are dropped.
+func mergeDependencies(ctx context.Context, querier db.Querier, target *db.Todo, source *db.Todo) error {
+	prerequisites, err := querier.ListTodoPrerequisites(ctx, source.TodoID)
+	if err != nil {
+		return err
+	}
+	dependents, err := querier.ListTodoDependents(ctx, source.TodoID)
+	if err != nil {
+		return err
+	}
+	if err := querier.DeleteTodoDependencies(ctx, source.TodoID); err != nil {
+		return err
+	}
+
+	var reqErr *requestError
+	for i := range prerequisites {
+		if prerequisites[i].TodoID == target.TodoID {
+			continue
+		}
+		err := addDependency(ctx, querier, target, prerequisites[i].TodoID)
+		if err != nil && !errors.As(err, &reqErr) {
+			return err
+		}
+	}
+	for i := range dependents {
+		if dependents[i].TodoID == target.TodoID {
+			continue
+		}
+		err := addDependency(ctx, querier, &dependents[i], target.TodoID)
+		if err != nil && !errors.As(err, &reqErr) {
+			return err
+		}
+	}
+	return nil
+}
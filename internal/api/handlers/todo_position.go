@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// MoveTodo reorders a todo among the todos with the same project, parent and
+// section
+func (h *TodoHandler) MoveTodo(c *gin.Context) {
+	var req MoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		// Concurrent moves would otherwise each place their todo against
+		// keys the other is rewriting
+		if err := querier.LockUser(ctx, todo.UserID); err != nil {
+			return nil, err
+		}
+		siblings, err := todoSiblings(ctx, querier, todo)
+		if err != nil {
+			return nil, err
+		}
+		if err := moveBetween(ctx, siblings, todo.TodoID, &req, setTodoPosition(querier)); err != nil {
+			return nil, err
+		}
+		moved, err := querier.GetTodo(ctx, todo.TodoID)
+		if err != nil {
+			return nil, err
+		}
+		return todoResponse(ctx, querier, &moved)
+	})
+}
+
+// todoSiblings lists the todos sharing todo's project, parent and section, in
+// order
+func todoSiblings(ctx context.Context, querier db.Querier, todo *db.Todo) ([]sibling, error) {
+	rows, err := querier.ListTodoSiblings(ctx, db.ListTodoSiblingsParams{
+		UserID:       todo.UserID,
+		ProjectID:    todo.ProjectID,
+		ParentTodoID: todo.ParentTodoID,
+		SectionID:    todo.SectionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	siblings := make([]sibling, len(rows))
+	for i, row := range rows {
+		siblings[i] = sibling{ID: row.TodoID, Position: row.Position}
+	}
+	return siblings, nil
+}
+
+func setTodoPosition(querier db.Querier) func(ctx context.Context, id int32, position string) error {
+	return func(ctx context.Context, id int32, position string) error {
+		return querier.SetTodoPosition(ctx, db.SetTodoPositionParams{Position: position, TodoID: id})
+	}
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/recurrence"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// RecurrenceFromSchedule moves a completed todo to the next occurrence
+	// of its rule, e.g. every Monday no matter when it was done
+	RecurrenceFromSchedule = "schedule"
+	// RecurrenceFromCompletion starts the rule again from the day the todo
+	// was completed, e.g. three days after it was last done
+	RecurrenceFromCompletion = "completion"
+)
+
+// RecurrenceRequest sets how a todo repeats. Exactly one of Rule, an RFC 5545
+// RRULE, and Preset, such as "weekdays", is required.
+type RecurrenceRequest struct {
+	Rule   string `json:"rule"`
+	Preset string `json:"preset"`
+	Mode   string `json:"mode"`
+}
+
+type RecurrenceResponse struct {
+	Rule  string    `json:"rule"`
+	Mode  string    `json:"mode"`
+	Start time.Time `json:"start"`
+	// Count is how many times the todo has been completed
+	Count int32 `json:"count"`
+}
+
+func NewRecurrenceResponse(todo *db.Todo) *RecurrenceResponse {
+	if !todo.RecurrenceRule.Valid {
+		return nil
+	}
+	return &RecurrenceResponse{
+		Rule:  todo.RecurrenceRule.String,
+		Mode:  todo.RecurrenceMode,
+		Start: todo.RecurrenceStart.Time,
+		Count: todo.RecurrenceCount,
+	}
+}
+
+type OccurrenceResponse struct {
+	ID             int32     `json:"id"`
+	OccurrenceDate time.Time `json:"occurrence_date"`
+	CompletedAt    time.Time `json:"completed_at"`
+}
+
+type CompleteTodoResponse struct {
+	Todo *TodoResponse `json:"todo"`
+	// Occurrence is the completed occurrence of a recurring todo
+	Occurrence *OccurrenceResponse `json:"occurrence"`
+	// Advanced is true when a recurring todo moved on to its next occurrence
+	// instead of being completed
+	Advanced bool `json:"advanced"`
+	// OpenBlockers are the open todos the todo was still waiting on, when it
+	// was completed with force
+	OpenBlockers []int32 `json:"open_blockers,omitempty"`
+}
+
+// SetTodoRecurrence makes a todo repeat, or changes how it repeats
+func (h *TodoHandler) SetTodoRecurrence(c *gin.Context) {
+	var req RecurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		location, err := userLocation(ctx, querier, todo.UserID)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := setRecurrence(ctx, querier, todo, &req, location)
+		if err != nil {
+			return nil, err
+		}
+		return todoResponse(ctx, querier, updated)
+	})
+}
+
+// ClearTodoRecurrence stops a todo from repeating. Its completed occurrences
+// are kept.
+func (h *TodoHandler) ClearTodoRecurrence(c *gin.Context) {
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		updated, err := querier.ClearTodoRecurrence(ctx, todo.TodoID)
+		if err != nil {
+			return nil, err
+		}
+		return todoResponse(ctx, querier, &updated)
+	})
+}
+
+// CompleteTodo completes a todo. A recurring todo instead records the
+// completed occurrence and moves on to the next one, and is only completed
+// once its rule has ended. A todo waiting on open todos is only completed
+// when the force query parameter is true.
+func (h *TodoHandler) CompleteTodo(c *gin.Context) {
+	force := c.Query("force") == "true"
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		if todo.IsCompleted.Bool {
+			completed, err := todoResponse(ctx, querier, todo)
+			if err != nil {
+				return nil, err
+			}
+			return &CompleteTodoResponse{Todo: completed}, nil
+		}
+		blockers, err := openBlockers(ctx, querier, []int32{todo.TodoID})
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 && !force {
+			return nil, &blockedError{blockers}
+		}
+
+		response, err := completeTodo(ctx, querier, todo, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		response.OpenBlockers = blockers
+		if err := fillDependencies(ctx, querier, []*TodoResponse{response.Todo}); err != nil {
+			return nil, err
+		}
+		return response, nil
+	})
+}
+
+func (h *TodoHandler) UncompleteTodo(c *gin.Context) {
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		updated, err := querier.UncompleteTodo(ctx, todo.TodoID)
+		if err != nil {
+			return nil, err
+		}
+		return todoResponse(ctx, querier, &updated)
+	})
+}
+
+// ListTodoOccurrences returns the completed occurrences of a recurring todo,
+// most recent first
+func (h *TodoHandler) ListTodoOccurrences(c *gin.Context) {
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		occurrences, err := querier.ListTodoOccurrences(ctx, todo.TodoID)
+		if err != nil {
+			return nil, err
+		}
+		responses := make([]*OccurrenceResponse, len(occurrences))
+		for i := range occurrences {
+			responses[i] = newOccurrenceResponse(&occurrences[i])
+		}
+		return responses, nil
+	})
+}
+
+// withTodo loads the todo named by the :id parameter and runs fn on it in a
+// transaction, responding with what fn returns
+func (h *TodoHandler) withTodo(c *gin.Context, fn func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Begin(ctx, h.pool)
+	if err != nil {
+		h.logger.Error("Failed to begin transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	var response any
+	todo, err := ownedTodo(ctx, querier, userID, int32(todoID))
+	if err == nil {
+		response, err = fn(ctx, querier, todo)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		h.todoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// setRecurrence validates req and applies it to todo, anchoring the rule at
+// its assigned date. The assigned date moves to the first occurrence if it
+// doesn't fall on the rule itself.
+func setRecurrence(ctx context.Context, querier db.Querier, todo *db.Todo, req *RecurrenceRequest, location *time.Location) (*db.Todo, error) {
+	value := req.Rule
+	if req.Preset != "" {
+		if req.Rule != "" {
+			return nil, &requestError{http.StatusBadRequest, "Only one of rule and preset can be set"}
+		}
+		preset, ok := recurrence.Preset(req.Preset)
+		if !ok {
+			return nil, &requestError{http.StatusBadRequest, "Unknown recurrence preset " + strconv.Quote(req.Preset)}
+		}
+		value = preset
+	}
+	if value == "" {
+		return nil, &requestError{http.StatusBadRequest, "rule or preset is required"}
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = RecurrenceFromSchedule
+	}
+	if mode != RecurrenceFromSchedule && mode != RecurrenceFromCompletion {
+		return nil, &requestError{http.StatusBadRequest, "mode must be schedule or completion"}
+	}
+
+	rule, err := recurrence.Parse(value)
+	if err != nil {
+		return nil, &requestError{http.StatusBadRequest, "Invalid recurrence rule: " + err.Error()}
+	}
+	if !todo.AssignedDate.Valid {
+		return nil, &requestError{http.StatusBadRequest, "A todo needs an assigned_date to repeat"}
+	}
+
+	start := todo.AssignedDate.Time.In(location)
+	first, ok := rule.Next(start, start.Add(-time.Nanosecond))
+	if !ok {
+		return nil, &requestError{http.StatusBadRequest, "The recurrence rule has no occurrences"}
+	}
+
+	updated, err := querier.SetTodoRecurrence(ctx, db.SetTodoRecurrenceParams{
+		RecurrenceRule:  pgtype.Text{String: rule.String(), Valid: true},
+		RecurrenceMode:  mode,
+		RecurrenceStart: pgtype.Timestamptz{Time: start, Valid: true},
+		AssignedDate:    pgtype.Timestamptz{Time: first, Valid: true},
+		TodoID:          todo.TodoID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// completeTodo completes a todo, or advances a recurring one to its next
+// occurrence. Occurrences are computed in the user's timezone so wall clock
+// times survive DST changes.
+func completeTodo(ctx context.Context, querier db.Querier, todo *db.Todo, now time.Time) (*CompleteTodoResponse, error) {
+	if !todo.RecurrenceRule.Valid {
+		completed, err := querier.CompleteTodo(ctx, todo.TodoID)
+		if err != nil {
+			return nil, err
+		}
+		return &CompleteTodoResponse{Todo: NewTodoResponse(&completed)}, nil
+	}
+
+	location, err := userLocation(ctx, querier, todo.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := now
+	if todo.AssignedDate.Valid {
+		current = todo.AssignedDate.Time
+	}
+	occurrence, err := querier.CreateTodoOccurrence(ctx, db.CreateTodoOccurrenceParams{
+		TodoID:         todo.TodoID,
+		UserID:         todo.UserID,
+		OccurrenceDate: pgtype.Timestamptz{Time: current, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := &CompleteTodoResponse{Occurrence: newOccurrenceResponse(&occurrence)}
+
+	next, ok, err := nextOccurrence(todo, current.In(location), now.In(location))
+	if err != nil {
+		return nil, err
+	}
+
+	var updated db.Todo
+	if ok {
+		updated, err = querier.AdvanceRecurringTodo(ctx, db.AdvanceRecurringTodoParams{
+			AssignedDate: pgtype.Timestamptz{Time: next, Valid: true},
+			TodoID:       todo.TodoID,
+		})
+		response.Advanced = true
+	} else {
+		updated, err = querier.CompleteTodo(ctx, todo.TodoID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	response.Todo = NewTodoResponse(&updated)
+	return response, nil
+}
+
+// nextOccurrence returns when a recurring todo that was due at current and
+// completed at now is next due, or false if its rule has ended
+func nextOccurrence(todo *db.Todo, current time.Time, now time.Time) (time.Time, bool, error) {
+	rule, err := recurrence.Parse(todo.RecurrenceRule.String)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if todo.RecurrenceMode == RecurrenceFromCompletion {
+		// Each completion restarts the rule, so COUNT is checked against the
+		// number of completions rather than occurrences since the start
+		if rule.Count > 0 && int(todo.RecurrenceCount)+1 >= rule.Count {
+			return time.Time{}, false, nil
+		}
+		rule.Count = 0
+		hour, minute, second := current.Clock()
+		anchor := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, second, 0, now.Location())
+		next, ok := rule.Next(anchor, anchor)
+		return next, ok, nil
+	}
+
+	start := todo.RecurrenceStart.Time.In(current.Location())
+	next, ok := rule.Next(start, current)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	// Completing an overdue todo skips the occurrences that were missed
+	// rather than leaving it overdue
+	today := startOfDay(now, 0)
+	if next.Before(today) {
+		next, ok = rule.Next(start, today.Add(-time.Nanosecond))
+	}
+	return next, ok, nil
+}
+
+func newOccurrenceResponse(occurrence *db.TodoOccurrence) *OccurrenceResponse {
+	return &OccurrenceResponse{
+		ID:             occurrence.TodoOccurrenceID,
+		OccurrenceDate: occurrence.OccurrenceDate.Time,
+		CompletedAt:    occurrence.CompletedAt.Time,
+	}
+}
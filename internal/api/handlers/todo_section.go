@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+type SetTodoSectionRequest struct {
+	// SectionID is a section of the todo's project, or null to take the todo
+	// out of its section
+	SectionID *int32 `json:"section_id"`
+}
+
+// SetTodoSection puts a top-level todo at the top of one of its project's
+// sections, or takes it out of its section
+func (h *TodoHandler) SetTodoSection(c *gin.Context) {
+	var req SetTodoSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		if req.SectionID != nil {
+			if todo.ParentTodoID.Valid {
+				return nil, &requestError{http.StatusBadRequest, "Subtasks show under their parent's section"}
+			}
+			section, err := ownedSection(ctx, querier, todo.UserID, *req.SectionID)
+			if err != nil {
+				return nil, err
+			}
+			if !todo.ProjectID.Valid || todo.ProjectID.Int32 != section.ProjectID {
+				return nil, &requestError{http.StatusBadRequest, "The section is in another project"}
+			}
+		}
+		sectionID := pgInt4(req.SectionID)
+		if sectionID == todo.SectionID {
+			return todoResponse(ctx, querier, todo)
+		}
+
+		if err := querier.LockUser(ctx, todo.UserID); err != nil {
+			return nil, err
+		}
+		first, err := querier.FirstTodoPosition(ctx, db.FirstTodoPositionParams{
+			UserID:       todo.UserID,
+			ProjectID:    todo.ProjectID,
+			ParentTodoID: todo.ParentTodoID,
+			SectionID:    sectionID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		position, ok := firstPosition(first)
+		updated, err := querier.SetTodoSection(ctx, db.SetTodoSectionParams{
+			SectionID: sectionID,
+			Position:  position,
+			TodoID:    todo.TodoID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			siblings, err := todoSiblings(ctx, querier, &updated)
+			if err != nil {
+				return nil, err
+			}
+			if err := placeFirst(ctx, siblings, updated.TodoID, setTodoPosition(querier)); err != nil {
+				return nil, err
+			}
+			if updated, err = querier.GetTodo(ctx, updated.TodoID); err != nil {
+				return nil, err
+			}
+		}
+		return todoResponse(ctx, querier, &updated)
+	})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SetTodoStatusRequest struct {
+	StatusID int32 `json:"status_id" binding:"required"`
+	// Force moves the todo past the status's WIP limit, and completes it
+	// while it waits on open todos
+	Force bool `json:"force"`
+}
+
+// SetTodoStatus moves a todo to another column of its project's board.
+// Moving into a done status completes the todo and moving out of one reopens
+// it. A recurring todo moved to done moves on to its next occurrence instead,
+// staying open in its column.
+func (h *TodoHandler) SetTodoStatus(c *gin.Context) {
+	var req SetTodoStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.withTodo(c, func(ctx context.Context, querier db.Querier, todo *db.Todo) (any, error) {
+		if !todo.ProjectID.Valid {
+			return nil, &requestError{http.StatusBadRequest, "Only todos in a project have a status"}
+		}
+		project := &db.Project{ProjectID: todo.ProjectID.Int32}
+		status, err := projectStatus(ctx, querier, project, strconv.Itoa(int(req.StatusID)))
+		if err != nil {
+			return nil, err
+		}
+		if todo.StatusID.Valid && todo.StatusID.Int32 == status.StatusID {
+			return todoResponse(ctx, querier, todo)
+		}
+
+		if status.WipLimit.Valid && !req.Force {
+			// Concurrent moves into the column would otherwise each count
+			// the same free slot
+			if err := querier.LockUser(ctx, todo.UserID); err != nil {
+				return nil, err
+			}
+			count, err := querier.CountStatusTodos(ctx, pgtype.Int4{Int32: status.StatusID, Valid: true})
+			if err != nil {
+				return nil, err
+			}
+			if count >= int64(status.WipLimit.Int32) {
+				return nil, &requestError{http.StatusConflict, "The status is at its WIP limit"}
+			}
+		}
+
+		done := status.Category == StatusCategoryDone
+		switch {
+		case done && !todo.IsCompleted.Bool:
+			blockers, err := openBlockers(ctx, querier, []int32{todo.TodoID})
+			if err != nil {
+				return nil, err
+			}
+			if len(blockers) > 0 && !req.Force {
+				return nil, &blockedError{blockers}
+			}
+			completed, err := completeTodo(ctx, querier, todo, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			if completed.Advanced {
+				return completed.Todo, fillDependencies(ctx, querier, []*TodoResponse{completed.Todo})
+			}
+		case !done && todo.IsCompleted.Bool:
+			if _, err := querier.UncompleteTodo(ctx, todo.TodoID); err != nil {
+				return nil, err
+			}
+		}
+
+		updated, err := querier.SetTodoStatus(ctx, db.SetTodoStatusParams{
+			StatusID: pgtype.Int4{Int32: status.StatusID, Valid: true},
+			TodoID:   todo.TodoID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return todoResponse(ctx, querier, &updated)
+	})
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/filter"
+	"github.com/boetro/odot/internal/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultUpcomingDays = 7
+	MaxUpcomingDays     = 90
+)
+
+// The built-in views work on days in the user's timezone. Boundaries are
+// computed with time.Date so days that gain or lose an hour to DST are still
+// a whole calendar day.
+
+type TodayResponse struct {
+	Date     string          `json:"date"`
+	Timezone string          `json:"timezone"`
+	Overdue  []*TodoResponse `json:"overdue"`
+	Today    []*TodoResponse `json:"today"`
+}
+
+type UpcomingDay struct {
+	Date  string          `json:"date"`
+	Todos []*TodoResponse `json:"todos"`
+}
+
+type UpcomingResponse struct {
+	Timezone string         `json:"timezone"`
+	Days     []*UpcomingDay `json:"days"`
+}
+
+// startOfDay returns local midnight offset days from the day t falls on
+func startOfDay(t time.Time, offset int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, t.Location())
+}
+
+// ListInboxTodos returns a page of open todos that aren't in any project, in
+// their manual order by default
+func (h *TodoHandler) ListInboxTodos(c *gin.Context) {
+	h.listView(c, "position", "asc", func(b *filter.Builder, opts filter.Options) {
+		b.WhereSQL("t.project_id IS NULL")
+		b.WhereSQL("NOT COALESCE(t.is_completed, FALSE)")
+	})
+}
+
+// ListOverdueTodos returns a page of open todos assigned before today,
+// oldest first by default
+func (h *TodoHandler) ListOverdueTodos(c *gin.Context) {
+	h.listView(c, "assigned", "asc", func(b *filter.Builder, opts filter.Options) {
+		today := startOfDay(opts.Now.In(opts.Location), 0)
+		b.WhereSQL("NOT COALESCE(t.is_completed, FALSE)")
+		b.Where("t.assigned_date < %s", today)
+	})
+}
+
+func (h *TodoHandler) listView(c *gin.Context, defaultSort string, defaultOrder string, scope todoScope) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var pageRequest pagination.Request
+	if err := c.ShouldBindQuery(&pageRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if pageRequest.Sort == "" && pageRequest.Order == "" {
+		pageRequest.Order = defaultOrder
+	}
+	query, err := pageRequest.Resolve(todoSortKeys, defaultSort, "t.todo_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.todoPage(c.Request.Context(), userID, query, scope)
+	if err != nil {
+		h.logger.Error("Failed to list todos", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetTodayTodos returns the open todos assigned today along with everything
+// overdue
+func (h *TodoHandler) GetTodayTodos(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	opts, err := h.filterOptions(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to load user timezone", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	today := startOfDay(opts.Now.In(opts.Location), 0)
+	tomorrow := startOfDay(today, 1)
+
+	b := filter.NewBuilder()
+	b.Where("t.user_id = %s", userID)
+	b.WhereSQL("NOT COALESCE(t.is_completed, FALSE)")
+	b.Where("t.assigned_date < %s", tomorrow)
+
+	todos, err := h.selectTodos(c.Request.Context(),
+		"SELECT t.* FROM todos t WHERE "+b.Conditions()+
+			" ORDER BY t.assigned_date, COALESCE(t.priority, 0) DESC, t.todo_id", b.Args())
+	if err != nil {
+		h.logger.Error("Failed to list today's todos", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	response := TodayResponse{
+		Date:     today.Format(time.DateOnly),
+		Timezone: opts.Location.String(),
+		Overdue:  []*TodoResponse{},
+		Today:    []*TodoResponse{},
+	}
+	for _, todo := range todos {
+		if todo.AssignedDate.Before(today) {
+			response.Overdue = append(response.Overdue, todo)
+		} else {
+			response.Today = append(response.Today, todo)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUpcomingTodos returns the open todos assigned over the next days,
+// starting today, grouped by day. Days without todos are included so
+// clients can render an agenda directly.
+func (h *TodoHandler) GetUpcomingTodos(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	days := DefaultUpcomingDays
+	if param := c.Query("days"); param != "" {
+		var err error
+		days, err = strconv.Atoi(param)
+		if err != nil || days < 1 || days > MaxUpcomingDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(MaxUpcomingDays)})
+			return
+		}
+	}
+
+	opts, err := h.filterOptions(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to load user timezone", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	today := startOfDay(opts.Now.In(opts.Location), 0)
+
+	b := filter.NewBuilder()
+	b.Where("t.user_id = %s", userID)
+	b.WhereSQL("NOT COALESCE(t.is_completed, FALSE)")
+	b.Where("t.assigned_date >= %s", today)
+	b.Where("t.assigned_date < %s", startOfDay(today, days))
+
+	todos, err := h.selectTodos(c.Request.Context(),
+		"SELECT t.* FROM todos t WHERE "+b.Conditions()+
+			" ORDER BY t.assigned_date, COALESCE(t.priority, 0) DESC, t.todo_id", b.Args())
+	if err != nil {
+		h.logger.Error("Failed to list upcoming todos", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	response := UpcomingResponse{
+		Timezone: opts.Location.String(),
+		Days:     make([]*UpcomingDay, days),
+	}
+	index := make(map[string]*UpcomingDay, days)
+	for i := range days {
+		date := startOfDay(today, i).Format(time.DateOnly)
+		response.Days[i] = &UpcomingDay{Date: date, Todos: []*TodoResponse{}}
+		index[date] = response.Days[i]
+	}
+	for _, todo := range todos {
+		date := todo.AssignedDate.In(opts.Location).Format(time.DateOnly)
+		if day, ok := index[date]; ok {
+			day.Todos = append(day.Todos, todo)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
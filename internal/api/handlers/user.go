@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/boetro/odot/internal/api/middleware"
 	"github.com/boetro/odot/internal/db"
@@ -13,6 +15,12 @@ type GetUserResponse struct {
 	ID                int32  `json:"id"`
 	Email             string `json:"email"`
 	ProfilePictureUrl string `json:"profile_picture_url"`
+	Timezone          string `json:"timezone"`
+}
+
+type UpdateUserRequest struct {
+	// Timezone is an IANA timezone name such as America/New_York
+	Timezone *string `json:"timezone"`
 }
 
 type UserHandler struct {
@@ -33,7 +41,8 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
 		return
 	}
-	user, err := h.querier.GetUser(c, userId)
+	querier := db.QuerierFromContext(c.Request.Context(), h.querier)
+	user, err := querier.GetUser(c, userId)
 
 	if err != nil {
 		h.logger.Error("failed to get user", err)
@@ -45,8 +54,70 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		ID:                user.UserID,
 		Email:             user.Email,
 		ProfilePictureUrl: user.ProfilePictureUrl.String,
+		Timezone:          user.Timezone,
 	}
 
 	c.JSON(http.StatusOK, apiUser)
 	return
 }
+
+// UpdateUser updates the user's preferences
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	userId, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	querier := db.QuerierFromContext(c.Request.Context(), h.querier)
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := querier.GetUser(c, userId)
+	if err != nil {
+		h.logger.Error("failed to get user", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unknown error"})
+		return
+	}
+
+	if req.Timezone != nil {
+		// "Local" would resolve to the server's timezone rather than the user's
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "timezone must be an IANA timezone name such as America/New_York"})
+			return
+		}
+		user, err = querier.UpdateUserTimezone(c, db.UpdateUserTimezoneParams{
+			UserID:   userId,
+			Timezone: *req.Timezone,
+		})
+		if err != nil {
+			h.logger.Error("failed to update user timezone", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unknown error"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, GetUserResponse{
+		ID:                user.UserID,
+		Email:             user.Email,
+		ProfilePictureUrl: user.ProfilePictureUrl.String,
+		Timezone:          user.Timezone,
+	})
+}
+
+// userLocation loads the user's timezone preference, falling back to UTC if
+// the stored name is no longer known
+func userLocation(ctx context.Context, querier db.Querier, userID int32) (*time.Location, error) {
+	user, err := db.QuerierFromContext(ctx, querier).GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return location, nil
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/auth"
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// appPasswordTouchInterval limits how often last_used_at is written, since
+// sync clients make many requests in a row
+const appPasswordTouchInterval = time.Minute
+
+type AppPasswordMiddleware struct {
+	querier db.Querier
+	logger  logger.Logger
+}
+
+func NewAppPasswordMiddleware(querier db.Querier, logger logger.Logger) *AppPasswordMiddleware {
+	return &AppPasswordMiddleware{
+		querier: querier,
+		logger:  logger,
+	}
+}
+
+// RequireAppPassword authenticates basic auth credentials, the user's email
+// and one of their app passwords, and sets the same user context as
+// RequireAuth
+func (m *AppPasswordMiddleware) RequireAppPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, password, ok := c.Request.BasicAuth()
+		if !ok {
+			m.unauthorized(c)
+			return
+		}
+
+		ctx := c.Request.Context()
+		appPassword, err := m.querier.GetAppPasswordByHash(ctx, auth.HashAppPassword(password))
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				m.logger.Error("Failed to look up app password", "error", err)
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			m.unauthorized(c)
+			return
+		}
+		user, err := m.querier.GetUser(ctx, appPassword.UserID)
+		if err != nil {
+			m.logger.Error("Failed to load app password user", "error", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if !strings.EqualFold(user.Email, email) {
+			m.unauthorized(c)
+			return
+		}
+
+		if !appPassword.LastUsedAt.Valid || time.Since(appPassword.LastUsedAt.Time) > appPasswordTouchInterval {
+			if err := m.querier.TouchAppPassword(ctx, appPassword.AppPasswordID); err != nil {
+				m.logger.Error("Failed to record app password use", "error", err)
+			}
+		}
+
+		c.Set("user_id", user.UserID)
+		c.Set("user_email", user.Email)
+		c.Next()
+	}
+}
+
+func (m *AppPasswordMiddleware) unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Basic realm="odot", charset="UTF-8"`)
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type IdempotencyMiddleware struct {
+	querier db.Querier
+	ttl     time.Duration
+	logger  logger.Logger
+}
+
+func NewIdempotencyMiddleware(querier db.Querier, ttl time.Duration, logger logger.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		querier: querier,
+		ttl:     ttl,
+		logger:  logger,
+	}
+}
+
+// Handle replays the stored response for POST/PATCH requests that carry an
+// Idempotency-Key header we have already seen. It must run after RequireAuth
+// since keys are scoped per user.
+func (m *IdempotencyMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method != http.MethodPost && method != http.MethodPatch {
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		// Put the body back so handlers can still bind it
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		path := c.Request.URL.Path
+		requestHash := fingerprint(method, path, body)
+
+		record, err := m.querier.CreateIdempotencyKey(c, db.CreateIdempotencyKeyParams{
+			UserID:         userID,
+			IdempotencyKey: key,
+			Method:         method,
+			Path:           path,
+			RequestHash:    requestHash,
+			ExpiresAt: pgtype.Timestamptz{
+				Time:  time.Now().Add(m.ttl),
+				Valid: true,
+			},
+		})
+		if err == nil {
+			m.execute(c, record)
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			m.logger.Error("Failed to store idempotency key", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+			c.Abort()
+			return
+		}
+
+		// The key already exists and hasn't expired, so this is a retry
+		existing, err := m.querier.GetIdempotencyKey(c, db.GetIdempotencyKeyParams{
+			UserID:         userID,
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			m.logger.Error("Failed to get idempotency key", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+			c.Abort()
+			return
+		}
+
+		if existing.RequestHash != requestHash {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			c.Abort()
+			return
+		}
+
+		if !existing.ResponseStatus.Valid {
+			c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			c.Abort()
+			return
+		}
+
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(int(existing.ResponseStatus.Int32), existing.ResponseContentType.String, existing.ResponseBody)
+		c.Abort()
+	}
+}
+
+// execute runs the rest of the chain and records the response against the key.
+// Server errors and panics release the key so the client can retry.
+func (m *IdempotencyMiddleware) execute(c *gin.Context, record db.IdempotencyKey) {
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	// A panicking handler never gets a status recorded here, and would leave
+	// the key in progress for its whole TTL
+	defer func() {
+		if r := recover(); r != nil {
+			if err := m.querier.DeleteIdempotencyKey(context.WithoutCancel(c.Request.Context()), record.IdempotencyKeyID); err != nil {
+				m.logger.Error("Failed to release idempotency key", "error", err)
+			}
+			panic(r)
+		}
+	}()
+
+	c.Next()
+
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError {
+		if err := m.querier.DeleteIdempotencyKey(c, record.IdempotencyKeyID); err != nil {
+			m.logger.Error("Failed to release idempotency key", "error", err)
+		}
+		return
+	}
+
+	err := m.querier.SaveIdempotencyKeyResponse(c, db.SaveIdempotencyKeyResponseParams{
+		IdempotencyKeyID: record.IdempotencyKeyID,
+		ResponseStatus: pgtype.Int4{
+			Int32: int32(status),
+			Valid: true,
+		},
+		ResponseContentType: pgtype.Text{
+			String: recorder.Header().Get("Content-Type"),
+			Valid:  true,
+		},
+		ResponseBody: recorder.body.Bytes(),
+	})
+	if err != nil {
+		m.logger.Error("Failed to save idempotent response", "error", err)
+	}
+}
+
+func fingerprint(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + "\n" + path + "\n"))
+	hash.Write(body)
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// responseRecorder tees everything written to the client into a buffer
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
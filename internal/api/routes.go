@@ -4,9 +4,11 @@ package api
 import (
 	"github.com/boetro/odot/internal/api/handlers"
 	"github.com/boetro/odot/internal/api/middleware"
+	"github.com/boetro/odot/internal/busytime"
 	"github.com/boetro/odot/internal/config"
 	"github.com/boetro/odot/internal/db"
 	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/safehttp"
 	"github.com/boetro/odot/ui"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -20,9 +22,30 @@ func RegisterRoutes(r *gin.Engine, database *pgxpool.Pool, querier db.Querier, c
 
 	ui.AddRoutes(r)
 
+	// Calendar downloads and webhook URLs come from users, so keep them off
+	// the server's own network
+	guard := safehttp.NewGuard(cfg.OutboundAllowlist)
+
 	// Health check endpoint
 	r.GET("/health", handlers.HealthCheck(database))
 
+	// CalDAV server, authenticated with app passwords
+	calDAVHandler := handlers.NewCalDAVHandler(querier, database, cfg.PublicURL, logger)
+	r.GET("/.well-known/caldav", calDAVHandler.WellKnown)
+	r.OPTIONS(handlers.DAVPrefix+"/*path", calDAVHandler.Options)
+	dav := r.Group(handlers.DAVPrefix)
+	appPasswordMiddleware := middleware.NewAppPasswordMiddleware(querier, logger)
+	dav.Use(appPasswordMiddleware.RequireAppPassword())
+	{
+		dav.Handle("PROPFIND", "/*path", calDAVHandler.Propfind)
+		dav.Handle("PROPPATCH", "/*path", calDAVHandler.Proppatch)
+		dav.Handle("REPORT", "/*path", calDAVHandler.Report)
+		dav.GET("/*path", calDAVHandler.Get)
+		dav.HEAD("/*path", calDAVHandler.Get)
+		dav.PUT("/*path", calDAVHandler.Put)
+		dav.DELETE("/*path", calDAVHandler.Delete)
+	}
+
 	// API routes
 	api := r.Group("/api")
 	{
@@ -37,17 +60,158 @@ func RegisterRoutes(r *gin.Engine, database *pgxpool.Pool, querier db.Querier, c
 			auth.POST("/refresh", authHandler.RefreshToken)
 		}
 
+		digestHandler := handlers.NewDigestHandler(querier, cfg.JWTSecret, cfg.PublicURL, logger)
+		api.GET("/digests/unsubscribe", digestHandler.UnsubscribePage)
+		api.POST("/digests/unsubscribe", digestHandler.Unsubscribe)
+
+		calendarFeedHandler := handlers.NewCalendarFeedHandler(querier, database, cfg.PublicURL, logger)
+		api.GET("/ical/:token/odot.ics", calendarFeedHandler.GetFeed)
+
 		protected := api.Group("/")
 		authMiddleware := middleware.NewAuthMiddleware(cfg, logger)
-		protected.Use(authMiddleware.RequireAuth())
+		idempotencyMiddleware := middleware.NewIdempotencyMiddleware(querier, cfg.IdempotencyTTL, logger)
+		protected.Use(authMiddleware.RequireAuth(), idempotencyMiddleware.Handle())
 		{
 			userHandler := handlers.NewUserHandler(querier, logger)
 			protected.GET("/me", userHandler.GetUser)
+			protected.PATCH("/me", userHandler.UpdateUser)
 		}
 		{
-			projectHandler := handlers.NewProjectHandler(querier, logger)
+			projectHandler := handlers.NewProjectHandler(querier, database, logger)
 			protected.GET("/projects", projectHandler.ListProjects)
 			protected.POST("/projects", projectHandler.CreateProject)
+			protected.GET("/projects/:id", projectHandler.GetProject)
+			protected.GET("/projects/:id/critical_path", projectHandler.GetCriticalPath)
+			protected.POST("/projects/:id/move", projectHandler.MoveProject)
+
+			boardHandler := handlers.NewBoardHandler(querier, database, logger)
+			protected.GET("/projects/:id/statuses", boardHandler.ListProjectStatuses)
+			protected.POST("/projects/:id/statuses", boardHandler.CreateProjectStatus)
+			protected.PUT("/projects/:id/statuses/order", boardHandler.ReorderProjectStatuses)
+			protected.PUT("/projects/:id/statuses/:status_id", boardHandler.UpdateProjectStatus)
+			protected.DELETE("/projects/:id/statuses/:status_id", boardHandler.DeleteProjectStatus)
+			protected.GET("/projects/:id/board", boardHandler.GetBoard)
+
+			sectionHandler := handlers.NewSectionHandler(querier, database, logger)
+			protected.GET("/projects/:id/sections", sectionHandler.ListProjectSections)
+			protected.POST("/projects/:id/sections", sectionHandler.CreateProjectSection)
+			protected.PATCH("/projects/:id/sections/:section_id", sectionHandler.UpdateProjectSection)
+			protected.DELETE("/projects/:id/sections/:section_id", sectionHandler.DeleteProjectSection)
+			protected.POST("/projects/:id/sections/:section_id/move", sectionHandler.MoveProjectSection)
+			protected.POST("/projects/:id/sections/:section_id/transfer", sectionHandler.TransferProjectSection)
+		}
+		{
+			todoHandler := handlers.NewTodoHandler(querier, database, logger)
+			protected.GET("/todos", todoHandler.ListTodos)
+			protected.POST("/todos", todoHandler.CreateTodo)
+			protected.POST("/todos/quickadd", todoHandler.QuickAdd)
+			protected.POST("/todos/quickadd/preview", todoHandler.PreviewQuickAdd)
+			protected.GET("/todos/completed", todoHandler.ListCompletedTodos)
+			protected.GET("/todos/inbox", todoHandler.ListInboxTodos)
+			protected.GET("/todos/today", todoHandler.GetTodayTodos)
+			protected.GET("/todos/upcoming", todoHandler.GetUpcomingTodos)
+			protected.GET("/todos/overdue", todoHandler.ListOverdueTodos)
+			protected.POST("/todos/:id/merge", todoHandler.MergeTodos)
+			protected.POST("/todos/:id/complete", todoHandler.CompleteTodo)
+			protected.POST("/todos/:id/uncomplete", todoHandler.UncompleteTodo)
+			protected.PUT("/todos/:id/recurrence", todoHandler.SetTodoRecurrence)
+			protected.DELETE("/todos/:id/recurrence", todoHandler.ClearTodoRecurrence)
+			protected.GET("/todos/:id/occurrences", todoHandler.ListTodoOccurrences)
+			protected.GET("/todos/:id/dependencies", todoHandler.ListTodoDependencies)
+			protected.POST("/todos/:id/dependencies", todoHandler.AddTodoDependency)
+			protected.DELETE("/todos/:id/dependencies/:depends_on_id", todoHandler.RemoveTodoDependency)
+			protected.PUT("/todos/:id/status", todoHandler.SetTodoStatus)
+			protected.POST("/todos/:id/move", todoHandler.MoveTodo)
+			protected.PUT("/todos/:id/section", todoHandler.SetTodoSection)
+			protected.POST("/todos/bulk/complete", todoHandler.BulkCompleteTodos)
+			protected.POST("/todos/bulk/uncomplete", todoHandler.BulkUncompleteTodos)
+			protected.POST("/todos/bulk/move", todoHandler.BulkMoveTodos)
+			protected.POST("/todos/bulk/tags/add", todoHandler.BulkAddTodoTag)
+			protected.POST("/todos/bulk/tags/remove", todoHandler.BulkRemoveTodoTag)
+			protected.POST("/todos/bulk/reschedule", todoHandler.BulkRescheduleTodos)
+			protected.POST("/todos/bulk/priority", todoHandler.BulkSetTodoPriority)
+			protected.POST("/todos/bulk/delete", todoHandler.BulkDeleteTodos)
+
+			savedFilterHandler := handlers.NewSavedFilterHandler(querier, todoHandler, logger)
+			protected.GET("/filters", savedFilterHandler.ListSavedFilters)
+			protected.POST("/filters", savedFilterHandler.CreateSavedFilter)
+			protected.GET("/filters/:id", savedFilterHandler.GetSavedFilter)
+			protected.PATCH("/filters/:id", savedFilterHandler.UpdateSavedFilter)
+			protected.DELETE("/filters/:id", savedFilterHandler.DeleteSavedFilter)
+			protected.GET("/filters/:id/todos", savedFilterHandler.ListSavedFilterTodos)
+		}
+		{
+			reminderHandler := handlers.NewReminderHandler(querier, guard, logger)
+			protected.GET("/reminders", reminderHandler.ListReminders)
+			protected.POST("/reminders", reminderHandler.CreateReminder)
+			protected.GET("/reminders/:id", reminderHandler.GetReminder)
+			protected.PATCH("/reminders/:id", reminderHandler.UpdateReminder)
+			protected.DELETE("/reminders/:id", reminderHandler.DeleteReminder)
+		}
+		{
+			notificationHandler := handlers.NewNotificationHandler(querier, database, logger)
+			protected.GET("/notifications", notificationHandler.ListNotifications)
+			protected.GET("/notifications/unread_count", notificationHandler.GetUnreadCount)
+			protected.POST("/notifications/read_all", notificationHandler.MarkAllNotificationsRead)
+			protected.POST("/notifications/:id/read", notificationHandler.MarkNotificationRead)
+			protected.POST("/notifications/:id/unread", notificationHandler.MarkNotificationUnread)
+			protected.GET("/notifications/preferences", notificationHandler.GetNotificationPreferences)
+			protected.PUT("/notifications/preferences", notificationHandler.UpdateNotificationPreferences)
+		}
+		{
+			protected.GET("/digests", digestHandler.ListDigests)
+			protected.PUT("/digests/:kind", digestHandler.UpdateDigest)
+			protected.GET("/digests/:kind/preview", digestHandler.PreviewDigest)
+		}
+		{
+			scheduleHandler := handlers.NewScheduleHandler(querier, database, logger)
+			protected.GET("/schedule", scheduleHandler.GetSchedule)
+			protected.POST("/schedule/plan", scheduleHandler.PlanSchedule)
+			protected.POST("/schedule/accept", scheduleHandler.AcceptSchedule)
+			protected.PATCH("/schedule/blocks/:id", scheduleHandler.UpdateScheduleBlock)
+			protected.DELETE("/schedule/blocks/:id", scheduleHandler.DeleteScheduleBlock)
+			protected.GET("/schedule/working_hours", scheduleHandler.GetWorkingHours)
+			protected.PUT("/schedule/working_hours", scheduleHandler.UpdateWorkingHours)
+		}
+		{
+			calendarHandler := handlers.NewCalendarHandler(querier, logger)
+			protected.GET("/calendar", calendarHandler.GetCalendar)
+			protected.GET("/calendar/feeds", calendarFeedHandler.ListCalendarFeeds)
+			protected.POST("/calendar/feeds", calendarFeedHandler.CreateCalendarFeed)
+			protected.POST("/calendar/feeds/:id/rotate", calendarFeedHandler.RotateCalendarFeed)
+			protected.DELETE("/calendar/feeds/:id", calendarFeedHandler.DeleteCalendarFeed)
+		}
+		{
+			busyCalendarHandler := handlers.NewBusyCalendarHandler(querier, database, busytime.NewImporter(database, guard, logger), logger)
+			protected.GET("/busy_calendars", busyCalendarHandler.ListBusyCalendars)
+			protected.POST("/busy_calendars", busyCalendarHandler.CreateBusyCalendar)
+			protected.POST("/busy_calendars/upload", busyCalendarHandler.UploadBusyCalendar)
+			protected.PATCH("/busy_calendars/:id", busyCalendarHandler.UpdateBusyCalendar)
+			protected.POST("/busy_calendars/:id/refresh", busyCalendarHandler.RefreshBusyCalendar)
+			protected.DELETE("/busy_calendars/:id", busyCalendarHandler.DeleteBusyCalendar)
+		}
+		{
+			appPasswordHandler := handlers.NewAppPasswordHandler(querier, cfg.PublicURL, logger)
+			protected.GET("/app_passwords", appPasswordHandler.ListAppPasswords)
+			protected.POST("/app_passwords", appPasswordHandler.CreateAppPassword)
+			protected.DELETE("/app_passwords/:id", appPasswordHandler.DeleteAppPassword)
+		}
+		{
+			planningHandler := handlers.NewPlanningHandler(querier, database, logger)
+			protected.GET("/planning/settings", planningHandler.GetPlanningSettings)
+			protected.PUT("/planning/settings", planningHandler.UpdatePlanningSettings)
+			protected.GET("/planning/days", planningHandler.ListDailyPlans)
+			protected.GET("/planning/days/:date", planningHandler.GetDailyPlan)
+			protected.PUT("/planning/days/:date", planningHandler.CommitPlan)
+			protected.GET("/planning/days/:date/proposal", planningHandler.ProposePlan)
+		}
+		{
+			searchHandler := handlers.NewSearchHandler(querier, database, logger)
+			protected.GET("/search", searchHandler.Search)
+		}
+		{
+			batchHandler := handlers.NewBatchHandler(r, database, logger)
+			protected.POST("/batch", batchHandler.ExecuteBatch)
 		}
 	}
 
@@ -127,3 +127,33 @@ func ValidateAccessToken(tokenString string, jwtSecret string) (*Claims, error)
 func HashRefreshToken(token string) string {
 	return hashToken(token)
 }
+
+// GenerateFeedToken creates the secret token in a calendar feed URL, along
+// with the hash to store
+func GenerateFeedToken() (string, string, error) {
+	token, err := generateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	return token, hashToken(token), nil
+}
+
+// HashFeedToken hashes a calendar feed token for database lookup
+func HashFeedToken(token string) string {
+	return hashToken(token)
+}
+
+// GenerateAppPassword creates a password for clients that only support basic
+// auth, along with the hash to store
+func GenerateAppPassword() (string, string, error) {
+	password, err := generateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	return password, hashToken(password), nil
+}
+
+// HashAppPassword hashes an app password for database lookup
+func HashAppPassword(password string) string {
+	return hashToken(password)
+}
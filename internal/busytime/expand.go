@@ -0,0 +1,275 @@
+// Package busytime imports events from external iCalendar files as busy
+// time, so the scheduler plans around meetings and the calendar shows them.
+package busytime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/ical"
+	"github.com/boetro/odot/internal/recurrence"
+)
+
+const (
+	// PastWindow and FutureWindow bound the occurrences that are stored,
+	// around the time of the sync
+	PastWindow   = 30 * 24 * time.Hour
+	FutureWindow = 180 * 24 * time.Hour
+
+	// maxEvents caps the busy blocks stored for one calendar
+	maxEvents = 10000
+	// maxOccurrencesPerEvent caps how many times one recurring event is
+	// expanded
+	maxOccurrencesPerEvent = 1000
+)
+
+// ErrInvalidCalendar is returned for data that isn't an iCalendar file
+var ErrInvalidCalendar = errors.New("not a valid iCalendar file")
+
+// floatingUntil matches an UNTIL without a Z, which recurrence.Parse doesn't
+// accept but some calendars write anyway
+var floatingUntil = regexp.MustCompile(`(?i)UNTIL=(\d{8}T\d{6})(;|$)`)
+
+// Event is one occurrence of an event, as busy time
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+}
+
+// Result is what was read from a calendar
+type Result struct {
+	Events []Event
+	// Skipped counts the events that couldn't be read
+	Skipped int
+}
+
+// Expand reads the events of data that overlap [from, to), with recurring
+// events expanded into their occurrences. Cancelled events and those marked
+// as free are left out. Floating times and all day events are read in loc.
+func Expand(data []byte, loc *time.Location, from time.Time, to time.Time) (*Result, error) {
+	cal, err := ical.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+	if cal.Name != "VCALENDAR" {
+		return nil, ErrInvalidCalendar
+	}
+
+	result := &Result{}
+	vevents := cal.Children("VEVENT")
+	// Occurrences of a recurring event that were moved or cancelled are
+	// replaced by a VEVENT of their own with the same UID
+	overridden := make(map[string]map[int64]bool)
+	for _, vevent := range vevents {
+		rid := vevent.Get("RECURRENCE-ID")
+		if rid == nil {
+			continue
+		}
+		t, _, err := rid.Time(loc)
+		if err != nil {
+			continue
+		}
+		uid := uidOf(vevent)
+		if overridden[uid] == nil {
+			overridden[uid] = make(map[int64]bool)
+		}
+		overridden[uid][t.Unix()] = true
+	}
+
+	for _, vevent := range vevents {
+		if len(result.Events) >= maxEvents {
+			result.Skipped++
+			continue
+		}
+		events, err := expandEvent(vevent, loc, from, to, overridden[uidOf(vevent)])
+		if err != nil {
+			result.Skipped++
+			continue
+		}
+		if room := maxEvents - len(result.Events); len(events) > room {
+			events = events[:room]
+		}
+		result.Events = append(result.Events, events...)
+	}
+	return result, nil
+}
+
+// CalendarName returns the X-WR-CALNAME of data, or "" if it has none or
+// isn't an iCalendar file
+func CalendarName(data []byte) string {
+	cal, err := ical.Parse(data)
+	if err != nil {
+		return ""
+	}
+	if name := cal.Get("X-WR-CALNAME"); name != nil {
+		return strings.TrimSpace(name.Text())
+	}
+	return ""
+}
+
+// expandEvent returns the occurrences of vevent that overlap [from, to),
+// leaving out those in overridden unless vevent is itself an override
+func expandEvent(vevent *ical.Component, loc *time.Location, from time.Time, to time.Time, overridden map[int64]bool) ([]Event, error) {
+	if p := vevent.Get("STATUS"); p != nil && strings.EqualFold(p.Value, "CANCELLED") {
+		return nil, nil
+	}
+	if p := vevent.Get("TRANSP"); p != nil && strings.EqualFold(p.Value, "TRANSPARENT") {
+		return nil, nil
+	}
+
+	dtstart := vevent.Get("DTSTART")
+	if dtstart == nil {
+		return nil, fmt.Errorf("missing DTSTART")
+	}
+	start, allDay, err := dtstart.Time(loc)
+	if err != nil {
+		return nil, err
+	}
+	end, err := eventEnd(vevent, start, allDay, loc)
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		// An event without a length takes up no time
+		return nil, nil
+	}
+	// All day events last a number of days rather than hours, which differ
+	// across DST changes
+	days := 0
+	if allDay {
+		days = calendarDays(start, end)
+	}
+	length := end.Sub(start)
+	endOf := func(t time.Time) time.Time {
+		if allDay {
+			return t.AddDate(0, 0, days)
+		}
+		return t.Add(length)
+	}
+
+	starts := []time.Time{start}
+	isOverride := vevent.Get("RECURRENCE-ID") != nil
+	if rrule := vevent.Get("RRULE"); rrule != nil && !isOverride {
+		rule, err := recurrence.Parse(normalizeUntil(rrule.Value, start.Location()))
+		if err != nil {
+			return nil, err
+		}
+		// Occurrences that started before from can still overlap it. A day
+		// of slack covers the hour an all day event gains across DST.
+		lower := from.Add(-length - 24*time.Hour)
+		dtstart := start
+		if rule.Count == 0 {
+			// Starting from an occurrence near the window gives the same
+			// occurrences after it, without walking every one since the
+			// event began
+			first, ok := rule.Next(start, lower.Add(-time.Nanosecond))
+			if !ok {
+				first = to
+			}
+			dtstart = first
+		}
+		starts = rule.Between(dtstart, lower, to, maxOccurrencesPerEvent)
+	}
+	excluded := make(map[int64]bool)
+	if !isOverride {
+		for _, p := range vevent.All("RDATE") {
+			starts = append(starts, propertyTimes(&p, loc)...)
+		}
+		for _, p := range vevent.All("EXDATE") {
+			for _, t := range propertyTimes(&p, loc) {
+				excluded[t.Unix()] = true
+			}
+		}
+	}
+
+	uid := uidOf(vevent)
+	var summary string
+	if p := vevent.Get("SUMMARY"); p != nil {
+		summary = strings.TrimSpace(p.Text())
+	}
+	var events []Event
+	seen := make(map[int64]bool)
+	for _, s := range starts {
+		key := s.Unix()
+		if seen[key] || excluded[key] || (!isOverride && overridden[key]) {
+			continue
+		}
+		seen[key] = true
+		e := endOf(s)
+		if s.Before(to) && e.After(from) {
+			events = append(events, Event{UID: uid, Summary: summary, Start: s, End: e, AllDay: allDay})
+		}
+	}
+	return events, nil
+}
+
+// eventEnd reads DTEND or DURATION. Without either an all day event lasts
+// the day and any other event takes no time.
+func eventEnd(vevent *ical.Component, start time.Time, allDay bool, loc *time.Location) (time.Time, error) {
+	if dtend := vevent.Get("DTEND"); dtend != nil {
+		end, _, err := dtend.Time(loc)
+		return end, err
+	}
+	if duration := vevent.Get("DURATION"); duration != nil {
+		d, err := ical.Duration(duration.Value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if allDay && d%(24*time.Hour) == 0 {
+			return start.AddDate(0, 0, int(d/(24*time.Hour))), nil
+		}
+		return start.Add(d), nil
+	}
+	if allDay {
+		return start.AddDate(0, 0, 1), nil
+	}
+	return start, nil
+}
+
+// propertyTimes reads the comma separated DATE or DATE-TIME values of an
+// RDATE or EXDATE. PERIOD values are ignored.
+func propertyTimes(p *ical.Property, loc *time.Location) []time.Time {
+	var times []time.Time
+	for _, value := range strings.Split(p.Value, ",") {
+		if value == "" || strings.Contains(value, "/") {
+			continue
+		}
+		single := ical.Property{Name: p.Name, Params: p.Params, Value: value}
+		if t, _, err := single.Time(loc); err == nil {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+// normalizeUntil rewrites a floating UNTIL as UTC, reading it in loc
+func normalizeUntil(rule string, loc *time.Location) string {
+	return floatingUntil.ReplaceAllStringFunc(rule, func(match string) string {
+		parts := floatingUntil.FindStringSubmatch(match)
+		t, err := time.ParseInLocation("20060102T150405", parts[1], loc)
+		if err != nil {
+			return match
+		}
+		return "UNTIL=" + t.UTC().Format("20060102T150405Z") + parts[2]
+	})
+}
+
+func uidOf(vevent *ical.Component) string {
+	if p := vevent.Get("UID"); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// calendarDays counts the whole days from one local date to another
+func calendarDays(from time.Time, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a) / (24 * time.Hour))
+}
@@ -0,0 +1,101 @@
+package busytime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxCalendarSize caps an uploaded or downloaded calendar
+	MaxCalendarSize = 5 << 20
+	fetchTimeout    = 30 * time.Second
+)
+
+// FetchError is a calendar URL that couldn't be downloaded
+type FetchError struct {
+	Err error
+}
+
+func (e *FetchError) Error() string { return "couldn't fetch calendar: " + e.Err.Error() }
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// Download is a fetched calendar. NotModified is set, and Data left empty,
+// when the server said it hasn't changed since the validators sent with the
+// request.
+type Download struct {
+	Data         string
+	ETag         string
+	LastModified string
+	NotModified  bool
+}
+
+// NormalizeURL checks that raw is an http or https URL, turning the webcal
+// scheme calendar apps hand out into https
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL")
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "webcal", "webcals":
+		u.Scheme = "https"
+	default:
+		return "", fmt.Errorf("URL must start with http://, https:// or webcal://")
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("URL must include a host")
+	}
+	return u.String(), nil
+}
+
+// fetch downloads the calendar at rawURL, sending back the validators of the
+// last download so an unchanged calendar isn't downloaded again
+func fetch(ctx context.Context, client *http.Client, rawURL string, etag string, lastModified string) (*Download, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, &FetchError{err}
+	}
+	req.Header.Set("Accept", "text/calendar, */*;q=0.5")
+	req.Header.Set("User-Agent", "odot")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &FetchError{err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return &Download{ETag: etag, LastModified: lastModified, NotModified: true}, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, &FetchError{fmt.Errorf("server responded %s", resp.Status)}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxCalendarSize+1))
+	if err != nil {
+		return nil, &FetchError{err}
+	}
+	if len(body) > MaxCalendarSize {
+		return nil, &FetchError{errors.New("calendar is larger than 5 MB")}
+	}
+	return &Download{
+		Data:         string(body),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
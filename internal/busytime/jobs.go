@@ -0,0 +1,262 @@
+package busytime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/jobs"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/safehttp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	dispatchBatchSize = 500
+	// refreshInterval is how often a calendar URL is fetched again, and how
+	// long to wait after one fails
+	refreshInterval = time.Hour
+	// reexpandInterval is how often an uploaded calendar is expanded again,
+	// to keep its occurrences up with the present
+	reexpandInterval = 24 * time.Hour
+)
+
+// Dispatch is a scheduled job that queues a Sync for every calendar that is
+// due
+type Dispatch struct{}
+
+func (Dispatch) Kind() string { return "busytime.dispatch" }
+
+// Sync is a job that refreshes one calendar's busy blocks
+type Sync struct {
+	CalendarID int32 `json:"calendar_id"`
+}
+
+func (Sync) Kind() string { return "busytime.sync" }
+
+// Importer downloads calendars and keeps their busy blocks up to date
+type Importer struct {
+	pool    *pgxpool.Pool
+	querier db.Querier
+	jobs    *jobs.Client
+	client  *http.Client
+	logger  logger.Logger
+}
+
+// NewImporter returns an Importer whose downloads only reach hosts guard
+// permits
+func NewImporter(pool *pgxpool.Pool, guard *safehttp.Guard, logger logger.Logger) *Importer {
+	querier := db.New(pool)
+	return &Importer{
+		pool:    pool,
+		querier: querier,
+		jobs:    jobs.NewClient(querier),
+		client:  guard.Client(fetchTimeout),
+		logger:  logger,
+	}
+}
+
+// Register adds the sync jobs to p and schedules the dispatcher
+func (i *Importer) Register(p *jobs.WorkerPool) error {
+	jobs.Handle(p, func(ctx context.Context, job *jobs.Job, args Dispatch) error {
+		return i.dispatch(ctx, time.Now())
+	})
+	jobs.Handle(p, func(ctx context.Context, job *jobs.Job, args Sync) error {
+		_, err := i.Sync(ctx, args.CalendarID, time.Now())
+		var fetchErr *FetchError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			// The calendar was deleted since this was queued
+			return nil
+		case errors.As(err, &fetchErr) || errors.Is(err, ErrInvalidCalendar):
+			// Recorded on the calendar, and tried again on its next sync
+			i.logger.Debug("Busy calendar sync failed", "calendar_id", args.CalendarID, "error", err)
+			return nil
+		}
+		return err
+	})
+	return p.Schedule("busytime.dispatch", "*/5 * * * *", Dispatch{})
+}
+
+// Fetch downloads a calendar URL
+func (i *Importer) Fetch(ctx context.Context, rawURL string) (*Download, error) {
+	return fetch(ctx, i.client, rawURL, "", "")
+}
+
+// Sync downloads a calendar again if it has a URL, and replaces its busy
+// blocks. A failed download or a file that can't be read is recorded as the
+// calendar's last error, and returned along with the calendar.
+func (i *Importer) Sync(ctx context.Context, calendarID int32, now time.Time) (*db.BusyCalendar, error) {
+	calendar, err := db.QuerierFromContext(ctx, i.querier).GetBusyCalendar(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	var download *Download
+	if calendar.Url.Valid {
+		// Downloads happen outside the transaction so a slow server doesn't
+		// hold the calendar's lock
+		download, err = fetch(ctx, i.client, calendar.Url.String, calendar.HttpEtag.String, calendar.HttpLastModified.String)
+		if err != nil {
+			return i.recordError(ctx, calendarID, err, now)
+		}
+	}
+
+	tx, err := db.Begin(ctx, i.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	calendar, err = querier.LockBusyCalendar(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	synced, err := Store(ctx, querier, &calendar, download, now)
+	if errors.Is(err, ErrInvalidCalendar) {
+		tx.Rollback(ctx)
+		return i.recordError(ctx, calendarID, err, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return synced, nil
+}
+
+// Store saves a download, if there is one, as the calendar's data, and
+// replaces the calendar's busy blocks with the occurrences of its events
+// around now
+func Store(ctx context.Context, querier db.Querier, calendar *db.BusyCalendar, download *Download, now time.Time) (*db.BusyCalendar, error) {
+	if download != nil && !download.NotModified {
+		updated, err := querier.SetBusyCalendarData(ctx, db.SetBusyCalendarDataParams{
+			CalendarID:       calendar.CalendarID,
+			Data:             download.Data,
+			HttpEtag:         optionalText(download.ETag),
+			HttpLastModified: optionalText(download.LastModified),
+		})
+		if err != nil {
+			return nil, err
+		}
+		calendar = &updated
+	}
+
+	user, err := querier.GetUser(ctx, calendar.UserID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	result, err := Expand([]byte(calendar.Data), loc, now.Add(-PastWindow), now.Add(FutureWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := querier.DeleteBusyBlocks(ctx, calendar.CalendarID); err != nil {
+		return nil, err
+	}
+	if len(result.Events) > 0 {
+		params := db.CreateBusyBlocksParams{
+			CalendarID: calendar.CalendarID,
+			UserID:     calendar.UserID,
+			Uids:       make([]string, len(result.Events)),
+			Summaries:  make([]string, len(result.Events)),
+			StartsAt:   make([]pgtype.Timestamptz, len(result.Events)),
+			EndsAt:     make([]pgtype.Timestamptz, len(result.Events)),
+			AllDay:     make([]bool, len(result.Events)),
+		}
+		for n, event := range result.Events {
+			params.Uids[n] = event.UID
+			params.Summaries[n] = event.Summary
+			params.StartsAt[n] = pgtype.Timestamptz{Time: event.Start, Valid: true}
+			params.EndsAt[n] = pgtype.Timestamptz{Time: event.End, Valid: true}
+			params.AllDay[n] = event.AllDay
+		}
+		if err := querier.CreateBusyBlocks(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+
+	synced, err := querier.RecordBusyCalendarSync(ctx, db.RecordBusyCalendarSyncParams{
+		CalendarID:   calendar.CalendarID,
+		EventCount:   int32(len(result.Events)),
+		SkippedCount: int32(result.Skipped),
+		NextSyncAt:   pgtype.Timestamptz{Time: now.Add(syncInterval(calendar)), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &synced, nil
+}
+
+// dispatch queues a Sync for each calendar that is due and moves it on to its
+// next sync, in one transaction so a calendar is queued once however many
+// workers run it
+func (i *Importer) dispatch(ctx context.Context, now time.Time) error {
+	tx, err := i.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	ctx = db.ContextWithTx(ctx, tx)
+	querier := db.New(tx)
+	due, err := querier.ClaimDueBusyCalendars(ctx, dispatchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for n := range due {
+		calendar := &due[n]
+		_, err := i.jobs.Enqueue(ctx, Sync{CalendarID: calendar.CalendarID}, &jobs.EnqueueOptions{
+			UniqueKey: "busytime:" + strconv.Itoa(int(calendar.CalendarID)),
+			// The next dispatch tries again soon enough
+			MaxAttempts: 3,
+		})
+		if err != nil && !errors.Is(err, jobs.ErrDuplicate) {
+			return err
+		}
+		err = querier.SetBusyCalendarNextSync(ctx, db.SetBusyCalendarNextSyncParams{
+			CalendarID: calendar.CalendarID,
+			NextSyncAt: pgtype.Timestamptz{Time: now.Add(syncInterval(calendar)), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// recordError saves why a sync failed on the calendar, to be tried again
+// after refreshInterval, and returns it along with the calendar
+func (i *Importer) recordError(ctx context.Context, calendarID int32, syncErr error, now time.Time) (*db.BusyCalendar, error) {
+	calendar, err := db.QuerierFromContext(ctx, i.querier).RecordBusyCalendarError(ctx, db.RecordBusyCalendarErrorParams{
+		CalendarID: calendarID,
+		LastError:  pgtype.Text{String: syncErr.Error(), Valid: true},
+		NextSyncAt: pgtype.Timestamptz{Time: now.Add(refreshInterval), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &calendar, syncErr
+}
+
+func syncInterval(calendar *db.BusyCalendar) time.Duration {
+	if calendar.Url.Valid {
+		return refreshInterval
+	}
+	return reexpandInterval
+}
+
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
@@ -0,0 +1,29 @@
+package caldav
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// syncTokenPrefix makes sync tokens URIs, as RFC 6578 requires
+const syncTokenPrefix = "urn:odot:sync:"
+
+// SyncToken formats the position in a collection's change log as a token
+func SyncToken(n int64) string {
+	return syncTokenPrefix + strconv.FormatInt(n, 10)
+}
+
+// ParseSyncToken reads a token made by SyncToken. An empty token, which asks
+// for the whole collection, is 0.
+func ParseSyncToken(token string) (int64, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(strings.TrimPrefix(token, syncTokenPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(token, syncTokenPrefix) || n < 0 {
+		return 0, fmt.Errorf("invalid sync token %q", token)
+	}
+	return n, nil
+}
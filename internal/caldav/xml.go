@@ -0,0 +1,325 @@
+// Package caldav reads WebDAV and CalDAV (RFC 4918, 4791, 6578) request
+// bodies and writes multistatus responses. Deciding what the properties and
+// resources are is left to the handler.
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Namespaces of the properties and reports that are supported
+const (
+	NamespaceDAV    = "DAV:"
+	NamespaceCalDAV = "urn:ietf:params:xml:ns:caldav"
+	// NamespaceCalendarServer holds getctag, which clients that predate
+	// sync-collection poll for changes
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+	NamespaceApple          = "http://apple.com/ns/ical/"
+)
+
+var prefixes = map[string]string{
+	NamespaceDAV:            "d",
+	NamespaceCalDAV:         "c",
+	NamespaceCalendarServer: "cs",
+	NamespaceApple:          "a",
+}
+
+// DAV returns the name of an element in the DAV: namespace
+func DAV(local string) xml.Name { return xml.Name{Space: NamespaceDAV, Local: local} }
+
+// CalDAV returns the name of an element in the CalDAV namespace
+func CalDAV(local string) xml.Name { return xml.Name{Space: NamespaceCalDAV, Local: local} }
+
+// Element is a parsed XML element
+type Element struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Children []*Element
+	Text     string
+}
+
+// Child returns the first child called name, or nil
+func (e *Element) Child(name xml.Name) *Element {
+	for _, child := range e.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Attr returns the value of the attribute called local, or ""
+func (e *Element) Attr(local string) string {
+	for _, attr := range e.Attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// ParseXML reads a request body into a tree. An empty body gives nil.
+func ParseXML(r io.Reader) (*Element, error) {
+	decoder := xml.NewDecoder(r)
+	var root *Element
+	var stack []*Element
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &Element{Name: t.Name, Attrs: t.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+	return root, nil
+}
+
+// PropRequest is which properties a PROPFIND or REPORT asks for
+type PropRequest struct {
+	// AllProp asks for every property that isn't expensive to compute
+	AllProp bool
+	// PropName asks for the names of properties without their values
+	PropName bool
+	Props    []xml.Name
+}
+
+// ParsePropRequest reads the allprop, propname or prop element among
+// parent's children. No parent, as for an empty PROPFIND, means allprop.
+func ParsePropRequest(parent *Element) *PropRequest {
+	if parent == nil {
+		return &PropRequest{AllProp: true}
+	}
+	req := &PropRequest{}
+	for _, child := range parent.Children {
+		switch child.Name {
+		case DAV("allprop"):
+			req.AllProp = true
+		case DAV("propname"):
+			req.PropName = true
+		case DAV("prop"):
+			for _, prop := range child.Children {
+				req.Props = append(req.Props, prop.Name)
+			}
+		}
+	}
+	if !req.PropName && len(req.Props) == 0 {
+		req.AllProp = true
+	}
+	return req
+}
+
+// Props is a resource's properties, mapped to their value as inner XML
+// written with the helpers in this package
+type Props map[xml.Name]string
+
+// Response is one resource in a multistatus
+type Response struct {
+	Href string
+	// Status is set instead of properties for resources that are gone or
+	// weren't found
+	Status   int
+	Found    Props
+	NotFound []xml.Name
+	// Denied are properties that can't be changed
+	Denied []xml.Name
+}
+
+// NewResponse answers req for the resource at href. props is every property
+// the resource has, and expensive lists the names that only come when asked
+// for by name.
+func NewResponse(href string, req *PropRequest, props Props, expensive ...xml.Name) *Response {
+	r := &Response{Href: href, Found: Props{}}
+	switch {
+	case req.PropName:
+		for name := range props {
+			r.Found[name] = ""
+		}
+	case len(req.Props) > 0:
+		for _, name := range req.Props {
+			if value, ok := props[name]; ok {
+				r.Found[name] = value
+			} else {
+				r.NotFound = append(r.NotFound, name)
+			}
+		}
+	}
+	if req.AllProp {
+	props:
+		for name, value := range props {
+			for _, e := range expensive {
+				if name == e {
+					continue props
+				}
+			}
+			r.Found[name] = value
+		}
+	}
+	return r
+}
+
+// Multistatus is a 207 response body
+type Multistatus struct {
+	Responses []*Response
+	// SyncToken is set in answer to a sync-collection report
+	SyncToken string
+}
+
+// Write sends m as a 207 Multi-Status response
+func (m *Multistatus) Write(w http.ResponseWriter) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<d:multistatus")
+	writeNamespaces(&buf)
+	buf.WriteString(">")
+	for _, r := range m.Responses {
+		buf.WriteString("<d:response>")
+		buf.WriteString(Href(r.Href))
+		if r.Status != 0 {
+			buf.WriteString(status(r.Status))
+		} else {
+			if len(r.Found) > 0 || len(r.NotFound)+len(r.Denied) == 0 {
+				buf.WriteString("<d:propstat><d:prop>")
+				names := make([]xml.Name, 0, len(r.Found))
+				for name := range r.Found {
+					names = append(names, name)
+				}
+				sortNames(names)
+				for _, name := range names {
+					buf.WriteString(Elem(name, r.Found[name]))
+				}
+				buf.WriteString("</d:prop>")
+				buf.WriteString(status(http.StatusOK))
+				buf.WriteString("</d:propstat>")
+			}
+			writePropstat(&buf, r.NotFound, http.StatusNotFound)
+			writePropstat(&buf, r.Denied, http.StatusForbidden)
+		}
+		buf.WriteString("</d:response>")
+	}
+	if m.SyncToken != "" {
+		buf.WriteString(Elem(DAV("sync-token"), Text(m.SyncToken)))
+	}
+	buf.WriteString("</d:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write(buf.Bytes())
+}
+
+// writePropstat lists names without values under the given status
+func writePropstat(buf *bytes.Buffer, names []xml.Name, code int) {
+	if len(names) == 0 {
+		return
+	}
+	buf.WriteString("<d:propstat><d:prop>")
+	for _, name := range names {
+		buf.WriteString(Elem(name, ""))
+	}
+	buf.WriteString("</d:prop>")
+	buf.WriteString(status(code))
+	buf.WriteString("</d:propstat>")
+}
+
+// WriteError sends a DAV:error body naming the precondition that failed
+func WriteError(w http.ResponseWriter, code int, condition xml.Name, inner string) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<d:error")
+	writeNamespaces(&buf)
+	buf.WriteString(">")
+	buf.WriteString(Elem(condition, inner))
+	buf.WriteString("</d:error>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(buf.Bytes())
+}
+
+// Elem writes an element with the given inner XML. Elements outside the
+// known namespaces declare their own.
+func Elem(name xml.Name, inner string) string {
+	tag, declaration := qualify(name)
+	if inner == "" {
+		return "<" + tag + declaration + "/>"
+	}
+	return "<" + tag + declaration + ">" + inner + "</" + tag + ">"
+}
+
+// Text escapes character data
+func Text(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// Href writes a DAV:href
+func Href(href string) string {
+	return Elem(DAV("href"), Text(href))
+}
+
+func qualify(name xml.Name) (string, string) {
+	if prefix, ok := prefixes[name.Space]; ok {
+		return prefix + ":" + name.Local, ""
+	}
+	if name.Space == "" {
+		return name.Local, ""
+	}
+	return "x:" + name.Local, ` xmlns:x="` + Text(name.Space) + `"`
+}
+
+func writeNamespaces(buf *bytes.Buffer) {
+	spaces := make([]string, 0, len(prefixes))
+	for space := range prefixes {
+		spaces = append(spaces, space)
+	}
+	sort.Strings(spaces)
+	for _, space := range spaces {
+		fmt.Fprintf(buf, ` xmlns:%s="%s"`, prefixes[space], space)
+	}
+}
+
+func status(code int) string {
+	return Elem(DAV("status"), Text(fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))))
+}
+
+func sortNames(names []xml.Name) {
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Space != names[j].Space {
+			return names[i].Space < names[j].Space
+		}
+		return names[i].Local < names[j].Local
+	})
+}
+
+// Depth reads the Depth header, which defaults to infinity. Infinity is
+// treated as 1 since collections here only ever hold resources.
+func Depth(r *http.Request) int {
+	if strings.TrimSpace(r.Header.Get("Depth")) == "0" {
+		return 0
+	}
+	return 1
+}
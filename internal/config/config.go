@@ -3,7 +3,11 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
@@ -16,6 +20,26 @@ type Config struct {
 	GoogleClientID     string
 	GoogleClientSecret string
 	GoogleRedirectURI  string
+	IdempotencyTTL     time.Duration
+	// PublicURL is where users reach the server, for links in emails
+	PublicURL string
+	// ReminderInterval is how often the reminder scheduler polls
+	ReminderInterval time.Duration
+	// JobConcurrency is how many background jobs run at once
+	JobConcurrency int
+	// SMTP settings for email reminders. Email is only logged when SMTPHost
+	// is empty.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	// MailDropDir collects email as .eml files instead of sending it, when
+	// SMTPHost is empty
+	MailDropDir string
+	// OutboundAllowlist lists private networks that calendar downloads and
+	// webhooks may still reach, for tests and local development
+	OutboundAllowlist []netip.Prefix
 }
 
 // Load reads configuration from environment variables
@@ -60,6 +84,64 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("GOOGLE_REDIRECT_URI environment variable is required")
 	}
 
+	idempotencyTTL := 24 * time.Hour // Default idempotency key lifetime
+	if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("IDEMPOTENCY_TTL must be a duration such as 24h: %w", err)
+		}
+		idempotencyTTL = parsed
+	}
+
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port // Default to the local server
+	}
+
+	reminderInterval := 30 * time.Second // Default reminder poll interval
+	if interval := os.Getenv("REMINDER_POLL_INTERVAL"); interval != "" {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("REMINDER_POLL_INTERVAL must be a positive duration such as 30s")
+		}
+		reminderInterval = parsed
+	}
+
+	jobConcurrency := 4 // Default number of job workers
+	if concurrency := os.Getenv("JOB_CONCURRENCY"); concurrency != "" {
+		parsed, err := strconv.Atoi(concurrency)
+		if err != nil || parsed < 1 {
+			return nil, fmt.Errorf("JOB_CONCURRENCY must be a positive number")
+		}
+		jobConcurrency = parsed
+	}
+
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587" // Default submission port
+	}
+	smtpFrom := os.Getenv("SMTP_FROM")
+	if smtpHost != "" && smtpFrom == "" {
+		return nil, fmt.Errorf("SMTP_FROM environment variable is required when SMTP_HOST is set")
+	}
+
+	var outboundAllowlist []netip.Prefix
+	if allowlist := os.Getenv("OUTBOUND_ALLOWLIST"); allowlist != "" {
+		for _, entry := range strings.Split(allowlist, ",") {
+			entry = strings.TrimSpace(entry)
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				addr, addrErr := netip.ParseAddr(entry)
+				if addrErr != nil {
+					return nil, fmt.Errorf("OUTBOUND_ALLOWLIST must be a comma separated list of CIDRs or IPs: %w", err)
+				}
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+			outboundAllowlist = append(outboundAllowlist, prefix)
+		}
+	}
+
 	return &Config{
 		Port:               port,
 		LogLevel:           logLevel,
@@ -69,5 +151,16 @@ func Load() (*Config, error) {
 		GoogleClientID:     googleClientID,
 		GoogleClientSecret: googleClientSecret,
 		GoogleRedirectURI:  googleRedirectURI,
+		IdempotencyTTL:     idempotencyTTL,
+		PublicURL:          publicURL,
+		ReminderInterval:   reminderInterval,
+		JobConcurrency:     jobConcurrency,
+		SMTPHost:           smtpHost,
+		SMTPPort:           smtpPort,
+		SMTPUsername:       os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:       os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:           smtpFrom,
+		MailDropDir:        os.Getenv("MAIL_DROP_DIR"),
+		OutboundAllowlist:  outboundAllowlist,
 	}, nil
 }
@@ -130,6 +130,25 @@ func (q *Queries) ListCommentsByUser(ctx context.Context, userID int32) ([]Comme
 	return items, nil
 }
 
+const moveTodoComments = `-- name: MoveTodoComments :execrows
+UPDATE comments
+SET todo_id = $1
+WHERE todo_id = $2
+`
+
+type MoveTodoCommentsParams struct {
+	TargetTodoID int32 `json:"targetTodoId"`
+	SourceTodoID int32 `json:"sourceTodoId"`
+}
+
+func (q *Queries) MoveTodoComments(ctx context.Context, arg MoveTodoCommentsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveTodoComments, arg.TargetTodoID, arg.SourceTodoID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateComment = `-- name: UpdateComment :one
 UPDATE comments
 SET content = $2
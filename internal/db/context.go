@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txContextKey struct{}
+
+// ContextWithTx returns a context that carries a transaction which handlers
+// should run their queries in instead of using the pool directly.
+func ContextWithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction stored in the context, if any.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// QuerierFromContext returns a querier bound to the transaction stored in the
+// context, or fallback if there isn't one.
+func QuerierFromContext(ctx context.Context, fallback Querier) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return New(tx)
+	}
+	return fallback
+}
+
+// DBFromContext returns the transaction stored in the context, or fallback if
+// there isn't one. It is used for queries that sqlc can't generate.
+func DBFromContext(ctx context.Context, fallback DBTX) DBTX {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return fallback
+}
+
+// Begin starts a transaction, nested as a savepoint when the context already
+// carries one.
+func Begin(ctx context.Context, pool *pgxpool.Pool) (pgx.Tx, error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.Begin(ctx)
+	}
+	return pool.Begin(ctx)
+}
@@ -8,6 +8,72 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AppPassword struct {
+	AppPasswordID int32              `json:"appPasswordId"`
+	UserID        int32              `json:"userId"`
+	Name          string             `json:"name"`
+	PasswordHash  string             `json:"passwordHash"`
+	LastUsedAt    pgtype.Timestamptz `json:"lastUsedAt"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+}
+
+type BusyBlock struct {
+	BusyBlockID int32              `json:"busyBlockId"`
+	CalendarID  int32              `json:"calendarId"`
+	UserID      int32              `json:"userId"`
+	Uid         string             `json:"uid"`
+	Summary     string             `json:"summary"`
+	StartsAt    pgtype.Timestamptz `json:"startsAt"`
+	EndsAt      pgtype.Timestamptz `json:"endsAt"`
+	AllDay      bool               `json:"allDay"`
+}
+
+type BusyCalendar struct {
+	CalendarID       int32              `json:"calendarId"`
+	UserID           int32              `json:"userId"`
+	Name             string             `json:"name"`
+	Url              pgtype.Text        `json:"url"`
+	Data             string             `json:"data"`
+	HttpEtag         pgtype.Text        `json:"httpEtag"`
+	HttpLastModified pgtype.Text        `json:"httpLastModified"`
+	EventCount       int32              `json:"eventCount"`
+	SkippedCount     int32              `json:"skippedCount"`
+	LastSyncedAt     pgtype.Timestamptz `json:"lastSyncedAt"`
+	LastError        pgtype.Text        `json:"lastError"`
+	NextSyncAt       pgtype.Timestamptz `json:"nextSyncAt"`
+	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type CaldavChange struct {
+	ChangeID  int64              `json:"changeId"`
+	UserID    int32              `json:"userId"`
+	ProjectID pgtype.Int4        `json:"projectId"`
+	TodoID    int32              `json:"todoId"`
+	Name      string             `json:"name"`
+	Deleted   bool               `json:"deleted"`
+	ChangedAt pgtype.Timestamptz `json:"changedAt"`
+}
+
+type CaldavObject struct {
+	TodoID int32  `json:"todoId"`
+	UserID int32  `json:"userId"`
+	Name   string `json:"name"`
+	Uid    string `json:"uid"`
+}
+
+type CalendarFeed struct {
+	FeedID         int32              `json:"feedId"`
+	UserID         int32              `json:"userId"`
+	Name           string             `json:"name"`
+	TokenHash      string             `json:"tokenHash"`
+	ProjectID      pgtype.Int4        `json:"projectId"`
+	SavedFilterID  pgtype.Int4        `json:"savedFilterId"`
+	LastAccessedAt pgtype.Timestamptz `json:"lastAccessedAt"`
+	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
+}
+
 type Comment struct {
 	CommentID int32              `json:"commentId"`
 	TodoID    int32              `json:"todoId"`
@@ -17,6 +83,105 @@ type Comment struct {
 	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
 }
 
+type DailyPlan struct {
+	DailyPlanID      int32              `json:"dailyPlanId"`
+	UserID           int32              `json:"userId"`
+	PlanDate         pgtype.Date        `json:"planDate"`
+	AvailableMinutes int32              `json:"availableMinutes"`
+	CommittedAt      pgtype.Timestamptz `json:"committedAt"`
+}
+
+type DailyPlanItem struct {
+	DailyPlanID    int32       `json:"dailyPlanId"`
+	TodoID         int32       `json:"todoId"`
+	Position       int32       `json:"position"`
+	PlannedMinutes pgtype.Int4 `json:"plannedMinutes"`
+}
+
+type DigestSubscription struct {
+	UserID      int32              `json:"userId"`
+	Kind        string             `json:"kind"`
+	Enabled     bool               `json:"enabled"`
+	SendHour    int32              `json:"sendHour"`
+	SendWeekday int32              `json:"sendWeekday"`
+	NextSendAt  pgtype.Timestamptz `json:"nextSendAt"`
+	LastSentAt  pgtype.Timestamptz `json:"lastSentAt"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type IdempotencyKey struct {
+	IdempotencyKeyID    int32              `json:"idempotencyKeyId"`
+	UserID              int32              `json:"userId"`
+	IdempotencyKey      string             `json:"idempotencyKey"`
+	Method              string             `json:"method"`
+	Path                string             `json:"path"`
+	RequestHash         string             `json:"requestHash"`
+	ResponseStatus      pgtype.Int4        `json:"responseStatus"`
+	ResponseContentType pgtype.Text        `json:"responseContentType"`
+	ResponseBody        []byte             `json:"responseBody"`
+	CreatedAt           pgtype.Timestamptz `json:"createdAt"`
+	ExpiresAt           pgtype.Timestamptz `json:"expiresAt"`
+}
+
+type Job struct {
+	JobID       int64              `json:"jobId"`
+	Kind        string             `json:"kind"`
+	Payload     []byte             `json:"payload"`
+	Status      string             `json:"status"`
+	Attempts    int32              `json:"attempts"`
+	MaxAttempts int32              `json:"maxAttempts"`
+	RunAt       pgtype.Timestamptz `json:"runAt"`
+	UniqueKey   pgtype.Text        `json:"uniqueKey"`
+	LockedAt    pgtype.Timestamptz `json:"lockedAt"`
+	LockedBy    pgtype.Text        `json:"lockedBy"`
+	LastError   pgtype.Text        `json:"lastError"`
+	FinishedAt  pgtype.Timestamptz `json:"finishedAt"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type JobSchedule struct {
+	Name      string             `json:"name"`
+	Spec      string             `json:"spec"`
+	NextRunAt pgtype.Timestamptz `json:"nextRunAt"`
+	LastRunAt pgtype.Timestamptz `json:"lastRunAt"`
+}
+
+type Notification struct {
+	NotificationID int32              `json:"notificationId"`
+	UserID         int32              `json:"userId"`
+	TodoID         pgtype.Int4        `json:"todoId"`
+	Event          string             `json:"event"`
+	Title          string             `json:"title"`
+	Body           pgtype.Text        `json:"body"`
+	ReadAt         pgtype.Timestamptz `json:"readAt"`
+	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+	ActorUserID    pgtype.Int4        `json:"actorUserId"`
+}
+
+type NotificationPreference struct {
+	UserID  int32  `json:"userId"`
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+type NotificationSetting struct {
+	UserID          int32              `json:"userId"`
+	QuietHoursStart pgtype.Time        `json:"quietHoursStart"`
+	QuietHoursEnd   pgtype.Time        `json:"quietHoursEnd"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type PlanningSetting struct {
+	UserID         int32              `json:"userId"`
+	RolloverMode   string             `json:"rolloverMode"`
+	NextRolloverAt pgtype.Timestamptz `json:"nextRolloverAt"`
+	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
+}
+
 type Project struct {
 	ProjectID       int32              `json:"projectId"`
 	UserID          int32              `json:"userId"`
@@ -26,6 +191,30 @@ type Project struct {
 	Color           pgtype.Text        `json:"color"`
 	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	Position        string             `json:"position"`
+}
+
+type ProjectSection struct {
+	SectionID   int32              `json:"sectionId"`
+	ProjectID   int32              `json:"projectId"`
+	UserID      int32              `json:"userId"`
+	Name        string             `json:"name"`
+	Position    string             `json:"position"`
+	IsCollapsed bool               `json:"isCollapsed"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type ProjectStatus struct {
+	StatusID  int32              `json:"statusId"`
+	ProjectID int32              `json:"projectId"`
+	UserID    int32              `json:"userId"`
+	Name      string             `json:"name"`
+	Category  string             `json:"category"`
+	Position  int32              `json:"position"`
+	WipLimit  pgtype.Int4        `json:"wipLimit"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
 }
 
 type RefreshToken struct {
@@ -38,6 +227,49 @@ type RefreshToken struct {
 	IsRevoked  pgtype.Bool        `json:"isRevoked"`
 }
 
+type Reminder struct {
+	ReminderID    int32              `json:"reminderId"`
+	UserID        int32              `json:"userId"`
+	TodoID        int32              `json:"todoId"`
+	RemindAt      pgtype.Timestamptz `json:"remindAt"`
+	OffsetMinutes pgtype.Int4        `json:"offsetMinutes"`
+	Channel       string             `json:"channel"`
+	WebhookUrl    pgtype.Text        `json:"webhookUrl"`
+	FiredFor      pgtype.Timestamptz `json:"firedFor"`
+	LastFiredAt   pgtype.Timestamptz `json:"lastFiredAt"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"nextAttemptAt"`
+	LastError     pgtype.Text        `json:"lastError"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt     pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type SavedFilter struct {
+	SavedFilterID int32              `json:"savedFilterId"`
+	UserID        int32              `json:"userId"`
+	Name          string             `json:"name"`
+	Color         string             `json:"color"`
+	Query         string             `json:"query"`
+	SortBy        string             `json:"sortBy"`
+	SortOrder     string             `json:"sortOrder"`
+	GroupBy       string             `json:"groupBy"`
+	IsPinned      bool               `json:"isPinned"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt     pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type ScheduleBlock struct {
+	BlockID   int32              `json:"blockId"`
+	UserID    int32              `json:"userId"`
+	TodoID    int32              `json:"todoId"`
+	StartsAt  pgtype.Timestamptz `json:"startsAt"`
+	EndsAt    pgtype.Timestamptz `json:"endsAt"`
+	Status    string             `json:"status"`
+	Pinned    bool               `json:"pinned"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+}
+
 type Tag struct {
 	TagID     int32              `json:"tagId"`
 	UserID    int32              `json:"userId"`
@@ -47,20 +279,44 @@ type Tag struct {
 }
 
 type Todo struct {
-	TodoID        int32              `json:"todoId"`
-	UserID        int32              `json:"userId"`
-	ProjectID     pgtype.Int4        `json:"projectId"`
-	ParentTodoID  pgtype.Int4        `json:"parentTodoId"`
-	Title         string             `json:"title"`
-	Description   pgtype.Text        `json:"description"`
-	IsCompleted   pgtype.Bool        `json:"isCompleted"`
-	DueDate       pgtype.Timestamptz `json:"dueDate"`
-	WorkStartTime pgtype.Timestamptz `json:"workStartTime"`
-	WorkEndTime   pgtype.Timestamptz `json:"workEndTime"`
-	Priority      pgtype.Int4        `json:"priority"`
-	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt     pgtype.Timestamptz `json:"updatedAt"`
-	CompletedAt   pgtype.Timestamptz `json:"completedAt"`
+	TodoID           int32              `json:"todoId"`
+	UserID           int32              `json:"userId"`
+	ProjectID        pgtype.Int4        `json:"projectId"`
+	ParentTodoID     pgtype.Int4        `json:"parentTodoId"`
+	Title            string             `json:"title"`
+	Description      pgtype.Text        `json:"description"`
+	IsCompleted      pgtype.Bool        `json:"isCompleted"`
+	AssignedDate     pgtype.Timestamptz `json:"assignedDate"`
+	DurationMin      pgtype.Int4        `json:"durationMin"`
+	Priority         pgtype.Int4        `json:"priority"`
+	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
+	CompletedAt      pgtype.Timestamptz `json:"completedAt"`
+	RecurrenceRule   pgtype.Text        `json:"recurrenceRule"`
+	RecurrenceMode   string             `json:"recurrenceMode"`
+	RecurrenceStart  pgtype.Timestamptz `json:"recurrenceStart"`
+	RecurrenceCount  int32              `json:"recurrenceCount"`
+	Deadline         pgtype.Timestamptz `json:"deadline"`
+	RolloverCount    int32              `json:"rolloverCount"`
+	LastRolledOverAt pgtype.Timestamptz `json:"lastRolledOverAt"`
+	StatusID         pgtype.Int4        `json:"statusId"`
+	Position         string             `json:"position"`
+	SectionID        pgtype.Int4        `json:"sectionId"`
+}
+
+type TodoDependency struct {
+	TodoID      int32              `json:"todoId"`
+	DependsOnID int32              `json:"dependsOnId"`
+	UserID      int32              `json:"userId"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+}
+
+type TodoOccurrence struct {
+	TodoOccurrenceID int32              `json:"todoOccurrenceId"`
+	TodoID           int32              `json:"todoId"`
+	UserID           int32              `json:"userId"`
+	OccurrenceDate   pgtype.Timestamptz `json:"occurrenceDate"`
+	CompletedAt      pgtype.Timestamptz `json:"completedAt"`
 }
 
 type TodoTag struct {
@@ -76,4 +332,12 @@ type User struct {
 	ProfilePictureUrl pgtype.Text        `json:"profilePictureUrl"`
 	CreatedAt         pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt         pgtype.Timestamptz `json:"updatedAt"`
+	Timezone          string             `json:"timezone"`
+}
+
+type WorkingHour struct {
+	UserID    int32       `json:"userId"`
+	Weekday   int32       `json:"weekday"`
+	StartTime pgtype.Time `json:"startTime"`
+	EndTime   pgtype.Time `json:"endTime"`
 }
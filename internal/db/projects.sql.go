@@ -12,9 +12,9 @@ import (
 )
 
 const createProject = `-- name: CreateProject :one
-INSERT INTO projects (user_id, parent_project_id, name, description, color)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING project_id, user_id, parent_project_id, name, description, color, created_at, updated_at
+INSERT INTO projects (user_id, parent_project_id, name, description, color, position)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING project_id, user_id, parent_project_id, name, description, color, created_at, updated_at, position
 `
 
 type CreateProjectParams struct {
@@ -23,6 +23,7 @@ type CreateProjectParams struct {
 	Name            string      `json:"name"`
 	Description     pgtype.Text `json:"description"`
 	Color           pgtype.Text `json:"color"`
+	Position        string      `json:"position"`
 }
 
 func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error) {
@@ -32,6 +33,7 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 		arg.Name,
 		arg.Description,
 		arg.Color,
+		arg.Position,
 	)
 	var i Project
 	err := row.Scan(
@@ -43,6 +45,7 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 		&i.Color,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Position,
 	)
 	return i, err
 }
@@ -57,8 +60,25 @@ func (q *Queries) DeleteProject(ctx context.Context, projectID int32) error {
 	return err
 }
 
+const firstProjectPosition = `-- name: FirstProjectPosition :one
+SELECT COALESCE(MIN(position), '')::text AS position FROM projects
+WHERE user_id = $1 AND parent_project_id IS NOT DISTINCT FROM $2::int
+`
+
+type FirstProjectPositionParams struct {
+	UserID          int32       `json:"userId"`
+	ParentProjectID pgtype.Int4 `json:"parentProjectId"`
+}
+
+func (q *Queries) FirstProjectPosition(ctx context.Context, arg FirstProjectPositionParams) (string, error) {
+	row := q.db.QueryRow(ctx, firstProjectPosition, arg.UserID, arg.ParentProjectID)
+	var position string
+	err := row.Scan(&position)
+	return position, err
+}
+
 const getProject = `-- name: GetProject :one
-SELECT project_id, user_id, parent_project_id, name, description, color, created_at, updated_at FROM projects
+SELECT project_id, user_id, parent_project_id, name, description, color, created_at, updated_at, position FROM projects
 WHERE project_id = $1
 `
 
@@ -74,14 +94,80 @@ func (q *Queries) GetProject(ctx context.Context, projectID int32) (Project, err
 		&i.Color,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Position,
 	)
 	return i, err
 }
 
+const getProjectByName = `-- name: GetProjectByName :one
+SELECT project_id, user_id, parent_project_id, name, description, color, created_at, updated_at, position FROM projects
+WHERE user_id = $1 AND lower(name) = lower($2::text)
+ORDER BY created_at ASC
+LIMIT 1
+`
+
+type GetProjectByNameParams struct {
+	UserID int32  `json:"userId"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) GetProjectByName(ctx context.Context, arg GetProjectByNameParams) (Project, error) {
+	row := q.db.QueryRow(ctx, getProjectByName, arg.UserID, arg.Name)
+	var i Project
+	err := row.Scan(
+		&i.ProjectID,
+		&i.UserID,
+		&i.ParentProjectID,
+		&i.Name,
+		&i.Description,
+		&i.Color,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Position,
+	)
+	return i, err
+}
+
+const listProjectSiblings = `-- name: ListProjectSiblings :many
+SELECT project_id, position FROM projects
+WHERE user_id = $1 AND parent_project_id IS NOT DISTINCT FROM $2::int
+ORDER BY position, project_id
+`
+
+type ListProjectSiblingsRow struct {
+	ProjectID int32  `json:"projectId"`
+	Position  string `json:"position"`
+}
+
+type ListProjectSiblingsParams struct {
+	UserID          int32       `json:"userId"`
+	ParentProjectID pgtype.Int4 `json:"parentProjectId"`
+}
+
+func (q *Queries) ListProjectSiblings(ctx context.Context, arg ListProjectSiblingsParams) ([]ListProjectSiblingsRow, error) {
+	rows, err := q.db.Query(ctx, listProjectSiblings, arg.UserID, arg.ParentProjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListProjectSiblingsRow{}
+	for rows.Next() {
+		var i ListProjectSiblingsRow
+		if err := rows.Scan(&i.ProjectID, &i.Position); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProjects = `-- name: ListProjects :many
-SELECT project_id, user_id, parent_project_id, name, description, color, created_at, updated_at FROM projects
+SELECT project_id, user_id, parent_project_id, name, description, color, created_at, updated_at, position FROM projects
 WHERE user_id = $1
-ORDER BY created_at DESC
+ORDER BY position, project_id
 `
 
 func (q *Queries) ListProjects(ctx context.Context, userID int32) ([]Project, error) {
@@ -102,6 +188,7 @@ func (q *Queries) ListProjects(ctx context.Context, userID int32) ([]Project, er
 			&i.Color,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Position,
 		); err != nil {
 			return nil, err
 		}
@@ -114,9 +201,9 @@ func (q *Queries) ListProjects(ctx context.Context, userID int32) ([]Project, er
 }
 
 const listProjectsByParent = `-- name: ListProjectsByParent :many
-SELECT project_id, user_id, parent_project_id, name, description, color, created_at, updated_at FROM projects
+SELECT project_id, user_id, parent_project_id, name, description, color, created_at, updated_at, position FROM projects
 WHERE user_id = $1 AND parent_project_id = $2
-ORDER BY created_at DESC
+ORDER BY position, project_id
 `
 
 type ListProjectsByParentParams struct {
@@ -142,6 +229,7 @@ func (q *Queries) ListProjectsByParent(ctx context.Context, arg ListProjectsByPa
 			&i.Color,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Position,
 		); err != nil {
 			return nil, err
 		}
@@ -153,11 +241,27 @@ func (q *Queries) ListProjectsByParent(ctx context.Context, arg ListProjectsByPa
 	return items, nil
 }
 
+const setProjectPosition = `-- name: SetProjectPosition :exec
+UPDATE projects
+SET position = $1
+WHERE project_id = $2
+`
+
+type SetProjectPositionParams struct {
+	Position  string `json:"position"`
+	ProjectID int32  `json:"projectId"`
+}
+
+func (q *Queries) SetProjectPosition(ctx context.Context, arg SetProjectPositionParams) error {
+	_, err := q.db.Exec(ctx, setProjectPosition, arg.Position, arg.ProjectID)
+	return err
+}
+
 const updateProject = `-- name: UpdateProject :one
 UPDATE projects
 SET parent_project_id = $2, name = $3, description = $4, color = $5
 WHERE project_id = $1
-RETURNING project_id, user_id, parent_project_id, name, description, color, created_at, updated_at
+RETURNING project_id, user_id, parent_project_id, name, description, color, created_at, updated_at, position
 `
 
 type UpdateProjectParams struct {
@@ -186,6 +290,7 @@ func (q *Queries) UpdateProject(ctx context.Context, arg UpdateProjectParams) (P
 		&i.Color,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Position,
 	)
 	return i, err
 }
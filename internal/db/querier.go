@@ -11,26 +11,105 @@ import (
 )
 
 type Querier interface {
+	AcceptScheduleBlocks(ctx context.Context, arg AcceptScheduleBlocksParams) ([]ScheduleBlock, error)
+	AdvanceRecurringTodo(ctx context.Context, arg AdvanceRecurringTodoParams) (Todo, error)
+	BulkCompleteTodos(ctx context.Context, arg BulkCompleteTodosParams) (int64, error)
+	BulkCreateTodoTags(ctx context.Context, arg BulkCreateTodoTagsParams) (int64, error)
+	BulkDeleteTodoTags(ctx context.Context, arg BulkDeleteTodoTagsParams) (int64, error)
+	BulkDeleteTodos(ctx context.Context, arg BulkDeleteTodosParams) (int64, error)
+	BulkMoveTodos(ctx context.Context, arg BulkMoveTodosParams) (int64, error)
+	BulkSetTodoPriority(ctx context.Context, arg BulkSetTodoPriorityParams) (int64, error)
+	BulkShiftTodoAssignedDates(ctx context.Context, arg BulkShiftTodoAssignedDatesParams) (int64, error)
+	BulkUncompleteTodos(ctx context.Context, arg BulkUncompleteTodosParams) (int64, error)
+	ClaimDueBusyCalendars(ctx context.Context, batchSize int32) ([]BusyCalendar, error)
+	ClaimDueDigestSubscriptions(ctx context.Context, batchSize int32) ([]ClaimDueDigestSubscriptionsRow, error)
+	ClaimDueJobSchedules(ctx context.Context, names []string) ([]JobSchedule, error)
+	ClaimDueReminders(ctx context.Context, arg ClaimDueRemindersParams) ([]ClaimDueRemindersRow, error)
+	ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error)
+	CleanupExpiredIdempotencyKeys(ctx context.Context) error
 	CleanupExpiredRefreshTokens(ctx context.Context) error
+	ClearTodoRecurrence(ctx context.Context, todoID int32) (Todo, error)
+	CompleteJob(ctx context.Context, jobID int64) error
 	CompleteTodo(ctx context.Context, todoID int32) (Todo, error)
+	CopyTodoTags(ctx context.Context, arg CopyTodoTagsParams) error
+	CountStatusTodos(ctx context.Context, statusID pgtype.Int4) (int64, error)
+	CountTodoAncestorsInSet(ctx context.Context, arg CountTodoAncestorsInSetParams) (int64, error)
+	CountUnreadNotifications(ctx context.Context, userID int32) ([]CountUnreadNotificationsRow, error)
+	CreateAppPassword(ctx context.Context, arg CreateAppPasswordParams) (AppPassword, error)
+	CreateBusyBlocks(ctx context.Context, arg CreateBusyBlocksParams) error
+	CreateBusyCalendar(ctx context.Context, arg CreateBusyCalendarParams) (BusyCalendar, error)
+	CreateCalendarFeed(ctx context.Context, arg CreateCalendarFeedParams) (CalendarFeed, error)
 	CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error)
+	CreateDailyPlanItem(ctx context.Context, arg CreateDailyPlanItemParams) error
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
 	CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error)
+	CreateProjectSection(ctx context.Context, arg CreateProjectSectionParams) (ProjectSection, error)
+	CreateProjectStatus(ctx context.Context, arg CreateProjectStatusParams) (ProjectStatus, error)
 	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	CreateReminder(ctx context.Context, arg CreateReminderParams) (Reminder, error)
+	CreateSavedFilter(ctx context.Context, arg CreateSavedFilterParams) (SavedFilter, error)
+	CreateScheduleBlock(ctx context.Context, arg CreateScheduleBlockParams) (ScheduleBlock, error)
 	CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error)
 	CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, error)
+	CreateTodoDependency(ctx context.Context, arg CreateTodoDependencyParams) (int64, error)
+	CreateTodoOccurrence(ctx context.Context, arg CreateTodoOccurrenceParams) (TodoOccurrence, error)
 	CreateTodoTag(ctx context.Context, arg CreateTodoTagParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWorkingHours(ctx context.Context, arg CreateWorkingHoursParams) error
 	DeleteAllTagTodos(ctx context.Context, tagID int32) error
 	DeleteAllTodoTags(ctx context.Context, todoID int32) error
+	DeleteAppPassword(ctx context.Context, appPasswordID int32) error
+	DeleteBusyBlocks(ctx context.Context, calendarID int32) error
+	DeleteBusyCalendar(ctx context.Context, calendarID int32) error
+	DeleteCalendarFeed(ctx context.Context, feedID int32) error
 	DeleteComment(ctx context.Context, commentID int32) error
+	DeleteDailyPlanItems(ctx context.Context, dailyPlanID int32) error
+	DeleteFinishedJobs(ctx context.Context, arg DeleteFinishedJobsParams) (int64, error)
+	DeleteIdempotencyKey(ctx context.Context, idempotencyKeyID int32) error
 	DeleteProject(ctx context.Context, projectID int32) error
+	DeleteProjectSection(ctx context.Context, sectionID int32) error
+	DeleteProjectStatus(ctx context.Context, statusID int32) error
+	DeleteProposedScheduleBlocks(ctx context.Context, userID int32) (int64, error)
+	DeleteReminder(ctx context.Context, reminderID int32) error
+	DeleteSavedFilter(ctx context.Context, savedFilterID int32) error
+	DeleteScheduleBlock(ctx context.Context, blockID int32) error
 	DeleteTag(ctx context.Context, tagID int32) error
 	DeleteTodo(ctx context.Context, todoID int32) error
+	DeleteTodoDependencies(ctx context.Context, todoID int32) error
+	DeleteTodoDependency(ctx context.Context, arg DeleteTodoDependencyParams) (int64, error)
 	DeleteTodoTag(ctx context.Context, arg DeleteTodoTagParams) error
 	DeleteUser(ctx context.Context, userID int32) error
+	DeleteWorkingHours(ctx context.Context, userID int32) error
+	DisableDigestSubscription(ctx context.Context, arg DisableDigestSubscriptionParams) (int64, error)
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (Job, error)
+	FindSimilarTodos(ctx context.Context, arg FindSimilarTodosParams) ([]FindSimilarTodosRow, error)
+	FirstProjectPosition(ctx context.Context, arg FirstProjectPositionParams) (string, error)
+	FirstTodoPosition(ctx context.Context, arg FirstTodoPositionParams) (string, error)
+	FlagRolledOverTodos(ctx context.Context, arg FlagRolledOverTodosParams) (int64, error)
+	GetAppPassword(ctx context.Context, appPasswordID int32) (AppPassword, error)
+	GetAppPasswordByHash(ctx context.Context, passwordHash string) (AppPassword, error)
+	GetBusyCalendar(ctx context.Context, calendarID int32) (BusyCalendar, error)
+	GetCaldavObjectByName(ctx context.Context, arg GetCaldavObjectByNameParams) (CaldavObject, error)
+	GetCaldavObjectByUID(ctx context.Context, arg GetCaldavObjectByUIDParams) (CaldavObject, error)
+	GetCalendarFeed(ctx context.Context, feedID int32) (CalendarFeed, error)
+	GetCalendarFeedByTokenHash(ctx context.Context, tokenHash string) (CalendarFeed, error)
+	GetCollectionSyncToken(ctx context.Context, arg GetCollectionSyncTokenParams) (int64, error)
 	GetComment(ctx context.Context, commentID int32) (Comment, error)
+	GetDailyPlan(ctx context.Context, arg GetDailyPlanParams) (DailyPlan, error)
+	GetDigestSubscription(ctx context.Context, arg GetDigestSubscriptionParams) (DigestSubscription, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetNotification(ctx context.Context, notificationID int32) (Notification, error)
+	GetNotificationSettings(ctx context.Context, userID int32) (NotificationSetting, error)
+	GetPlanningSettings(ctx context.Context, userID int32) (PlanningSetting, error)
 	GetProject(ctx context.Context, projectID int32) (Project, error)
+	GetProjectByName(ctx context.Context, arg GetProjectByNameParams) (Project, error)
+	GetProjectSection(ctx context.Context, sectionID int32) (ProjectSection, error)
+	GetProjectStatus(ctx context.Context, statusID int32) (ProjectStatus, error)
 	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
+	GetReminder(ctx context.Context, reminderID int32) (Reminder, error)
+	GetSavedFilter(ctx context.Context, savedFilterID int32) (SavedFilter, error)
+	GetScheduleBlock(ctx context.Context, blockID int32) (ScheduleBlock, error)
 	GetTag(ctx context.Context, tagID int32) (Tag, error)
 	GetTagByName(ctx context.Context, arg GetTagByNameParams) (Tag, error)
 	GetTagTodos(ctx context.Context, tagID int32) ([]TodoTag, error)
@@ -40,28 +119,123 @@ type Querier interface {
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByGoogleID(ctx context.Context, googleID pgtype.Text) (User, error)
 	GetUserRefreshTokens(ctx context.Context, userID int32) ([]RefreshToken, error)
+	KillJob(ctx context.Context, arg KillJobParams) error
+	LeaseReminders(ctx context.Context, arg LeaseRemindersParams) error
+	ListAppPasswords(ctx context.Context, userID int32) ([]AppPassword, error)
+	ListBusyBlocksBetween(ctx context.Context, arg ListBusyBlocksBetweenParams) ([]ListBusyBlocksBetweenRow, error)
+	ListBusyCalendars(ctx context.Context, userID int32) ([]BusyCalendar, error)
+	ListCaldavObjects(ctx context.Context, arg ListCaldavObjectsParams) ([]CaldavObject, error)
+	ListCalendarFeeds(ctx context.Context, userID int32) ([]CalendarFeed, error)
+	ListCollectionChanges(ctx context.Context, arg ListCollectionChangesParams) ([]CaldavChange, error)
+	ListCollectionTodos(ctx context.Context, arg ListCollectionTodosParams) ([]Todo, error)
 	ListComments(ctx context.Context, todoID int32) ([]Comment, error)
 	ListCommentsByUser(ctx context.Context, userID int32) ([]Comment, error)
 	ListCompletedTodos(ctx context.Context, userID int32) ([]Todo, error)
+	ListDailyPlanItems(ctx context.Context, dailyPlanID int32) ([]ListDailyPlanItemsRow, error)
+	ListDailyPlans(ctx context.Context, arg ListDailyPlansParams) ([]DailyPlan, error)
+	ListDeadJobs(ctx context.Context, limit int32) ([]Job, error)
+	ListDigestSubscriptions(ctx context.Context, userID int32) ([]DigestSubscription, error)
+	ListDueRollovers(ctx context.Context, batchSize int32) ([]ListDueRolloversRow, error)
+	ListFixedScheduleBlocks(ctx context.Context, userID int32) ([]ScheduleBlock, error)
+	ListNotificationPreferences(ctx context.Context, userID int32) ([]NotificationPreference, error)
+	ListOpenDependencyEdges(ctx context.Context, todoIds []int32) ([]ListOpenDependencyEdgesRow, error)
+	ListOpenTodosBefore(ctx context.Context, arg ListOpenTodosBeforeParams) ([]Todo, error)
+	ListOpenTodosBetween(ctx context.Context, arg ListOpenTodosBetweenParams) ([]Todo, error)
+	ListOwnedTodoIDs(ctx context.Context, arg ListOwnedTodoIDsParams) ([]int32, error)
 	ListPendingTodos(ctx context.Context, userID int32) ([]Todo, error)
+	ListPlanningCandidates(ctx context.Context, arg ListPlanningCandidatesParams) ([]Todo, error)
+	ListProjectOpenTodos(ctx context.Context, projectID pgtype.Int4) ([]Todo, error)
+	ListProjectSections(ctx context.Context, projectID int32) ([]ProjectSection, error)
+	ListProjectSiblings(ctx context.Context, arg ListProjectSiblingsParams) ([]ListProjectSiblingsRow, error)
+	ListProjectStatuses(ctx context.Context, projectID int32) ([]ProjectStatus, error)
+	ListProjectTodos(ctx context.Context, projectID pgtype.Int4) ([]Todo, error)
 	ListProjects(ctx context.Context, userID int32) ([]Project, error)
 	ListProjectsByParent(ctx context.Context, arg ListProjectsByParentParams) ([]Project, error)
+	ListRecurringTodosForUpdate(ctx context.Context, arg ListRecurringTodosForUpdateParams) ([]Todo, error)
+	ListRecurringTodosStartedBefore(ctx context.Context, arg ListRecurringTodosStartedBeforeParams) ([]Todo, error)
+	ListReminders(ctx context.Context, userID int32) ([]Reminder, error)
+	ListRemindersByTodo(ctx context.Context, todoID int32) ([]Reminder, error)
+	ListSavedFilters(ctx context.Context, userID int32) ([]SavedFilter, error)
+	ListScheduleBlocks(ctx context.Context, arg ListScheduleBlocksParams) ([]ListScheduleBlocksRow, error)
 	ListTags(ctx context.Context, userID int32) ([]Tag, error)
+	ListTodoDependents(ctx context.Context, todoID int32) ([]Todo, error)
+	ListTodoOccurrences(ctx context.Context, todoID int32) ([]TodoOccurrence, error)
+	ListTodoOccurrencesBetween(ctx context.Context, arg ListTodoOccurrencesBetweenParams) ([]ListTodoOccurrencesBetweenRow, error)
+	ListTodoPrerequisites(ctx context.Context, todoID int32) ([]Todo, error)
+	ListTodoSiblings(ctx context.Context, arg ListTodoSiblingsParams) ([]ListTodoSiblingsRow, error)
+	ListTodoTagNames(ctx context.Context, todoIds []int32) ([]ListTodoTagNamesRow, error)
 	ListTodoTagsByTodo(ctx context.Context, todoID int32) ([]Tag, error)
 	ListTodos(ctx context.Context, userID int32) ([]Todo, error)
+	ListTodosAssignedBetween(ctx context.Context, arg ListTodosAssignedBetweenParams) ([]Todo, error)
+	ListTodosByIDs(ctx context.Context, arg ListTodosByIDsParams) ([]Todo, error)
 	ListTodosByParent(ctx context.Context, arg ListTodosByParentParams) ([]Todo, error)
 	ListTodosByProject(ctx context.Context, arg ListTodosByProjectParams) ([]Todo, error)
 	ListTodosByTag(ctx context.Context, tagID int32) ([]Todo, error)
+	ListTodosCompletedBetween(ctx context.Context, arg ListTodosCompletedBetweenParams) ([]Todo, error)
+	ListUserOpenDependencies(ctx context.Context, userID int32) ([]ListUserOpenDependenciesRow, error)
 	ListUsers(ctx context.Context) ([]User, error)
+	ListWorkingHours(ctx context.Context, userID int32) ([]WorkingHour, error)
+	LockBusyCalendar(ctx context.Context, calendarID int32) (BusyCalendar, error)
+	LockUser(ctx context.Context, userID int32) error
+	MarkAllNotificationsRead(ctx context.Context, userID int32) (int64, error)
+	MarkNotificationRead(ctx context.Context, notificationID int32) (Notification, error)
+	MarkNotificationUnread(ctx context.Context, notificationID int32) (Notification, error)
+	MarkReminderFailed(ctx context.Context, arg MarkReminderFailedParams) error
+	MarkReminderFired(ctx context.Context, arg MarkReminderFiredParams) error
+	MoveProjectSection(ctx context.Context, arg MoveProjectSectionParams) (ProjectSection, error)
+	MoveRolledOverTodos(ctx context.Context, arg MoveRolledOverTodosParams) (int64, error)
+	MoveSectionTodos(ctx context.Context, arg MoveSectionTodosParams) (int64, error)
+	MoveStatusTodos(ctx context.Context, arg MoveStatusTodosParams) (int64, error)
+	MoveSubtasks(ctx context.Context, arg MoveSubtasksParams) (int64, error)
+	MoveTodoComments(ctx context.Context, arg MoveTodoCommentsParams) (int64, error)
+	RecordBusyCalendarError(ctx context.Context, arg RecordBusyCalendarErrorParams) (BusyCalendar, error)
+	RecordBusyCalendarSync(ctx context.Context, arg RecordBusyCalendarSyncParams) (BusyCalendar, error)
+	RenameBusyCalendar(ctx context.Context, arg RenameBusyCalendarParams) (BusyCalendar, error)
+	RequeueDeadJob(ctx context.Context, jobID int64) (int64, error)
+	RescueStuckJobs(ctx context.Context, stuckAfter pgtype.Interval) (int64, error)
+	ResyncProjectTodoStatuses(ctx context.Context, projectID pgtype.Int4) (int64, error)
+	RetryJob(ctx context.Context, arg RetryJobParams) error
 	RevokeAllUserRefreshTokens(ctx context.Context, userID int32) error
 	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+	RotateCalendarFeedToken(ctx context.Context, arg RotateCalendarFeedTokenParams) (CalendarFeed, error)
+	SaveIdempotencyKeyResponse(ctx context.Context, arg SaveIdempotencyKeyResponseParams) error
+	SetBusyCalendarData(ctx context.Context, arg SetBusyCalendarDataParams) (BusyCalendar, error)
+	SetBusyCalendarNextSync(ctx context.Context, arg SetBusyCalendarNextSyncParams) error
+	SetDescendantProjects(ctx context.Context, arg SetDescendantProjectsParams) error
+	SetDigestNextSend(ctx context.Context, arg SetDigestNextSendParams) error
+	SetJobScheduleNextRun(ctx context.Context, arg SetJobScheduleNextRunParams) error
+	SetNextRollover(ctx context.Context, arg SetNextRolloverParams) error
+	SetProjectPosition(ctx context.Context, arg SetProjectPositionParams) error
+	SetProjectSectionPosition(ctx context.Context, arg SetProjectSectionPositionParams) error
+	SetProjectStatusPosition(ctx context.Context, arg SetProjectStatusPositionParams) error
+	SetTodoAssignedDate(ctx context.Context, arg SetTodoAssignedDateParams) (Todo, error)
+	SetTodoPosition(ctx context.Context, arg SetTodoPositionParams) error
+	SetTodoRecurrence(ctx context.Context, arg SetTodoRecurrenceParams) (Todo, error)
+	SetTodoSection(ctx context.Context, arg SetTodoSectionParams) (Todo, error)
+	SetTodoStatus(ctx context.Context, arg SetTodoStatusParams) (Todo, error)
+	TodoDependsOn(ctx context.Context, arg TodoDependsOnParams) (bool, error)
+	TouchAppPassword(ctx context.Context, appPasswordID int32) error
+	TouchCalendarFeed(ctx context.Context, feedID int32) error
 	UncompleteTodo(ctx context.Context, todoID int32) (Todo, error)
 	UpdateComment(ctx context.Context, arg UpdateCommentParams) (Comment, error)
 	UpdateProject(ctx context.Context, arg UpdateProjectParams) (Project, error)
+	UpdateProjectSection(ctx context.Context, arg UpdateProjectSectionParams) (ProjectSection, error)
+	UpdateProjectStatus(ctx context.Context, arg UpdateProjectStatusParams) (ProjectStatus, error)
 	UpdateRefreshTokenLastUsed(ctx context.Context, tokenHash string) error
+	UpdateReminder(ctx context.Context, arg UpdateReminderParams) (Reminder, error)
+	UpdateSavedFilter(ctx context.Context, arg UpdateSavedFilterParams) (SavedFilter, error)
+	UpdateScheduleBlock(ctx context.Context, arg UpdateScheduleBlockParams) (ScheduleBlock, error)
 	UpdateTag(ctx context.Context, arg UpdateTagParams) (Tag, error)
 	UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserTimezone(ctx context.Context, arg UpdateUserTimezoneParams) (User, error)
+	UpsertCaldavObject(ctx context.Context, arg UpsertCaldavObjectParams) error
+	UpsertDailyPlan(ctx context.Context, arg UpsertDailyPlanParams) (DailyPlan, error)
+	UpsertDigestSubscription(ctx context.Context, arg UpsertDigestSubscriptionParams) (DigestSubscription, error)
+	UpsertJobSchedule(ctx context.Context, arg UpsertJobScheduleParams) error
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) error
+	UpsertNotificationSettings(ctx context.Context, arg UpsertNotificationSettingsParams) (NotificationSetting, error)
+	UpsertPlanningSettings(ctx context.Context, arg UpsertPlanningSettingsParams) (PlanningSetting, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -66,7 +66,9 @@ func (q *Queries) GetTag(ctx context.Context, tagID int32) (Tag, error) {
 
 const getTagByName = `-- name: GetTagByName :one
 SELECT tag_id, user_id, name, color, created_at FROM tags
-WHERE user_id = $1 AND name = $2
+WHERE user_id = $1 AND lower(name) = lower($2::text)
+ORDER BY created_at ASC, tag_id
+LIMIT 1
 `
 
 type GetTagByNameParams struct {
@@ -9,6 +9,60 @@ import (
 	"context"
 )
 
+const bulkCreateTodoTags = `-- name: BulkCreateTodoTags :execrows
+INSERT INTO todo_tags (todo_id, tag_id)
+SELECT unnest($1::int[]), $2::int
+ON CONFLICT DO NOTHING
+`
+
+type BulkCreateTodoTagsParams struct {
+	TodoIds []int32 `json:"todoIds"`
+	TagID   int32   `json:"tagId"`
+}
+
+func (q *Queries) BulkCreateTodoTags(ctx context.Context, arg BulkCreateTodoTagsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkCreateTodoTags, arg.TodoIds, arg.TagID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const bulkDeleteTodoTags = `-- name: BulkDeleteTodoTags :execrows
+DELETE FROM todo_tags
+WHERE tag_id = $1 AND todo_id = ANY($2::int[])
+`
+
+type BulkDeleteTodoTagsParams struct {
+	TagID   int32   `json:"tagId"`
+	TodoIds []int32 `json:"todoIds"`
+}
+
+func (q *Queries) BulkDeleteTodoTags(ctx context.Context, arg BulkDeleteTodoTagsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkDeleteTodoTags, arg.TagID, arg.TodoIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const copyTodoTags = `-- name: CopyTodoTags :exec
+INSERT INTO todo_tags (todo_id, tag_id)
+SELECT $1::int, tag_id FROM todo_tags
+WHERE todo_id = $2
+ON CONFLICT DO NOTHING
+`
+
+type CopyTodoTagsParams struct {
+	TargetTodoID int32 `json:"targetTodoId"`
+	SourceTodoID int32 `json:"sourceTodoId"`
+}
+
+func (q *Queries) CopyTodoTags(ctx context.Context, arg CopyTodoTagsParams) error {
+	_, err := q.db.Exec(ctx, copyTodoTags, arg.TargetTodoID, arg.SourceTodoID)
+	return err
+}
+
 const createTodoTag = `-- name: CreateTodoTag :exec
 INSERT INTO todo_tags (todo_id, tag_id)
 VALUES ($1, $2)
@@ -143,7 +197,7 @@ func (q *Queries) ListTodoTagsByTodo(ctx context.Context, todoID int32) ([]Tag,
 }
 
 const listTodosByTag = `-- name: ListTodosByTag :many
-SELECT td.todo_id, td.user_id, td.project_id, td.parent_todo_id, td.title, td.description, td.is_completed, td.due_date, td.work_start_time, td.work_end_time, td.priority, td.created_at, td.updated_at, td.completed_at FROM todos td
+SELECT td.todo_id, td.user_id, td.project_id, td.parent_todo_id, td.title, td.description, td.is_completed, td.assigned_date, td.duration_min, td.priority, td.created_at, td.updated_at, td.completed_at, td.recurrence_rule, td.recurrence_mode, td.recurrence_start, td.recurrence_count, td.deadline, td.rollover_count, td.last_rolled_over_at, td.status_id, td.position, td.section_id FROM todos td
 JOIN todo_tags tt ON td.todo_id = tt.todo_id
 WHERE tt.tag_id = $1
 ORDER BY td.created_at DESC
@@ -166,13 +220,22 @@ func (q *Queries) ListTodosByTag(ctx context.Context, tagID int32) ([]Todo, erro
 			&i.Title,
 			&i.Description,
 			&i.IsCompleted,
-			&i.DueDate,
-			&i.WorkStartTime,
-			&i.WorkEndTime,
+			&i.AssignedDate,
+			&i.DurationMin,
 			&i.Priority,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
 		); err != nil {
 			return nil, err
 		}
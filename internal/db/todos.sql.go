@@ -11,11 +11,214 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const advanceRecurringTodo = `-- name: AdvanceRecurringTodo :one
+UPDATE todos
+SET assigned_date = $1, recurrence_count = recurrence_count + 1
+WHERE todo_id = $2
+RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id
+`
+
+type AdvanceRecurringTodoParams struct {
+	AssignedDate pgtype.Timestamptz `json:"assignedDate"`
+	TodoID       int32              `json:"todoId"`
+}
+
+func (q *Queries) AdvanceRecurringTodo(ctx context.Context, arg AdvanceRecurringTodoParams) (Todo, error) {
+	row := q.db.QueryRow(ctx, advanceRecurringTodo, arg.AssignedDate, arg.TodoID)
+	var i Todo
+	err := row.Scan(
+		&i.TodoID,
+		&i.UserID,
+		&i.ProjectID,
+		&i.ParentTodoID,
+		&i.Title,
+		&i.Description,
+		&i.IsCompleted,
+		&i.AssignedDate,
+		&i.DurationMin,
+		&i.Priority,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
+	)
+	return i, err
+}
+
+const bulkCompleteTodos = `-- name: BulkCompleteTodos :execrows
+UPDATE todos
+SET is_completed = true
+WHERE user_id = $1 AND todo_id = ANY($2::int[]) AND is_completed = false
+`
+
+type BulkCompleteTodosParams struct {
+	UserID  int32   `json:"userId"`
+	TodoIds []int32 `json:"todoIds"`
+}
+
+func (q *Queries) BulkCompleteTodos(ctx context.Context, arg BulkCompleteTodosParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkCompleteTodos, arg.UserID, arg.TodoIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const bulkDeleteTodos = `-- name: BulkDeleteTodos :execrows
+DELETE FROM todos
+WHERE user_id = $1 AND todo_id = ANY($2::int[])
+`
+
+type BulkDeleteTodosParams struct {
+	UserID  int32   `json:"userId"`
+	TodoIds []int32 `json:"todoIds"`
+}
+
+func (q *Queries) BulkDeleteTodos(ctx context.Context, arg BulkDeleteTodosParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkDeleteTodos, arg.UserID, arg.TodoIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const bulkMoveTodos = `-- name: BulkMoveTodos :execrows
+UPDATE todos
+SET project_id = $1, parent_todo_id = $2
+WHERE user_id = $3 AND todo_id = ANY($4::int[])
+`
+
+type BulkMoveTodosParams struct {
+	ProjectID    pgtype.Int4 `json:"projectId"`
+	ParentTodoID pgtype.Int4 `json:"parentTodoId"`
+	UserID       int32       `json:"userId"`
+	TodoIds      []int32     `json:"todoIds"`
+}
+
+func (q *Queries) BulkMoveTodos(ctx context.Context, arg BulkMoveTodosParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkMoveTodos,
+		arg.ProjectID,
+		arg.ParentTodoID,
+		arg.UserID,
+		arg.TodoIds,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const bulkSetTodoPriority = `-- name: BulkSetTodoPriority :execrows
+UPDATE todos
+SET priority = $1::int
+WHERE user_id = $2 AND todo_id = ANY($3::int[])
+`
+
+type BulkSetTodoPriorityParams struct {
+	Priority int32   `json:"priority"`
+	UserID   int32   `json:"userId"`
+	TodoIds  []int32 `json:"todoIds"`
+}
+
+func (q *Queries) BulkSetTodoPriority(ctx context.Context, arg BulkSetTodoPriorityParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkSetTodoPriority, arg.Priority, arg.UserID, arg.TodoIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const bulkShiftTodoAssignedDates = `-- name: BulkShiftTodoAssignedDates :execrows
+UPDATE todos
+SET assigned_date = assigned_date + $1::interval
+WHERE user_id = $2 AND todo_id = ANY($3::int[]) AND assigned_date IS NOT NULL
+`
+
+type BulkShiftTodoAssignedDatesParams struct {
+	Shift   pgtype.Interval `json:"shift"`
+	UserID  int32           `json:"userId"`
+	TodoIds []int32         `json:"todoIds"`
+}
+
+func (q *Queries) BulkShiftTodoAssignedDates(ctx context.Context, arg BulkShiftTodoAssignedDatesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkShiftTodoAssignedDates, arg.Shift, arg.UserID, arg.TodoIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const bulkUncompleteTodos = `-- name: BulkUncompleteTodos :execrows
+UPDATE todos
+SET is_completed = false
+WHERE user_id = $1 AND todo_id = ANY($2::int[]) AND is_completed = true
+`
+
+type BulkUncompleteTodosParams struct {
+	UserID  int32   `json:"userId"`
+	TodoIds []int32 `json:"todoIds"`
+}
+
+func (q *Queries) BulkUncompleteTodos(ctx context.Context, arg BulkUncompleteTodosParams) (int64, error) {
+	result, err := q.db.Exec(ctx, bulkUncompleteTodos, arg.UserID, arg.TodoIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const clearTodoRecurrence = `-- name: ClearTodoRecurrence :one
+UPDATE todos
+SET recurrence_rule = NULL, recurrence_start = NULL, recurrence_count = 0
+WHERE todo_id = $1
+RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id
+`
+
+func (q *Queries) ClearTodoRecurrence(ctx context.Context, todoID int32) (Todo, error) {
+	row := q.db.QueryRow(ctx, clearTodoRecurrence, todoID)
+	var i Todo
+	err := row.Scan(
+		&i.TodoID,
+		&i.UserID,
+		&i.ProjectID,
+		&i.ParentTodoID,
+		&i.Title,
+		&i.Description,
+		&i.IsCompleted,
+		&i.AssignedDate,
+		&i.DurationMin,
+		&i.Priority,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
+	)
+	return i, err
+}
+
 const completeTodo = `-- name: CompleteTodo :one
 UPDATE todos
 SET is_completed = true
 WHERE todo_id = $1
-RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at
+RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id
 `
 
 func (q *Queries) CompleteTodo(ctx context.Context, todoID int32) (Todo, error) {
@@ -29,33 +232,68 @@ func (q *Queries) CompleteTodo(ctx context.Context, todoID int32) (Todo, error)
 		&i.Title,
 		&i.Description,
 		&i.IsCompleted,
-		&i.DueDate,
-		&i.WorkStartTime,
-		&i.WorkEndTime,
+		&i.AssignedDate,
+		&i.DurationMin,
 		&i.Priority,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
 	)
 	return i, err
 }
 
+const countTodoAncestorsInSet = `-- name: CountTodoAncestorsInSet :one
+WITH RECURSIVE ancestors AS (
+    SELECT todo_id, parent_todo_id FROM todos
+    WHERE todo_id = $1
+    UNION
+    SELECT t.todo_id, t.parent_todo_id FROM todos t
+    JOIN ancestors a ON t.todo_id = a.parent_todo_id
+)
+SELECT count(*) FROM ancestors
+WHERE todo_id = ANY($2::int[])
+`
+
+type CountTodoAncestorsInSetParams struct {
+	TodoID  int32   `json:"todoId"`
+	TodoIds []int32 `json:"todoIds"`
+}
+
+func (q *Queries) CountTodoAncestorsInSet(ctx context.Context, arg CountTodoAncestorsInSetParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTodoAncestorsInSet, arg.TodoID, arg.TodoIds)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTodo = `-- name: CreateTodo :one
-INSERT INTO todos (user_id, project_id, parent_todo_id, title, description, due_date, work_start_time, work_end_time, priority)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at
+INSERT INTO todos (user_id, project_id, parent_todo_id, title, description, assigned_date, duration_min, priority, deadline, position, section_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id
 `
 
 type CreateTodoParams struct {
-	UserID        int32              `json:"userId"`
-	ProjectID     pgtype.Int4        `json:"projectId"`
-	ParentTodoID  pgtype.Int4        `json:"parentTodoId"`
-	Title         string             `json:"title"`
-	Description   pgtype.Text        `json:"description"`
-	DueDate       pgtype.Timestamptz `json:"dueDate"`
-	WorkStartTime pgtype.Timestamptz `json:"workStartTime"`
-	WorkEndTime   pgtype.Timestamptz `json:"workEndTime"`
-	Priority      pgtype.Int4        `json:"priority"`
+	UserID       int32              `json:"userId"`
+	ProjectID    pgtype.Int4        `json:"projectId"`
+	ParentTodoID pgtype.Int4        `json:"parentTodoId"`
+	Title        string             `json:"title"`
+	Description  pgtype.Text        `json:"description"`
+	AssignedDate pgtype.Timestamptz `json:"assignedDate"`
+	DurationMin  pgtype.Int4        `json:"durationMin"`
+	Priority     pgtype.Int4        `json:"priority"`
+	Deadline     pgtype.Timestamptz `json:"deadline"`
+	Position     string             `json:"position"`
+	SectionID    pgtype.Int4        `json:"sectionId"`
 }
 
 func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, error) {
@@ -65,10 +303,12 @@ func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, e
 		arg.ParentTodoID,
 		arg.Title,
 		arg.Description,
-		arg.DueDate,
-		arg.WorkStartTime,
-		arg.WorkEndTime,
+		arg.AssignedDate,
+		arg.DurationMin,
 		arg.Priority,
+		arg.Deadline,
+		arg.Position,
+		arg.SectionID,
 	)
 	var i Todo
 	err := row.Scan(
@@ -79,13 +319,22 @@ func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, e
 		&i.Title,
 		&i.Description,
 		&i.IsCompleted,
-		&i.DueDate,
-		&i.WorkStartTime,
-		&i.WorkEndTime,
+		&i.AssignedDate,
+		&i.DurationMin,
 		&i.Priority,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
 	)
 	return i, err
 }
@@ -100,8 +349,124 @@ func (q *Queries) DeleteTodo(ctx context.Context, todoID int32) error {
 	return err
 }
 
+const findSimilarTodos = `-- name: FindSimilarTodos :many
+SELECT t.todo_id, t.user_id, t.project_id, t.parent_todo_id, t.title, t.description, t.is_completed, t.assigned_date, t.duration_min, t.priority, t.created_at, t.updated_at, t.completed_at, t.recurrence_rule, t.recurrence_mode, t.recurrence_start, t.recurrence_count, t.deadline, t.rollover_count, t.last_rolled_over_at, t.status_id, t.position, t.section_id, similarity(t.title, $1::text)::real AS similarity
+FROM todos t
+WHERE t.user_id = $2 AND t.is_completed = false AND t.todo_id <> $3 AND t.title % $1::text
+ORDER BY similarity DESC, t.todo_id
+LIMIT $4
+`
+
+type FindSimilarTodosRow struct {
+	TodoID           int32              `json:"todoId"`
+	UserID           int32              `json:"userId"`
+	ProjectID        pgtype.Int4        `json:"projectId"`
+	ParentTodoID     pgtype.Int4        `json:"parentTodoId"`
+	Title            string             `json:"title"`
+	Description      pgtype.Text        `json:"description"`
+	IsCompleted      pgtype.Bool        `json:"isCompleted"`
+	AssignedDate     pgtype.Timestamptz `json:"assignedDate"`
+	DurationMin      pgtype.Int4        `json:"durationMin"`
+	Priority         pgtype.Int4        `json:"priority"`
+	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
+	CompletedAt      pgtype.Timestamptz `json:"completedAt"`
+	RecurrenceRule   pgtype.Text        `json:"recurrenceRule"`
+	RecurrenceMode   string             `json:"recurrenceMode"`
+	RecurrenceStart  pgtype.Timestamptz `json:"recurrenceStart"`
+	RecurrenceCount  int32              `json:"recurrenceCount"`
+	Deadline         pgtype.Timestamptz `json:"deadline"`
+	RolloverCount    int32              `json:"rolloverCount"`
+	LastRolledOverAt pgtype.Timestamptz `json:"lastRolledOverAt"`
+	StatusID         pgtype.Int4        `json:"statusId"`
+	Position         string             `json:"position"`
+	SectionID        pgtype.Int4        `json:"sectionId"`
+	Similarity       float32            `json:"similarity"`
+}
+
+type FindSimilarTodosParams struct {
+	Title         string `json:"title"`
+	UserID        int32  `json:"userId"`
+	ExcludeTodoID int32  `json:"excludeTodoId"`
+	MaxResults    int32  `json:"maxResults"`
+}
+
+func (q *Queries) FindSimilarTodos(ctx context.Context, arg FindSimilarTodosParams) ([]FindSimilarTodosRow, error) {
+	rows, err := q.db.Query(ctx, findSimilarTodos,
+		arg.Title,
+		arg.UserID,
+		arg.ExcludeTodoID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FindSimilarTodosRow{}
+	for rows.Next() {
+		var i FindSimilarTodosRow
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.ParentTodoID,
+			&i.Title,
+			&i.Description,
+			&i.IsCompleted,
+			&i.AssignedDate,
+			&i.DurationMin,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
+			&i.Similarity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const firstTodoPosition = `-- name: FirstTodoPosition :one
+SELECT COALESCE(MIN(position), '')::text AS position FROM todos
+WHERE user_id = $1 AND project_id IS NOT DISTINCT FROM $2::int AND parent_todo_id IS NOT DISTINCT FROM $3::int AND section_id IS NOT DISTINCT FROM $4::int
+`
+
+type FirstTodoPositionParams struct {
+	UserID       int32       `json:"userId"`
+	ProjectID    pgtype.Int4 `json:"projectId"`
+	ParentTodoID pgtype.Int4 `json:"parentTodoId"`
+	SectionID    pgtype.Int4 `json:"sectionId"`
+}
+
+func (q *Queries) FirstTodoPosition(ctx context.Context, arg FirstTodoPositionParams) (string, error) {
+	row := q.db.QueryRow(ctx, firstTodoPosition,
+		arg.UserID,
+		arg.ProjectID,
+		arg.ParentTodoID,
+		arg.SectionID,
+	)
+	var position string
+	err := row.Scan(&position)
+	return position, err
+}
+
 const getTodo = `-- name: GetTodo :one
-SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at FROM todos
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
 WHERE todo_id = $1
 `
 
@@ -116,19 +481,28 @@ func (q *Queries) GetTodo(ctx context.Context, todoID int32) (Todo, error) {
 		&i.Title,
 		&i.Description,
 		&i.IsCompleted,
-		&i.DueDate,
-		&i.WorkStartTime,
-		&i.WorkEndTime,
+		&i.AssignedDate,
+		&i.DurationMin,
 		&i.Priority,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
 	)
 	return i, err
 }
 
 const listCompletedTodos = `-- name: ListCompletedTodos :many
-SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at FROM todos
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
 WHERE user_id = $1 AND is_completed = true
 ORDER BY completed_at DESC
 `
@@ -150,13 +524,22 @@ func (q *Queries) ListCompletedTodos(ctx context.Context, userID int32) ([]Todo,
 			&i.Title,
 			&i.Description,
 			&i.IsCompleted,
-			&i.DueDate,
-			&i.WorkStartTime,
-			&i.WorkEndTime,
+			&i.AssignedDate,
+			&i.DurationMin,
 			&i.Priority,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
 		); err != nil {
 			return nil, err
 		}
@@ -168,10 +551,162 @@ func (q *Queries) ListCompletedTodos(ctx context.Context, userID int32) ([]Todo,
 	return items, nil
 }
 
+const listOpenTodosBefore = `-- name: ListOpenTodosBefore :many
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
+WHERE user_id = $1 AND NOT COALESCE(is_completed, FALSE)
+    AND assigned_date < $2
+ORDER BY assigned_date, COALESCE(priority, 0) DESC, todo_id
+LIMIT $3
+`
+
+type ListOpenTodosBeforeParams struct {
+	UserID     int32              `json:"userId"`
+	EndsAt     pgtype.Timestamptz `json:"endsAt"`
+	MaxResults int32              `json:"maxResults"`
+}
+
+func (q *Queries) ListOpenTodosBefore(ctx context.Context, arg ListOpenTodosBeforeParams) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, listOpenTodosBefore, arg.UserID, arg.EndsAt, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Todo{}
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.ParentTodoID,
+			&i.Title,
+			&i.Description,
+			&i.IsCompleted,
+			&i.AssignedDate,
+			&i.DurationMin,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenTodosBetween = `-- name: ListOpenTodosBetween :many
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
+WHERE user_id = $1 AND NOT COALESCE(is_completed, FALSE)
+    AND assigned_date >= $2 AND assigned_date < $3
+ORDER BY assigned_date, COALESCE(priority, 0) DESC, todo_id
+LIMIT $4
+`
+
+type ListOpenTodosBetweenParams struct {
+	UserID     int32              `json:"userId"`
+	StartsAt   pgtype.Timestamptz `json:"startsAt"`
+	EndsAt     pgtype.Timestamptz `json:"endsAt"`
+	MaxResults int32              `json:"maxResults"`
+}
+
+func (q *Queries) ListOpenTodosBetween(ctx context.Context, arg ListOpenTodosBetweenParams) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, listOpenTodosBetween,
+		arg.UserID,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Todo{}
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.ParentTodoID,
+			&i.Title,
+			&i.Description,
+			&i.IsCompleted,
+			&i.AssignedDate,
+			&i.DurationMin,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOwnedTodoIDs = `-- name: ListOwnedTodoIDs :many
+SELECT todo_id FROM todos
+WHERE user_id = $1 AND todo_id = ANY($2::int[])
+`
+
+type ListOwnedTodoIDsParams struct {
+	UserID  int32   `json:"userId"`
+	TodoIds []int32 `json:"todoIds"`
+}
+
+func (q *Queries) ListOwnedTodoIDs(ctx context.Context, arg ListOwnedTodoIDsParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listOwnedTodoIDs, arg.UserID, arg.TodoIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var todoID int32
+		if err := rows.Scan(&todoID); err != nil {
+			return nil, err
+		}
+		items = append(items, todoID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPendingTodos = `-- name: ListPendingTodos :many
-SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at FROM todos
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
 WHERE user_id = $1 AND is_completed = false
-ORDER BY created_at DESC
+ORDER BY position, todo_id
 `
 
 func (q *Queries) ListPendingTodos(ctx context.Context, userID int32) ([]Todo, error) {
@@ -191,13 +726,22 @@ func (q *Queries) ListPendingTodos(ctx context.Context, userID int32) ([]Todo, e
 			&i.Title,
 			&i.Description,
 			&i.IsCompleted,
-			&i.DueDate,
-			&i.WorkStartTime,
-			&i.WorkEndTime,
+			&i.AssignedDate,
+			&i.DurationMin,
 			&i.Priority,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
 		); err != nil {
 			return nil, err
 		}
@@ -209,10 +753,164 @@ func (q *Queries) ListPendingTodos(ctx context.Context, userID int32) ([]Todo, e
 	return items, nil
 }
 
+const listRecurringTodosForUpdate = `-- name: ListRecurringTodosForUpdate :many
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
+WHERE user_id = $1 AND todo_id = ANY($2::int[]) AND recurrence_rule IS NOT NULL AND is_completed = false
+FOR UPDATE
+`
+
+type ListRecurringTodosForUpdateParams struct {
+	UserID  int32   `json:"userId"`
+	TodoIds []int32 `json:"todoIds"`
+}
+
+func (q *Queries) ListRecurringTodosForUpdate(ctx context.Context, arg ListRecurringTodosForUpdateParams) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, listRecurringTodosForUpdate, arg.UserID, arg.TodoIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Todo{}
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.ParentTodoID,
+			&i.Title,
+			&i.Description,
+			&i.IsCompleted,
+			&i.AssignedDate,
+			&i.DurationMin,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringTodosStartedBefore = `-- name: ListRecurringTodosStartedBefore :many
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
+WHERE user_id = $1 AND recurrence_rule IS NOT NULL AND NOT COALESCE(is_completed, FALSE)
+    AND recurrence_start < $2
+ORDER BY todo_id
+`
+
+type ListRecurringTodosStartedBeforeParams struct {
+	UserID int32              `json:"userId"`
+	EndsAt pgtype.Timestamptz `json:"endsAt"`
+}
+
+func (q *Queries) ListRecurringTodosStartedBefore(ctx context.Context, arg ListRecurringTodosStartedBeforeParams) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, listRecurringTodosStartedBefore, arg.UserID, arg.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Todo{}
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.ParentTodoID,
+			&i.Title,
+			&i.Description,
+			&i.IsCompleted,
+			&i.AssignedDate,
+			&i.DurationMin,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTodoSiblings = `-- name: ListTodoSiblings :many
+SELECT todo_id, position FROM todos
+WHERE user_id = $1 AND project_id IS NOT DISTINCT FROM $2::int AND parent_todo_id IS NOT DISTINCT FROM $3::int AND section_id IS NOT DISTINCT FROM $4::int
+ORDER BY position, todo_id
+`
+
+type ListTodoSiblingsRow struct {
+	TodoID   int32  `json:"todoId"`
+	Position string `json:"position"`
+}
+
+type ListTodoSiblingsParams struct {
+	UserID       int32       `json:"userId"`
+	ProjectID    pgtype.Int4 `json:"projectId"`
+	ParentTodoID pgtype.Int4 `json:"parentTodoId"`
+	SectionID    pgtype.Int4 `json:"sectionId"`
+}
+
+func (q *Queries) ListTodoSiblings(ctx context.Context, arg ListTodoSiblingsParams) ([]ListTodoSiblingsRow, error) {
+	rows, err := q.db.Query(ctx, listTodoSiblings,
+		arg.UserID,
+		arg.ProjectID,
+		arg.ParentTodoID,
+		arg.SectionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTodoSiblingsRow{}
+	for rows.Next() {
+		var i ListTodoSiblingsRow
+		if err := rows.Scan(&i.TodoID, &i.Position); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTodos = `-- name: ListTodos :many
-SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at FROM todos
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
 WHERE user_id = $1
-ORDER BY created_at DESC
+ORDER BY position, todo_id
 `
 
 func (q *Queries) ListTodos(ctx context.Context, userID int32) ([]Todo, error) {
@@ -232,13 +930,86 @@ func (q *Queries) ListTodos(ctx context.Context, userID int32) ([]Todo, error) {
 			&i.Title,
 			&i.Description,
 			&i.IsCompleted,
-			&i.DueDate,
-			&i.WorkStartTime,
-			&i.WorkEndTime,
+			&i.AssignedDate,
+			&i.DurationMin,
 			&i.Priority,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTodosAssignedBetween = `-- name: ListTodosAssignedBetween :many
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
+WHERE user_id = $1 AND recurrence_rule IS NULL
+    AND assigned_date >= $2 AND assigned_date < $3
+ORDER BY assigned_date, todo_id
+LIMIT $4
+`
+
+type ListTodosAssignedBetweenParams struct {
+	UserID     int32              `json:"userId"`
+	StartsAt   pgtype.Timestamptz `json:"startsAt"`
+	EndsAt     pgtype.Timestamptz `json:"endsAt"`
+	MaxResults int32              `json:"maxResults"`
+}
+
+func (q *Queries) ListTodosAssignedBetween(ctx context.Context, arg ListTodosAssignedBetweenParams) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, listTodosAssignedBetween,
+		arg.UserID,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Todo{}
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.ParentTodoID,
+			&i.Title,
+			&i.Description,
+			&i.IsCompleted,
+			&i.AssignedDate,
+			&i.DurationMin,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
 		); err != nil {
 			return nil, err
 		}
@@ -251,9 +1022,9 @@ func (q *Queries) ListTodos(ctx context.Context, userID int32) ([]Todo, error) {
 }
 
 const listTodosByParent = `-- name: ListTodosByParent :many
-SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at FROM todos
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
 WHERE user_id = $1 AND parent_todo_id = $2
-ORDER BY created_at DESC
+ORDER BY position, todo_id
 `
 
 type ListTodosByParentParams struct {
@@ -278,13 +1049,22 @@ func (q *Queries) ListTodosByParent(ctx context.Context, arg ListTodosByParentPa
 			&i.Title,
 			&i.Description,
 			&i.IsCompleted,
-			&i.DueDate,
-			&i.WorkStartTime,
-			&i.WorkEndTime,
+			&i.AssignedDate,
+			&i.DurationMin,
 			&i.Priority,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
 		); err != nil {
 			return nil, err
 		}
@@ -297,9 +1077,9 @@ func (q *Queries) ListTodosByParent(ctx context.Context, arg ListTodosByParentPa
 }
 
 const listTodosByProject = `-- name: ListTodosByProject :many
-SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at FROM todos
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
 WHERE user_id = $1 AND project_id = $2
-ORDER BY created_at DESC
+ORDER BY position, todo_id
 `
 
 type ListTodosByProjectParams struct {
@@ -324,13 +1104,86 @@ func (q *Queries) ListTodosByProject(ctx context.Context, arg ListTodosByProject
 			&i.Title,
 			&i.Description,
 			&i.IsCompleted,
-			&i.DueDate,
-			&i.WorkStartTime,
-			&i.WorkEndTime,
+			&i.AssignedDate,
+			&i.DurationMin,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTodosCompletedBetween = `-- name: ListTodosCompletedBetween :many
+SELECT todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id FROM todos
+WHERE user_id = $1 AND COALESCE(is_completed, FALSE)
+    AND completed_at >= $2 AND completed_at < $3
+ORDER BY completed_at, todo_id
+LIMIT $4
+`
+
+type ListTodosCompletedBetweenParams struct {
+	UserID     int32              `json:"userId"`
+	StartsAt   pgtype.Timestamptz `json:"startsAt"`
+	EndsAt     pgtype.Timestamptz `json:"endsAt"`
+	MaxResults int32              `json:"maxResults"`
+}
+
+func (q *Queries) ListTodosCompletedBetween(ctx context.Context, arg ListTodosCompletedBetweenParams) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, listTodosCompletedBetween,
+		arg.UserID,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Todo{}
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.ParentTodoID,
+			&i.Title,
+			&i.Description,
+			&i.IsCompleted,
+			&i.AssignedDate,
+			&i.DurationMin,
 			&i.Priority,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.RecurrenceRule,
+			&i.RecurrenceMode,
+			&i.RecurrenceStart,
+			&i.RecurrenceCount,
+			&i.Deadline,
+			&i.RolloverCount,
+			&i.LastRolledOverAt,
+			&i.StatusID,
+			&i.Position,
+			&i.SectionID,
 		); err != nil {
 			return nil, err
 		}
@@ -342,11 +1195,121 @@ func (q *Queries) ListTodosByProject(ctx context.Context, arg ListTodosByProject
 	return items, nil
 }
 
+const moveSubtasks = `-- name: MoveSubtasks :execrows
+UPDATE todos
+SET parent_todo_id = $1::int
+WHERE parent_todo_id = $2::int
+`
+
+type MoveSubtasksParams struct {
+	TargetTodoID int32 `json:"targetTodoId"`
+	SourceTodoID int32 `json:"sourceTodoId"`
+}
+
+func (q *Queries) MoveSubtasks(ctx context.Context, arg MoveSubtasksParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveSubtasks, arg.TargetTodoID, arg.SourceTodoID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setDescendantProjects = `-- name: SetDescendantProjects :exec
+WITH RECURSIVE moved AS (
+    SELECT todo_id FROM todos
+    WHERE parent_todo_id = ANY($1::int[])
+    UNION
+    SELECT t.todo_id FROM todos t
+    JOIN moved m ON t.parent_todo_id = m.todo_id
+)
+UPDATE todos
+SET project_id = $2
+WHERE todo_id IN (SELECT todo_id FROM moved)
+`
+
+type SetDescendantProjectsParams struct {
+	TodoIds   []int32     `json:"todoIds"`
+	ProjectID pgtype.Int4 `json:"projectId"`
+}
+
+func (q *Queries) SetDescendantProjects(ctx context.Context, arg SetDescendantProjectsParams) error {
+	_, err := q.db.Exec(ctx, setDescendantProjects, arg.TodoIds, arg.ProjectID)
+	return err
+}
+
+const setTodoPosition = `-- name: SetTodoPosition :exec
+UPDATE todos
+SET position = $1
+WHERE todo_id = $2
+`
+
+type SetTodoPositionParams struct {
+	Position string `json:"position"`
+	TodoID   int32  `json:"todoId"`
+}
+
+func (q *Queries) SetTodoPosition(ctx context.Context, arg SetTodoPositionParams) error {
+	_, err := q.db.Exec(ctx, setTodoPosition, arg.Position, arg.TodoID)
+	return err
+}
+
+const setTodoRecurrence = `-- name: SetTodoRecurrence :one
+UPDATE todos
+SET recurrence_rule = $1, recurrence_mode = $2, recurrence_start = $3, recurrence_count = 0, assigned_date = $4
+WHERE todo_id = $5
+RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id
+`
+
+type SetTodoRecurrenceParams struct {
+	RecurrenceRule  pgtype.Text        `json:"recurrenceRule"`
+	RecurrenceMode  string             `json:"recurrenceMode"`
+	RecurrenceStart pgtype.Timestamptz `json:"recurrenceStart"`
+	AssignedDate    pgtype.Timestamptz `json:"assignedDate"`
+	TodoID          int32              `json:"todoId"`
+}
+
+func (q *Queries) SetTodoRecurrence(ctx context.Context, arg SetTodoRecurrenceParams) (Todo, error) {
+	row := q.db.QueryRow(ctx, setTodoRecurrence,
+		arg.RecurrenceRule,
+		arg.RecurrenceMode,
+		arg.RecurrenceStart,
+		arg.AssignedDate,
+		arg.TodoID,
+	)
+	var i Todo
+	err := row.Scan(
+		&i.TodoID,
+		&i.UserID,
+		&i.ProjectID,
+		&i.ParentTodoID,
+		&i.Title,
+		&i.Description,
+		&i.IsCompleted,
+		&i.AssignedDate,
+		&i.DurationMin,
+		&i.Priority,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
+	)
+	return i, err
+}
+
 const uncompleteTodo = `-- name: UncompleteTodo :one
 UPDATE todos
 SET is_completed = false
 WHERE todo_id = $1
-RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at
+RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id
 `
 
 func (q *Queries) UncompleteTodo(ctx context.Context, todoID int32) (Todo, error) {
@@ -360,34 +1323,42 @@ func (q *Queries) UncompleteTodo(ctx context.Context, todoID int32) (Todo, error
 		&i.Title,
 		&i.Description,
 		&i.IsCompleted,
-		&i.DueDate,
-		&i.WorkStartTime,
-		&i.WorkEndTime,
+		&i.AssignedDate,
+		&i.DurationMin,
 		&i.Priority,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
 	)
 	return i, err
 }
 
 const updateTodo = `-- name: UpdateTodo :one
 UPDATE todos
-SET project_id = $2, parent_todo_id = $3, title = $4, description = $5, due_date = $6, work_start_time = $7, work_end_time = $8, priority = $9
+SET project_id = $2, parent_todo_id = $3, title = $4, description = $5, assigned_date = $6, duration_min = $7, priority = $8
 WHERE todo_id = $1
-RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, due_date, work_start_time, work_end_time, priority, created_at, updated_at, completed_at
+RETURNING todo_id, user_id, project_id, parent_todo_id, title, description, is_completed, assigned_date, duration_min, priority, created_at, updated_at, completed_at, recurrence_rule, recurrence_mode, recurrence_start, recurrence_count, deadline, rollover_count, last_rolled_over_at, status_id, position, section_id
 `
 
 type UpdateTodoParams struct {
-	TodoID        int32              `json:"todoId"`
-	ProjectID     pgtype.Int4        `json:"projectId"`
-	ParentTodoID  pgtype.Int4        `json:"parentTodoId"`
-	Title         string             `json:"title"`
-	Description   pgtype.Text        `json:"description"`
-	DueDate       pgtype.Timestamptz `json:"dueDate"`
-	WorkStartTime pgtype.Timestamptz `json:"workStartTime"`
-	WorkEndTime   pgtype.Timestamptz `json:"workEndTime"`
-	Priority      pgtype.Int4        `json:"priority"`
+	TodoID       int32              `json:"todoId"`
+	ProjectID    pgtype.Int4        `json:"projectId"`
+	ParentTodoID pgtype.Int4        `json:"parentTodoId"`
+	Title        string             `json:"title"`
+	Description  pgtype.Text        `json:"description"`
+	AssignedDate pgtype.Timestamptz `json:"assignedDate"`
+	DurationMin  pgtype.Int4        `json:"durationMin"`
+	Priority     pgtype.Int4        `json:"priority"`
 }
 
 func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, error) {
@@ -397,9 +1368,8 @@ func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, e
 		arg.ParentTodoID,
 		arg.Title,
 		arg.Description,
-		arg.DueDate,
-		arg.WorkStartTime,
-		arg.WorkEndTime,
+		arg.AssignedDate,
+		arg.DurationMin,
 		arg.Priority,
 	)
 	var i Todo
@@ -411,13 +1381,22 @@ func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, e
 		&i.Title,
 		&i.Description,
 		&i.IsCompleted,
-		&i.DueDate,
-		&i.WorkStartTime,
-		&i.WorkEndTime,
+		&i.AssignedDate,
+		&i.DurationMin,
 		&i.Priority,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.RecurrenceRule,
+		&i.RecurrenceMode,
+		&i.RecurrenceStart,
+		&i.RecurrenceCount,
+		&i.Deadline,
+		&i.RolloverCount,
+		&i.LastRolledOverAt,
+		&i.StatusID,
+		&i.Position,
+		&i.SectionID,
 	)
 	return i, err
 }
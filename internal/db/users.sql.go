@@ -14,7 +14,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password_hash, google_id, profile_picture_url)
 VALUES ($1, $2, $3, $4)
-RETURNING user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at
+RETURNING user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at, timezone
 `
 
 type CreateUserParams struct {
@@ -40,6 +40,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.ProfilePictureUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
 	)
 	return i, err
 }
@@ -55,7 +56,7 @@ func (q *Queries) DeleteUser(ctx context.Context, userID int32) error {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at FROM users
+SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at, timezone FROM users
 WHERE user_id = $1
 `
 
@@ -70,12 +71,13 @@ func (q *Queries) GetUser(ctx context.Context, userID int32) (User, error) {
 		&i.ProfilePictureUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at FROM users
+SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at, timezone FROM users
 WHERE email = $1
 `
 
@@ -90,12 +92,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.ProfilePictureUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getUserByGoogleID = `-- name: GetUserByGoogleID :one
-SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at FROM users
+SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at, timezone FROM users
 WHERE google_id = $1
 `
 
@@ -110,12 +113,13 @@ func (q *Queries) GetUserByGoogleID(ctx context.Context, googleID pgtype.Text) (
 		&i.ProfilePictureUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at FROM users
+SELECT user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at, timezone FROM users
 ORDER BY created_at DESC
 `
 
@@ -136,6 +140,7 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.ProfilePictureUrl,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Timezone,
 		); err != nil {
 			return nil, err
 		}
@@ -147,11 +152,22 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const lockUser = `-- name: LockUser :exec
+SELECT user_id FROM users
+WHERE user_id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUser(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, lockUser, userID)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET email = $2, password_hash = $3, google_id = $4, profile_picture_url = $5
 WHERE user_id = $1
-RETURNING user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at
+RETURNING user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at, timezone
 `
 
 type UpdateUserParams struct {
@@ -179,6 +195,35 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.ProfilePictureUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const updateUserTimezone = `-- name: UpdateUserTimezone :one
+UPDATE users
+SET timezone = $2
+WHERE user_id = $1
+RETURNING user_id, email, password_hash, google_id, profile_picture_url, created_at, updated_at, timezone
+`
+
+type UpdateUserTimezoneParams struct {
+	UserID   int32  `json:"userId"`
+	Timezone string `json:"timezone"`
+}
+
+func (q *Queries) UpdateUserTimezone(ctx context.Context, arg UpdateUserTimezoneParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserTimezone, arg.UserID, arg.Timezone)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.GoogleID,
+		&i.ProfilePictureUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
 	)
 	return i, err
 }
@@ -0,0 +1,193 @@
+// Package depgraph works out the order todos that depend on each other can
+// be done in, and which of them decide when the whole set is finished.
+package depgraph
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrCycle is returned when tasks depend, directly or not, on themselves
+var ErrCycle = errors.New("dependencies form a cycle")
+
+// Task is a piece of work that can start once the tasks it depends on are
+// finished
+type Task struct {
+	ID       int32
+	Duration time.Duration
+	// DependsOn are the tasks that must be finished first. Tasks that aren't
+	// part of the graph are treated as already finished.
+	DependsOn []int32
+}
+
+// Timing is when a task can be done, as offsets from when work starts
+type Timing struct {
+	ID             int32
+	EarliestStart  time.Duration
+	EarliestFinish time.Duration
+	// Slack is how long the task can slip without delaying the finish of the
+	// whole graph
+	Slack time.Duration
+	// Critical is true when the task has no slack
+	Critical bool
+}
+
+// Result is the schedule of a graph with no limit on how many tasks run at
+// once
+type Result struct {
+	// Timings are in an order where each task comes after the ones it
+	// depends on
+	Timings []Timing
+	// CriticalPath is the chain of tasks, first to last, that decides when
+	// the graph is finished
+	CriticalPath []int32
+	// Length is when the last task finishes
+	Length time.Duration
+}
+
+// CriticalPath schedules tasks at their earliest and finds the longest chain
+// of dependencies through them
+func CriticalPath(tasks []Task) (*Result, error) {
+	order, err := topoSort(tasks)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int32]*Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	timings := make(map[int32]*Timing, len(order))
+	result := &Result{Timings: make([]Timing, 0, len(order))}
+	for _, id := range order {
+		task := byID[id]
+		timing := &Timing{ID: id}
+		for _, dep := range task.DependsOn {
+			if before, ok := timings[dep]; ok && before.EarliestFinish > timing.EarliestStart {
+				timing.EarliestStart = before.EarliestFinish
+			}
+		}
+		timing.EarliestFinish = timing.EarliestStart + task.Duration
+		if timing.EarliestFinish > result.Length {
+			result.Length = timing.EarliestFinish
+		}
+		timings[id] = timing
+	}
+
+	// Walking back from the end, a task must finish before the latest start
+	// of every task waiting on it
+	latestFinish := make(map[int32]time.Duration, len(order))
+	for _, id := range order {
+		latestFinish[id] = result.Length
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		task := byID[order[i]]
+		latestStart := latestFinish[task.ID] - task.Duration
+		timing := timings[task.ID]
+		timing.Slack = latestStart - timing.EarliestStart
+		timing.Critical = timing.Slack == 0
+		for _, dep := range task.DependsOn {
+			if finish, ok := latestFinish[dep]; ok && latestStart < finish {
+				latestFinish[dep] = latestStart
+			}
+		}
+	}
+	for _, id := range order {
+		result.Timings = append(result.Timings, *timings[id])
+	}
+
+	result.CriticalPath = criticalChain(order, byID, timings, result.Length)
+	return result, nil
+}
+
+// criticalChain follows critical tasks back from one that finishes last to
+// one that starts straight away
+func criticalChain(order []int32, byID map[int32]*Task, timings map[int32]*Timing, length time.Duration) []int32 {
+	// The latest in order comes after any task that takes no time, like a
+	// milestone, finishing at the same moment
+	var last *Timing
+	for i := len(order) - 1; i >= 0; i-- {
+		if t := timings[order[i]]; t.Critical && t.EarliestFinish == length {
+			last = t
+			break
+		}
+	}
+	var chain []int32
+	for last != nil {
+		chain = append(chain, last.ID)
+		var prev *Timing
+		for _, dep := range sortedIDs(byID[last.ID].DependsOn) {
+			if t, ok := timings[dep]; ok && t.Critical && t.EarliestFinish == last.EarliestStart {
+				prev = t
+				break
+			}
+		}
+		last = prev
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// topoSort orders tasks so each comes after the ones it depends on, lowest
+// ID first among those that are ready
+func topoSort(tasks []Task) ([]int32, error) {
+	waiting := make(map[int32]int, len(tasks))
+	dependents := make(map[int32][]int32)
+	for _, task := range tasks {
+		waiting[task.ID] = 0
+	}
+	for _, task := range tasks {
+		for _, dep := range uniqueIDs(task.DependsOn) {
+			if _, ok := waiting[dep]; !ok || dep == task.ID {
+				continue
+			}
+			waiting[task.ID]++
+			dependents[dep] = append(dependents[dep], task.ID)
+		}
+	}
+
+	var ready []int32
+	for id, n := range waiting {
+		if n == 0 {
+			ready = append(ready, id)
+		}
+	}
+	order := make([]int32, 0, len(waiting))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, next := range dependents[id] {
+			waiting[next]--
+			if waiting[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	if len(order) < len(waiting) {
+		return nil, ErrCycle
+	}
+	return order, nil
+}
+
+func uniqueIDs(ids []int32) []int32 {
+	seen := make(map[int32]bool, len(ids))
+	unique := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func sortedIDs(ids []int32) []int32 {
+	sorted := append([]int32(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
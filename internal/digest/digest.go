@@ -0,0 +1,148 @@
+// Package digest builds and sends the emailed daily agenda and weekly review.
+package digest
+
+import (
+	"context"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	KindDaily  = "daily"
+	KindWeekly = "weekly"
+)
+
+// maxItems caps each section so a long backlog doesn't make a huge email
+const maxItems = 50
+
+// IsKind reports whether kind is a known digest
+func IsKind(kind string) bool {
+	return kind == KindDaily || kind == KindWeekly
+}
+
+// Item is a todo as listed in a digest
+type Item struct {
+	ID       int32
+	Title    string
+	When     string
+	Priority int32
+}
+
+// Digest is everything a digest email shows. The daily agenda fills Today and
+// Overdue; the weekly review fills Completed, Slipped and Upcoming.
+type Digest struct {
+	Kind     string
+	Date     time.Time
+	Timezone string
+
+	Today   []Item
+	Overdue []Item
+
+	Completed []Item
+	Slipped   []Item
+	Upcoming  []Item
+
+	UnsubscribeURL string
+}
+
+// Empty reports whether there is nothing worth sending
+func (d *Digest) Empty() bool {
+	return len(d.Today)+len(d.Overdue)+len(d.Completed)+len(d.Slipped)+len(d.Upcoming) == 0
+}
+
+// Build gathers a user's digest as of now, using days in loc
+func Build(ctx context.Context, querier db.Querier, userID int32, kind string, now time.Time, loc *time.Location) (*Digest, error) {
+	now = now.In(loc)
+	today := startOfDay(now, 0)
+	d := &Digest{Kind: kind, Date: today, Timezone: loc.String()}
+
+	var err error
+	switch kind {
+	case KindDaily:
+		if d.Today, err = openBetween(ctx, querier, userID, today, startOfDay(now, 1), loc); err != nil {
+			return nil, err
+		}
+		overdue, err := querier.ListOpenTodosBefore(ctx, db.ListOpenTodosBeforeParams{
+			UserID:     userID,
+			EndsAt:     pgtype.Timestamptz{Time: today, Valid: true},
+			MaxResults: maxItems,
+		})
+		if err != nil {
+			return nil, err
+		}
+		d.Overdue = items(overdue, loc)
+	case KindWeekly:
+		weekAgo := startOfDay(now, -7)
+		completed, err := querier.ListTodosCompletedBetween(ctx, db.ListTodosCompletedBetweenParams{
+			UserID:     userID,
+			StartsAt:   pgtype.Timestamptz{Time: weekAgo, Valid: true},
+			EndsAt:     pgtype.Timestamptz{Time: now, Valid: true},
+			MaxResults: maxItems,
+		})
+		if err != nil {
+			return nil, err
+		}
+		d.Completed = items(completed, loc)
+		// Slipped are last week's todos that are still open
+		if d.Slipped, err = openBetween(ctx, querier, userID, weekAgo, today, loc); err != nil {
+			return nil, err
+		}
+		if d.Upcoming, err = openBetween(ctx, querier, userID, today, startOfDay(now, 7), loc); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func openBetween(ctx context.Context, querier db.Querier, userID int32, start time.Time, end time.Time, loc *time.Location) ([]Item, error) {
+	todos, err := querier.ListOpenTodosBetween(ctx, db.ListOpenTodosBetweenParams{
+		UserID:     userID,
+		StartsAt:   pgtype.Timestamptz{Time: start, Valid: true},
+		EndsAt:     pgtype.Timestamptz{Time: end, Valid: true},
+		MaxResults: maxItems,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items(todos, loc), nil
+}
+
+func items(todos []db.Todo, loc *time.Location) []Item {
+	list := make([]Item, len(todos))
+	for i, todo := range todos {
+		list[i] = Item{ID: todo.TodoID, Title: todo.Title, Priority: todo.Priority.Int32}
+		if todo.AssignedDate.Valid {
+			assigned := todo.AssignedDate.Time.In(loc)
+			// Todos at local midnight are for the whole day
+			if assigned.Equal(startOfDay(assigned, 0)) {
+				list[i].When = assigned.Format("Mon Jan 2")
+			} else {
+				list[i].When = assigned.Format("Mon Jan 2, 3:04 PM")
+			}
+		}
+	}
+	return list
+}
+
+// startOfDay returns local midnight offset days from the day t falls on
+func startOfDay(t time.Time, offset int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, t.Location())
+}
+
+// NextSend returns when a digest sent at hour, and for the weekly digest on
+// weekday, in loc is next due after t
+func NextSend(kind string, hour int, weekday time.Weekday, loc *time.Location, t time.Time) time.Time {
+	local := t.In(loc)
+	for offset := 0; ; offset++ {
+		day := startOfDay(local, offset)
+		if kind == KindWeekly && day.Weekday() != weekday {
+			continue
+		}
+		send := time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, loc)
+		if send.After(t) {
+			return send
+		}
+	}
+}
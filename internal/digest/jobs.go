@@ -0,0 +1,186 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/jobs"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/mailer"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	dispatchBatchSize = 500
+	// maxLateness is how late a digest can go out; a morning agenda that
+	// would arrive in the afternoon is skipped instead
+	maxLateness = 6 * time.Hour
+)
+
+// Dispatch is a scheduled job that queues a Send for every digest that is due
+type Dispatch struct{}
+
+func (Dispatch) Kind() string { return "digests.dispatch" }
+
+// Send is a job that builds and emails one digest
+type Send struct {
+	UserID int32  `json:"user_id"`
+	Digest string `json:"digest"`
+}
+
+func (Send) Kind() string { return "digests.send" }
+
+// Sender builds and emails digests
+type Sender struct {
+	pool      *pgxpool.Pool
+	querier   db.Querier
+	jobs      *jobs.Client
+	mailer    mailer.Mailer
+	secret    string
+	publicURL string
+	logger    logger.Logger
+}
+
+// NewSender returns a Sender. secret signs unsubscribe links, which point at
+// publicURL.
+func NewSender(pool *pgxpool.Pool, mailer mailer.Mailer, secret string, publicURL string, logger logger.Logger) *Sender {
+	querier := db.New(pool)
+	return &Sender{
+		pool:      pool,
+		querier:   querier,
+		jobs:      jobs.NewClient(querier),
+		mailer:    mailer,
+		secret:    secret,
+		publicURL: publicURL,
+		logger:    logger,
+	}
+}
+
+// Register adds the digest jobs to p and schedules the dispatcher
+func (s *Sender) Register(p *jobs.WorkerPool) error {
+	jobs.Handle(p, func(ctx context.Context, job *jobs.Job, args Dispatch) error {
+		return s.dispatch(ctx, time.Now())
+	})
+	jobs.Handle(p, func(ctx context.Context, job *jobs.Job, args Send) error {
+		return s.send(ctx, args.UserID, args.Digest, time.Now())
+	})
+	return p.Schedule("digests.dispatch", "*/5 * * * *", Dispatch{})
+}
+
+// UnsubscribeURL is the link in a digest that turns it off
+func UnsubscribeURL(publicURL string, secret string, userID int32, kind string) string {
+	return strings.TrimRight(publicURL, "/") + "/api/digests/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(secret, userID, kind))
+}
+
+// Preview builds and renders a user's digest as of now without sending it.
+// The message is rendered even when the digest is empty.
+func Preview(ctx context.Context, querier db.Querier, userID int32, kind string, now time.Time, unsubscribeURL string) (*mailer.Message, error) {
+	user, err := querier.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := Build(ctx, querier, userID, kind, now, userLocation(user.Timezone))
+	if err != nil {
+		return nil, err
+	}
+	d.UnsubscribeURL = unsubscribeURL
+	msg, err := Render(d)
+	if err != nil {
+		return nil, err
+	}
+	msg.To = user.Email
+	return msg, nil
+}
+
+// dispatch claims the digests that are due, queues them and moves each on
+// to its next send time, all in one transaction so a digest is queued once
+// however many workers run it
+func (s *Sender) dispatch(ctx context.Context, now time.Time) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	ctx = db.ContextWithTx(ctx, tx)
+	querier := db.New(tx)
+	due, err := querier.ClaimDueDigestSubscriptions(ctx, dispatchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range due {
+		loc := userLocation(sub.Timezone)
+		if now.Sub(sub.NextSendAt.Time) <= maxLateness {
+			_, err := s.jobs.Enqueue(ctx, Send{UserID: sub.UserID, Digest: sub.Kind}, &jobs.EnqueueOptions{
+				UniqueKey: "digest:" + strconv.Itoa(int(sub.UserID)) + ":" + sub.Kind,
+				// A digest is only worth a few tries before it's stale
+				MaxAttempts: 5,
+			})
+			if err != nil && !errors.Is(err, jobs.ErrDuplicate) {
+				return err
+			}
+		}
+
+		next := NextSend(sub.Kind, int(sub.SendHour), time.Weekday(sub.SendWeekday), loc, now)
+		err = querier.SetDigestNextSend(ctx, db.SetDigestNextSendParams{
+			UserID:     sub.UserID,
+			Kind:       sub.Kind,
+			NextSendAt: pgtype.Timestamptz{Time: next, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Sender) send(ctx context.Context, userID int32, kind string, now time.Time) error {
+	sub, err := s.querier.GetDigestSubscription(ctx, db.GetDigestSubscriptionParams{UserID: userID, Kind: kind})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// The user may have unsubscribed since this was queued
+	if !sub.Enabled {
+		return nil
+	}
+
+	user, err := s.querier.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	d, err := Build(ctx, s.querier, userID, kind, now, userLocation(user.Timezone))
+	if err != nil {
+		return err
+	}
+	if d.Empty() {
+		s.logger.Debug("Skipping empty digest", "user_id", userID, "kind", kind)
+		return nil
+	}
+	d.UnsubscribeURL = UnsubscribeURL(s.publicURL, s.secret, userID, kind)
+
+	msg, err := Render(d)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	msg.To = user.Email
+	return s.mailer.Send(ctx, msg)
+}
+
+func userLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,64 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"github.com/boetro/odot/internal/mailer"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// section is what the shared "section" template renders
+type section struct {
+	Heading string
+	Items   []Item
+}
+
+func newSection(heading string, items []Item) section {
+	return section{Heading: heading, Items: items}
+}
+
+var (
+	htmlTemplates = map[string]*htmltemplate.Template{}
+	textTemplates = map[string]*texttemplate.Template{}
+)
+
+func init() {
+	for _, kind := range []string{KindDaily, KindWeekly} {
+		htmlTemplates[kind] = htmltemplate.Must(htmltemplate.New("").
+			Funcs(htmltemplate.FuncMap{"section": newSection}).
+			ParseFS(templateFS, "templates/layout.html.tmpl", "templates/"+kind+".html.tmpl"))
+		textTemplates[kind] = texttemplate.Must(texttemplate.New("").
+			Funcs(texttemplate.FuncMap{"section": newSection}).
+			ParseFS(templateFS, "templates/layout.txt.tmpl", "templates/"+kind+".txt.tmpl"))
+	}
+}
+
+// Render turns a digest into an email. The caller fills in the recipient.
+func Render(d *Digest) (*mailer.Message, error) {
+	var html, text bytes.Buffer
+	if err := htmlTemplates[d.Kind].ExecuteTemplate(&html, "layout", d); err != nil {
+		return nil, err
+	}
+	if err := textTemplates[d.Kind].ExecuteTemplate(&text, "layout", d); err != nil {
+		return nil, err
+	}
+
+	subject := "Your agenda for " + d.Date.Format("Monday, Jan 2")
+	if d.Kind == KindWeekly {
+		subject = "Your week in review, " + d.Date.Format("Jan 2")
+	}
+	return &mailer.Message{
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + d.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
@@ -0,0 +1,58 @@
+package digest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidToken is returned for unsubscribe tokens that weren't issued by
+// this server
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// UnsubscribeToken returns a token that turns off one kind of digest for a
+// user without logging in. It doesn't expire, as links in old emails should
+// keep working.
+func UnsubscribeToken(secret string, userID int32, kind string) string {
+	payload := strconv.Itoa(int(userID)) + ":" + kind
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(secret, payload))
+}
+
+// ParseUnsubscribeToken verifies a token and returns who and what it
+// unsubscribes
+func ParseUnsubscribeToken(secret string, token string) (int32, string, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, sign(secret, string(payload))) {
+		return 0, "", ErrInvalidToken
+	}
+
+	userText, kind, ok := strings.Cut(string(payload), ":")
+	if !ok || !IsKind(kind) {
+		return 0, "", ErrInvalidToken
+	}
+	userID, err := strconv.ParseInt(userText, 10, 32)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+	return int32(userID), kind, nil
+}
+
+// sign is an HMAC of the payload. The prefix keeps these signatures from
+// being valid for anything else signed with the same secret.
+func sign(secret string, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("digest-unsubscribe:" + payload))
+	return mac.Sum(nil)
+}
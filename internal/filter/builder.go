@@ -0,0 +1,52 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder collects positional arguments while SQL conditions are assembled so
+// callers never interpolate user input into a query.
+type Builder struct {
+	args       []any
+	conditions []string
+}
+
+// NewBuilder creates a builder whose placeholders start after the given
+// arguments, which are typically the ones a base query already uses.
+func NewBuilder(args ...any) *Builder {
+	return &Builder{args: args}
+}
+
+// Arg registers a value and returns its placeholder
+func (b *Builder) Arg(value any) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// Where adds a condition that is ANDed with the others
+func (b *Builder) Where(format string, values ...any) {
+	placeholders := make([]any, len(values))
+	for i, value := range values {
+		placeholders[i] = b.Arg(value)
+	}
+	b.conditions = append(b.conditions, fmt.Sprintf(format, placeholders...))
+}
+
+// WhereSQL adds a condition that has already been rendered with this builder
+func (b *Builder) WhereSQL(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// Conditions returns all conditions joined with AND, or TRUE when there are none
+func (b *Builder) Conditions() string {
+	if len(b.conditions) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(b.conditions, " AND ")
+}
+
+// Args returns the positional arguments in placeholder order
+func (b *Builder) Args() []any {
+	return b.args
+}
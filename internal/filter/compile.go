@@ -0,0 +1,197 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Options supplies the context an expression is evaluated in
+type Options struct {
+	UserID int32
+	// Now and Location determine what today means
+	Now      time.Time
+	Location *time.Location
+}
+
+// Apply compiles the expression into a condition over todos aliased as "t"
+// and adds it to the builder
+func (e *Expr) Apply(b *Builder, opts Options) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	c := &compiler{b: b, opts: opts}
+	b.WhereSQL(e.root.compile(c))
+}
+
+type compiler struct {
+	b    *Builder
+	opts Options
+}
+
+// startOfDay returns midnight of the given day in the user's timezone. Going
+// through time.Date keeps this correct across DST transitions.
+func (c *compiler) startOfDay(d day) time.Time {
+	if d.date != "" {
+		t, _ := time.ParseInLocation(time.DateOnly, d.date, c.opts.Location)
+		return t
+	}
+	now := c.opts.Now.In(c.opts.Location)
+	return time.Date(now.Year(), now.Month(), now.Day()+d.relative, 0, 0, 0, 0, c.opts.Location)
+}
+
+func (c *compiler) addDays(t time.Time, days int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, c.opts.Location)
+}
+
+// Every node compiles to a condition that is never NULL, so negation behaves
+// as users expect for todos with missing values.
+type node interface {
+	compile(c *compiler) string
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) compile(c *compiler) string {
+	return "(" + n.left.compile(c) + " AND " + n.right.compile(c) + ")"
+}
+
+type orNode struct{ left, right node }
+
+func (n *orNode) compile(c *compiler) string {
+	return "(" + n.left.compile(c) + " OR " + n.right.compile(c) + ")"
+}
+
+type notNode struct{ inner node }
+
+func (n *notNode) compile(c *compiler) string {
+	return "NOT " + n.inner.compile(c)
+}
+
+type projectNode struct {
+	name        string
+	subprojects bool
+}
+
+func (n *projectNode) compile(c *compiler) string {
+	user, name := c.b.Arg(c.opts.UserID), c.b.Arg(n.name)
+	if !n.subprojects {
+		return fmt.Sprintf("COALESCE(t.project_id IN (SELECT project_id FROM projects WHERE user_id = %s AND lower(name) = lower(%s)), FALSE)", user, name)
+	}
+	return fmt.Sprintf("COALESCE(t.project_id IN ("+
+		"WITH RECURSIVE tree AS ("+
+		"SELECT project_id FROM projects WHERE user_id = %s AND lower(name) = lower(%s) "+
+		"UNION SELECT p.project_id FROM projects p JOIN tree ON p.parent_project_id = tree.project_id"+
+		") SELECT project_id FROM tree), FALSE)", user, name)
+}
+
+type tagNode struct{ name string }
+
+func (n *tagNode) compile(c *compiler) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM todo_tags tt JOIN tags tg ON tg.tag_id = tt.tag_id "+
+		"WHERE tt.todo_id = t.todo_id AND tg.user_id = t.user_id AND lower(tg.name) = lower(%s))", c.b.Arg(n.name))
+}
+
+type priorityNode struct{ priority int32 }
+
+func (n *priorityNode) compile(c *compiler) string {
+	return fmt.Sprintf("COALESCE(t.priority, 0) = %s", c.b.Arg(n.priority))
+}
+
+type dateField int
+
+const (
+	dateAssigned dateField = iota
+	dateCompleted
+)
+
+type dateComparison int
+
+const (
+	dateOn dateComparison = iota
+	dateBefore
+	dateAfter
+)
+
+type dateNode struct {
+	field dateField
+	cmp   dateComparison
+	day   day
+}
+
+func (n *dateNode) compile(c *compiler) string {
+	column := "t.assigned_date"
+	if n.field == dateCompleted {
+		column = "t.completed_at"
+	}
+	start := c.startOfDay(n.day)
+
+	switch n.cmp {
+	case dateBefore:
+		return fmt.Sprintf("COALESCE(%s < %s, FALSE)", column, c.b.Arg(start))
+	case dateAfter:
+		return fmt.Sprintf("COALESCE(%s >= %s, FALSE)", column, c.b.Arg(c.addDays(start, 1)))
+	}
+	return rangeCondition(c, column, start, c.addDays(start, 1))
+}
+
+func rangeCondition(c *compiler, column string, from time.Time, to time.Time) string {
+	return fmt.Sprintf("COALESCE(%s >= %s AND %s < %s, FALSE)", column, c.b.Arg(from), column, c.b.Arg(to))
+}
+
+type nextDaysNode struct{ days int }
+
+func (n *nextDaysNode) compile(c *compiler) string {
+	today := c.startOfDay(day{})
+	return rangeCondition(c, "t.assigned_date", today, c.addDays(today, n.days))
+}
+
+type overdueNode struct{}
+
+func (n *overdueNode) compile(c *compiler) string {
+	return fmt.Sprintf("(COALESCE(t.assigned_date < %s, FALSE) AND NOT COALESCE(t.is_completed, FALSE))", c.b.Arg(c.startOfDay(day{})))
+}
+
+type noDateNode struct{}
+
+func (n *noDateNode) compile(c *compiler) string {
+	return "t.assigned_date IS NULL"
+}
+
+type completedNode struct{}
+
+func (n *completedNode) compile(c *compiler) string {
+	return "COALESCE(t.is_completed, FALSE)"
+}
+
+type searchNode struct{ text string }
+
+func (n *searchNode) compile(c *compiler) string {
+	pattern := c.b.Arg("%" + escapeLike(n.text) + "%")
+	return fmt.Sprintf("(t.title ILIKE %s OR COALESCE(t.description ILIKE %s, FALSE))", pattern, pattern)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+type subtaskNode struct{}
+
+func (n *subtaskNode) compile(c *compiler) string {
+	return "t.parent_todo_id IS NOT NULL"
+}
+
+type parentNode struct{}
+
+func (n *parentNode) compile(c *compiler) string {
+	return "EXISTS (SELECT 1 FROM todos child WHERE child.parent_todo_id = t.todo_id)"
+}
+
+type noProjectNode struct{}
+
+func (n *noProjectNode) compile(c *compiler) string {
+	return "t.project_id IS NULL"
+}
@@ -0,0 +1,354 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Error describes an invalid filter expression. Pos and End are byte offsets
+// into the expression marking the offending text.
+type Error struct {
+	Pos int
+	End int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Expr is a parsed filter expression such as
+//
+//	(today | overdue) & #work & !@waiting & p1
+//
+// Terms are combined with & (and), | (or), ! (not) and parentheses; & binds
+// tighter than |. Supported terms:
+//
+//	#name, ##name          project, or project and its subprojects
+//	@name                  tag
+//	p1 .. p4, no priority  priority
+//	today, tomorrow, yesterday, overdue, no date, next N days
+//	date: D, date before: D, date after: D   (also assigned: ...)
+//	completed, completed: D, completed before: D, completed after: D
+//	search: text           title or description contains text
+//	subtask, parent        has a parent todo, has subtasks
+//	no project             todos in the inbox
+//
+// where D is YYYY-MM-DD, today, tomorrow or yesterday.
+type Expr struct {
+	source string
+	root   node
+}
+
+// String returns the expression as it was written
+func (e *Expr) String() string {
+	return e.source
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+	tokenTerm
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	end  int
+}
+
+func lex(src string) []token {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '&':
+			tokens = append(tokens, token{tokenAnd, "&", i, i + 1})
+			i++
+		case ch == '|':
+			tokens = append(tokens, token{tokenOr, "|", i, i + 1})
+			i++
+		case ch == '!':
+			tokens = append(tokens, token{tokenNot, "!", i, i + 1})
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i, i + 1})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i, i + 1})
+			i++
+		default:
+			// A term runs until the next operator; backslash escapes one
+			start := i
+			var b strings.Builder
+			for i < len(src) && !strings.ContainsRune("&|!()", rune(src[i])) {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			text := strings.TrimRightFunc(b.String(), unicode.IsSpace)
+			end := start + len(strings.TrimRightFunc(src[start:i], unicode.IsSpace))
+			tokens = append(tokens, token{tokenTerm, text, start, end})
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(src), len(src)})
+}
+
+// maxDepth is how deeply parentheses and ! may nest
+const maxDepth = 32
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// enter descends one nesting level at tok, failing past maxDepth
+func (p *parser) enter(tok token) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return &Error{Pos: tok.pos, End: tok.end, Msg: "filter is nested too deeply"}
+	}
+	return nil
+}
+
+// Parse parses a filter expression
+func Parse(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, &Error{Pos: 0, End: len(src), Msg: "empty filter"}
+	}
+
+	p := &parser{tokens: lex(src)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &Error{Pos: tok.pos, End: tok.end, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+	return &Expr{source: src, root: root}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokenNot {
+		if err := p.enter(p.next()); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		p.depth--
+		return &notNode{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &Error{Pos: tok.pos, End: tok.end, Msg: "unclosed parenthesis"}
+		}
+		p.depth--
+		return inner, nil
+	case tokenTerm:
+		return parseTerm(tok)
+	case tokenEOF:
+		return nil, &Error{Pos: tok.pos, End: tok.end, Msg: "unexpected end of filter"}
+	}
+	return nil, &Error{Pos: tok.pos, End: tok.end, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+}
+
+var (
+	priorityPattern = regexp.MustCompile(`^p([1-4])$`)
+	nextDaysPattern = regexp.MustCompile(`^next (\d+) days?$`)
+	datePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+func parseTerm(tok token) (node, error) {
+	text := tok.text
+	fail := func(msg string) (node, error) {
+		return nil, &Error{Pos: tok.pos, End: tok.end, Msg: msg}
+	}
+
+	switch {
+	case strings.HasPrefix(text, "##"):
+		name := strings.TrimSpace(text[2:])
+		if name == "" {
+			return fail("missing project name")
+		}
+		return &projectNode{name: name, subprojects: true}, nil
+	case strings.HasPrefix(text, "#"):
+		name := strings.TrimSpace(text[1:])
+		if name == "" {
+			return fail("missing project name")
+		}
+		return &projectNode{name: name}, nil
+	case strings.HasPrefix(text, "@"):
+		name := strings.TrimSpace(text[1:])
+		if name == "" {
+			return fail("missing tag name")
+		}
+		return &tagNode{name: name}, nil
+	}
+
+	// Everything else is a keyword, optionally followed by ": value"
+	keyword, value, hasValue := strings.Cut(text, ":")
+	keyword = strings.Join(strings.Fields(strings.ToLower(keyword)), " ")
+	value = strings.TrimSpace(value)
+
+	if hasValue {
+		switch keyword {
+		case "search":
+			if value == "" {
+				return fail("missing search text")
+			}
+			return &searchNode{text: value}, nil
+		case "date", "assigned", "date before", "assigned before", "date after", "assigned after",
+			"completed", "completed before", "completed after":
+			day, ok := parseDay(value)
+			if !ok {
+				return fail(fmt.Sprintf("invalid date %q, expected YYYY-MM-DD, today, tomorrow or yesterday", value))
+			}
+			field := dateAssigned
+			if strings.HasPrefix(keyword, "completed") {
+				field = dateCompleted
+			}
+			cmp := dateOn
+			if strings.HasSuffix(keyword, "before") {
+				cmp = dateBefore
+			} else if strings.HasSuffix(keyword, "after") {
+				cmp = dateAfter
+			}
+			return &dateNode{field: field, cmp: cmp, day: day}, nil
+		}
+		return fail(fmt.Sprintf("unknown filter %q", keyword))
+	}
+
+	switch keyword {
+	case "today", "tomorrow", "yesterday":
+		day, _ := parseDay(keyword)
+		return &dateNode{field: dateAssigned, cmp: dateOn, day: day}, nil
+	case "overdue":
+		return &overdueNode{}, nil
+	case "no date":
+		return &noDateNode{}, nil
+	case "no priority":
+		return &priorityNode{priority: 0}, nil
+	case "completed":
+		return &completedNode{}, nil
+	case "subtask":
+		return &subtaskNode{}, nil
+	case "parent":
+		return &parentNode{}, nil
+	case "no project":
+		return &noProjectNode{}, nil
+	}
+
+	if m := priorityPattern.FindStringSubmatch(keyword); m != nil {
+		// p1 is the most urgent, stored as 4
+		priority, _ := strconv.Atoi(m[1])
+		return &priorityNode{priority: int32(5 - priority)}, nil
+	}
+	if m := nextDaysPattern.FindStringSubmatch(keyword); m != nil {
+		days, err := strconv.Atoi(m[1])
+		if err != nil || days < 1 || days > 366 {
+			return fail("next N days must be between 1 and 366")
+		}
+		return &nextDaysNode{days: days}, nil
+	}
+
+	return fail(fmt.Sprintf("unknown filter %q", keyword))
+}
+
+// day is either an absolute date or an offset in days from today
+type day struct {
+	date     string
+	relative int
+}
+
+func parseDay(value string) (day, bool) {
+	switch strings.ToLower(value) {
+	case "today":
+		return day{relative: 0}, true
+	case "tomorrow":
+		return day{relative: 1}, true
+	case "yesterday":
+		return day{relative: -1}, true
+	}
+	if datePattern.MatchString(value) {
+		if _, err := time.Parse(time.DateOnly, value); err == nil {
+			return day{date: value}, true
+		}
+	}
+	return day{}, false
+}
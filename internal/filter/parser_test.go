@@ -0,0 +1,152 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		pos, end int
+		msg      string
+	}{
+		{"", 0, 0, "empty filter"},
+		{"   ", 0, 3, "empty filter"},
+		{"today &", 7, 7, "unexpected end of filter"},
+		{"today & | overdue", 8, 9, `unexpected "|"`},
+		{"(today | overdue", 0, 1, "unclosed parenthesis"},
+		{"today)", 5, 6, `unexpected ")"`},
+		{"p5", 0, 2, `unknown filter "p5"`},
+		{"today & #  ", 8, 9, "missing project name"},
+		{"@ & today", 0, 1, "missing tag name"},
+		{"search:   ", 0, 7, "missing search text"},
+		{"today | date: 2024-02-30", 8, 24, `invalid date "2024-02-30", expected YYYY-MM-DD, today, tomorrow or yesterday`},
+		{"next 400 days", 0, 13, "next N days must be between 1 and 366"},
+		{"colour: red", 0, 11, `unknown filter "colour"`},
+		{strings.Repeat("!", maxDepth) + "!today", maxDepth, maxDepth + 1, "filter is nested too deeply"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			var parseErr *Error
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.src, err)
+			}
+			if parseErr.Pos != tt.pos || parseErr.End != tt.end || parseErr.Msg != tt.msg {
+				t.Errorf("Parse(%q) = %d-%d %q, want %d-%d %q",
+					tt.src, parseErr.Pos, parseErr.End, parseErr.Msg, tt.pos, tt.end, tt.msg)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		src  string
+		sql  string
+		args []any
+	}{
+		{
+			src:  "p1",
+			sql:  "COALESCE(t.priority, 0) = $2",
+			args: []any{int32(7), int32(4)},
+		},
+		{
+			src:  "p4 | no priority",
+			sql:  "(COALESCE(t.priority, 0) = $2 OR COALESCE(t.priority, 0) = $3)",
+			args: []any{int32(7), int32(1), int32(0)},
+		},
+		{
+			// & binds tighter than |
+			src:  "subtask | parent & !no project",
+			sql:  "(t.parent_todo_id IS NOT NULL OR (EXISTS (SELECT 1 FROM todos child WHERE child.parent_todo_id = t.todo_id) AND NOT t.project_id IS NULL))",
+			args: []any{int32(7)},
+		},
+		{
+			src:  `search: 100% \& done_`,
+			sql:  "(t.title ILIKE $2 OR COALESCE(t.description ILIKE $2, FALSE))",
+			args: []any{int32(7), `%100\% & done\_%`},
+		},
+		{
+			src:  "@Waiting",
+			sql:  "EXISTS (SELECT 1 FROM todo_tags tt JOIN tags tg ON tg.tag_id = tt.tag_id WHERE tt.todo_id = t.todo_id AND tg.user_id = t.user_id AND lower(tg.name) = lower($2))",
+			args: []any{int32(7), "Waiting"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			b := NewBuilder(int32(7))
+			expr.Apply(b, Options{UserID: 7})
+			if got := b.Conditions(); got != tt.sql {
+				t.Errorf("Conditions() = %s\nwant %s", got, tt.sql)
+			}
+			if got := b.Args(); !equalArgs(got, tt.args) {
+				t.Errorf("Args() = %v, want %v", got, tt.args)
+			}
+		})
+	}
+}
+
+func TestApplyDays(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, newYork)
+	}
+	// Clocks went forward at 02:00 on 2024-03-10, so that day is 23 hours long
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, newYork)
+
+	tests := []struct {
+		src  string
+		args []any
+	}{
+		{"today", []any{at(2024, 3, 10), at(2024, 3, 11)}},
+		{"yesterday", []any{at(2024, 3, 9), at(2024, 3, 10)}},
+		{"next 2 days", []any{at(2024, 3, 10), at(2024, 3, 12)}},
+		{"date before: tomorrow", []any{at(2024, 3, 11)}},
+		{"date after: 2024-11-02", []any{at(2024, 11, 3)}},
+		{"completed: 2024-11-03", []any{at(2024, 11, 3), at(2024, 11, 4)}},
+		{"overdue", []any{at(2024, 3, 10)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			b := NewBuilder()
+			expr.Apply(b, Options{Now: now, Location: newYork})
+			if got := b.Args(); !equalArgs(got, tt.args) {
+				t.Errorf("Args() = %v, want %v", got, tt.args)
+			}
+		})
+	}
+}
+
+func equalArgs(got []any, want []any) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if gt, ok := got[i].(time.Time); ok {
+			wt, ok := want[i].(time.Time)
+			if !ok || !gt.Equal(wt) {
+				return false
+			}
+			continue
+		}
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
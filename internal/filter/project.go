@@ -0,0 +1,25 @@
+package filter
+
+// ProjectFilter narrows down a user's projects. Nil fields are ignored.
+// Conditions reference the projects table through the alias "p".
+type ProjectFilter struct {
+	ParentProjectID *int32 `json:"parent_project_id" form:"parent_project_id"`
+	HasParent       *bool  `json:"has_parent" form:"has_parent"`
+}
+
+// Apply adds the filter's conditions to the builder
+func (f *ProjectFilter) Apply(b *Builder) {
+	if f == nil {
+		return
+	}
+	if f.ParentProjectID != nil {
+		b.Where("p.parent_project_id = %s", *f.ParentProjectID)
+	}
+	if f.HasParent != nil {
+		if *f.HasParent {
+			b.WhereSQL("p.parent_project_id IS NOT NULL")
+		} else {
+			b.WhereSQL("p.parent_project_id IS NULL")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package filter
+
+import "time"
+
+// TodoFilter narrows down a user's todos. Nil fields are ignored. Conditions
+// reference the todos table through the alias "t".
+type TodoFilter struct {
+	ProjectID       *int32     `json:"project_id" form:"project_id"`
+	TagID           *int32     `json:"tag_id" form:"tag_id"`
+	Completed       *bool      `json:"completed" form:"completed"`
+	HasParent       *bool      `json:"has_parent" form:"has_parent"`
+	AssignedAfter   *time.Time `json:"assigned_after" form:"assigned_after"`
+	AssignedBefore  *time.Time `json:"assigned_before" form:"assigned_before"`
+	CreatedAfter    *time.Time `json:"created_after" form:"created_after"`
+	CreatedBefore   *time.Time `json:"created_before" form:"created_before"`
+	CompletedAfter  *time.Time `json:"completed_after" form:"completed_after"`
+	CompletedBefore *time.Time `json:"completed_before" form:"completed_before"`
+	ParentTodoID    *int32     `json:"parent_todo_id" form:"parent_todo_id"`
+}
+
+// Apply adds the filter's conditions to the builder
+func (f *TodoFilter) Apply(b *Builder) {
+	if f == nil {
+		return
+	}
+	if f.ProjectID != nil {
+		b.Where("t.project_id = %s", *f.ProjectID)
+	}
+	if f.TagID != nil {
+		b.Where("EXISTS (SELECT 1 FROM todo_tags tt WHERE tt.todo_id = t.todo_id AND tt.tag_id = %s)", *f.TagID)
+	}
+	if f.Completed != nil {
+		b.Where("t.is_completed = %s", *f.Completed)
+	}
+	if f.HasParent != nil {
+		if *f.HasParent {
+			b.WhereSQL("t.parent_todo_id IS NOT NULL")
+		} else {
+			b.WhereSQL("t.parent_todo_id IS NULL")
+		}
+	}
+	if f.AssignedAfter != nil {
+		b.Where("t.assigned_date >= %s", *f.AssignedAfter)
+	}
+	if f.AssignedBefore != nil {
+		b.Where("t.assigned_date < %s", *f.AssignedBefore)
+	}
+	if f.CreatedAfter != nil {
+		b.Where("t.created_at >= %s", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		b.Where("t.created_at < %s", *f.CreatedBefore)
+	}
+	if f.CompletedAfter != nil {
+		b.Where("t.completed_at >= %s", *f.CompletedAfter)
+	}
+	if f.CompletedBefore != nil {
+		b.Where("t.completed_at < %s", *f.CompletedBefore)
+	}
+	if f.ParentTodoID != nil {
+		b.Where("t.parent_todo_id = %s", *f.ParentTodoID)
+	}
+}
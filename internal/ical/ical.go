@@ -0,0 +1,119 @@
+// Package ical writes iCalendar (RFC 5545) data.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is how long a content line can be before it's folded
+const maxLineOctets = 75
+
+// Component is a BEGIN/END block such as VCALENDAR or VTODO
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// Property is a content line. Params are written as is, e.g. "VALUE=DATE".
+type Property struct {
+	Name   string
+	Params []string
+	Value  string
+}
+
+// NewComponent returns an empty component called name
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Add appends a property whose value is already encoded
+func (c *Component) Add(name string, value string, params ...string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText appends a TEXT property, escaping its value
+func (c *Component) AddText(name string, value string) {
+	c.Add(name, EscapeText(value))
+}
+
+// AddComponent nests child inside c
+func (c *Component) AddComponent(child *Component) {
+	c.Components = append(c.Components, child)
+}
+
+// Encode writes c with CRLF line endings, folding long lines
+func (c *Component) Encode() []byte {
+	var buf bytes.Buffer
+	c.encode(&buf)
+	return buf.Bytes()
+}
+
+func (c *Component) encode(buf *bytes.Buffer) {
+	writeLine(buf, "BEGIN:"+c.Name)
+	for _, p := range c.Properties {
+		line := p.Name
+		for _, param := range p.Params {
+			line += ";" + param
+		}
+		writeLine(buf, line+":"+p.Value)
+	}
+	for _, child := range c.Components {
+		child.encode(buf)
+	}
+	writeLine(buf, "END:"+c.Name)
+}
+
+// writeLine folds line into chunks of at most maxLineOctets, never splitting
+// a UTF-8 sequence. Continuation lines start with a space, which counts
+// towards their length.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// EscapeText escapes a TEXT value
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// ParamValue quotes a parameter value if it contains characters that need
+// it
+func ParamValue(s string) string {
+	if strings.ContainsAny(s, `:;,`) {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
+
+// Date formats a DATE value
+func Date(t time.Time) string {
+	return t.Format("20060102")
+}
+
+// DateTimeUTC formats a DATE-TIME value in UTC
+func DateTimeUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// LocalDateTime formats a DATE-TIME value as wall clock time, to be used with
+// a TZID parameter
+func LocalDateTime(t time.Time) string {
+	return t.Format("20060102T150405")
+}
@@ -0,0 +1,280 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Parse reads iCalendar data holding a single top level component, usually
+// a VCALENDAR
+func Parse(data []byte) (*Component, error) {
+	lines, err := unfold(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for n, line := range lines {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		switch p.Name {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(p.Value))
+			if len(stack) > 0 {
+				stack[len(stack)-1].AddComponent(c)
+			} else if root != nil {
+				return nil, fmt.Errorf("line %d: more than one top level component", n+1)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside of a component", n+1)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, p)
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no component found")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab, onto the
+// line before them
+func unfold(data []byte) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into its name, parameters and value.
+// Colons and semicolons inside quoted parameter values don't count.
+func parseLine(line string) (Property, error) {
+	var p Property
+	quoted := false
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case ch == '"':
+			quoted = !quoted
+		case quoted:
+		case ch == ';' || ch == ':':
+			part := line[start:i]
+			if p.Name == "" {
+				p.Name = strings.ToUpper(part)
+			} else {
+				p.Params = append(p.Params, part)
+			}
+			start = i + 1
+			if ch == ':' {
+				p.Value = line[i+1:]
+				if p.Name == "" {
+					return p, fmt.Errorf("missing property name")
+				}
+				return p, nil
+			}
+		}
+	}
+	return p, fmt.Errorf("missing ':' in %q", line)
+}
+
+// Get returns the first property called name, or nil
+func (c *Component) Get(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// All returns every property called name
+func (c *Component) All(name string) []Property {
+	var props []Property
+	for _, p := range c.Properties {
+		if p.Name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Children returns the nested components called name
+func (c *Component) Children(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// Param returns the value of the parameter called name, unquoted, or "" if
+// it isn't set
+func (p *Property) Param(name string) string {
+	for _, param := range p.Params {
+		key, value, ok := strings.Cut(param, "=")
+		if ok && strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// Text returns the value unescaped as TEXT
+func (p *Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// UnescapeText reverses EscapeText
+func UnescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// SplitText splits a multi-valued TEXT property such as CATEGORIES on its
+// unescaped commas
+func SplitText(s string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, UnescapeText(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, UnescapeText(s[start:]))
+}
+
+// Time parses a DATE or DATE-TIME value. A TZID that isn't a known IANA zone,
+// and floating times without one, are read in loc. dateOnly reports a DATE,
+// which is returned as midnight in loc.
+func (p *Property) Time(loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	value := p.Value
+	if strings.EqualFold(p.Param("VALUE"), "DATE") || len(value) == len("20060102") {
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	if tzid := p.Param("TZID"); tzid != "" {
+		if zone, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = zone
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// Duration parses a DURATION value such as PT1H30M or P1D. Weeks and days
+// are taken as 7 and 1 times 24 hours.
+func Duration(value string) (time.Duration, error) {
+	s := value
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	number := 0
+	digits := 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= '0' && ch <= '9' {
+			number = number*10 + int(ch-'0')
+			digits++
+			continue
+		}
+		if ch == 'T' {
+			inTime = true
+			continue
+		}
+		if digits == 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		var unit time.Duration
+		switch {
+		case ch == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case ch == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case ch == 'H' && inTime:
+			unit = time.Hour
+		case ch == 'M' && inTime:
+			unit = time.Minute
+		case ch == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		d += time.Duration(number) * unit
+		number, digits = 0, 0
+	}
+	if digits > 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
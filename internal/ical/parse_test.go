@@ -0,0 +1,242 @@
+package ical
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncodeFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"short", "Buy milk"},
+		{"exactly one line", strings.Repeat("a", maxLineOctets-len("SUMMARY:"))},
+		{"long ascii", strings.Repeat("abcdefghij", 30)},
+		// Three byte runes must never be split across lines
+		{"long utf-8", strings.Repeat("日本語", 40)},
+		{"escaped", "a;b,c\\d\nsecond line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewComponent("VTODO")
+			c.AddText("SUMMARY", tt.value)
+			data := c.Encode()
+
+			for _, line := range strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n") {
+				if len(line) > maxLineOctets {
+					t.Errorf("line %q is %d octets", line, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %q splits a rune", line)
+				}
+			}
+
+			parsed, err := Parse(data)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := parsed.Get("SUMMARY").Text(); got != tt.value {
+				t.Errorf("SUMMARY = %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc\r\n" +
+		"SUMMARY:Folded\r\n" +
+		" \tsummary\r\n" +
+		"X-LINK;LABEL=\"a:b;c\":https://example.com\r\n" +
+		"CATEGORIES:one,two\\,three\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:def\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	cal, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	todos := cal.Children("VTODO")
+	if len(todos) != 2 {
+		t.Fatalf("got %d VTODOs, want 2", len(todos))
+	}
+	todo := todos[0]
+	if got := todo.Get("SUMMARY").Value; got != "Folded\tsummary" {
+		t.Errorf("SUMMARY = %q, want the folded line joined", got)
+	}
+	link := todo.Get("X-LINK")
+	if link.Value != "https://example.com" || link.Param("label") != "a:b;c" {
+		t.Errorf("X-LINK = %q with LABEL %q", link.Value, link.Param("label"))
+	}
+	if got := SplitText(todo.Get("CATEGORIES").Value); !reflect.DeepEqual(got, []string{"one", "two,three"}) {
+		t.Errorf("CATEGORIES = %q", got)
+	}
+	if todo.Get("DESCRIPTION") != nil {
+		t.Error("Get returned a property that isn't there")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty", "", "no component found"},
+		{"unclosed", "BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VTODO\n", "missing END:VCALENDAR"},
+		{"mismatched end", "BEGIN:VCALENDAR\nEND:VTODO\n", "line 2: unexpected END:VTODO"},
+		{"two roots", "BEGIN:VCALENDAR\nEND:VCALENDAR\nBEGIN:VCALENDAR\n", "line 3: more than one top level component"},
+		{"stray property", "SUMMARY:x\n", "line 1: property outside of a component"},
+		{"no colon", "BEGIN:VCALENDAR\nSUMMARY\n", `line 2: missing ':' in "SUMMARY"`},
+		{"no name", "BEGIN:VCALENDAR\n:value\n", "line 2: missing property name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Parse error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPropertyTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+
+	tests := []struct {
+		line     string
+		want     time.Time
+		dateOnly bool
+	}{
+		{"DUE;VALUE=DATE:20240310", time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), true},
+		{"DUE:20240310", time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), true},
+		{"DUE:20240310T070000Z", time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), false},
+		// Floating times are read in the given location, on either side of
+		// the change
+		{"DUE:20240310T010000", time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), false},
+		{"DUE:20240310T030000", time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), false},
+		{"DUE;TZID=Europe/Berlin:20240331T030000", time.Date(2024, 3, 31, 3, 0, 0, 0, berlin), false},
+		{"DUE;TZID=\"/Europe/Berlin\":20241027T040000", time.Date(2024, 10, 27, 3, 0, 0, 0, time.UTC), false},
+		{"DUE;TZID=Not/A_Zone:20240310T120000", time.Date(2024, 3, 10, 12, 0, 0, 0, newYork), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			p, err := parseLine(tt.line)
+			if err != nil {
+				t.Fatalf("parseLine: %v", err)
+			}
+			got, dateOnly, err := p.Time(newYork)
+			if err != nil {
+				t.Fatalf("Time: %v", err)
+			}
+			if !got.Equal(tt.want) || dateOnly != tt.dateOnly {
+				t.Errorf("Time = %v (date %t), want %v (date %t)", got, dateOnly, tt.want, tt.dateOnly)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"PT1H30M", 90 * time.Minute, true},
+		{"P1D", 24 * time.Hour, true},
+		{"P2W", 14 * 24 * time.Hour, true},
+		{"P1DT2H3M4S", 26*time.Hour + 3*time.Minute + 4*time.Second, true},
+		{"-PT15M", -15 * time.Minute, true},
+		{"+PT5S", 5 * time.Second, true},
+		{"PT", 0, false},
+		{"P", 0, false},
+		{"1H", 0, false},
+		{"P1H", 0, false},
+		{"PT1D", 0, false},
+		{"PT1", 0, false},
+		{"PTH", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := Duration(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("Duration(%q) = %v, %v; want %v, ok %t", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want string
+	}{
+		{
+			name: "new york",
+			loc:  newYork,
+			want: "BEGIN:VTIMEZONE\r\n" +
+				"TZID:America/New_York\r\n" +
+				"BEGIN:STANDARD\r\n" +
+				"DTSTART:19700101T000000\r\n" +
+				"TZOFFSETFROM:-0500\r\n" +
+				"TZOFFSETTO:-0500\r\n" +
+				"TZNAME:EST\r\n" +
+				"END:STANDARD\r\n" +
+				"BEGIN:DAYLIGHT\r\n" +
+				"DTSTART:20240310T020000\r\n" +
+				"TZOFFSETFROM:-0500\r\n" +
+				"TZOFFSETTO:-0400\r\n" +
+				"TZNAME:EDT\r\n" +
+				"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU\r\n" +
+				"END:DAYLIGHT\r\n" +
+				"BEGIN:STANDARD\r\n" +
+				"DTSTART:20241103T020000\r\n" +
+				"TZOFFSETFROM:-0400\r\n" +
+				"TZOFFSETTO:-0500\r\n" +
+				"TZNAME:EST\r\n" +
+				"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU\r\n" +
+				"END:STANDARD\r\n" +
+				"END:VTIMEZONE\r\n",
+		},
+		{
+			name: "utc",
+			loc:  time.UTC,
+			want: "BEGIN:VTIMEZONE\r\n" +
+				"TZID:UTC\r\n" +
+				"BEGIN:STANDARD\r\n" +
+				"DTSTART:19700101T000000\r\n" +
+				"TZOFFSETFROM:+0000\r\n" +
+				"TZOFFSETTO:+0000\r\n" +
+				"TZNAME:UTC\r\n" +
+				"END:STANDARD\r\n" +
+				"END:VTIMEZONE\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := time.Date(2024, time.June, 1, 0, 0, 0, 0, tt.loc)
+			if got := string(Timezone(tt.loc, day, day).Encode()); got != tt.want {
+				t.Errorf("Timezone =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// transition is a change of UTC offset
+type transition struct {
+	at         time.Time
+	offsetFrom int
+	offsetTo   int
+	name       string
+	dst        bool
+}
+
+// Timezone builds the VTIMEZONE for loc that DATE-TIME values with a TZID
+// refer to. Transitions are listed one by one from the start of from's year
+// to the end of to's year, and the last of each kind repeats yearly after
+// that.
+func Timezone(loc *time.Location, from time.Time, to time.Time) *Component {
+	tz := NewComponent("VTIMEZONE")
+	tz.Add("TZID", loc.String())
+
+	start := time.Date(from.Year(), time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(to.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+	name, offset := start.Zone()
+	// The first observance covers everything before the first transition
+	initial := NewComponent(observance(start.IsDST()))
+	initial.Add("DTSTART", "19700101T000000")
+	initial.Add("TZOFFSETFROM", formatOffset(offset))
+	initial.Add("TZOFFSETTO", formatOffset(offset))
+	initial.Add("TZNAME", EscapeText(name))
+	tz.AddComponent(initial)
+
+	transitions := findTransitions(start, end)
+	lastOfKind := map[bool]int{}
+	for i, t := range transitions {
+		lastOfKind[t.dst] = i
+	}
+	for i, t := range transitions {
+		c := NewComponent(observance(t.dst))
+		// DTSTART is the wall clock time the transition happens at, as read
+		// before it
+		c.Add("DTSTART", LocalDateTime(t.at.UTC().Add(time.Duration(t.offsetFrom)*time.Second)))
+		c.Add("TZOFFSETFROM", formatOffset(t.offsetFrom))
+		c.Add("TZOFFSETTO", formatOffset(t.offsetTo))
+		c.Add("TZNAME", EscapeText(t.name))
+		if lastOfKind[t.dst] == i && len(transitions) > 1 {
+			c.Add("RRULE", yearlyRule(t.at.UTC().Add(time.Duration(t.offsetFrom)*time.Second)))
+		}
+		tz.AddComponent(c)
+	}
+	return tz
+}
+
+// findTransitions steps through [start, end) a day at a time looking for
+// offset changes, then narrows each one down to the second
+func findTransitions(start time.Time, end time.Time) []transition {
+	var transitions []transition
+	_, prevOffset := start.Zone()
+	for day := start; day.Before(end); {
+		next := day.Add(24 * time.Hour)
+		_, offset := next.Zone()
+		if offset != prevOffset {
+			lo, hi := day, next
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.Zone(); o == prevOffset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			name, _ := hi.Zone()
+			transitions = append(transitions, transition{
+				at:         hi,
+				offsetFrom: prevOffset,
+				offsetTo:   offset,
+				name:       name,
+				dst:        hi.IsDST(),
+			})
+			prevOffset = offset
+		}
+		day = next
+	}
+	return transitions
+}
+
+func observance(dst bool) string {
+	if dst {
+		return "DAYLIGHT"
+	}
+	return "STANDARD"
+}
+
+// yearlyRule describes the day local falls on as the nth, or last, weekday
+// of its month, e.g. the second Sunday of March
+func yearlyRule(local time.Time) string {
+	daysInMonth := time.Date(local.Year(), local.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	ordinal := (local.Day()-1)/7 + 1
+	if local.Day()+7 > daysInMonth {
+		ordinal = -1
+	}
+	weekday := [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}[local.Weekday()]
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", int(local.Month()), ordinal, weekday)
+}
+
+// formatOffset formats a UTC offset in seconds as +HHMM
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds/60%60)
+}
@@ -0,0 +1,364 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/recurrence"
+)
+
+// ProductID identifies odot as the producer of calendars it writes
+const ProductID = "-//odot//odot//EN"
+
+// recurrenceFromSchedule matches the handlers' mode for todos whose later
+// occurrences are known up front
+const recurrenceFromSchedule = "schedule"
+
+// NewCalendar returns a VCALENDAR for publishing, named name and defaulting
+// to loc
+func NewCalendar(name string, loc *time.Location) *Component {
+	cal := NewComponent("VCALENDAR")
+	cal.Add("VERSION", "2.0")
+	cal.Add("PRODID", ProductID)
+	cal.Add("CALSCALE", "GREGORIAN")
+	cal.Add("METHOD", "PUBLISH")
+	cal.AddText("X-WR-CALNAME", name)
+	cal.Add("X-WR-TIMEZONE", loc.String())
+	cal.Add("REFRESH-INTERVAL", "PT1H", "VALUE=DURATION")
+	cal.Add("X-PUBLISHED-TTL", "PT1H")
+	return cal
+}
+
+// NewResource returns a VCALENDAR to hold a single calendar object resource,
+// as stored by CalDAV, which unlike a published calendar has no METHOD
+func NewResource() *Component {
+	cal := NewComponent("VCALENDAR")
+	cal.Add("VERSION", "2.0")
+	cal.Add("PRODID", ProductID)
+	return cal
+}
+
+// TodoUID is the stable UID of a todo, so clients replace it on update
+// instead of adding a copy
+func TodoUID(todoID int32, domain string) string {
+	return fmt.Sprintf("todo-%d@%s", todoID, domain)
+}
+
+// IsTimed reports whether a todo happens at a particular time of day. Those
+// with a duration become events, everything else is a todo due on its day.
+func IsTimed(todo *db.Todo, loc *time.Location) bool {
+	if !todo.AssignedDate.Valid || !todo.DurationMin.Valid || todo.DurationMin.Int32 <= 0 {
+		return false
+	}
+	local := todo.AssignedDate.Time.In(loc)
+	return local.Hour() != 0 || local.Minute() != 0 || local.Second() != 0
+}
+
+// TodoOptions adjusts how TodoComponent writes a todo
+type TodoOptions struct {
+	// Domain is the right hand side of UIDs derived from todo IDs
+	Domain string
+	// UID replaces the derived UID, for todos a client created with its own
+	UID string
+	// ParentUID replaces the derived UID of the todo's parent
+	ParentUID string
+	// Categories are the names of the todo's tags
+	Categories []string
+	// TodosOnly writes timed todos as a VTODO running from DTSTART to DUE
+	// rather than a VEVENT, for task lists that only hold VTODOs
+	TodosOnly bool
+}
+
+// TodoComponent maps a todo onto a VEVENT or VTODO. Recurring todos get an
+// RRULE when their later occurrences are fixed, starting from the one due
+// now. The returned bool reports whether it refers to loc by TZID, in which
+// case the calendar needs loc's VTIMEZONE.
+func TodoComponent(todo *db.Todo, loc *time.Location, opts TodoOptions) (*Component, bool, error) {
+	timed := IsTimed(todo, loc)
+	event := timed && !opts.TodosOnly
+	name := "VTODO"
+	if event {
+		name = "VEVENT"
+	}
+	uid := opts.UID
+	if uid == "" {
+		uid = TodoUID(todo.TodoID, opts.Domain)
+	}
+	c := NewComponent(name)
+	c.Add("UID", uid)
+	c.Add("DTSTAMP", DateTimeUTC(todo.UpdatedAt.Time))
+	if todo.CreatedAt.Valid {
+		c.Add("CREATED", DateTimeUTC(todo.CreatedAt.Time))
+	}
+	if todo.UpdatedAt.Valid {
+		c.Add("LAST-MODIFIED", DateTimeUTC(todo.UpdatedAt.Time))
+	}
+	c.AddText("SUMMARY", todo.Title)
+	if todo.Description.Valid && todo.Description.String != "" {
+		c.AddText("DESCRIPTION", todo.Description.String)
+	}
+	if p := priority(todo.Priority.Int32); todo.Priority.Valid && p > 0 {
+		c.Add("PRIORITY", strconv.Itoa(p))
+	}
+	if todo.ParentTodoID.Valid {
+		parentUID := opts.ParentUID
+		if parentUID == "" {
+			parentUID = TodoUID(todo.ParentTodoID.Int32, opts.Domain)
+		}
+		c.Add("RELATED-TO", parentUID)
+	}
+	if len(opts.Categories) > 0 {
+		escaped := make([]string, len(opts.Categories))
+		for i, category := range opts.Categories {
+			escaped[i] = EscapeText(category)
+		}
+		c.Add("CATEGORIES", strings.Join(escaped, ","))
+	}
+
+	rule, err := TodoRule(todo)
+	if err != nil {
+		return nil, false, err
+	}
+	// Repeating times are written in loc so they keep their wall clock time
+	// across DST, everything else in UTC
+	usesZone := false
+	if todo.AssignedDate.Valid {
+		start := todo.AssignedDate.Time.In(loc)
+		dateTime := func(name string, t time.Time) {
+			if rule != nil {
+				c.Add(name, LocalDateTime(t.In(loc)), "TZID="+ParamValue(loc.String()))
+				usesZone = true
+			} else {
+				c.Add(name, DateTimeUTC(t))
+			}
+		}
+		switch {
+		case timed:
+			end := start.Add(time.Duration(todo.DurationMin.Int32) * time.Minute)
+			dateTime("DTSTART", start)
+			if event {
+				dateTime("DTEND", end)
+			} else {
+				dateTime("DUE", end)
+			}
+		case IsMidnight(start):
+			c.Add("DUE", Date(start), "VALUE=DATE")
+			if rule != nil {
+				c.Add("DTSTART", Date(start), "VALUE=DATE")
+			}
+		default:
+			if rule != nil {
+				dateTime("DTSTART", start)
+			}
+			dateTime("DUE", start)
+		}
+		if rule != nil {
+			matchUntil(rule, IsMidnight(start) && !timed, loc)
+			c.Add("RRULE", rule.String())
+		}
+	}
+	if !timed && todo.DurationMin.Valid && todo.DurationMin.Int32 > 0 {
+		c.Add("ESTIMATED-DURATION", fmt.Sprintf("PT%dM", todo.DurationMin.Int32))
+	}
+
+	completed := todo.IsCompleted.Valid && todo.IsCompleted.Bool
+	if event {
+		c.Add("STATUS", "CONFIRMED")
+	} else if completed {
+		c.Add("STATUS", "COMPLETED")
+		c.Add("PERCENT-COMPLETE", "100")
+		if todo.CompletedAt.Valid {
+			c.Add("COMPLETED", DateTimeUTC(todo.CompletedAt.Time))
+		}
+	} else {
+		c.Add("STATUS", "NEEDS-ACTION")
+	}
+	return c, usesZone, nil
+}
+
+// TodoRule returns the rule written as a recurring todo's RRULE, or nil if
+// it doesn't get one. It starts at the occurrence due now, so the
+// occurrences already completed no longer count towards COUNT.
+func TodoRule(todo *db.Todo) (*recurrence.Rule, error) {
+	if !todo.RecurrenceRule.Valid || todo.RecurrenceMode != recurrenceFromSchedule {
+		return nil, nil
+	}
+	rule, err := recurrence.Parse(todo.RecurrenceRule.String)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Count > 0 {
+		rule.Count -= int(todo.RecurrenceCount)
+		if rule.Count < 1 {
+			rule.Count = 1
+		}
+	}
+	return rule, nil
+}
+
+// IsMidnight reports whether t is the start of its day, which is how todos
+// due on a day without a time are stored
+func IsMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0
+}
+
+// priority maps odot's priorities, 4 being the most urgent, onto
+// iCalendar's, where 1 is highest and 0 undefined
+func priority(p int32) int {
+	switch {
+	case p >= 4:
+		return 1
+	case p == 3:
+		return 3
+	case p == 2:
+		return 5
+	case p == 1:
+		return 7
+	}
+	return 0
+}
+
+// matchUntil makes UNTIL the same value type as DTSTART, as RFC 5545
+// requires
+func matchUntil(rule *recurrence.Rule, dateOnly bool, loc *time.Location) {
+	if rule.Until == nil || rule.Until.DateOnly == dateOnly {
+		return
+	}
+	if dateOnly {
+		until := rule.Until.Time.In(loc)
+		rule.Until = &recurrence.Until{Time: time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC), DateOnly: true}
+		return
+	}
+	// A date-only UNTIL includes the whole day
+	until := rule.Until.Time
+	end := time.Date(until.Year(), until.Month(), until.Day(), 23, 59, 59, 0, loc)
+	rule.Until = &recurrence.Until{Time: end, DateOnly: false}
+}
+
+// TodoData is what a client sent for a todo in a VTODO
+type TodoData struct {
+	UID         string
+	Summary     string
+	Description string
+	// Date is when the todo is due, or starts if it runs from DTSTART to DUE
+	Date     *time.Time
+	DateOnly bool
+	// DurationMin is how long the todo runs for, or its estimate
+	DurationMin *int32
+	Priority    int32
+	Completed   bool
+	// Rule is the RRULE value, or "" if the todo doesn't repeat
+	Rule       string
+	ParentUID  string
+	Categories []string
+}
+
+// ParseTodo reads a VTODO. Dates without a known zone are read in loc.
+func ParseTodo(c *Component, loc *time.Location) (*TodoData, error) {
+	data := &TodoData{}
+	if p := c.Get("UID"); p != nil {
+		data.UID = p.Value
+	}
+	if data.UID == "" {
+		return nil, fmt.Errorf("missing UID")
+	}
+	if p := c.Get("SUMMARY"); p != nil {
+		data.Summary = strings.TrimSpace(p.Text())
+	}
+	if p := c.Get("DESCRIPTION"); p != nil {
+		data.Description = p.Text()
+	}
+
+	var start, due *time.Time
+	startDateOnly, dueDateOnly := false, false
+	if p := c.Get("DTSTART"); p != nil {
+		t, dateOnly, err := p.Time(loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DTSTART: %w", err)
+		}
+		start, startDateOnly = &t, dateOnly
+	}
+	if p := c.Get("DUE"); p != nil {
+		t, dateOnly, err := p.Time(loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DUE: %w", err)
+		}
+		due, dueDateOnly = &t, dateOnly
+	}
+	switch {
+	case start != nil && due != nil && !startDateOnly && !dueDateOnly && due.After(*start):
+		// The inverse of a timed todo written with TodosOnly
+		minutes := int32(due.Sub(*start) / time.Minute)
+		data.Date, data.DurationMin = start, &minutes
+	case due != nil:
+		data.Date, data.DateOnly = due, dueDateOnly
+	case start != nil:
+		data.Date, data.DateOnly = start, startDateOnly
+	}
+	if data.DurationMin == nil {
+		for _, name := range []string{"DURATION", "ESTIMATED-DURATION"} {
+			p := c.Get(name)
+			if p == nil {
+				continue
+			}
+			d, err := Duration(p.Value)
+			if err != nil {
+				return nil, err
+			}
+			if minutes := int32(d / time.Minute); minutes > 0 {
+				data.DurationMin = &minutes
+				break
+			}
+		}
+	}
+
+	if p := c.Get("PRIORITY"); p != nil {
+		n, err := strconv.Atoi(strings.TrimSpace(p.Value))
+		if err != nil || n < 0 || n > 9 {
+			return nil, fmt.Errorf("invalid PRIORITY %q", p.Value)
+		}
+		data.Priority = fromPriority(n)
+	}
+	if p := c.Get("STATUS"); p != nil && strings.EqualFold(p.Value, "COMPLETED") {
+		data.Completed = true
+	}
+	if c.Get("COMPLETED") != nil {
+		data.Completed = true
+	}
+	if p := c.Get("RRULE"); p != nil {
+		data.Rule = p.Value
+	}
+	for _, p := range c.All("RELATED-TO") {
+		if reltype := p.Param("RELTYPE"); reltype == "" || strings.EqualFold(reltype, "PARENT") {
+			data.ParentUID = p.Value
+			break
+		}
+	}
+	for _, p := range c.All("CATEGORIES") {
+		for _, category := range SplitText(p.Value) {
+			if category = strings.TrimSpace(category); category != "" {
+				data.Categories = append(data.Categories, category)
+			}
+		}
+	}
+	return data, nil
+}
+
+// fromPriority maps an iCalendar priority back onto odot's, so that
+// priority(fromPriority(p)) == p for the values priority returns
+func fromPriority(p int) int32 {
+	switch {
+	case p == 0:
+		return 0
+	case p == 1:
+		return 4
+	case p <= 4:
+		return 3
+	case p == 5:
+		return 2
+	}
+	return 1
+}
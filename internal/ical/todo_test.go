@@ -0,0 +1,245 @@
+package ical
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestTodoComponent(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	at := func(day int, hour int) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: time.Date(2024, time.March, day, hour, 0, 0, 0, newYork), Valid: true}
+	}
+	updated := pgtype.Timestamptz{Time: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC), Valid: true}
+
+	tests := []struct {
+		name     string
+		todo     db.Todo
+		opts     TodoOptions
+		comp     string
+		usesZone bool
+		props    map[string]string
+	}{
+		{
+			name: "due on a day",
+			todo: db.Todo{
+				TodoID:       1,
+				Title:        "Taxes; forms, receipts",
+				AssignedDate: at(10, 0),
+				Priority:     pgtype.Int4{Int32: 4, Valid: true},
+				DurationMin:  pgtype.Int4{Int32: 45, Valid: true},
+			},
+			comp: "VTODO",
+			props: map[string]string{
+				"UID":                "todo-1@example.com",
+				"SUMMARY":            `Taxes\; forms\, receipts`,
+				"DUE":                "20240310",
+				"PRIORITY":           "1",
+				"ESTIMATED-DURATION": "PT45M",
+				"STATUS":             "NEEDS-ACTION",
+			},
+		},
+		{
+			name: "timed becomes an event",
+			todo: db.Todo{
+				TodoID:       2,
+				ParentTodoID: pgtype.Int4{Int32: 1, Valid: true},
+				Title:        "Standup",
+				AssignedDate: at(11, 9),
+				DurationMin:  pgtype.Int4{Int32: 30, Valid: true},
+			},
+			comp: "VEVENT",
+			props: map[string]string{
+				"DTSTART":    "20240311T130000Z",
+				"DTEND":      "20240311T133000Z",
+				"RELATED-TO": "todo-1@example.com",
+				"STATUS":     "CONFIRMED",
+			},
+		},
+		{
+			name: "timed in a task list",
+			todo: db.Todo{
+				TodoID:       3,
+				Title:        "Standup",
+				AssignedDate: at(11, 9),
+				DurationMin:  pgtype.Int4{Int32: 30, Valid: true},
+			},
+			opts: TodoOptions{TodosOnly: true, UID: "client-uid"},
+			comp: "VTODO",
+			props: map[string]string{
+				"UID":     "client-uid",
+				"DTSTART": "20240311T130000Z",
+				"DUE":     "20240311T133000Z",
+			},
+		},
+		{
+			// Written as local time so it stays at 09:00 after the DST change
+			name: "repeating across DST",
+			todo: db.Todo{
+				TodoID:          4,
+				Title:           "Gym",
+				AssignedDate:    at(8, 9),
+				RecurrenceRule:  pgtype.Text{String: "FREQ=WEEKLY;COUNT=5", Valid: true},
+				RecurrenceMode:  recurrenceFromSchedule,
+				RecurrenceCount: 2,
+			},
+			comp:     "VTODO",
+			usesZone: true,
+			props: map[string]string{
+				"DTSTART": "20240308T090000",
+				"DUE":     "20240308T090000",
+				"RRULE":   "FREQ=WEEKLY;COUNT=3",
+			},
+		},
+		{
+			name: "repeating on a day",
+			todo: db.Todo{
+				TodoID:         5,
+				Title:          "Rent",
+				AssignedDate:   at(1, 0),
+				RecurrenceRule: pgtype.Text{String: "FREQ=MONTHLY;UNTIL=20241231T235959Z", Valid: true},
+				RecurrenceMode: recurrenceFromSchedule,
+			},
+			comp: "VTODO",
+			props: map[string]string{
+				"DTSTART": "20240301",
+				"DUE":     "20240301",
+				"RRULE":   "FREQ=MONTHLY;UNTIL=20241231",
+			},
+		},
+		{
+			name: "completed",
+			todo: db.Todo{
+				TodoID:      6,
+				Title:       "Done",
+				IsCompleted: pgtype.Bool{Bool: true, Valid: true},
+				CompletedAt: updated,
+			},
+			comp: "VTODO",
+			props: map[string]string{
+				"STATUS":           "COMPLETED",
+				"PERCENT-COMPLETE": "100",
+				"COMPLETED":        "20240301T120000Z",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.todo.UpdatedAt = updated
+			if tt.opts.Domain == "" {
+				tt.opts.Domain = "example.com"
+			}
+			c, usesZone, err := TodoComponent(&tt.todo, newYork, tt.opts)
+			if err != nil {
+				t.Fatalf("TodoComponent: %v", err)
+			}
+			if c.Name != tt.comp || usesZone != tt.usesZone {
+				t.Errorf("got %s using the zone %t, want %s and %t", c.Name, usesZone, tt.comp, tt.usesZone)
+			}
+			for name, want := range tt.props {
+				p := c.Get(name)
+				if p == nil {
+					t.Errorf("%s is missing", name)
+				} else if p.Value != want {
+					t.Errorf("%s = %q, want %q", name, p.Value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTodo(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	date := func(day int, hour int) *time.Time {
+		t := time.Date(2024, time.March, day, hour, 0, 0, 0, newYork)
+		return &t
+	}
+	minutes := func(n int32) *int32 { return &n }
+
+	tests := []struct {
+		name  string
+		lines string
+		want  TodoData
+	}{
+		{
+			name: "due on a day",
+			lines: "UID:a\nSUMMARY:  Taxes\\, forms \nDUE;VALUE=DATE:20240310\n" +
+				"ESTIMATED-DURATION:PT45M\nPRIORITY:2\nCATEGORIES:home, ,work\\,ish\n",
+			want: TodoData{
+				UID: "a", Summary: "Taxes, forms", Date: date(10, 0), DateOnly: true,
+				DurationMin: minutes(45), Priority: 3, Categories: []string{"home", "work,ish"},
+			},
+		},
+		{
+			name:  "start to due",
+			lines: "UID:b\nDTSTART;TZID=America/New_York:20240310T090000\nDUE:20240310T143000Z\n",
+			want:  TodoData{UID: "b", Date: date(10, 9), DurationMin: minutes(90)},
+		},
+		{
+			name:  "completed with a parent",
+			lines: "UID:c\nCOMPLETED:20240301T120000Z\nRELATED-TO;RELTYPE=CHILD:x\nRELATED-TO:parent\nRRULE:FREQ=DAILY\n",
+			want:  TodoData{UID: "c", Completed: true, ParentUID: "parent", Rule: "FREQ=DAILY"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte("BEGIN:VTODO\n" + tt.lines + "END:VTODO\n"))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, err := ParseTodo(c, newYork)
+			if err != nil {
+				t.Fatalf("ParseTodo: %v", err)
+			}
+			if (got.Date == nil) != (tt.want.Date == nil) || (got.Date != nil && !got.Date.Equal(*tt.want.Date)) {
+				t.Errorf("Date = %v, want %v", got.Date, tt.want.Date)
+			}
+			got.Date, tt.want.Date = nil, nil
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseTodo = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTodoErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines string
+	}{
+		{"no uid", "SUMMARY:x\n"},
+		{"bad due", "UID:a\nDUE:tomorrow\n"},
+		{"bad start", "UID:a\nDTSTART:20241301T000000\n"},
+		{"bad priority", "UID:a\nPRIORITY:10\n"},
+		{"bad duration", "UID:a\nDURATION:1h\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte("BEGIN:VTODO\n" + tt.lines + "END:VTODO\n"))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if _, err := ParseTodo(c, time.UTC); err == nil {
+				t.Error("ParseTodo succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestPriorityRoundTrip(t *testing.T) {
+	for p := int32(0); p <= 4; p++ {
+		if got := fromPriority(priority(p)); got != p {
+			t.Errorf("fromPriority(priority(%d)) = %d", p, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// completedJobRetention is how long finished jobs are kept
+	completedJobRetention = 24 * time.Hour
+	// deadJobRetention gives someone time to look at jobs that failed for good
+	deadJobRetention = 30 * 24 * time.Hour
+)
+
+// CleanupRefreshTokens deletes expired and revoked refresh tokens
+type CleanupRefreshTokens struct{}
+
+func (CleanupRefreshTokens) Kind() string { return "cleanup.refresh_tokens" }
+
+// CleanupIdempotencyKeys deletes idempotency keys past their TTL
+type CleanupIdempotencyKeys struct{}
+
+func (CleanupIdempotencyKeys) Kind() string { return "cleanup.idempotency_keys" }
+
+// CleanupJobs deletes finished jobs once they are past their retention
+type CleanupJobs struct{}
+
+func (CleanupJobs) Kind() string { return "cleanup.jobs" }
+
+// RegisterCleanup adds the housekeeping jobs to p and schedules them
+func RegisterCleanup(p *WorkerPool, querier db.Querier) error {
+	Handle(p, func(ctx context.Context, job *Job, args CleanupRefreshTokens) error {
+		return querier.CleanupExpiredRefreshTokens(ctx)
+	})
+	Handle(p, func(ctx context.Context, job *Job, args CleanupIdempotencyKeys) error {
+		return querier.CleanupExpiredIdempotencyKeys(ctx)
+	})
+	Handle(p, func(ctx context.Context, job *Job, args CleanupJobs) error {
+		deleted, err := querier.DeleteFinishedJobs(ctx, db.DeleteFinishedJobsParams{
+			CompletedRetention: pgtype.Interval{Microseconds: completedJobRetention.Microseconds(), Valid: true},
+			DeadRetention:      pgtype.Interval{Microseconds: deadJobRetention.Microseconds(), Valid: true},
+		})
+		if err == nil && deleted > 0 {
+			p.logger.Info("Deleted finished jobs", "count", deleted)
+		}
+		return err
+	})
+
+	if err := p.Schedule("cleanup.refresh_tokens", "@hourly", CleanupRefreshTokens{}); err != nil {
+		return err
+	}
+	if err := p.Schedule("cleanup.idempotency_keys", "@every 15m", CleanupIdempotencyKeys{}); err != nil {
+		return err
+	}
+	return p.Schedule("cleanup.jobs", "30 3 * * *", CleanupJobs{})
+}
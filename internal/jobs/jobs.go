@@ -0,0 +1,124 @@
+// Package jobs runs background work from a durable queue in Postgres.
+//
+// Work is described by an Args type whose Kind names the job, and is done by
+// a handler registered for that kind on a WorkerPool. Failed jobs are retried
+// with exponential backoff and become dead, kept for inspection, once they
+// run out of attempts. Any number of replicas can run workers; jobs are
+// claimed with FOR UPDATE SKIP LOCKED.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const defaultMaxAttempts = 10
+
+// ErrDuplicate is returned by Enqueue when a job with the same unique key is
+// already pending or running
+var ErrDuplicate = errors.New("jobs: a job with this unique key is already queued")
+
+// Args is the payload of a job. It is stored as JSON, and its Kind picks the
+// handler that runs it.
+type Args interface {
+	Kind() string
+}
+
+// Job is a claimed job as seen by its handler
+type Job struct {
+	ID          int64
+	Kind        string
+	Attempt     int32
+	MaxAttempts int32
+}
+
+// EnqueueOptions are optional settings for a new job
+type EnqueueOptions struct {
+	// RunAt delays the job until the given time
+	RunAt time.Time
+	// MaxAttempts defaults to 10
+	MaxAttempts int32
+	// UniqueKey keeps a second job with the same key from being queued while
+	// one is pending or running
+	UniqueKey string
+}
+
+// Client enqueues jobs. When the context carries a transaction the job is
+// enqueued in it, so it only runs if the transaction commits.
+type Client struct {
+	querier db.Querier
+}
+
+func NewClient(querier db.Querier) *Client {
+	return &Client{querier: querier}
+}
+
+// Enqueue adds a job to the queue and returns its ID
+func (c *Client) Enqueue(ctx context.Context, args Args, opts *EnqueueOptions) (int64, error) {
+	return enqueue(ctx, db.QuerierFromContext(ctx, c.querier), args, opts)
+}
+
+func enqueue(ctx context.Context, querier db.Querier, args Args, opts *EnqueueOptions) (int64, error) {
+	if opts == nil {
+		opts = &EnqueueOptions{}
+	}
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return 0, err
+	}
+
+	params := db.EnqueueJobParams{
+		Kind:        args.Kind(),
+		Payload:     payload,
+		RunAt:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		MaxAttempts: defaultMaxAttempts,
+	}
+	if !opts.RunAt.IsZero() {
+		params.RunAt.Time = opts.RunAt
+	}
+	if opts.MaxAttempts > 0 {
+		params.MaxAttempts = opts.MaxAttempts
+	}
+	if opts.UniqueKey != "" {
+		params.UniqueKey = pgtype.Text{String: opts.UniqueKey, Valid: true}
+	}
+
+	job, err := querier.EnqueueJob(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrDuplicate
+	}
+	if err != nil {
+		return 0, err
+	}
+	return job.JobID, nil
+}
+
+// Requeue gives a dead job a fresh set of attempts
+func (c *Client) Requeue(ctx context.Context, jobID int64) (bool, error) {
+	requeued, err := db.QuerierFromContext(ctx, c.querier).RequeueDeadJob(ctx, jobID)
+	return requeued > 0, err
+}
+
+// DeadJobs returns the most recently failed dead jobs
+func (c *Client) DeadJobs(ctx context.Context, limit int32) ([]db.Job, error) {
+	return db.QuerierFromContext(ctx, c.querier).ListDeadJobs(ctx, limit)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error that retrying won't fix, such as a malformed
+// payload, so the job goes straight to dead
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
@@ -0,0 +1,317 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// pollInterval is how long an idle worker waits before looking for work
+	pollInterval = time.Second
+	// maintenanceInterval is how often schedules and stuck jobs are checked
+	maintenanceInterval = 15 * time.Second
+	// jobTimeout bounds a single attempt
+	jobTimeout = 5 * time.Minute
+	// stuckAfter is when a running job is assumed to belong to a worker that
+	// died. It must be well above jobTimeout.
+	stuckAfter = 30 * time.Minute
+	// maxBackoff caps the wait between attempts
+	maxBackoff = 6 * time.Hour
+)
+
+type handler func(ctx context.Context, job *Job, payload []byte) error
+
+type scheduledJob struct {
+	name     string
+	spec     string
+	schedule Schedule
+	args     Args
+}
+
+// WorkerPool runs jobs with a fixed number of workers, and enqueues
+// scheduled jobs when they are due
+type WorkerPool struct {
+	pool        *pgxpool.Pool
+	querier     db.Querier
+	handlers    map[string]handler
+	schedules   []*scheduledJob
+	concurrency int
+	id          string
+	logger      logger.Logger
+
+	// stop is closed to stop claiming new jobs; cancelJobs aborts the ones
+	// already running
+	stop       chan struct{}
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewWorkerPool(pool *pgxpool.Pool, concurrency int, logger logger.Logger) *WorkerPool {
+	hostname, _ := os.Hostname()
+	return &WorkerPool{
+		pool:        pool,
+		querier:     db.New(pool),
+		handlers:    make(map[string]handler),
+		concurrency: max(concurrency, 1),
+		id:          fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		logger:      logger,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Handle registers fn to run jobs of the kind of T. A job whose handler
+// returns an error is retried unless the error is Permanent.
+func Handle[T Args](p *WorkerPool, fn func(ctx context.Context, job *Job, args T) error) {
+	var zero T
+	p.handlers[zero.Kind()] = func(ctx context.Context, job *Job, payload []byte) error {
+		var args T
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		return fn(ctx, job, args)
+	}
+}
+
+// Schedule enqueues args on the given schedule, which is parsed by
+// ParseSchedule. Runs that come due while the previous one is still queued
+// are skipped. The handler for args must be registered separately.
+func (p *WorkerPool) Schedule(name string, spec string, args Args) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", name, err)
+	}
+	p.schedules = append(p.schedules, &scheduledJob{name: name, spec: spec, schedule: schedule, args: args})
+	return nil
+}
+
+// Start registers the schedules and starts the workers. Handlers and
+// schedules must be added before Start.
+func (p *WorkerPool) Start(ctx context.Context) error {
+	now := time.Now()
+	for _, s := range p.schedules {
+		err := p.querier.UpsertJobSchedule(ctx, db.UpsertJobScheduleParams{
+			Name:      s.name,
+			Spec:      s.spec,
+			NextRunAt: pgtype.Timestamptz{Time: s.schedule.Next(now), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("registering schedule %s: %w", s.name, err)
+		}
+	}
+
+	p.jobCtx, p.cancelJobs = context.WithCancel(context.Background())
+	for range p.concurrency {
+		p.wg.Add(1)
+		go p.work()
+	}
+	p.wg.Add(1)
+	go p.maintain()
+
+	p.logger.Info("Started job workers", "concurrency", p.concurrency, "schedules", len(p.schedules))
+	return nil
+}
+
+// Shutdown stops claiming jobs and waits for running ones to finish. If ctx
+// ends first the running jobs are cancelled, and retried later.
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	close(p.stop)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+
+	kinds := make([]string, 0, len(p.handlers))
+	for kind := range p.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-timer.C:
+		}
+
+		// Keep going while there is work, and only sleep once the queue is
+		// empty
+		worked, err := p.runNext(kinds)
+		if err != nil {
+			p.logger.Error("Failed to run job", "error", err)
+		}
+		if worked {
+			timer.Reset(0)
+		} else {
+			timer.Reset(pollInterval)
+		}
+	}
+}
+
+// runNext claims and runs one job, returning false if there was none
+func (p *WorkerPool) runNext(kinds []string) (bool, error) {
+	claimed, err := p.querier.ClaimJob(p.jobCtx, db.ClaimJobParams{
+		LockedBy: pgtype.Text{String: p.id, Valid: true},
+		Kinds:    kinds,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	job := &Job{
+		ID:          claimed.JobID,
+		Kind:        claimed.Kind,
+		Attempt:     claimed.Attempts,
+		MaxAttempts: claimed.MaxAttempts,
+	}
+	start := time.Now()
+	runErr := p.run(job, claimed.Payload)
+
+	// Record the outcome even if the pool is being shut down
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if runErr == nil {
+		p.logger.Debug("Job completed", "job_id", job.ID, "kind", job.Kind, "duration", time.Since(start))
+		return true, p.querier.CompleteJob(ctx, job.ID)
+	}
+
+	lastError := pgtype.Text{String: runErr.Error(), Valid: true}
+	var permanent *permanentError
+	if errors.As(runErr, &permanent) || job.Attempt >= job.MaxAttempts {
+		p.logger.Error("Job failed for good", "job_id", job.ID, "kind", job.Kind, "attempt", job.Attempt, "error", runErr)
+		return true, p.querier.KillJob(ctx, db.KillJobParams{LastError: lastError, JobID: job.ID})
+	}
+
+	retryAt := time.Now().Add(backoff(job.Attempt))
+	p.logger.Info("Job failed, retrying", "job_id", job.ID, "kind", job.Kind, "attempt", job.Attempt, "retry_at", retryAt, "error", runErr)
+	return true, p.querier.RetryJob(ctx, db.RetryJobParams{
+		RunAt:     pgtype.Timestamptz{Time: retryAt, Valid: true},
+		LastError: lastError,
+		JobID:     job.ID,
+	})
+}
+
+func (p *WorkerPool) run(job *Job, payload []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	fn, ok := p.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job kind %q", job.Kind))
+	}
+	ctx, cancel := context.WithTimeout(p.jobCtx, jobTimeout)
+	defer cancel()
+	return fn(ctx, job, payload)
+}
+
+// maintain enqueues due scheduled jobs and puts jobs whose worker died back
+// in the queue
+func (p *WorkerPool) maintain() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+	for {
+		if err := p.enqueueScheduled(p.jobCtx); err != nil {
+			p.logger.Error("Failed to enqueue scheduled jobs", "error", err)
+		}
+		rescued, err := p.querier.RescueStuckJobs(p.jobCtx, pgtype.Interval{Microseconds: stuckAfter.Microseconds(), Valid: true})
+		if err != nil {
+			p.logger.Error("Failed to rescue stuck jobs", "error", err)
+		} else if rescued > 0 {
+			p.logger.Info("Rescued stuck jobs", "count", rescued)
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *WorkerPool) enqueueScheduled(ctx context.Context) error {
+	if len(p.schedules) == 0 {
+		return nil
+	}
+	byName := make(map[string]*scheduledJob, len(p.schedules))
+	names := make([]string, 0, len(p.schedules))
+	for _, s := range p.schedules {
+		byName[s.name] = s
+		names = append(names, s.name)
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	due, err := querier.ClaimDueJobSchedules(ctx, names)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, row := range due {
+		s := byName[row.Name]
+		_, err := enqueue(ctx, querier, s.args, &EnqueueOptions{UniqueKey: "schedule:" + s.name})
+		if err != nil && !errors.Is(err, ErrDuplicate) {
+			return err
+		}
+		err = querier.SetJobScheduleNextRun(ctx, db.SetJobScheduleNextRunParams{
+			Name:      s.name,
+			NextRunAt: pgtype.Timestamptz{Time: s.schedule.Next(now), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// backoff doubles the wait after each failed attempt, starting at around ten
+// seconds, with jitter so failed jobs don't all retry at once
+func backoff(attempt int32) time.Duration {
+	wait := maxBackoff
+	if attempt < 16 {
+		wait = min(10*time.Second<<(attempt-1), maxBackoff)
+	}
+	return wait + rand.N(wait/10+1)
+}
@@ -0,0 +1,205 @@
+// Package mailer sends email over SMTP, into a directory as .eml files for
+// tests and local development, or to the log when neither is configured.
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boetro/odot/internal/logger"
+)
+
+// Message is a single email to one recipient. HTML is optional; when set the
+// email is sent with both parts so clients can choose.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers such as List-Unsubscribe
+	Headers map[string]string
+}
+
+// Mailer sends email
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPConfig is how to reach the SMTP server. Username may be empty for
+// servers that don't require authentication.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	cfg SMTPConfig
+}
+
+// NewSMTP returns a Mailer that sends through an SMTP server, using STARTTLS
+// when the server offers it
+func NewSMTP(cfg SMTPConfig) Mailer {
+	return &smtpMailer{cfg: cfg}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg *Message) error {
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	body, err := Build(m.cfg.From, msg)
+	if err != nil {
+		return err
+	}
+
+	// net/smtp doesn't take a context, so run it in the background and give
+	// up waiting if the context ends first
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.cfg.Host, m.cfg.Port), auth, m.cfg.From, []string{msg.To}, body)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileDrop returns a Mailer that writes each email to dir as an .eml file
+// instead of sending it
+func NewFileDrop(dir string, from string) Mailer {
+	return &fileMailer{dir: dir, from: from}
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg *Message) error {
+	body, err := Build(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	// Write under a temporary name first so anything watching the directory
+	// never sees a partial file
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path+".tmp", body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+type logMailer struct {
+	logger logger.Logger
+}
+
+// NewLog returns a Mailer that only logs what it would send, for development
+// without an SMTP server
+func NewLog(logger logger.Logger) Mailer {
+	return &logMailer{logger: logger}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg *Message) error {
+	m.logger.Info("Email not sent, no SMTP server configured", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
+
+// Build renders msg as an RFC 5322 message from the given sender
+func Build(from string, msg *Message) ([]byte, error) {
+	var b strings.Builder
+	header := func(name string, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, headerValue(value))
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	names := make([]string, 0, len(msg.Headers))
+	for name := range msg.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header(textproto.CanonicalMIMEHeaderKey(name), msg.Headers[name])
+	}
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=UTF-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQuotedPrintable(&b, msg.Text); err != nil {
+			return nil, err
+		}
+		return []byte(b.String()), nil
+	}
+
+	var body strings.Builder
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	b.WriteString("\r\n")
+	b.WriteString(body.String())
+	return []byte(b.String()), nil
+}
+
+// headerValue keeps user supplied text such as a todo title from adding
+// headers of its own
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+// writeQuotedPrintable encodes text so long lines and non-ASCII characters
+// survive any mail server
+func writeQuotedPrintable(w io.Writer, text string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(text, "\r\n", "\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
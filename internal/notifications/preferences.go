@@ -0,0 +1,130 @@
+// Package notifications records in-app notifications and decides, from each
+// user's preferences and quiet hours, which channels an event goes out on.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Events a user can be notified about. The notifications table also accepts
+// comment, assignment, mention and share_invitation for when those features
+// exist.
+const EventReminder = "reminder"
+
+// Channels a notification can be delivered on. Webhooks are only used by
+// reminders, which carry their own URL.
+const (
+	ChannelInApp   = "in_app"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+var (
+	Events   = []string{EventReminder}
+	Channels = []string{ChannelInApp, ChannelEmail, ChannelWebhook}
+)
+
+// IsEvent reports whether name is a known event
+func IsEvent(name string) bool {
+	for _, event := range Events {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsChannel reports whether name is a known channel
+func IsChannel(name string) bool {
+	for _, channel := range Channels {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
+
+type preferenceKey struct {
+	event   string
+	channel string
+}
+
+// Preferences are a user's notification choices
+type Preferences struct {
+	enabled map[preferenceKey]bool
+	// QuietStart and QuietEnd are offsets from local midnight, set only when
+	// the user has quiet hours
+	QuietStart *time.Duration
+	QuietEnd   *time.Duration
+	Location   *time.Location
+}
+
+// LoadPreferences reads a user's preferences, quiet hours and timezone
+func LoadPreferences(ctx context.Context, querier db.Querier, userID int32) (*Preferences, error) {
+	user, err := querier.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	rows, err := querier.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := &Preferences{enabled: make(map[preferenceKey]bool, len(rows)), Location: location}
+	for _, row := range rows {
+		prefs.enabled[preferenceKey{row.Event, row.Channel}] = row.Enabled
+	}
+
+	settings, err := querier.GetNotificationSettings(ctx, userID)
+	switch {
+	case err == nil:
+		prefs.QuietStart = timeOfDay(settings.QuietHoursStart)
+		prefs.QuietEnd = timeOfDay(settings.QuietHoursEnd)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// Allows reports whether event should be delivered on channel. Everything is
+// on until the user turns it off.
+func (p *Preferences) Allows(event string, channel string) bool {
+	enabled, ok := p.enabled[preferenceKey{event, channel}]
+	return !ok || enabled
+}
+
+// Quiet reports whether t falls in the user's quiet hours, during which
+// nothing but in-app notifications are sent
+func (p *Preferences) Quiet(t time.Time) bool {
+	if p.QuietStart == nil || p.QuietEnd == nil {
+		return false
+	}
+	local := t.In(p.Location)
+	hour, minute, second := local.Clock()
+	now := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+
+	start, end := *p.QuietStart, *p.QuietEnd
+	if start <= end {
+		return now >= start && now < end
+	}
+	// The window runs overnight, e.g. 22:00 to 07:00
+	return now >= start || now < end
+}
+
+func timeOfDay(t pgtype.Time) *time.Duration {
+	if !t.Valid {
+		return nil
+	}
+	d := time.Duration(t.Microseconds) * time.Microsecond
+	return &d
+}
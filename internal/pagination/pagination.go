@@ -0,0 +1,238 @@
+// Package pagination implements keyset pagination with opaque cursors for the
+// list endpoints.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boetro/odot/internal/filter"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Kind is the Go type a sort column is decoded into from a cursor
+type Kind int
+
+const (
+	KindInt Kind = iota
+	KindText
+	KindTime
+)
+
+// SortKey describes a column a list can be ordered by
+type SortKey struct {
+	Column   string
+	Kind     Kind
+	Nullable bool
+}
+
+// Request holds the pagination query parameters accepted by list endpoints
+type Request struct {
+	Limit  int32  `form:"limit"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+	Order  string `form:"order"`
+}
+
+// Page is the response envelope for paginated lists
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// Query is a validated Request for a specific set of sort keys
+type Query struct {
+	Limit int32
+
+	sort     string
+	key      SortKey
+	desc     bool
+	idColumn string
+	after    *cursor
+	// afterValue is the decoded sort value of the cursor
+	afterValue any
+}
+
+type cursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d"`
+	Value json.RawMessage `json:"v"`
+	ID    int32           `json:"id"`
+}
+
+// Sort returns the name of the sort key in use
+func (q *Query) Sort() string {
+	return q.sort
+}
+
+// Resolve validates the request against the sort keys a list supports.
+// idColumn is the unique column used to break ties between equal sort values.
+func (r *Request) Resolve(keys map[string]SortKey, defaultSort string, idColumn string) (*Query, error) {
+	q := &Query{
+		Limit:    r.Limit,
+		sort:     r.Sort,
+		idColumn: idColumn,
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+
+	if q.sort == "" {
+		q.sort = defaultSort
+	}
+	key, ok := keys[q.sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort %q", q.sort)
+	}
+	q.key = key
+
+	switch r.Order {
+	case "", "desc":
+		q.desc = true
+	case "asc":
+		q.desc = false
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if r.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(r.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		var c cursor
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		// A cursor is only meaningful for the ordering it was created with
+		if c.Sort != q.sort || c.Desc != q.desc {
+			return nil, ErrInvalidCursor
+		}
+		value, err := q.decodeValue(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		q.after = &c
+		q.afterValue = value
+	}
+
+	return q, nil
+}
+
+// Apply adds the keyset condition that skips everything up to the cursor
+func (q *Query) Apply(b *filter.Builder) {
+	if q.after == nil {
+		return
+	}
+
+	value := q.afterValue
+
+	op := ">"
+	if q.desc {
+		op = "<"
+	}
+	col, id := q.key.Column, q.idColumn
+
+	if !q.key.Nullable {
+		b.Where(fmt.Sprintf("(%s, %s) %s (%%s, %%s)", col, id, op), value, q.after.ID)
+		return
+	}
+
+	// NULLs always sort last, so once we're into them only the ID moves forward
+	if value == nil {
+		b.Where(fmt.Sprintf("(%s IS NULL AND %s %s %%s)", col, id, op), q.after.ID)
+		return
+	}
+	v := b.Arg(value)
+	b.WhereSQL(fmt.Sprintf("(%s %s %s OR %s IS NULL OR (%s = %s AND %s %s %s))",
+		col, op, v, col, col, v, id, op, b.Arg(q.after.ID)))
+}
+
+// OrderBy returns the ORDER BY clause matching the keyset condition
+func (q *Query) OrderBy() string {
+	direction := "ASC"
+	if q.desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s NULLS LAST, %s %s", q.key.Column, direction, q.idColumn, direction)
+}
+
+// LimitArg is the number of rows to fetch: one extra to know whether there is
+// a next page
+func (q *Query) LimitArg() int32 {
+	return q.Limit + 1
+}
+
+// NewPage trims the extra row fetched by LimitArg and builds the cursor for
+// the next page. keyOf returns the sort value and ID of an item, and is called
+// with the last item on the page.
+func NewPage[T any](q *Query, items []T, keyOf func(T) (any, int32)) (*Page[T], error) {
+	page := &Page[T]{Items: items}
+	if int32(len(items)) <= q.Limit {
+		return page, nil
+	}
+
+	page.Items = items[:q.Limit]
+	value, id := keyOf(page.Items[len(page.Items)-1])
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(cursor{
+		Sort:  q.sort,
+		Desc:  q.desc,
+		Value: encoded,
+		ID:    id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	next := base64.RawURLEncoding.EncodeToString(raw)
+	page.NextCursor = &next
+	return page, nil
+}
+
+func (q *Query) decodeValue(raw json.RawMessage) (any, error) {
+	if string(raw) == "null" {
+		if !q.key.Nullable {
+			return nil, ErrInvalidCursor
+		}
+		return nil, nil
+	}
+
+	switch q.key.Kind {
+	case KindInt:
+		var v int64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return v, nil
+	case KindText:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return v, nil
+	case KindTime:
+		var v time.Time
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return v, nil
+	}
+	return nil, ErrInvalidCursor
+}
@@ -0,0 +1,182 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boetro/odot/internal/filter"
+)
+
+var testKeys = map[string]SortKey{
+	"id":      {Column: "t.todo_id", Kind: KindInt},
+	"title":   {Column: "t.title", Kind: KindText},
+	"created": {Column: "t.created_at", Kind: KindTime},
+	"due":     {Column: "t.assigned_date", Kind: KindTime, Nullable: true},
+}
+
+type item struct {
+	id    int32
+	value any
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, time.March, 10, 3, 30, 0, 123456789, time.FixedZone("EDT", -4*60*60))
+
+	tests := []struct {
+		name  string
+		sort  string
+		order string
+		last  item
+		sql   string
+		args  []any
+	}{
+		{
+			name: "int",
+			sort: "id",
+			last: item{42, int64(42)},
+			sql:  "(t.todo_id, t.todo_id) < ($1, $2)",
+			args: []any{int64(42), int32(42)},
+		},
+		{
+			name:  "text ascending",
+			sort:  "title",
+			order: "asc",
+			last:  item{7, `Quote " and ünïcode`},
+			sql:   "(t.title, t.todo_id) > ($1, $2)",
+			args:  []any{`Quote " and ünïcode`, int32(7)},
+		},
+		{
+			name: "time",
+			sort: "created",
+			last: item{3, created},
+			sql:  "(t.created_at, t.todo_id) < ($1, $2)",
+			args: []any{created, int32(3)},
+		},
+		{
+			name: "nullable with a value",
+			sort: "due",
+			last: item{9, created},
+			sql:  "(t.assigned_date < $1 OR t.assigned_date IS NULL OR (t.assigned_date = $1 AND t.todo_id < $2))",
+			args: []any{created, int32(9)},
+		},
+		{
+			name:  "nullable past the values",
+			sort:  "due",
+			order: "asc",
+			last:  item{9, nil},
+			sql:   "(t.assigned_date IS NULL AND t.todo_id > $1)",
+			args:  []any{int32(9)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := Request{Limit: 2, Sort: tt.sort, Order: tt.order}
+			q, err := req.Resolve(testKeys, "id", "t.todo_id")
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			items := []item{{1, nil}, tt.last, {100, nil}}
+			page, err := NewPage(q, items, func(it item) (any, int32) { return it.value, it.id })
+			if err != nil {
+				t.Fatalf("NewPage: %v", err)
+			}
+			if len(page.Items) != 2 || page.NextCursor == nil {
+				t.Fatalf("NewPage returned %d items and cursor %v, want 2 and a cursor", len(page.Items), page.NextCursor)
+			}
+
+			req.Cursor = *page.NextCursor
+			next, err := req.Resolve(testKeys, "id", "t.todo_id")
+			if err != nil {
+				t.Fatalf("Resolve with cursor: %v", err)
+			}
+			b := filter.NewBuilder()
+			next.Apply(b)
+			if got := b.Conditions(); got != tt.sql {
+				t.Errorf("Conditions() = %s, want %s", got, tt.sql)
+			}
+			got := b.Args()
+			if len(got) != len(tt.args) {
+				t.Fatalf("Args() = %v, want %v", got, tt.args)
+			}
+			for i := range got {
+				if want, ok := tt.args[i].(time.Time); ok {
+					if v, ok := got[i].(time.Time); !ok || !v.Equal(want) {
+						t.Errorf("Args()[%d] = %v, want %v", i, got[i], want)
+					}
+				} else if got[i] != tt.args[i] {
+					t.Errorf("Args()[%d] = %#v, want %#v", i, got[i], tt.args[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLastPage(t *testing.T) {
+	q, err := (&Request{Limit: 3}).Resolve(testKeys, "id", "t.todo_id")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	page, err := NewPage(q, []int32{1, 2, 3}, func(id int32) (any, int32) { return id, id })
+	if err != nil {
+		t.Fatalf("NewPage: %v", err)
+	}
+	if len(page.Items) != 3 || page.NextCursor != nil {
+		t.Errorf("NewPage returned %d items and cursor %v, want 3 and none", len(page.Items), page.NextCursor)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name    string
+		req     Request
+		limit   int32
+		orderBy string
+		err     error
+	}{
+		{name: "defaults", req: Request{}, limit: DefaultLimit, orderBy: "t.todo_id DESC NULLS LAST, t.todo_id DESC"},
+		{name: "limit capped", req: Request{Limit: 1000, Sort: "title", Order: "asc"}, limit: MaxLimit, orderBy: "t.title ASC NULLS LAST, t.todo_id ASC"},
+		{name: "unknown sort", req: Request{Sort: "priority"}, err: errAny},
+		{name: "unknown order", req: Request{Order: "up"}, err: errAny},
+		{name: "not base64", req: Request{Cursor: "!!!"}, err: ErrInvalidCursor},
+		{name: "not json", req: Request{Cursor: encode("nope")}, err: ErrInvalidCursor},
+		{name: "other sort", req: Request{Sort: "title", Cursor: encode(`{"s":"id","d":true,"v":1,"id":1}`)}, err: ErrInvalidCursor},
+		{name: "other order", req: Request{Order: "asc", Cursor: encode(`{"s":"id","d":true,"v":1,"id":1}`)}, err: ErrInvalidCursor},
+		{name: "wrong type", req: Request{Cursor: encode(`{"s":"id","d":true,"v":"one","id":1}`)}, err: ErrInvalidCursor},
+		{name: "null for required", req: Request{Cursor: encode(`{"s":"id","d":true,"v":null,"id":1}`)}, err: ErrInvalidCursor},
+		{name: "bad time", req: Request{Sort: "created", Cursor: encode(`{"s":"created","d":true,"v":"yesterday","id":1}`)}, err: ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.req.Resolve(testKeys, "id", "t.todo_id")
+			switch {
+			case tt.err == errAny:
+				if err == nil {
+					t.Fatal("Resolve succeeded, want an error")
+				}
+				return
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Resolve error = %v, want %v", err, tt.err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("Resolve: %v", err)
+			}
+			if q.Limit != tt.limit || q.LimitArg() != tt.limit+1 {
+				t.Errorf("Limit = %d, LimitArg = %d, want %d and %d", q.Limit, q.LimitArg(), tt.limit, tt.limit+1)
+			}
+			if got := q.OrderBy(); got != tt.orderBy {
+				t.Errorf("OrderBy() = %s, want %s", got, tt.orderBy)
+			}
+		})
+	}
+}
+
+// errAny marks cases that should fail with any error
+var errAny = errors.New("any error")
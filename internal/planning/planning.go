@@ -0,0 +1,66 @@
+// Package planning rolls unfinished todos over to the next day and proposes
+// what to work on each day.
+package planning
+
+import (
+	"sort"
+	"time"
+)
+
+// How unfinished todos are handled once the day they were assigned to is over
+const (
+	// RolloverMove moves them to the new day, keeping their time of day
+	RolloverMove = "move"
+	// RolloverFlag leaves them where they are and counts the missed day
+	RolloverFlag = "flag"
+	// RolloverOff leaves them alone
+	RolloverOff = "off"
+)
+
+// IsRolloverMode reports whether mode is a known rollover mode
+func IsRolloverMode(mode string) bool {
+	return mode == RolloverMove || mode == RolloverFlag || mode == RolloverOff
+}
+
+// Candidate is an open todo that could be worked on
+type Candidate struct {
+	ID int32
+	// Minutes is the todo's estimate, or 0 if it has none
+	Minutes       int32
+	Priority      int32
+	RolloverCount int32
+	CreatedAt     time.Time
+}
+
+// Rank sorts candidates by priority, highest first, and then by age: the
+// ones that have been put off the most times, then the oldest
+func Rank(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.RolloverCount != b.RolloverCount {
+			return a.RolloverCount > b.RolloverCount
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// Propose picks, in order, the ranked candidates whose estimates still fit
+// into the available minutes. A candidate that doesn't fit is skipped in
+// favor of smaller ones further down; ones without an estimate are never
+// picked.
+func Propose(ranked []Candidate, available int32) []Candidate {
+	var picked []Candidate
+	for _, candidate := range ranked {
+		if candidate.Minutes > 0 && candidate.Minutes <= available {
+			picked = append(picked, candidate)
+			available -= candidate.Minutes
+		}
+	}
+	return picked
+}
@@ -0,0 +1,89 @@
+package planning
+
+import (
+	"context"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/jobs"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// rolloverBatchSize caps how many users one run of the job handles; the rest
+// are picked up by the next run
+const rolloverBatchSize = 500
+
+// Rollover is a scheduled job that handles the unfinished todos of every user
+// whose day has ended since it last ran
+type Rollover struct{}
+
+func (Rollover) Kind() string { return "planning.rollover" }
+
+// RegisterJobs adds the rollover job to p and schedules it
+func RegisterJobs(p *jobs.WorkerPool, pool *pgxpool.Pool, logger logger.Logger) error {
+	jobs.Handle(p, func(ctx context.Context, job *jobs.Job, args Rollover) error {
+		return rollover(ctx, pool, logger, time.Now())
+	})
+	return p.Schedule("planning.rollover", "*/15 * * * *", Rollover{})
+}
+
+// rollover applies each due user's rollover mode to the todos assigned before
+// the start of their day, and schedules them again for the next midnight in
+// their timezone. A todo is only rolled over once a day, however often this
+// runs.
+func rollover(ctx context.Context, pool *pgxpool.Pool, logger logger.Logger, now time.Time) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	due, err := querier.ListDueRollovers(ctx, rolloverBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range due {
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		local := now.In(loc)
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		before := pgtype.Timestamptz{Time: today, Valid: true}
+
+		var rolled int64
+		switch user.RolloverMode {
+		case RolloverMove:
+			rolled, err = querier.MoveRolledOverTodos(ctx, db.MoveRolledOverTodosParams{
+				Today:    pgtype.Date{Time: time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC), Valid: true},
+				Timezone: loc.String(),
+				UserID:   user.UserID,
+				Before:   before,
+			})
+		case RolloverFlag:
+			rolled, err = querier.FlagRolledOverTodos(ctx, db.FlagRolledOverTodosParams{
+				UserID: user.UserID,
+				Before: before,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if rolled > 0 {
+			logger.Debug("Rolled over unfinished todos", "user_id", user.UserID, "mode", user.RolloverMode, "count", rolled)
+		}
+
+		err = querier.SetNextRollover(ctx, db.SetNextRolloverParams{
+			UserID:         user.UserID,
+			NextRolloverAt: pgtype.Timestamptz{Time: today.AddDate(0, 0, 1), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
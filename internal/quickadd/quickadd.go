@@ -0,0 +1,419 @@
+// Package quickadd parses a single line of text into the parts of a new todo,
+// for example
+//
+//	Call dentist tomorrow 3pm for 30m #Personal @health p1
+//
+// Recognized parts are removed from the line and whatever is left becomes the
+// title. The parser only deals with text; resolving project and tag names is
+// up to the caller.
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind identifies what a part of the line was recognized as
+type Kind string
+
+const (
+	KindDate     Kind = "date"
+	KindTime     Kind = "time"
+	KindDuration Kind = "duration"
+	KindProject  Kind = "project"
+	KindTag      Kind = "tag"
+	KindPriority Kind = "priority"
+)
+
+// Match is a recognized part of the line. Start and End are byte offsets so
+// clients can highlight it while the user types.
+type Match struct {
+	Kind  Kind   `json:"kind"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Result is a parsed line. AssignedDate is midnight in the given timezone
+// unless a time of day was given.
+type Result struct {
+	Title        string
+	AssignedDate *time.Time
+	HasTime      bool
+	DurationMin  *int32
+	Project      string
+	Tags         []string
+	Priority     int32
+	Matches      []Match
+}
+
+type word struct {
+	text  string
+	lower string
+	start int
+	end   int
+}
+
+var (
+	priorityPattern = regexp.MustCompile(`^p([1-4])$`)
+	// 30m, 1h, 1h30m, 90min, 2hrs
+	durationPattern = regexp.MustCompile(`^(?:(\d+)\s*h(?:ours?|rs?)?)?\s*(?:(\d+)\s*m(?:in(?:ute)?s?)?)?$`)
+	// 3pm, 3:30pm, 15:00
+	clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	isoPattern   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	numberWord   = regexp.MustCompile(`^\d+$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "saturday": time.Saturday,
+}
+
+// shortWeekdays are abbreviations that are also ordinary words, as in "buy
+// sun cream", so they only name a day after "on" or "next"
+var shortWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "january": time.January, "feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March, "apr": time.April, "april": time.April,
+	"may": time.May, "jun": time.June, "june": time.June, "jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August, "sep": time.September, "sept": time.September,
+	"september": time.September, "oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November, "dec": time.December, "december": time.December,
+}
+
+// parser holds the state of a single Parse call
+type parser struct {
+	words  []word
+	now    time.Time
+	result Result
+
+	date    *time.Time
+	hour    int
+	minute  int
+	hasTime bool
+}
+
+// Parse parses a line relative to now in loc. Only the first date, time and
+// duration are recognized; repeats are left in the title.
+func Parse(line string, now time.Time, loc *time.Location) *Result {
+	p := &parser{words: split(line), now: now.In(loc)}
+
+	var title []string
+	for i := 0; i < len(p.words); {
+		n := p.match(i)
+		if n == 0 {
+			title = append(title, p.words[i].text)
+			i++
+			continue
+		}
+		i += n
+	}
+	p.result.Title = strings.Join(title, " ")
+
+	if p.date == nil && p.hasTime {
+		today := startOfDay(p.now, 0)
+		p.date = &today
+	}
+	if p.date != nil {
+		assigned := *p.date
+		if p.hasTime {
+			assigned = time.Date(assigned.Year(), assigned.Month(), assigned.Day(), p.hour, p.minute, 0, 0, loc)
+		}
+		p.result.AssignedDate = &assigned
+		p.result.HasTime = p.hasTime
+	}
+	return &p.result
+}
+
+// match tries to recognize the words starting at i and returns how many it
+// consumed
+func (p *parser) match(i int) int {
+	w := p.words[i]
+
+	switch {
+	case strings.HasPrefix(w.text, "#") && len(w.text) > 1:
+		if p.result.Project != "" {
+			return 0
+		}
+		p.result.Project = w.text[1:]
+		return p.record(KindProject, i, 1)
+	case strings.HasPrefix(w.text, "@") && len(w.text) > 1:
+		p.result.Tags = append(p.result.Tags, w.text[1:])
+		return p.record(KindTag, i, 1)
+	}
+
+	if m := priorityPattern.FindStringSubmatch(w.lower); m != nil && p.result.Priority == 0 {
+		// p1 is the most urgent, stored as 4
+		priority, _ := strconv.Atoi(m[1])
+		p.result.Priority = int32(5 - priority)
+		return p.record(KindPriority, i, 1)
+	}
+
+	if w.lower == "for" && p.result.DurationMin == nil {
+		if n, minutes := p.duration(i + 1); n > 0 {
+			p.result.DurationMin = &minutes
+			return p.record(KindDuration, i, n+1)
+		}
+	}
+
+	if !p.hasTime {
+		if w.lower == "at" {
+			if n := p.clock(i + 1); n > 0 {
+				return p.record(KindTime, i, n+1)
+			}
+		}
+		if n := p.clock(i); n > 0 {
+			return p.record(KindTime, i, n)
+		}
+	}
+
+	if p.date == nil {
+		if w.lower == "on" {
+			if n := p.day(i + 1); n > 0 {
+				return p.record(KindDate, i, n+1)
+			}
+		}
+		if n := p.day(i); n > 0 {
+			return p.record(KindDate, i, n)
+		}
+	}
+
+	return 0
+}
+
+func (p *parser) record(kind Kind, i int, n int) int {
+	start, end := p.words[i].start, p.words[i+n-1].end
+	text := make([]string, n)
+	for j := range n {
+		text[j] = p.words[i+j].text
+	}
+	p.result.Matches = append(p.result.Matches, Match{Kind: kind, Text: strings.Join(text, " "), Start: start, End: end})
+	return n
+}
+
+// duration recognizes "30m", "1h30m", "1h 30m", "90 min" or "2 hours"
+func (p *parser) duration(i int) (int, int32) {
+	// Prefer the longest run of words that reads as a duration so "90 min"
+	// isn't cut short
+	for n := min(4, len(p.words)-i); n > 0; n-- {
+		var text strings.Builder
+		for j := range n {
+			text.WriteString(p.words[i+j].lower)
+		}
+		m := durationPattern.FindStringSubmatch(text.String())
+		if m == nil || (m[1] == "" && m[2] == "") {
+			continue
+		}
+		hours, _ := strconv.Atoi(m[1])
+		minutes, _ := strconv.Atoi(m[2])
+		total := hours*60 + minutes
+		if total <= 0 || total > 24*60 {
+			continue
+		}
+		return n, int32(total)
+	}
+	return 0, 0
+}
+
+// clock recognizes "3pm", "3:30 pm", "15:00", "noon" and "midnight"
+func (p *parser) clock(i int) int {
+	if i >= len(p.words) {
+		return 0
+	}
+	switch p.words[i].lower {
+	case "noon":
+		p.setTime(12, 0)
+		return 1
+	case "midnight":
+		p.setTime(0, 0)
+		return 1
+	}
+
+	text, n := p.words[i].lower, 1
+	if i+1 < len(p.words) && (p.words[i+1].lower == "am" || p.words[i+1].lower == "pm") {
+		text, n = text+p.words[i+1].lower, 2
+	}
+	m := clockPattern.FindStringSubmatch(text)
+	// A bare number is more likely part of the title than a time
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	if minute > 59 {
+		return 0
+	}
+	switch m[3] {
+	case "":
+		if hour > 23 {
+			return 0
+		}
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	p.setTime(hour, minute)
+	return n
+}
+
+func (p *parser) setTime(hour int, minute int) {
+	p.hour, p.minute, p.hasTime = hour, minute, true
+}
+
+// day recognizes relative days, weekdays, "in N days", "next week", ISO dates
+// and month-day dates. A bare weekday is the next one including today, while
+// "next" skips today.
+func (p *parser) day(i int) int {
+	if i >= len(p.words) {
+		return 0
+	}
+	w := p.words[i].lower
+	setDay := func(offset int, n int) int {
+		day := startOfDay(p.now, offset)
+		p.date = &day
+		return n
+	}
+
+	switch w {
+	case "today", "tod", "tonight":
+		return setDay(0, 1)
+	case "tomorrow", "tmr", "tmrw":
+		return setDay(1, 1)
+	case "yesterday":
+		return setDay(-1, 1)
+	}
+
+	if weekday, ok := p.weekday(i); ok {
+		return setDay(daysUntil(p.now.Weekday(), weekday, false), 1)
+	}
+
+	if w == "next" && i+1 < len(p.words) {
+		next := p.words[i+1].lower
+		if weekday, ok := p.weekday(i + 1); ok {
+			return setDay(daysUntil(p.now.Weekday(), weekday, true), 2)
+		}
+		if next == "week" {
+			return setDay(daysUntil(p.now.Weekday(), time.Monday, true), 2)
+		}
+	}
+
+	if w == "in" && i+2 < len(p.words) && numberWord.MatchString(p.words[i+1].lower) {
+		count, err := strconv.Atoi(p.words[i+1].lower)
+		if err == nil && count <= 366 {
+			switch p.words[i+2].lower {
+			case "day", "days":
+				return setDay(count, 3)
+			case "week", "weeks":
+				return setDay(count*7, 3)
+			}
+		}
+	}
+
+	if isoPattern.MatchString(w) {
+		if t, err := time.ParseInLocation(time.DateOnly, w, p.now.Location()); err == nil {
+			p.date = &t
+			return 1
+		}
+	}
+
+	// "oct 21" or "21 oct", in the next year if the date has passed
+	if i+1 < len(p.words) {
+		next := p.words[i+1].lower
+		month, ok := months[w]
+		dayText := next
+		if !ok {
+			month, ok = months[next]
+			dayText = w
+		}
+		if ok && numberWord.MatchString(dayText) {
+			dayOfMonth, _ := strconv.Atoi(dayText)
+			if date, valid := p.monthDay(month, dayOfMonth); valid {
+				p.date = &date
+				return 2
+			}
+		}
+	}
+
+	return 0
+}
+
+// weekday reads the i-th word as a day of the week
+func (p *parser) weekday(i int) (time.Weekday, bool) {
+	w := p.words[i].lower
+	if weekday, ok := weekdays[w]; ok {
+		return weekday, true
+	}
+	if weekday, ok := shortWeekdays[w]; ok && i > 0 {
+		if prev := p.words[i-1].lower; prev == "on" || prev == "next" {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+func (p *parser) monthDay(month time.Month, dayOfMonth int) (time.Time, bool) {
+	loc := p.now.Location()
+	year := p.now.Year()
+	date := time.Date(year, month, dayOfMonth, 0, 0, 0, 0, loc)
+	// time.Date normalizes Feb 30 into March, which isn't what the user meant
+	if date.Month() != month {
+		return time.Time{}, false
+	}
+	if date.Before(startOfDay(p.now, 0)) {
+		date = time.Date(year+1, month, dayOfMonth, 0, 0, 0, 0, loc)
+		if date.Month() != month {
+			return time.Time{}, false
+		}
+	}
+	return date, true
+}
+
+func daysUntil(from time.Weekday, to time.Weekday, skipToday bool) int {
+	days := (int(to) - int(from) + 7) % 7
+	if days == 0 && skipToday {
+		days = 7
+	}
+	return days
+}
+
+// startOfDay returns local midnight offset days from the day t falls on. It
+// goes through time.Date so it stays correct across DST transitions.
+func startOfDay(t time.Time, offset int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, t.Location())
+}
+
+func split(line string) []word {
+	var words []word
+	start := -1
+	for i, r := range line {
+		space := r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		if space && start >= 0 {
+			words = append(words, newWord(line, start, i))
+			start = -1
+		} else if !space && start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, newWord(line, start, len(line)))
+	}
+	return words
+}
+
+func newWord(line string, start int, end int) word {
+	text := line[start:end]
+	return word{text: text, lower: strings.ToLower(text), start: start, end: end}
+}
@@ -0,0 +1,139 @@
+package quickadd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	// A Saturday evening; clocks go forward at 02:00 the next day
+	springForward := time.Date(2024, time.March, 9, 20, 0, 0, 0, newYork)
+	// A Saturday; clocks go back at 02:00 the next day, so 01:30 happens twice
+	fallBack := time.Date(2024, time.November, 2, 20, 0, 0, 0, newYork)
+
+	tests := []struct {
+		line     string
+		now      time.Time
+		title    string
+		assigned string
+		hasTime  bool
+		duration int32
+		project  string
+		tags     []string
+		priority int32
+	}{
+		{
+			line:     "Call dentist tomorrow 3pm for 30m #Personal @health p1",
+			title:    "Call dentist",
+			assigned: "2024-03-10T15:00:00-04:00",
+			hasTime:  true,
+			duration: 30,
+			project:  "Personal",
+			tags:     []string{"health"},
+			priority: 4,
+		},
+		{line: "Plain title", title: "Plain title"},
+		{line: "report in 2 days p4", title: "report", assigned: "2024-03-11T00:00:00-04:00", priority: 1},
+		{line: "p2 p3", title: "p3", priority: 3},
+		{line: "read for 1h 30m", title: "read", duration: 90},
+		{line: "read for 90 min", title: "read", duration: 90},
+		{line: "sleep for 25h", title: "sleep for 25h"},
+		{line: "room 101", title: "room 101"},
+		{line: "meeting 15:00", title: "meeting", assigned: "2024-03-09T15:00:00-05:00", hasTime: true},
+		{line: "lunch at noon on friday", title: "lunch", assigned: "2024-03-15T12:00:00-04:00", hasTime: true},
+		{line: "#Work #Home @a @b", title: "#Home", project: "Work", tags: []string{"a", "b"}},
+
+		// Weekdays: a bare one may be today, "next" skips it
+		{line: "gym saturday", title: "gym", assigned: "2024-03-09T00:00:00-05:00"},
+		{line: "gym next saturday", title: "gym", assigned: "2024-03-16T00:00:00-04:00"},
+		{line: "Pay rent next week", title: "Pay rent", assigned: "2024-03-11T00:00:00-04:00"},
+		{line: "Buy sun cream", title: "Buy sun cream"},
+		{line: "sat exam prep", title: "sat exam prep"},
+		{line: "call mom on sat", title: "call mom", assigned: "2024-03-09T00:00:00-05:00"},
+		{line: "brunch next sun", title: "brunch", assigned: "2024-03-10T00:00:00-05:00"},
+
+		// Month days roll over to next year once they have passed
+		{line: "party 9 mar", title: "party", assigned: "2024-03-09T00:00:00-05:00"},
+		{line: "party mar 8", title: "party", assigned: "2025-03-08T00:00:00-05:00"},
+		{line: "taxes feb 30", title: "taxes feb 30"},
+		{line: "due 2024-02-29", title: "due", assigned: "2024-02-29T00:00:00-05:00"},
+
+		// DST transitions
+		{line: "backup tomorrow 1:30am", now: fallBack, title: "backup", assigned: "2024-11-03T01:30:00-04:00", hasTime: true},
+		{line: "review in 1 week", now: fallBack, title: "review", assigned: "2024-11-09T00:00:00-05:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			now := tt.now
+			if now.IsZero() {
+				now = springForward
+			}
+			got := Parse(tt.line, now, newYork)
+
+			if got.Title != tt.title {
+				t.Errorf("Title = %q, want %q", got.Title, tt.title)
+			}
+			assigned := ""
+			if got.AssignedDate != nil {
+				assigned = got.AssignedDate.Format(time.RFC3339)
+			}
+			if assigned != tt.assigned || got.HasTime != tt.hasTime {
+				t.Errorf("AssignedDate = %q (time %t), want %q (time %t)", assigned, got.HasTime, tt.assigned, tt.hasTime)
+			}
+			var duration int32
+			if got.DurationMin != nil {
+				duration = *got.DurationMin
+			}
+			if duration != tt.duration {
+				t.Errorf("DurationMin = %d, want %d", duration, tt.duration)
+			}
+			if got.Project != tt.project {
+				t.Errorf("Project = %q, want %q", got.Project, tt.project)
+			}
+			if !reflect.DeepEqual(got.Tags, tt.tags) {
+				t.Errorf("Tags = %q, want %q", got.Tags, tt.tags)
+			}
+			if got.Priority != tt.priority {
+				t.Errorf("Priority = %d, want %d", got.Priority, tt.priority)
+			}
+		})
+	}
+}
+
+func TestParseMatches(t *testing.T) {
+	tests := []struct {
+		line    string
+		matches []Match
+	}{
+		{
+			line: "Call dentist tomorrow 3 pm #Personal",
+			matches: []Match{
+				{Kind: KindDate, Text: "tomorrow", Start: 13, End: 21},
+				{Kind: KindTime, Text: "3 pm", Start: 22, End: 26},
+				{Kind: KindProject, Text: "#Personal", Start: 27, End: 36},
+			},
+		},
+		{
+			line: "call mom  on sat for 1h",
+			matches: []Match{
+				{Kind: KindDate, Text: "on sat", Start: 10, End: 16},
+				{Kind: KindDuration, Text: "for 1h", Start: 17, End: 23},
+			},
+		},
+		{line: "nothing to see", matches: nil},
+	}
+	now := time.Date(2024, time.March, 9, 20, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := Parse(tt.line, now, time.UTC)
+			if !reflect.DeepEqual(got.Matches, tt.matches) {
+				t.Errorf("Matches = %+v, want %+v", got.Matches, tt.matches)
+			}
+		})
+	}
+}
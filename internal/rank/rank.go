@@ -0,0 +1,154 @@
+// Package rank builds keys that order a list by plain byte comparison, so an
+// item is moved by giving it a key between its new neighbours' without
+// touching the rest of the list.
+package rank
+
+import "errors"
+
+// digits are the characters keys are made of, in byte order. A key reads
+// as a base 62 fraction, and never ends in the lowest digit so there is
+// always room before it.
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// MaxLength is how long keys may get before a list should be rebalanced.
+// Inserting at the same spot in the middle of a list over and over grows
+// keys by about one character every six inserts.
+const MaxLength = 32
+
+var (
+	ErrInvalid = errors.New("invalid rank key")
+	// ErrNoRoom is returned for bounds that aren't in order, including equal
+	// keys, which a rebalance spreads apart
+	ErrNoRoom = errors.New("no key between bounds that aren't in order")
+)
+
+// Valid reports whether key is a key of this package
+func Valid(key string) bool {
+	if key == "" || key[len(key)-1] == digits[0] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if digit(key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns a key that sorts after a and before b. An empty a stands
+// for the start of the list and an empty b for its end.
+func Between(a, b string) (string, error) {
+	if (a != "" && !Valid(a)) || (b != "" && !Valid(b)) {
+		return "", ErrInvalid
+	}
+	if b != "" && a >= b {
+		return "", ErrNoRoom
+	}
+	// Keys at the ends of a list step by one digit rather than halving the
+	// gap, so adding to the start or end again and again keeps keys short
+	switch {
+	case a != "" && b == "":
+		return after(a), nil
+	case a == "" && b != "":
+		return before(b), nil
+	}
+	return midpoint(a, b), nil
+}
+
+// Spread returns n keys in order between a and b, as short as they can be.
+// Spread("", "", n) gives the keys to rebalance a list of n items with.
+func Spread(a, b string, n int) ([]string, error) {
+	if _, err := Between(a, b); err != nil {
+		return nil, err
+	}
+	return spread(a, b, n), nil
+}
+
+func spread(a, b string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	mid := midpoint(a, b)
+	keys := append(spread(a, mid, n/2), mid)
+	return append(keys, spread(mid, b, n-1-n/2)...)
+}
+
+// after returns a short key after a
+func after(a string) string {
+	if a == "" {
+		return digits[1:2]
+	}
+	if d := digit(a[0]); d < len(digits)-1 {
+		return digits[d+1 : d+2]
+	}
+	return a[:1] + after(a[1:])
+}
+
+// before returns a short key before b, which doesn't end in the lowest
+// digit
+func before(b string) string {
+	switch d := digit(b[0]); d {
+	case 0:
+		return b[:1] + before(b[1:])
+	case 1:
+		return digits[:1] + digits[len(digits)-1:]
+	default:
+		return digits[d-1 : d]
+	}
+}
+
+// midpoint finds a key between a < b, where a is padded with the lowest
+// digit and an empty b is past every key
+func midpoint(a, b string) string {
+	if b != "" {
+		// Leading digits the bounds share are kept as they are
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(suffix(a, n), b[n:])
+		}
+	}
+
+	lo := digit(digitAt(a, 0))
+	hi := len(digits)
+	if b != "" {
+		hi = digit(b[0])
+	}
+	if hi-lo > 1 {
+		return string(digits[(lo+hi)/2])
+	}
+	// The first digits are next to each other, so the key goes one digit
+	// deeper: either b cut short or a followed by anything higher
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[lo]) + midpoint(suffix(a, 1), "")
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+func suffix(key string, i int) string {
+	if i < len(key) {
+		return key[i:]
+	}
+	return ""
+}
+
+func digit(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 36
+	}
+	return -1
+}
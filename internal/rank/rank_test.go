@@ -0,0 +1,163 @@
+package rank
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", "V"},
+		{"U", "", "V"},
+		{"z", "", "z1"},
+		{"zz", "", "zz1"},
+		{"", "U", "T"},
+		{"", "1", "0z"},
+		{"", "01", "00z"},
+		{"a", "c", "b"},
+		{"a", "b", "aV"},
+		{"a", "a1", "a0V"},
+		{"az", "b", "azV"},
+		{"a", "bz", "b"},
+		{"1", "11", "10V"},
+		{"0z", "1", "0zV"},
+		{"aU", "aV", "aUV"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			got, err := Between(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Between(%q, %q): %v", tt.a, tt.b, err)
+			}
+			if got != tt.want {
+				t.Errorf("Between(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+			checkOrder(t, tt.a, got, tt.b)
+		})
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	tests := []struct {
+		a, b string
+		err  error
+	}{
+		{"b", "a", ErrNoRoom},
+		{"a", "a", ErrNoRoom},
+		{"a0", "", ErrInvalid},
+		{"", "a!", ErrInvalid},
+		{"a-b", "c", ErrInvalid},
+	}
+	for _, tt := range tests {
+		if _, err := Between(tt.a, tt.b); !errors.Is(err, tt.err) {
+			t.Errorf("Between(%q, %q) error = %v, want %v", tt.a, tt.b, err, tt.err)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"", false},
+		{"0", false},
+		{"a0", false},
+		{"1", true},
+		{"0z", true},
+		{"Zz9", true},
+		{"a b", false},
+		{"é", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.key); got != tt.want {
+			t.Errorf("Valid(%q) = %t, want %t", tt.key, got, tt.want)
+		}
+	}
+}
+
+// TestRepeatedInserts inserts over and over at one spot and checks every key
+// stays in order and within MaxLength
+func TestRepeatedInserts(t *testing.T) {
+	tests := []struct {
+		name   string
+		lo, hi string
+		n      int
+		// next returns the bounds of the following insert
+		next func(lo, hi, key string) (string, string)
+	}{
+		// Appending and prepending step by one digit, so keys stay short
+		{"append", "1", "", 1000, func(lo, hi, key string) (string, string) { return key, hi }},
+		{"prepend", "", "1", 1000, func(lo, hi, key string) (string, string) { return lo, key }},
+		// Inserting at the same spot in the middle halves the same gap
+		{"after first", "1", "2", 150, func(lo, hi, key string) (string, string) { return lo, key }},
+		{"before last", "1", "2", 150, func(lo, hi, key string) (string, string) { return key, hi }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lo, hi := tt.lo, tt.hi
+			for i := range tt.n {
+				key, err := Between(lo, hi)
+				if err != nil {
+					t.Fatalf("insert %d: Between(%q, %q): %v", i, lo, hi, err)
+				}
+				checkOrder(t, lo, key, hi)
+				if len(key) > MaxLength {
+					t.Fatalf("insert %d: key %q is longer than %d", i, key, MaxLength)
+				}
+				lo, hi = tt.next(lo, hi, key)
+			}
+		})
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		a, b string
+		n    int
+	}{
+		{"", "", 0},
+		{"", "", 1},
+		{"", "", 10},
+		{"", "", 1000},
+		{"a", "b", 100},
+		{"", "01", 50},
+		{"zz", "", 62},
+	}
+	for _, tt := range tests {
+		keys, err := Spread(tt.a, tt.b, tt.n)
+		if err != nil {
+			t.Fatalf("Spread(%q, %q, %d): %v", tt.a, tt.b, tt.n, err)
+		}
+		if len(keys) != tt.n {
+			t.Fatalf("Spread(%q, %q, %d) returned %d keys", tt.a, tt.b, tt.n, len(keys))
+		}
+		prev := tt.a
+		for _, key := range keys {
+			checkOrder(t, prev, key, tt.b)
+			prev = key
+		}
+	}
+
+	if _, err := Spread("b", "a", 3); !errors.Is(err, ErrNoRoom) {
+		t.Errorf("Spread(b, a) error = %v, want %v", err, ErrNoRoom)
+	}
+}
+
+// checkOrder fails unless key is valid and sorts strictly between a and b,
+// where empty bounds are open
+func checkOrder(t *testing.T, a, key, b string) {
+	t.Helper()
+	if !Valid(key) {
+		t.Fatalf("key %q is invalid", key)
+	}
+	if a != "" && key <= a {
+		t.Fatalf("key %q doesn't sort after %q", key, a)
+	}
+	if b != "" && key >= b {
+		t.Fatalf("key %q doesn't sort before %q", key, b)
+	}
+}
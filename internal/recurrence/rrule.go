@@ -0,0 +1,605 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used
+// for repeating todos: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT,
+// UNTIL, BYDAY (with ordinals such as 1MO or -1FR), BYMONTHDAY, BYMONTH,
+// BYSETPOS and WKST.
+//
+// Occurrences keep the wall clock time of the start in its location, so a
+// todo due at 09:00 stays at 09:00 across DST transitions.
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxYears bounds how far ahead occurrences are searched. A rule that only
+// matches rarely, such as a Monday February 29th, still does within it, while
+// one that never can, say with a BYSETPOS past its candidates, gives up.
+const maxYears = 50
+
+// WeekdayNum is a BYDAY entry. N is the ordinal within the month or year,
+// negative counting from the end, or 0 for every such weekday.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed RRULE
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *Until
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// Until is the UNTIL of a rule. A date-only UNTIL includes the whole day in
+// the rule's location, so it is kept as a date until then.
+type Until struct {
+	Time     time.Time
+	DateOnly bool
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var presets = map[string]string{
+	"daily":                 "FREQ=DAILY",
+	"weekdays":              "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	"weekends":              "FREQ=WEEKLY;BYDAY=SA,SU",
+	"weekly":                "FREQ=WEEKLY",
+	"biweekly":              "FREQ=WEEKLY;INTERVAL=2",
+	"monthly":               "FREQ=MONTHLY",
+	"yearly":                "FREQ=YEARLY",
+	"first_monday":          "FREQ=MONTHLY;BYDAY=1MO",
+	"last_day_of_month":     "FREQ=MONTHLY;BYMONTHDAY=-1",
+	"last_weekday_of_month": "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+}
+
+// Preset returns the RRULE for a named preset such as "weekdays"
+func Preset(name string) (string, bool) {
+	rule, ok := presets[name]
+	return rule, ok
+}
+
+// Parse parses an RRULE value, with or without the "RRULE:" prefix
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			switch f := Frequency(strings.ToUpper(value)); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseInt(value, 1, 1000)
+		case "COUNT":
+			r.Count, err = parseInt(value, 1, 100000)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				var wd WeekdayNum
+				wd, err = parseWeekdayNum(day)
+				if err != nil {
+					break
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(value, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(value, 1, 12)
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			r.BySetPos, err = parseIntList(value, -366, 366)
+		case "WKST":
+			wd, ok := weekdayCodes[strings.ToUpper(value)]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", value)
+			}
+			r.WeekStart = wd
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", strings.ToUpper(name), err)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL can't both be set")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return nil, fmt.Errorf("BYDAY ordinals are only allowed with MONTHLY or YEARLY")
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == Weekly {
+		return nil, fmt.Errorf("BYMONTHDAY is not allowed with WEEKLY")
+	}
+	if !r.monthDayPossible() {
+		return nil, fmt.Errorf("BYMONTHDAY never falls in BYMONTH")
+	}
+	return r, nil
+}
+
+// monthDayPossible reports whether some BYMONTHDAY exists in some BYMONTH,
+// counting February as 29 days, so February 30th is refused
+func (r *Rule) monthDayPossible() bool {
+	if len(r.ByMonthDay) == 0 || len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, month := range r.ByMonth {
+		// 2000 is a leap year
+		length := daysIn(time.Date(2000, month, 1, 0, 0, 0, 0, time.UTC))
+		for _, d := range r.ByMonthDay {
+			if d <= length && -d <= length {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String formats the rule as an RRULE value
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		if r.Until.DateOnly {
+			parts = append(parts, "UNTIL="+r.Until.Time.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.Time.UTC().Format("20060102T150405Z"))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = weekdayCode(wd.Weekday)
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = int(m)
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCode(r.WeekStart))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the rule started at dtstart that is
+// strictly after after, or false if the rule has ended by then. dtstart is
+// only an occurrence if it matches the rule, and its location decides local
+// days.
+func (r *Rule) Next(dtstart time.Time, after time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	r.walk(dtstart, after, func(t time.Time) bool {
+		if t.After(after) {
+			next, found = t, true
+			return false
+		}
+		return true
+	})
+	return next, found
+}
+
+// Between returns the occurrences in [from, to), at most limit of them
+func (r *Rule) Between(dtstart time.Time, from time.Time, to time.Time, limit int) []time.Time {
+	var occurrences []time.Time
+	if limit <= 0 {
+		return occurrences
+	}
+	r.walk(dtstart, from, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// walk calls yield with the occurrences in order until it returns false or
+// the rule ends. Without COUNT, periods that end before from are skipped
+// since they can't hold an occurrence from yield is after.
+func (r *Rule) walk(dtstart time.Time, from time.Time, yield func(time.Time) bool) {
+	first := 0
+	if r.Count == 0 {
+		first = r.periodOf(dtstart, from)
+	}
+	found := 0
+	for period := first; period < first+r.maxPeriods(); period++ {
+		for _, t := range r.period(dtstart, period) {
+			if t.Before(dtstart) {
+				continue
+			}
+			if r.Until != nil && r.pastUntil(t) {
+				return
+			}
+			found++
+			if r.Count > 0 && found > r.Count {
+				return
+			}
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// maxPeriods returns how many periods cover maxYears, and at least the one
+// after from's for long intervals
+func (r *Rule) maxPeriods() int {
+	perYear := 1
+	switch r.Freq {
+	case Daily:
+		perYear = 366
+	case Weekly:
+		perYear = 53
+	case Monthly:
+		perYear = 12
+	}
+	return maxYears*perYear/r.Interval + 2
+}
+
+// periodOf returns the period whose days include t's local date, or 0 when
+// t is before the first period
+func (r *Rule) periodOf(dtstart time.Time, t time.Time) int {
+	t = t.In(dtstart.Location())
+	var n int
+	switch r.Freq {
+	case Daily:
+		n = calendarDays(dtstart, t)
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		n = (calendarDays(dtstart, t) + offset) / 7
+	case Monthly:
+		n = (t.Year()-dtstart.Year())*12 + int(t.Month()) - int(dtstart.Month())
+	case Yearly:
+		n = t.Year() - dtstart.Year()
+	}
+	if n <= 0 {
+		return 0
+	}
+	return n / r.Interval
+}
+
+func (r *Rule) pastUntil(t time.Time) bool {
+	if r.Until.DateOnly {
+		u := r.Until.Time
+		endOfDay := time.Date(u.Year(), u.Month(), u.Day()+1, 0, 0, 0, 0, t.Location())
+		return !t.Before(endOfDay)
+	}
+	return t.After(r.Until.Time)
+}
+
+// period returns the sorted candidate occurrences of the n-th period after
+// dtstart, before BYSETPOS is applied to them
+func (r *Rule) period(dtstart time.Time, n int) []time.Time {
+	loc := dtstart.Location()
+	hour, minute, second := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, loc)
+	}
+	step := n * r.Interval
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := at(dtstart.Year(), dtstart.Month(), dtstart.Day()+step)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := at(dtstart.Year(), dtstart.Month(), dtstart.Day()-offset+step*7)
+		for i := range 7 {
+			day := at(weekStart.Year(), weekStart.Month(), weekStart.Day()+i)
+			if !r.matchesMonth(day) {
+				continue
+			}
+			if len(r.ByDay) == 0 && day.Weekday() != dtstart.Weekday() {
+				continue
+			}
+			if len(r.ByDay) > 0 && !r.matchesWeekday(day) {
+				continue
+			}
+			days = append(days, day)
+		}
+	case Monthly:
+		first := at(dtstart.Year(), dtstart.Month()+time.Month(step), 1)
+		if !r.matchesMonth(first) {
+			return nil
+		}
+		days = r.daysInMonth(first, dtstart.Day(), at)
+	case Yearly:
+		year := dtstart.Year() + step
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, month := range r.sortedMonths() {
+				days = append(days, r.daysInMonth(at(year, month, 1), dtstart.Day(), at)...)
+			}
+		case len(r.ByDay) > 0 || len(r.ByMonthDay) > 0:
+			// Without BYMONTH, ordinals count within the whole year
+			for month := time.January; month <= time.December; month++ {
+				days = append(days, r.monthDayMatches(at(year, month, 1), at)...)
+			}
+			days = r.filterByDay(days, at(year, time.January, 1), at(year+1, time.January, 1))
+		default:
+			day := at(year, dtstart.Month(), dtstart.Day())
+			if day.Month() == dtstart.Month() {
+				days = append(days, day)
+			}
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	days = slices.CompactFunc(days, func(a, b time.Time) bool { return a.Equal(b) })
+	return r.applySetPos(days)
+}
+
+// daysInMonth returns the days of the month starting at first that the rule
+// selects, defaulting to the start's day of the month
+func (r *Rule) daysInMonth(first time.Time, defaultDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		day := at(first.Year(), first.Month(), defaultDay)
+		// Months without that day are skipped, as RFC 5545 requires
+		if day.Month() != first.Month() {
+			return nil
+		}
+		return []time.Time{day}
+	}
+	days := r.monthDayMatches(first, at)
+	next := at(first.Year(), first.Month()+1, 1)
+	return r.filterByDay(days, first, next)
+}
+
+// monthDayMatches returns every day of the month if there is no BYMONTHDAY,
+// otherwise just the listed days
+func (r *Rule) monthDayMatches(first time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	length := daysIn(first)
+	var days []time.Time
+	if len(r.ByMonthDay) == 0 {
+		for d := 1; d <= length; d++ {
+			days = append(days, at(first.Year(), first.Month(), d))
+		}
+		return days
+	}
+	for _, d := range r.ByMonthDay {
+		if d < 0 {
+			d = length + d + 1
+		}
+		if d >= 1 && d <= length {
+			days = append(days, at(first.Year(), first.Month(), d))
+		}
+	}
+	return days
+}
+
+// filterByDay keeps the days matching BYDAY, with ordinals counted within
+// [start, end)
+func (r *Rule) filterByDay(days []time.Time, start time.Time, end time.Time) []time.Time {
+	if len(r.ByDay) == 0 {
+		return days
+	}
+	var kept []time.Time
+	for _, day := range days {
+		for _, wd := range r.ByDay {
+			if day.Weekday() != wd.Weekday {
+				continue
+			}
+			if wd.N == 0 || ordinalOf(day, start) == wd.N || negativeOrdinalOf(day, end) == wd.N {
+				kept = append(kept, day)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+func (r *Rule) applySetPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(days) == 0 {
+		return days
+	}
+	var selected []time.Time
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(days) + pos
+		}
+		if i >= 0 && i < len(days) {
+			selected = append(selected, days[i])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Before(selected[j]) })
+	return slices.CompactFunc(selected, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+func (r *Rule) matchesMonth(t time.Time) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, t.Month())
+}
+
+func (r *Rule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := daysIn(t)
+	for _, d := range r.ByMonthDay {
+		if d == t.Day() || (d < 0 && length+d+1 == t.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) sortedMonths() []time.Month {
+	months := slices.Clone(r.ByMonth)
+	slices.Sort(months)
+	return slices.Compact(months)
+}
+
+// ordinalOf returns which occurrence of its weekday day is since start, 1 based
+func ordinalOf(day time.Time, start time.Time) int {
+	return (calendarDays(start, day))/7 + 1
+}
+
+// negativeOrdinalOf returns which occurrence of its weekday day is counting
+// back from end, -1 being the last
+func negativeOrdinalOf(day time.Time, end time.Time) int {
+	return -((calendarDays(day, end)-1)/7 + 1)
+}
+
+// calendarDays counts whole days between two local dates, ignoring the
+// hour lost or gained to DST
+func calendarDays(from time.Time, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func parseInt(s string, minValue int, maxValue int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if n < minValue || n > maxValue || n == 0 {
+		return 0, fmt.Errorf("%d is out of range", n)
+	}
+	return n, nil
+}
+
+func parseIntList(s string, minValue int, maxValue int) ([]int, error) {
+	var values []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := parseInt(part, minValue, maxValue)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid day %q", s)
+	}
+	wd, ok := weekdayCodes[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid day %q", s)
+	}
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = parseInt(strings.TrimPrefix(prefix, "+"), -53, 53)
+		if err != nil {
+			return WeekdayNum{}, err
+		}
+	}
+	return WeekdayNum{N: n, Weekday: wd}, nil
+}
+
+func parseUntil(s string) (*Until, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return &Until{Time: t}, nil
+	}
+	if t, err := time.Parse("20060102", s); err == nil {
+		return &Until{Time: t, DateOnly: true}, nil
+	}
+	return nil, fmt.Errorf("%q must be YYYYMMDD or YYYYMMDDTHHMMSSZ", s)
+}
+
+func weekdayCode(wd time.Weekday) string {
+	for code, day := range weekdayCodes {
+		if day == wd {
+			return code
+		}
+	}
+	return ""
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,247 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"RRULE:FREQ=DAILY", "FREQ=DAILY"},
+		{"freq=weekly;interval=2;byday=mo,fr", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{"FREQ=MONTHLY;BYDAY=+1MO,-1FR;COUNT=10", "FREQ=MONTHLY;COUNT=10;BYDAY=1MO,-1FR"},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", "FREQ=YEARLY;BYMONTHDAY=29;BYMONTH=2"},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;WKST=SU", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;WKST=SU"},
+		{"FREQ=DAILY;UNTIL=20241231", "FREQ=DAILY;UNTIL=20241231"},
+		{"FREQ=DAILY;UNTIL=20241231T120000Z", "FREQ=DAILY;UNTIL=20241231T120000Z"},
+		{"FREQ=MONTHLY;BYMONTH=4,6;BYMONTHDAY=-31,31,30", "FREQ=MONTHLY;BYMONTHDAY=-31,31,30;BYMONTH=4,6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{"", "empty recurrence rule"},
+		{"RRULE:", "empty recurrence rule"},
+		{"INTERVAL=2", "FREQ is required"},
+		{"FREQ=HOURLY", `unsupported FREQ "HOURLY"`},
+		{"FREQ=DAILY;INTERVAL", `invalid rule part "INTERVAL"`},
+		{"FREQ=DAILY;INTERVAL=0", "invalid INTERVAL: 0 is out of range"},
+		{"FREQ=DAILY;COUNT=x", `invalid COUNT: "x" is not a number`},
+		{"FREQ=DAILY;BYMONTHDAY=32", "invalid BYMONTHDAY: 32 is out of range"},
+		{"FREQ=DAILY;BYMONTH=13", "invalid BYMONTH: 13 is out of range"},
+		{"FREQ=DAILY;BYDAY=XX", `invalid BYDAY: invalid day "XX"`},
+		{"FREQ=DAILY;UNTIL=tomorrow", `invalid UNTIL: "tomorrow" must be YYYYMMDD or YYYYMMDDTHHMMSSZ`},
+		{"FREQ=DAILY;WKST=XX", `invalid WKST "XX"`},
+		{"FREQ=DAILY;BYHOUR=9", "unsupported rule part BYHOUR"},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20241231", "COUNT and UNTIL can't both be set"},
+		{"FREQ=WEEKLY;BYDAY=1MO", "BYDAY ordinals are only allowed with MONTHLY or YEARLY"},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", "BYMONTHDAY is not allowed with WEEKLY"},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", "BYMONTHDAY never falls in BYMONTH"},
+		{"FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=-30", "BYMONTHDAY never falls in BYMONTH"},
+		{"FREQ=DAILY;BYMONTH=4,6,9,11;BYMONTHDAY=31,-31", "BYMONTHDAY never falls in BYMONTH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := Parse(tt.in)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Parse error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	// A Friday
+	start := time.Date(2024, time.March, 1, 9, 0, 0, 0, newYork)
+
+	tests := []struct {
+		rule  string
+		start time.Time
+		limit int
+		want  []string
+	}{
+		{
+			// 09:00 local on both sides of the DST change on the 10th
+			rule:  "FREQ=DAILY;INTERVAL=3",
+			limit: 5,
+			want:  []string{"2024-03-01 09:00 -05", "2024-03-04 09:00 -05", "2024-03-07 09:00 -05", "2024-03-10 09:00 -04", "2024-03-13 09:00 -04"},
+		},
+		{
+			rule:  "FREQ=DAILY;COUNT=3",
+			limit: 10,
+			want:  []string{"2024-03-01 09:00 -05", "2024-03-02 09:00 -05", "2024-03-03 09:00 -05"},
+		},
+		{
+			// A date-only UNTIL includes its whole day
+			rule:  "FREQ=DAILY;UNTIL=20240302",
+			limit: 10,
+			want:  []string{"2024-03-01 09:00 -05", "2024-03-02 09:00 -05"},
+		},
+		{
+			rule:  "FREQ=DAILY;UNTIL=20240302T130000Z",
+			limit: 10,
+			want:  []string{"2024-03-01 09:00 -05"},
+		},
+		{
+			rule:  "FREQ=WEEKLY;BYDAY=MO,FR",
+			limit: 4,
+			want:  []string{"2024-03-01 09:00 -05", "2024-03-04 09:00 -05", "2024-03-08 09:00 -05", "2024-03-11 09:00 -04"},
+		},
+		{
+			// With WKST=SU the Sunday starts the week, so INTERVAL=2 skips
+			// different days than with the default Monday
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,TU;WKST=SU",
+			limit: 4,
+			want:  []string{"2024-03-10 09:00 -04", "2024-03-12 09:00 -04", "2024-03-24 09:00 -04", "2024-03-26 09:00 -04"},
+		},
+		{
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,TU",
+			limit: 4,
+			want:  []string{"2024-03-03 09:00 -05", "2024-03-12 09:00 -04", "2024-03-17 09:00 -04", "2024-03-26 09:00 -04"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;BYDAY=1MO,-1FR",
+			limit: 4,
+			want:  []string{"2024-03-04 09:00 -05", "2024-03-29 09:00 -04", "2024-04-01 09:00 -04", "2024-04-26 09:00 -04"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			limit: 3,
+			want:  []string{"2024-03-31 09:00 -04", "2024-04-30 09:00 -04", "2024-05-31 09:00 -04"},
+		},
+		{
+			// Months without a 31st are skipped
+			rule:  "FREQ=MONTHLY",
+			start: time.Date(2024, time.January, 31, 9, 0, 0, 0, newYork),
+			limit: 3,
+			want:  []string{"2024-01-31 09:00 -05", "2024-03-31 09:00 -04", "2024-05-31 09:00 -04"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			limit: 3,
+			want:  []string{"2024-03-29 09:00 -04", "2024-04-30 09:00 -04", "2024-05-31 09:00 -04"},
+		},
+		{
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29",
+			limit: 3,
+			want:  []string{"2028-02-29 09:00 -05", "2032-02-29 09:00 -05", "2036-02-29 09:00 -05"},
+		},
+		{
+			// Ordinals count within the year without BYMONTH
+			rule:  "FREQ=YEARLY;BYDAY=-1SU",
+			limit: 2,
+			want:  []string{"2024-12-29 09:00 -05", "2025-12-28 09:00 -05"},
+		},
+		{
+			rule:  "FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+			limit: 2,
+			want:  []string{"2024-11-03 09:00 -05", "2025-11-02 09:00 -05"},
+		},
+		{
+			// Rare, but found: the next Monday February 29th is 28 years on
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;BYDAY=MO",
+			limit: 1,
+			want:  []string{"2044-02-29 09:00 -05"},
+		},
+		{
+			// Never matches, and gives up rather than searching forever
+			rule:  "FREQ=WEEKLY;BYDAY=MO;BYSETPOS=2",
+			limit: 1,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			dtstart := tt.start
+			if dtstart.IsZero() {
+				dtstart = start
+			}
+			got := format(r.Between(dtstart, dtstart, dtstart.AddDate(50, 0, 0), tt.limit))
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("Between =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	start := time.Date(2024, time.March, 1, 9, 0, 0, 0, newYork)
+
+	tests := []struct {
+		rule  string
+		after time.Time
+		want  string
+	}{
+		// Strictly after, so an occurrence at after itself is skipped
+		{"FREQ=DAILY", start, "2024-03-02 09:00 -05"},
+		{"FREQ=DAILY", start.Add(-time.Hour), "2024-03-01 09:00 -05"},
+		// Far from the start, found without walking every period in between
+		{"FREQ=WEEKLY", time.Date(2030, time.June, 1, 0, 0, 0, 0, newYork), "2030-06-07 09:00 -04"},
+		{"FREQ=DAILY;COUNT=3", start.AddDate(0, 0, 2), ""},
+		{"FREQ=DAILY;UNTIL=20240305", start.AddDate(0, 0, 4), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got := ""
+			if next, ok := r.Next(start, tt.after); ok {
+				got = format([]time.Time{next})[0]
+			}
+			if got != tt.want {
+				t.Errorf("Next(%v) = %q, want %q", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreset(t *testing.T) {
+	for name, rule := range presets {
+		if _, err := Parse(rule); err != nil {
+			t.Errorf("preset %s: %v", name, err)
+		}
+	}
+	if _, ok := Preset("fortnightly"); ok {
+		t.Error("Preset found an unknown name")
+	}
+}
+
+func format(times []time.Time) []string {
+	var out []string
+	for _, t := range times {
+		out = append(out, t.Format("2006-01-02 15:04 -07"))
+	}
+	return out
+}
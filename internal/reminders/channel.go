@@ -0,0 +1,128 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/mailer"
+	"github.com/boetro/odot/internal/notifications"
+	"github.com/boetro/odot/internal/safehttp"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ChannelInApp   = notifications.ChannelInApp
+	ChannelEmail   = notifications.ChannelEmail
+	ChannelWebhook = notifications.ChannelWebhook
+)
+
+// Delivery is a due reminder along with what channels need to deliver it
+type Delivery struct {
+	ReminderID int32     `json:"reminder_id"`
+	UserID     int32     `json:"user_id"`
+	TodoID     int32     `json:"todo_id"`
+	TodoTitle  string    `json:"todo_title"`
+	DueAt      time.Time `json:"due_at"`
+	UserEmail  string    `json:"-"`
+	WebhookURL string    `json:"-"`
+}
+
+// Channel delivers reminders one way. Deliver runs outside any transaction,
+// after the reminder is claimed, and may be called again for a reminder whose
+// outcome the scheduler failed to record.
+type Channel interface {
+	Name() string
+	Deliver(ctx context.Context, delivery *Delivery) error
+}
+
+type inAppChannel struct {
+	querier db.Querier
+}
+
+// NewInAppChannel returns a channel that adds a notification to the user's
+// notifications center
+func NewInAppChannel(querier db.Querier) Channel {
+	return &inAppChannel{querier: querier}
+}
+
+func (ch *inAppChannel) Name() string {
+	return ChannelInApp
+}
+
+func (ch *inAppChannel) Deliver(ctx context.Context, delivery *Delivery) error {
+	_, err := db.QuerierFromContext(ctx, ch.querier).CreateNotification(ctx, db.CreateNotificationParams{
+		UserID: delivery.UserID,
+		TodoID: pgtype.Int4{Int32: delivery.TodoID, Valid: true},
+		Event:  notifications.EventReminder,
+		Title:  delivery.TodoTitle,
+		Body:   pgtype.Text{String: "Due " + delivery.DueAt.UTC().Format(time.RFC3339), Valid: true},
+	})
+	return err
+}
+
+type emailChannel struct {
+	mailer mailer.Mailer
+}
+
+// NewEmailChannel returns a channel that emails the user
+func NewEmailChannel(mailer mailer.Mailer) Channel {
+	return &emailChannel{mailer: mailer}
+}
+
+func (ch *emailChannel) Name() string {
+	return ChannelEmail
+}
+
+func (ch *emailChannel) Deliver(ctx context.Context, delivery *Delivery) error {
+	return ch.mailer.Send(ctx, &mailer.Message{
+		To:      delivery.UserEmail,
+		Subject: "Reminder: " + delivery.TodoTitle,
+		Text:    fmt.Sprintf("%s\n\nDue %s\n", delivery.TodoTitle, delivery.DueAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+type webhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel returns a channel that POSTs the reminder as JSON to the
+// reminder's webhook URL, if guard permits its host. Any status other than
+// 2xx is a failed delivery.
+func NewWebhookChannel(guard *safehttp.Guard) Channel {
+	return &webhookChannel{client: guard.Client(10 * time.Second)}
+}
+
+func (ch *webhookChannel) Name() string {
+	return ChannelWebhook
+}
+
+func (ch *webhookChannel) Deliver(ctx context.Context, delivery *Delivery) error {
+	body, err := json.Marshal(struct {
+		Event string `json:"event"`
+		*Delivery
+	}{notifications.EventReminder, delivery})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
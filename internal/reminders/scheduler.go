@@ -0,0 +1,202 @@
+// Package reminders fires due reminders through their delivery channels.
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/boetro/odot/internal/db"
+	"github.com/boetro/odot/internal/logger"
+	"github.com/boetro/odot/internal/notifications"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// batchSize is how many reminders one replica claims per transaction
+	batchSize = 100
+	// maxAttempts is how many times delivery is tried before giving up
+	maxAttempts = 5
+	// maxLateness is how overdue a reminder can be and still fire, so a
+	// server that was down doesn't send a burst of stale reminders
+	maxLateness = time.Hour
+	// claimLease is how long a claimed batch has to be delivered before
+	// another replica may claim what is left of it
+	claimLease = 10 * time.Minute
+)
+
+// Scheduler polls for due reminders and delivers them. A batch is claimed
+// with FOR UPDATE SKIP LOCKED and leased by pushing its next attempt out by
+// claimLease, so any number of replicas can run a Scheduler without firing a
+// reminder twice. Delivery happens after the claim commits, and each outcome
+// is recorded on its own. Delivery is at least once: a reminder whose outcome
+// couldn't be recorded is sent again once its lease runs out.
+type Scheduler struct {
+	pool     *pgxpool.Pool
+	querier  db.Querier
+	channels map[string]Channel
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func NewScheduler(pool *pgxpool.Pool, interval time.Duration, logger logger.Logger, channels ...Channel) *Scheduler {
+	byName := make(map[string]Channel, len(channels))
+	for _, channel := range channels {
+		byName[channel.Name()] = channel
+	}
+	return &Scheduler{
+		pool:     pool,
+		querier:  db.New(pool),
+		channels: byName,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run polls until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back so a backlog drains
+		// without waiting for the next tick
+		for {
+			fired, err := s.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to fire reminders", "error", err)
+			}
+			if err != nil || fired < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims a batch of due reminders and delivers them, returning how
+// many were claimed
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	due, err := s.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Reminders not reached before the lease runs out are left for whoever
+	// claims them next
+	deliverCtx, cancel := context.WithTimeout(ctx, claimLease)
+	defer cancel()
+	for i := range due {
+		if deliverCtx.Err() != nil {
+			break
+		}
+		if err := s.fire(ctx, deliverCtx, &due[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), nil
+}
+
+// claim leases a batch of due reminders to this scheduler
+func (s *Scheduler) claim(ctx context.Context) ([]db.ClaimDueRemindersRow, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	querier := db.New(tx)
+	due, err := querier.ClaimDueReminders(ctx, db.ClaimDueRemindersParams{
+		MaxLateness: pgtype.Interval{Microseconds: maxLateness.Microseconds(), Valid: true},
+		BatchSize:   batchSize,
+	})
+	if err != nil || len(due) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int32, len(due))
+	for i := range due {
+		ids[i] = due[i].ReminderID
+	}
+	err = querier.LeaseReminders(ctx, db.LeaseRemindersParams{
+		LeaseUntil:  pgtype.Timestamptz{Time: time.Now().Add(claimLease), Valid: true},
+		ReminderIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return due, tx.Commit(ctx)
+}
+
+// fire delivers one claimed reminder with deliverCtx and records the outcome
+func (s *Scheduler) fire(ctx context.Context, deliverCtx context.Context, reminder *db.ClaimDueRemindersRow) error {
+	deliveryErr := s.deliver(deliverCtx, reminder)
+	if deliveryErr == nil {
+		return s.querier.MarkReminderFired(ctx, db.MarkReminderFiredParams{
+			FiredFor:   reminder.DueAt,
+			ReminderID: reminder.ReminderID,
+		})
+	}
+
+	lastError := pgtype.Text{String: deliveryErr.Error(), Valid: true}
+	attempts := reminder.Attempts + 1
+	if attempts >= maxAttempts {
+		s.logger.Error("Giving up on reminder", "reminder_id", reminder.ReminderID, "channel", reminder.Channel, "error", deliveryErr)
+		// Marking it fired stops retries until the due time changes
+		return s.querier.MarkReminderFired(ctx, db.MarkReminderFiredParams{
+			FiredFor:   reminder.DueAt,
+			LastError:  lastError,
+			ReminderID: reminder.ReminderID,
+		})
+	}
+
+	s.logger.Debug("Reminder delivery failed", "reminder_id", reminder.ReminderID, "channel", reminder.Channel, "attempts", attempts, "error", deliveryErr)
+	return s.querier.MarkReminderFailed(ctx, db.MarkReminderFailedParams{
+		LastError:     lastError,
+		NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(backoff(attempts)), Valid: true},
+		ReminderID:    reminder.ReminderID,
+	})
+}
+
+// deliver sends a reminder on its channel, following the user's notification
+// preferences. During quiet hours email and webhook reminders are delivered
+// in-app instead, and a reminder on a channel the user turned off is dropped.
+func (s *Scheduler) deliver(ctx context.Context, reminder *db.ClaimDueRemindersRow) error {
+	prefs, err := notifications.LoadPreferences(ctx, s.querier, reminder.UserID)
+	if err != nil {
+		return err
+	}
+	name := reminder.Channel
+	if name != ChannelInApp && prefs.Quiet(time.Now()) {
+		name = ChannelInApp
+	}
+	if !prefs.Allows(notifications.EventReminder, name) {
+		s.logger.Debug("Reminder channel turned off", "reminder_id", reminder.ReminderID, "channel", name)
+		return nil
+	}
+
+	channel, ok := s.channels[name]
+	if !ok {
+		return fmt.Errorf("channel %q is not configured", name)
+	}
+	return channel.Deliver(ctx, &Delivery{
+		ReminderID: reminder.ReminderID,
+		UserID:     reminder.UserID,
+		TodoID:     reminder.TodoID,
+		TodoTitle:  reminder.TodoTitle,
+		DueAt:      reminder.DueAt.Time,
+		UserEmail:  reminder.UserEmail,
+		WebhookURL: reminder.WebhookUrl.String,
+	})
+}
+
+// backoff doubles the wait after each failed attempt, starting at a minute
+func backoff(attempts int32) time.Duration {
+	return time.Minute << (attempts - 1)
+}
@@ -0,0 +1,116 @@
+// Package safehttp makes requests to URLs that users give us, such as
+// calendar subscriptions and webhooks, without letting them reach the
+// server's own network.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a URL's host is, or resolves to, an
+// address that isn't publicly routable
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// reserved are ranges that aren't publicly routable but that netip.Addr
+// doesn't classify as private
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Guard decides which addresses outgoing requests may connect to. Loopback,
+// private, link-local and other reserved addresses are refused unless they
+// are in the allowlist.
+type Guard struct {
+	allowed []netip.Prefix
+}
+
+// NewGuard returns a Guard that also permits the networks in allowed, which
+// is meant for tests and local development
+func NewGuard(allowed []netip.Prefix) *Guard {
+	return &Guard{allowed: allowed}
+}
+
+// Permits reports whether connections to addr are allowed
+func (g *Guard) Permits(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Client returns an HTTP client that refuses to connect to addresses the
+// guard doesn't permit. The check runs on the resolved address of every
+// connection, so it also covers redirects and hosts whose DNS changes after
+// they were checked.
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !g.Permits(addrPort.Addr()) {
+				return fmt.Errorf("%s: %w", addrPort.Addr(), ErrForbiddenAddress)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the connection on our behalf, out of the guard's
+	// sight
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// CheckURL resolves the host of rawURL and fails with ErrForbiddenAddress if
+// any of its addresses aren't permitted. It lets a URL be refused when it is
+// saved; requests still need a client from Client.
+func (g *Guard) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !g.Permits(addr) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !g.Permits(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Package search builds full-text search queries over todos, comments and
+// projects.
+package search
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/boetro/odot/internal/filter"
+)
+
+// Document vectors. These must stay in sync with the expression indexes in
+// the full_text_search migration or searches fall back to sequential scans.
+const (
+	todoVector    = "(setweight(to_tsvector('english', t.title), 'A') || setweight(to_tsvector('english', COALESCE(t.description, '')), 'B'))"
+	commentVector = "to_tsvector('english', c.content)"
+	projectVector = "(setweight(to_tsvector('english', p.name), 'A') || setweight(to_tsvector('english', COALESCE(p.description, '')), 'B'))"
+)
+
+// Highlighted terms are wrapped in control characters so the surrounding text
+// can be HTML escaped before they are turned into <mark> tags.
+const (
+	startSel = "\x02"
+	stopSel  = "\x03"
+)
+
+type Type string
+
+const (
+	TypeTodo    Type = "todo"
+	TypeComment Type = "comment"
+	TypeProject Type = "project"
+)
+
+// Options describes a search. ProjectID and TagID restrict results to todos,
+// and comments on todos, in that project or with that tag.
+type Options struct {
+	UserID    int32
+	Text      string
+	ProjectID *int32
+	TagID     *int32
+	Types     []Type
+	Limit     int32
+	Offset    int32
+}
+
+// Result is a single search hit. TodoID is set for todos and comments,
+// ProjectID for projects and for todos and comments in a project.
+type Result struct {
+	Type      Type    `db:"type" json:"type"`
+	ID        int32   `db:"id" json:"id"`
+	TodoID    *int32  `db:"todo_id" json:"todo_id"`
+	ProjectID *int32  `db:"project_id" json:"project_id"`
+	Title     string  `db:"title" json:"title"`
+	Snippet   string  `db:"snippet" json:"snippet"`
+	Rank      float32 `db:"rank" json:"rank"`
+}
+
+// TSQuery turns free text into a tsquery that matches documents containing
+// every word, treating each word as a prefix so partial input matches while
+// the user is still typing. It returns "" if the text has no words.
+func TSQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = strings.ToLower(word) + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Build returns the SQL and arguments for a search, ordered by rank
+func Build(opts Options) (string, []any) {
+	b := filter.NewBuilder()
+	query := "to_tsquery('english', " + b.Arg(TSQuery(opts.Text)) + ")"
+	user := b.Arg(opts.UserID)
+	headline := "'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=20, MinWords=5'"
+
+	// Scope conditions for todos, also applied to the todo a comment is on
+	var scope []string
+	if opts.ProjectID != nil {
+		scope = append(scope, "t.project_id = "+b.Arg(*opts.ProjectID))
+	}
+	if opts.TagID != nil {
+		scope = append(scope, "EXISTS (SELECT 1 FROM todo_tags tt WHERE tt.todo_id = t.todo_id AND tt.tag_id = "+b.Arg(*opts.TagID)+")")
+	}
+	scoped := ""
+	if len(scope) > 0 {
+		scoped = " AND " + strings.Join(scope, " AND ")
+	}
+
+	var parts []string
+	for _, typ := range opts.Types {
+		switch typ {
+		case TypeTodo:
+			parts = append(parts, fmt.Sprintf(
+				"SELECT 'todo' AS type, t.todo_id AS id, t.todo_id AS todo_id, t.project_id AS project_id, t.title AS title, "+
+					"ts_headline('english', t.title || ' ' || COALESCE(t.description, ''), %[1]s, %[2]s) AS snippet, "+
+					"ts_rank_cd(%[3]s, %[1]s) AS rank "+
+					"FROM todos t WHERE t.user_id = %[4]s AND %[3]s @@ %[1]s%[5]s",
+				query, headline, todoVector, user, scoped))
+		case TypeComment:
+			parts = append(parts, fmt.Sprintf(
+				"SELECT 'comment' AS type, c.comment_id AS id, t.todo_id AS todo_id, t.project_id AS project_id, t.title AS title, "+
+					"ts_headline('english', c.content, %[1]s, %[2]s) AS snippet, "+
+					"ts_rank_cd(%[3]s, %[1]s) AS rank "+
+					"FROM comments c JOIN todos t ON t.todo_id = c.todo_id "+
+					"WHERE t.user_id = %[4]s AND %[3]s @@ %[1]s%[5]s",
+				query, headline, commentVector, user, scoped))
+		case TypeProject:
+			// Projects have no tags and can't be inside themselves
+			if len(scope) > 0 {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf(
+				"SELECT 'project' AS type, p.project_id AS id, NULL::int AS todo_id, p.project_id AS project_id, p.name AS title, "+
+					"ts_headline('english', p.name || ' ' || COALESCE(p.description, ''), %[1]s, %[2]s) AS snippet, "+
+					"ts_rank_cd(%[3]s, %[1]s) AS rank "+
+					"FROM projects p WHERE p.user_id = %[4]s AND %[3]s @@ %[1]s",
+				query, headline, projectVector, user))
+		}
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	sql := strings.Join(parts, " UNION ALL ") +
+		" ORDER BY rank DESC, type, id" +
+		" LIMIT " + b.Arg(opts.Limit) + " OFFSET " + b.Arg(opts.Offset)
+	return sql, b.Args()
+}
+
+// Highlight escapes a snippet for HTML and marks the matched terms with
+// <mark> tags
+func Highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, startSel, "<mark>")
+	return strings.ReplaceAll(escaped, stopSel, "</mark>")
+}
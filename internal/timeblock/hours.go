@@ -0,0 +1,117 @@
+// Package timeblock fits open todos into concrete time slots around a user's
+// working hours and the things already on their calendar.
+package timeblock
+
+import (
+	"sort"
+	"time"
+)
+
+// Window is a span of working time on one day of the week, as offsets from
+// midnight in the user's timezone
+type Window struct {
+	Weekday time.Weekday
+	Start   time.Duration
+	End     time.Duration
+}
+
+// DefaultHours are used for users who haven't set their working hours:
+// 09:00-17:00, Monday to Friday
+var DefaultHours = []Window{
+	{Weekday: time.Monday, Start: 9 * time.Hour, End: 17 * time.Hour},
+	{Weekday: time.Tuesday, Start: 9 * time.Hour, End: 17 * time.Hour},
+	{Weekday: time.Wednesday, Start: 9 * time.Hour, End: 17 * time.Hour},
+	{Weekday: time.Thursday, Start: 9 * time.Hour, End: 17 * time.Hour},
+	{Weekday: time.Friday, Start: 9 * time.Hour, End: 17 * time.Hour},
+}
+
+// Interval is a span of time from Start up to, but not including, End
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// Overlaps reports whether i and other share any time
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// WorkingTime returns the working hours between from and to, in order. Days
+// are laid out in loc, so a window keeps its wall clock times across DST
+// changes.
+func WorkingTime(hours []Window, loc *time.Location, from time.Time, to time.Time) []Interval {
+	var spans []Interval
+	from, to = from.In(loc), to.In(loc)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, w := range hours {
+			if w.Weekday != day.Weekday() {
+				continue
+			}
+			span := Interval{Start: atClock(day, w.Start), End: atClock(day, w.End)}
+			if span.Start.Before(from) {
+				span.Start = from
+			}
+			if span.End.After(to) {
+				span.End = to
+			}
+			if span.Start.Before(span.End) {
+				spans = append(spans, span)
+			}
+		}
+	}
+	return merge(spans)
+}
+
+// FreeSlots returns the working hours between from and to that none of busy
+// overlaps
+func FreeSlots(hours []Window, loc *time.Location, from time.Time, to time.Time, busy []Interval) []Interval {
+	free := WorkingTime(hours, loc, from, to)
+	for _, b := range busy {
+		free = subtract(free, b)
+	}
+	return free
+}
+
+// atClock returns the wall clock time offset from midnight on day
+func atClock(day time.Time, offset time.Duration) time.Time {
+	minutes := int(offset / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// merge sorts spans and joins the ones that overlap or touch
+func merge(spans []Interval) []Interval {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+	var merged []Interval
+	for _, span := range spans {
+		if n := len(merged); n > 0 && !span.Start.After(merged[n-1].End) {
+			if span.End.After(merged[n-1].End) {
+				merged[n-1].End = span.End
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// subtract removes cut from each of spans
+func subtract(spans []Interval, cut Interval) []Interval {
+	result := make([]Interval, 0, len(spans)+1)
+	for _, span := range spans {
+		if !span.Overlaps(cut) {
+			result = append(result, span)
+			continue
+		}
+		if span.Start.Before(cut.Start) {
+			result = append(result, Interval{Start: span.Start, End: cut.Start})
+		}
+		if cut.End.Before(span.End) {
+			result = append(result, Interval{Start: cut.End, End: span.End})
+		}
+	}
+	return result
+}
@@ -0,0 +1,223 @@
+package timeblock
+
+import "time"
+
+// Reason explains why a task was left out of a plan
+type Reason string
+
+const (
+	// ReasonNoRoom means no free slot is long enough for the task
+	ReasonNoRoom Reason = "no_room"
+	// ReasonDeadline means the task can't be finished before its deadline
+	ReasonDeadline Reason = "deadline"
+	// ReasonBlocked means the task waits on another one that couldn't be
+	// scheduled
+	ReasonBlocked Reason = "blocked"
+	// ReasonCycle means the task depends, directly or not, on itself
+	ReasonCycle Reason = "cycle"
+)
+
+// Task is an open todo to fit into the plan
+type Task struct {
+	ID       int32
+	Duration time.Duration
+	// Priority ranks tasks with the same deadline, higher first
+	Priority int32
+	// Deadline is zero for tasks without one
+	Deadline time.Time
+	// NotBefore keeps the task from starting earlier, e.g. on a later day it
+	// was assigned to
+	NotBefore time.Time
+	// DependsOn are the tasks that must be finished first
+	DependsOn []int32
+	CreatedAt time.Time
+}
+
+// Block is a task placed into a time slot
+type Block struct {
+	TaskID int32
+	Interval
+}
+
+// Unscheduled is a task that didn't fit
+type Unscheduled struct {
+	TaskID int32
+	Reason Reason
+}
+
+type Plan struct {
+	Blocks      []Block
+	Unscheduled []Unscheduled
+}
+
+// Schedule places tasks into the free slots, each in one piece at the
+// earliest time it fits. A task is only placed once the tasks it depends on
+// are, and after they end; placed holds when tasks outside of this plan, such
+// as pinned blocks, end. Among the tasks that are ready the one with the
+// earliest deadline goes first, then the one with the highest priority, then
+// the oldest. A task is ranked at least as urgently as the tasks waiting on
+// it.
+func Schedule(free []Interval, tasks []Task, placed map[int32]time.Time) *Plan {
+	ranks := rankTasks(tasks)
+	ends := make(map[int32]time.Time, len(placed)+len(tasks))
+	for id, end := range placed {
+		ends[id] = end
+	}
+	pending := make(map[int32]*Task, len(tasks))
+	for i := range tasks {
+		pending[tasks[i].ID] = &tasks[i]
+	}
+
+	plan := &Plan{}
+	failed := make(map[int32]bool)
+	for len(pending) > 0 {
+		var next *Task
+		var blocked *Task
+		for i := range tasks {
+			task := &tasks[i]
+			if pending[task.ID] == nil {
+				continue
+			}
+			ready, ok := readiness(task, ends, pending, failed)
+			if !ok {
+				blocked = task
+				break
+			}
+			if ready && (next == nil || ranks[task.ID].before(ranks[next.ID])) {
+				next = task
+			}
+		}
+
+		if blocked != nil {
+			delete(pending, blocked.ID)
+			failed[blocked.ID] = true
+			plan.Unscheduled = append(plan.Unscheduled, Unscheduled{TaskID: blocked.ID, Reason: ReasonBlocked})
+			continue
+		}
+		if next == nil {
+			// Everything left waits on something else that is left
+			for i := range tasks {
+				if pending[tasks[i].ID] != nil {
+					plan.Unscheduled = append(plan.Unscheduled, Unscheduled{TaskID: tasks[i].ID, Reason: ReasonCycle})
+				}
+			}
+			break
+		}
+
+		delete(pending, next.ID)
+		earliest := next.NotBefore
+		for _, id := range next.DependsOn {
+			if end := ends[id]; end.After(earliest) {
+				earliest = end
+			}
+		}
+		slot, ok := fit(free, earliest, next.Duration)
+		switch {
+		case !ok:
+			failed[next.ID] = true
+			plan.Unscheduled = append(plan.Unscheduled, Unscheduled{TaskID: next.ID, Reason: ReasonNoRoom})
+		case !next.Deadline.IsZero() && slot.End.After(next.Deadline):
+			failed[next.ID] = true
+			plan.Unscheduled = append(plan.Unscheduled, Unscheduled{TaskID: next.ID, Reason: ReasonDeadline})
+		default:
+			free = subtract(free, slot)
+			ends[next.ID] = slot.End
+			plan.Blocks = append(plan.Blocks, Block{TaskID: next.ID, Interval: slot})
+		}
+	}
+	return plan
+}
+
+// readiness reports whether every dependency of task has been placed, and
+// false for ok if one of them never will be
+func readiness(task *Task, ends map[int32]time.Time, pending map[int32]*Task, failed map[int32]bool) (ready bool, ok bool) {
+	ready = true
+	for _, id := range task.DependsOn {
+		if _, done := ends[id]; done {
+			continue
+		}
+		if failed[id] || pending[id] == nil {
+			return false, false
+		}
+		ready = false
+	}
+	return ready, true
+}
+
+// rank orders ready tasks
+type rank struct {
+	id        int32
+	deadline  time.Time
+	priority  int32
+	createdAt time.Time
+}
+
+// rankTasks ranks each task by its own deadline and priority, raised to those
+// of the tasks that depend on it. A dependency has to end early enough for
+// the task waiting on it to still make its deadline.
+func rankTasks(tasks []Task) map[int32]*rank {
+	ranks := make(map[int32]*rank, len(tasks))
+	for _, task := range tasks {
+		ranks[task.ID] = &rank{id: task.ID, deadline: task.Deadline, priority: task.Priority, createdAt: task.CreatedAt}
+	}
+	// Each pass raises dependencies one more level down, so a chain is done
+	// after as many passes as it is long. Cycles stop changing by then too.
+	for range tasks {
+		changed := false
+		for _, task := range tasks {
+			r := ranks[task.ID]
+			for _, id := range task.DependsOn {
+				dep, ok := ranks[id]
+				if !ok {
+					continue
+				}
+				if r.priority > dep.priority {
+					dep.priority = r.priority
+					changed = true
+				}
+				if !r.deadline.IsZero() {
+					if deadline := r.deadline.Add(-task.Duration); dep.deadline.IsZero() || deadline.Before(dep.deadline) {
+						dep.deadline = deadline
+						changed = true
+					}
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return ranks
+}
+
+// before reports whether r should be scheduled ahead of other
+func (r *rank) before(other *rank) bool {
+	if r.deadline.IsZero() != other.deadline.IsZero() {
+		return !r.deadline.IsZero()
+	}
+	if !r.deadline.Equal(other.deadline) {
+		return r.deadline.Before(other.deadline)
+	}
+	if r.priority != other.priority {
+		return r.priority > other.priority
+	}
+	if !r.createdAt.Equal(other.createdAt) {
+		return r.createdAt.Before(other.createdAt)
+	}
+	return r.id < other.id
+}
+
+// fit finds the earliest span of length duration, starting no earlier than
+// earliest, within one of the free slots
+func fit(free []Interval, earliest time.Time, duration time.Duration) (Interval, bool) {
+	for _, slot := range free {
+		start := slot.Start
+		if earliest.After(start) {
+			start = earliest
+		}
+		if end := start.Add(duration); !end.After(slot.End) {
+			return Interval{Start: start, End: end}, true
+		}
+	}
+	return Interval{}, false
+}
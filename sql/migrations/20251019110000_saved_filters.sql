@@ -0,0 +1,25 @@
+-- +goose Up
+-- Saved filters are named filter expressions shown as custom views
+CREATE TABLE saved_filters (
+    saved_filter_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#3B82F6',
+    query TEXT NOT NULL,
+    sort_by VARCHAR(20) NOT NULL DEFAULT 'created',
+    sort_order VARCHAR(4) NOT NULL DEFAULT 'desc',
+    group_by VARCHAR(20) NOT NULL DEFAULT 'none',
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    CONSTRAINT sort_order_check CHECK (sort_order IN ('asc', 'desc'))
+);
+
+CREATE TRIGGER update_saved_filters_updated_at BEFORE
+UPDATE ON saved_filters FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_saved_filters_updated_at ON saved_filters;
+
+DROP TABLE IF EXISTS saved_filters;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- IANA timezone name used to work out what "today" means for the user
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Full-text search indexes. The expressions must match the ones used by the
+-- search endpoint (internal/search) for Postgres to use the indexes.
+CREATE INDEX idx_todos_search ON todos USING GIN (
+    (setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', COALESCE(description, '')), 'B'))
+);
+
+CREATE INDEX idx_comments_search ON comments USING GIN (to_tsvector('english', content));
+
+CREATE INDEX idx_projects_search ON projects USING GIN (
+    (setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', COALESCE(description, '')), 'B'))
+);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_projects_search;
+
+DROP INDEX IF EXISTS idx_comments_search;
+
+DROP INDEX IF EXISTS idx_todos_search;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Trigram similarity is used to spot likely duplicate todos
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_todos_title_trgm ON todos USING GIN (title gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todos_title_trgm;
+
+DROP EXTENSION IF EXISTS pg_trgm;
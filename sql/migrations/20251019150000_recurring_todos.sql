@@ -0,0 +1,36 @@
+-- +goose Up
+-- Recurring todos keep one row that moves to its next occurrence when it is
+-- completed. recurrence_start anchors the rule (RFC 5545 DTSTART) and
+-- recurrence_mode decides whether the next occurrence follows the schedule
+-- or the completion date.
+ALTER TABLE todos
+ADD COLUMN recurrence_rule TEXT,
+ADD COLUMN recurrence_mode VARCHAR(20) NOT NULL DEFAULT 'schedule',
+ADD COLUMN recurrence_start TIMESTAMP WITH TIME ZONE,
+ADD COLUMN recurrence_count INTEGER NOT NULL DEFAULT 0,
+ADD CONSTRAINT recurrence_mode_check CHECK (recurrence_mode IN ('schedule', 'completion')),
+ADD CONSTRAINT recurrence_start_check CHECK (recurrence_rule IS NULL OR recurrence_start IS NOT NULL);
+
+-- Each completed occurrence of a recurring todo
+CREATE TABLE todo_occurrences (
+    todo_occurrence_id SERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos (todo_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    occurrence_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_todo_occurrences_todo_id ON todo_occurrences (todo_id, occurrence_date);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todo_occurrences_todo_id;
+
+DROP TABLE IF EXISTS todo_occurrences;
+
+ALTER TABLE todos
+DROP CONSTRAINT IF EXISTS recurrence_start_check,
+DROP CONSTRAINT IF EXISTS recurrence_mode_check,
+DROP COLUMN IF EXISTS recurrence_count,
+DROP COLUMN IF EXISTS recurrence_start,
+DROP COLUMN IF EXISTS recurrence_mode,
+DROP COLUMN IF EXISTS recurrence_rule;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- Reminders fire either at remind_at or offset_minutes before the todo's
+-- assigned_date. fired_for holds the due time that last fired, so a relative
+-- reminder fires again when a recurring todo moves to its next occurrence.
+CREATE TABLE reminders (
+    reminder_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    todo_id INTEGER NOT NULL REFERENCES todos (todo_id) ON DELETE CASCADE,
+    remind_at TIMESTAMP WITH TIME ZONE,
+    offset_minutes INTEGER,
+    channel VARCHAR(20) NOT NULL DEFAULT 'in_app',
+    webhook_url TEXT,
+    fired_for TIMESTAMP WITH TIME ZONE,
+    last_fired_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT reminder_time_check CHECK ((remind_at IS NULL) <> (offset_minutes IS NULL)),
+    CONSTRAINT reminder_channel_check CHECK (channel IN ('in_app', 'email', 'webhook')),
+    CONSTRAINT reminder_webhook_check CHECK (channel <> 'webhook' OR webhook_url IS NOT NULL)
+);
+
+CREATE INDEX idx_reminders_todo_id ON reminders (todo_id);
+
+CREATE INDEX idx_reminders_user_id ON reminders (user_id);
+
+CREATE TRIGGER update_reminders_updated_at BEFORE
+UPDATE ON reminders FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- In-app notifications, shown in the notifications center
+CREATE TABLE notifications (
+    notification_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    todo_id INTEGER REFERENCES todos (todo_id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications (user_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notifications_user_id;
+
+DROP TABLE IF EXISTS notifications;
+
+DROP TRIGGER IF EXISTS update_reminders_updated_at ON reminders;
+
+DROP INDEX IF EXISTS idx_reminders_user_id;
+
+DROP INDEX IF EXISTS idx_reminders_todo_id;
+
+DROP TABLE IF EXISTS reminders;
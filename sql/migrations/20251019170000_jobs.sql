@@ -0,0 +1,57 @@
+-- +goose Up
+-- Background jobs. A job is pending until a worker claims it, and goes back
+-- to pending with a later run_at when an attempt fails. Jobs that run out of
+-- attempts are dead and kept for inspection.
+CREATE TABLE jobs (
+    job_id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 10,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    unique_key TEXT,
+    locked_at TIMESTAMP WITH TIME ZONE,
+    locked_by TEXT,
+    last_error TEXT,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT job_status_check CHECK (status IN ('pending', 'running', 'completed', 'dead'))
+);
+
+CREATE INDEX idx_jobs_pending ON jobs (run_at, job_id) WHERE status = 'pending';
+
+CREATE INDEX idx_jobs_running ON jobs (locked_at) WHERE status = 'running';
+
+CREATE INDEX idx_jobs_finished ON jobs (finished_at) WHERE status IN ('completed', 'dead');
+
+-- Only one job with a given key can be waiting or running at a time
+CREATE UNIQUE INDEX idx_jobs_unique_key ON jobs (unique_key) WHERE status IN ('pending', 'running');
+
+CREATE TRIGGER update_jobs_updated_at BEFORE
+UPDATE ON jobs FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- Recurring jobs. Workers claim due schedules with SKIP LOCKED so each run
+-- is enqueued once no matter how many replicas are up.
+CREATE TABLE job_schedules (
+    name VARCHAR(100) PRIMARY KEY,
+    spec TEXT NOT NULL,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS job_schedules;
+
+DROP TRIGGER IF EXISTS update_jobs_updated_at ON jobs;
+
+DROP INDEX IF EXISTS idx_jobs_unique_key;
+
+DROP INDEX IF EXISTS idx_jobs_finished;
+
+DROP INDEX IF EXISTS idx_jobs_running;
+
+DROP INDEX IF EXISTS idx_jobs_pending;
+
+DROP TABLE IF EXISTS jobs;
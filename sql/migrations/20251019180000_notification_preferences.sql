@@ -0,0 +1,41 @@
+-- +goose Up
+ALTER TABLE notifications
+ADD COLUMN actor_user_id INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+ADD CONSTRAINT notification_event_check CHECK (event IN ('reminder', 'comment', 'assignment', 'mention', 'share_invitation'));
+
+CREATE INDEX idx_notifications_unread ON notifications (user_id, event) WHERE read_at IS NULL;
+
+-- Only choices that differ from the defaults need a row
+CREATE TABLE notification_preferences (
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, event, channel)
+);
+
+-- Quiet hours are wall clock times in the user's timezone. A window that
+-- ends before it starts runs overnight.
+CREATE TABLE notification_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    quiet_hours_start TIME,
+    quiet_hours_end TIME,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT quiet_hours_check CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL))
+);
+
+CREATE TRIGGER update_notification_settings_updated_at BEFORE
+UPDATE ON notification_settings FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_notification_settings_updated_at ON notification_settings;
+
+DROP TABLE IF EXISTS notification_settings;
+
+DROP TABLE IF EXISTS notification_preferences;
+
+DROP INDEX IF EXISTS idx_notifications_unread;
+
+ALTER TABLE notifications
+DROP CONSTRAINT IF EXISTS notification_event_check,
+DROP COLUMN IF EXISTS actor_user_id;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Emailed digests. send_hour is in the user's timezone; send_weekday only
+-- applies to the weekly digest, with 0 for Sunday.
+CREATE TABLE digest_subscriptions (
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    send_hour INTEGER NOT NULL DEFAULT 7,
+    send_weekday INTEGER NOT NULL DEFAULT 1,
+    next_send_at TIMESTAMP WITH TIME ZONE,
+    last_sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, kind),
+    CONSTRAINT digest_kind_check CHECK (kind IN ('daily', 'weekly')),
+    CONSTRAINT digest_send_hour_check CHECK (send_hour BETWEEN 0 AND 23),
+    CONSTRAINT digest_send_weekday_check CHECK (send_weekday BETWEEN 0 AND 6)
+);
+
+CREATE INDEX idx_digest_subscriptions_due ON digest_subscriptions (next_send_at) WHERE enabled;
+
+CREATE TRIGGER update_digest_subscriptions_updated_at BEFORE
+UPDATE ON digest_subscriptions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_digest_subscriptions_updated_at ON digest_subscriptions;
+
+DROP INDEX IF EXISTS idx_digest_subscriptions_due;
+
+DROP TABLE IF EXISTS digest_subscriptions;
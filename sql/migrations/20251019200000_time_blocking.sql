@@ -0,0 +1,48 @@
+-- +goose Up
+ALTER TABLE todos ADD COLUMN deadline TIMESTAMP WITH TIME ZONE;
+
+-- The hours a user is available for scheduled work, as times of day in their
+-- timezone. weekday is 0 for Sunday and a day may have several windows. Users
+-- without any rows work 09:00-17:00 on weekdays.
+CREATE TABLE working_hours (
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    weekday INTEGER NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    PRIMARY KEY (user_id, weekday, start_time),
+    CONSTRAINT working_hours_weekday_check CHECK (weekday BETWEEN 0 AND 6),
+    CONSTRAINT working_hours_range_check CHECK (start_time < end_time)
+);
+
+-- Time slots the auto-scheduler placed todos into. Proposed blocks are
+-- replaced whenever the plan is re-run unless they are pinned; accepted blocks
+-- have been copied onto the todo's assigned_date.
+CREATE TABLE schedule_blocks (
+    block_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    todo_id INTEGER NOT NULL UNIQUE REFERENCES todos (todo_id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'proposed',
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT schedule_block_status_check CHECK (status IN ('proposed', 'accepted')),
+    CONSTRAINT schedule_block_range_check CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX idx_schedule_blocks_user_starts_at ON schedule_blocks (user_id, starts_at);
+
+CREATE TRIGGER update_schedule_blocks_updated_at BEFORE
+UPDATE ON schedule_blocks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_schedule_blocks_updated_at ON schedule_blocks;
+
+DROP INDEX IF EXISTS idx_schedule_blocks_user_starts_at;
+
+DROP TABLE IF EXISTS schedule_blocks;
+
+DROP TABLE IF EXISTS working_hours;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS deadline;
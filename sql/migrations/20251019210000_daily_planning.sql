@@ -0,0 +1,55 @@
+-- +goose Up
+-- rollover_count is how many times a todo was left unfinished at the end of
+-- the day it was assigned to
+ALTER TABLE todos ADD COLUMN rollover_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE todos ADD COLUMN last_rolled_over_at TIMESTAMP WITH TIME ZONE;
+
+-- How unfinished todos are handled once their day is over: moved to the new
+-- day, only flagged, or left alone. Rows are created by the rollover job the
+-- first time it sees a user.
+CREATE TABLE planning_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    rollover_mode VARCHAR(20) NOT NULL DEFAULT 'flag',
+    next_rollover_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT planning_rollover_mode_check CHECK (rollover_mode IN ('move', 'flag', 'off'))
+);
+
+CREATE INDEX idx_planning_settings_next_rollover_at ON planning_settings (next_rollover_at);
+
+-- The todos a user committed to for a day. Items keep the estimate they were
+-- planned with so the day can be reviewed against it later.
+CREATE TABLE daily_plans (
+    daily_plan_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    plan_date DATE NOT NULL,
+    available_minutes INTEGER NOT NULL,
+    committed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, plan_date)
+);
+
+CREATE TABLE daily_plan_items (
+    daily_plan_id INTEGER NOT NULL REFERENCES daily_plans (daily_plan_id) ON DELETE CASCADE,
+    todo_id INTEGER NOT NULL REFERENCES todos (todo_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    planned_minutes INTEGER,
+    PRIMARY KEY (daily_plan_id, todo_id)
+);
+
+CREATE TRIGGER update_planning_settings_updated_at BEFORE
+UPDATE ON planning_settings FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_planning_settings_updated_at ON planning_settings;
+
+DROP TABLE IF EXISTS daily_plan_items;
+
+DROP TABLE IF EXISTS daily_plans;
+
+DROP INDEX IF EXISTS idx_planning_settings_next_rollover_at;
+
+DROP TABLE IF EXISTS planning_settings;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS last_rolled_over_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS rollover_count;
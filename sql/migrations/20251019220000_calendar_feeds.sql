@@ -0,0 +1,28 @@
+-- +goose Up
+-- Read-only iCalendar feeds. The token in the feed URL is the only
+-- credential, so only its hash is stored. A feed covers all of a user's
+-- scheduled todos, or those of one project or saved filter.
+CREATE TABLE calendar_feeds (
+    feed_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+    project_id INTEGER REFERENCES projects (project_id) ON DELETE CASCADE,
+    saved_filter_id INTEGER REFERENCES saved_filters (saved_filter_id) ON DELETE CASCADE,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT calendar_feed_scope_check CHECK (project_id IS NULL OR saved_filter_id IS NULL)
+);
+
+CREATE INDEX idx_calendar_feeds_user_id ON calendar_feeds (user_id);
+
+CREATE TRIGGER update_calendar_feeds_updated_at BEFORE
+UPDATE ON calendar_feeds FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_calendar_feeds_updated_at ON calendar_feeds;
+
+DROP INDEX IF EXISTS idx_calendar_feeds_user_id;
+
+DROP TABLE IF EXISTS calendar_feeds;
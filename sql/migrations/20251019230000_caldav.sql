@@ -0,0 +1,129 @@
+-- +goose Up
+-- App passwords let CalDAV clients, which only speak basic auth, sign in
+-- without the user's Google account. Like refresh tokens only the hash is
+-- stored.
+CREATE TABLE app_passwords (
+    app_password_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL UNIQUE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_app_passwords_user_id ON app_passwords (user_id);
+
+-- The resource name and UID a CalDAV client chose for a todo it created.
+-- Todos without a row are served as todo-<id>.ics with a UID derived from
+-- their ID.
+CREATE TABLE caldav_objects (
+    todo_id INTEGER PRIMARY KEY REFERENCES todos (todo_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    uid VARCHAR(255) NOT NULL,
+    UNIQUE (user_id, name),
+    UNIQUE (user_id, uid)
+);
+
+-- The latest change to each todo in each collection, a project or the inbox
+-- when project_id is NULL. change_id only grows, so it doubles as the sync
+-- token of a collection. A todo that left a collection, or was deleted,
+-- keeps a deleted row there so clients learn it is gone.
+CREATE TABLE caldav_changes (
+    change_id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    project_id INTEGER,
+    todo_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_caldav_changes_collection ON caldav_changes (user_id, project_id, change_id);
+
+CREATE INDEX idx_caldav_changes_todo_id ON caldav_changes (todo_id);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_caldav_change (change_user_id INTEGER, change_project_id INTEGER, change_todo_id INTEGER, change_deleted BOOLEAN) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM caldav_changes
+    WHERE todo_id = change_todo_id AND project_id IS NOT DISTINCT FROM change_project_id;
+    INSERT INTO caldav_changes (user_id, project_id, todo_id, name, deleted)
+    VALUES (
+        change_user_id,
+        change_project_id,
+        change_todo_id,
+        COALESCE((SELECT name FROM caldav_objects WHERE todo_id = change_todo_id), 'todo-' || change_todo_id || '.ics'),
+        change_deleted
+    );
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Runs before the change so a deleted todo's caldav_objects row, which goes
+-- with it, still names the resource
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_todo_caldav_change () RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM record_caldav_change(OLD.user_id, OLD.project_id, OLD.todo_id, TRUE);
+        RETURN OLD;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD.project_id IS DISTINCT FROM NEW.project_id THEN
+        PERFORM record_caldav_change(OLD.user_id, OLD.project_id, OLD.todo_id, TRUE);
+    END IF;
+    PERFORM record_caldav_change(NEW.user_id, NEW.project_id, NEW.todo_id, FALSE);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Tags show up as CATEGORIES, so tagging a todo changes it too
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_todo_tag_caldav_change () RETURNS TRIGGER AS $$
+DECLARE
+    changed RECORD;
+BEGIN
+    FOR changed IN
+        SELECT t.user_id, t.project_id, t.todo_id FROM todos t
+        WHERE (TG_OP = 'INSERT' AND t.todo_id = NEW.todo_id)
+            OR (TG_OP = 'DELETE' AND t.todo_id = OLD.todo_id)
+            OR (TG_OP = 'UPDATE' AND t.todo_id IN (SELECT todo_id FROM todo_tags WHERE tag_id = NEW.tag_id))
+    LOOP
+        PERFORM record_caldav_change(changed.user_id, changed.project_id, changed.todo_id, FALSE);
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER record_todos_caldav_change BEFORE INSERT OR UPDATE OR DELETE ON todos FOR EACH ROW EXECUTE FUNCTION record_todo_caldav_change ();
+
+CREATE TRIGGER record_todo_tags_caldav_change AFTER INSERT OR DELETE ON todo_tags FOR EACH ROW EXECUTE FUNCTION record_todo_tag_caldav_change ();
+
+CREATE TRIGGER record_tags_caldav_change AFTER UPDATE OF name ON tags FOR EACH ROW EXECUTE FUNCTION record_todo_tag_caldav_change ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS record_tags_caldav_change ON tags;
+
+DROP TRIGGER IF EXISTS record_todo_tags_caldav_change ON todo_tags;
+
+DROP TRIGGER IF EXISTS record_todos_caldav_change ON todos;
+
+DROP FUNCTION IF EXISTS record_todo_tag_caldav_change ();
+
+DROP FUNCTION IF EXISTS record_todo_caldav_change ();
+
+DROP FUNCTION IF EXISTS record_caldav_change (INTEGER, INTEGER, INTEGER, BOOLEAN);
+
+DROP INDEX IF EXISTS idx_caldav_changes_todo_id;
+
+DROP INDEX IF EXISTS idx_caldav_changes_collection;
+
+DROP TABLE IF EXISTS caldav_changes;
+
+DROP TABLE IF EXISTS caldav_objects;
+
+DROP INDEX IF EXISTS idx_app_passwords_user_id;
+
+DROP TABLE IF EXISTS app_passwords;
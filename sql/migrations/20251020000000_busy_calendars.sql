@@ -0,0 +1,63 @@
+-- +goose Up
+-- External calendars whose events count as busy time. A calendar is either an
+-- uploaded ICS file or a URL that is fetched again every so often; data holds
+-- the latest copy either way so recurring events can be expanded again as
+-- time moves on.
+CREATE TABLE busy_calendars (
+    calendar_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    url TEXT,
+    data TEXT NOT NULL DEFAULT '',
+    -- Validators from the last fetch, sent back to skip unchanged downloads
+    http_etag VARCHAR(255),
+    http_last_modified VARCHAR(255),
+    event_count INTEGER NOT NULL DEFAULT 0,
+    -- Events that couldn't be read, such as ones with unsupported recurrences
+    skipped_count INTEGER NOT NULL DEFAULT 0,
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    next_sync_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_busy_calendars_user_id ON busy_calendars (user_id);
+
+CREATE INDEX idx_busy_calendars_next_sync_at ON busy_calendars (next_sync_at);
+
+-- The occurrences of a busy calendar's events around the present, replaced
+-- whenever the calendar is synced
+CREATE TABLE busy_blocks (
+    busy_block_id SERIAL PRIMARY KEY,
+    calendar_id INTEGER NOT NULL REFERENCES busy_calendars (calendar_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    uid TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    all_day BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT busy_block_range_check CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX idx_busy_blocks_user_starts_at ON busy_blocks (user_id, starts_at);
+
+CREATE INDEX idx_busy_blocks_calendar_id ON busy_blocks (calendar_id);
+
+CREATE TRIGGER update_busy_calendars_updated_at BEFORE
+UPDATE ON busy_calendars FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_busy_calendars_updated_at ON busy_calendars;
+
+DROP INDEX IF EXISTS idx_busy_blocks_calendar_id;
+
+DROP INDEX IF EXISTS idx_busy_blocks_user_starts_at;
+
+DROP TABLE IF EXISTS busy_blocks;
+
+DROP INDEX IF EXISTS idx_busy_calendars_next_sync_at;
+
+DROP INDEX IF EXISTS idx_busy_calendars_user_id;
+
+DROP TABLE IF EXISTS busy_calendars;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- A todo can't start until the todos it depends on are completed. Cycles are
+-- rejected when a dependency is added.
+CREATE TABLE todo_dependencies (
+    todo_id INTEGER NOT NULL REFERENCES todos (todo_id) ON DELETE CASCADE,
+    depends_on_id INTEGER NOT NULL REFERENCES todos (todo_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (todo_id, depends_on_id),
+    CONSTRAINT no_self_dependency CHECK (todo_id != depends_on_id)
+);
+
+CREATE INDEX idx_todo_dependencies_depends_on_id ON todo_dependencies (depends_on_id);
+
+CREATE INDEX idx_todo_dependencies_user_id ON todo_dependencies (user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todo_dependencies_user_id;
+
+DROP INDEX IF EXISTS idx_todo_dependencies_depends_on_id;
+
+DROP TABLE IF EXISTS todo_dependencies;
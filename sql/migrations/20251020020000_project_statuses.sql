@@ -0,0 +1,75 @@
+-- +goose Up
+-- Workflow statuses are the columns of a project's board. Each falls into a
+-- category: todos in a done status are completed, and todos in any other are
+-- open.
+CREATE TABLE project_statuses (
+    status_id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects (project_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    category VARCHAR(10) NOT NULL CHECK (category IN ('todo', 'doing', 'done')),
+    position INTEGER NOT NULL DEFAULT 0,
+    -- wip_limit caps how many todos the status holds, or NULL for no limit
+    wip_limit INTEGER CHECK (wip_limit > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_project_statuses_project_id ON project_statuses (project_id, position);
+
+CREATE UNIQUE INDEX idx_project_statuses_name ON project_statuses (project_id, lower(name));
+
+CREATE TRIGGER update_project_statuses_updated_at BEFORE
+UPDATE ON project_statuses FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+ALTER TABLE todos ADD COLUMN status_id INTEGER REFERENCES project_statuses (status_id) ON DELETE SET NULL;
+
+CREATE INDEX idx_todos_status_id ON todos (status_id);
+
+-- Keeps a todo's status in its project and in line with is_completed, so
+-- completing a todo anywhere moves it to a done status and reopening it
+-- moves it back. A todo whose status doesn't fit gets the first one that
+-- does, preferring todo over doing, or none if its project has no such
+-- status.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION sync_todo_status () RETURNS TRIGGER AS $$
+DECLARE
+    current_category VARCHAR(10);
+BEGIN
+    IF NEW.project_id IS NULL THEN
+        NEW.status_id := NULL;
+        RETURN NEW;
+    END IF;
+    SELECT category INTO current_category FROM project_statuses
+    WHERE status_id = NEW.status_id AND project_id = NEW.project_id;
+    IF current_category IS NULL OR (current_category = 'done') != COALESCE(NEW.is_completed, FALSE) THEN
+        NEW.status_id := (
+            SELECT status_id FROM project_statuses
+            WHERE project_id = NEW.project_id AND (category = 'done') = COALESCE(NEW.is_completed, FALSE)
+            ORDER BY category = 'todo' DESC, position, status_id
+            LIMIT 1
+        );
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER sync_todos_status BEFORE INSERT OR UPDATE OF project_id, is_completed, status_id ON todos FOR EACH ROW EXECUTE FUNCTION sync_todo_status ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS sync_todos_status ON todos;
+
+DROP FUNCTION IF EXISTS sync_todo_status ();
+
+DROP INDEX IF EXISTS idx_todos_status_id;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS status_id;
+
+DROP TRIGGER IF EXISTS update_project_statuses_updated_at ON project_statuses;
+
+DROP INDEX IF EXISTS idx_project_statuses_name;
+
+DROP INDEX IF EXISTS idx_project_statuses_project_id;
+
+DROP TABLE IF EXISTS project_statuses;
@@ -0,0 +1,50 @@
+-- +goose Up
+-- position orders a todo among the todos with the same project and parent,
+-- and a project among the projects with the same parent. Keys compare byte by
+-- byte, so moving an item only rewrites its own key.
+ALTER TABLE todos ADD COLUMN position TEXT COLLATE "C";
+
+ALTER TABLE projects ADD COLUMN position TEXT COLLATE "C";
+
+-- Existing lists keep the newest first order they were shown in. Triggers are
+-- off so the backfill doesn't touch updated_at or show up as CalDAV changes.
+ALTER TABLE todos DISABLE TRIGGER USER;
+
+UPDATE todos t
+SET position = lpad(r.n::text, 10, '0') || 'V'
+FROM (
+    SELECT todo_id, row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC, todo_id DESC) AS n
+    FROM todos
+) r
+WHERE t.todo_id = r.todo_id;
+
+ALTER TABLE todos ENABLE TRIGGER USER;
+
+ALTER TABLE projects DISABLE TRIGGER USER;
+
+UPDATE projects p
+SET position = lpad(r.n::text, 10, '0') || 'V'
+FROM (
+    SELECT project_id, row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC, project_id DESC) AS n
+    FROM projects
+) r
+WHERE p.project_id = r.project_id;
+
+ALTER TABLE projects ENABLE TRIGGER USER;
+
+ALTER TABLE todos ALTER COLUMN position SET NOT NULL;
+
+ALTER TABLE projects ALTER COLUMN position SET NOT NULL;
+
+CREATE INDEX idx_todos_position ON todos (user_id, project_id, parent_todo_id, position);
+
+CREATE INDEX idx_projects_position ON projects (user_id, parent_project_id, position);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_projects_position;
+
+DROP INDEX IF EXISTS idx_todos_position;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS position;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS position;
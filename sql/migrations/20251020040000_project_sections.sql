@@ -0,0 +1,60 @@
+-- +goose Up
+-- Sections group the todos of a project under headings, ordered by the same
+-- kind of position keys as todos.
+CREATE TABLE project_sections (
+    section_id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects (project_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    position TEXT COLLATE "C" NOT NULL,
+    is_collapsed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_project_sections_project_id ON project_sections (project_id, position);
+
+CREATE TRIGGER update_project_sections_updated_at BEFORE
+UPDATE ON project_sections FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+-- Deleting a section leaves its todos in the project, outside any section
+ALTER TABLE todos ADD COLUMN section_id INTEGER REFERENCES project_sections (section_id) ON DELETE SET NULL;
+
+CREATE INDEX idx_todos_section_id ON todos (section_id);
+
+-- Only top-level todos are in a section, and only in one of their own
+-- project's. Subtasks show under their parent, and a todo moved to another
+-- project or under a parent leaves its section.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION sync_todo_section () RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.section_id IS NOT NULL AND (
+        NEW.parent_todo_id IS NOT NULL
+        OR NOT EXISTS (
+            SELECT 1 FROM project_sections
+            WHERE section_id = NEW.section_id AND project_id = NEW.project_id
+        )
+    ) THEN
+        NEW.section_id := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER sync_todos_section BEFORE INSERT OR UPDATE OF project_id, parent_todo_id, section_id ON todos FOR EACH ROW EXECUTE FUNCTION sync_todo_section ();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS sync_todos_section ON todos;
+
+DROP FUNCTION IF EXISTS sync_todo_section ();
+
+DROP INDEX IF EXISTS idx_todos_section_id;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS section_id;
+
+DROP TRIGGER IF EXISTS update_project_sections_updated_at ON project_sections;
+
+DROP INDEX IF EXISTS idx_project_sections_project_id;
+
+DROP TABLE IF EXISTS project_sections;
@@ -0,0 +1,74 @@
+-- name: CreateBusyCalendar :one
+INSERT INTO busy_calendars (user_id, name, url)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetBusyCalendar :one
+SELECT * FROM busy_calendars
+WHERE calendar_id = $1;
+
+-- name: LockBusyCalendar :one
+SELECT * FROM busy_calendars
+WHERE calendar_id = $1
+FOR UPDATE;
+
+-- name: ListBusyCalendars :many
+SELECT * FROM busy_calendars
+WHERE user_id = $1
+ORDER BY created_at, calendar_id;
+
+-- name: RenameBusyCalendar :one
+UPDATE busy_calendars
+SET name = $2
+WHERE calendar_id = $1
+RETURNING *;
+
+-- name: DeleteBusyCalendar :exec
+DELETE FROM busy_calendars
+WHERE calendar_id = $1;
+
+-- name: SetBusyCalendarData :one
+UPDATE busy_calendars
+SET data = $2, http_etag = $3, http_last_modified = $4
+WHERE calendar_id = $1
+RETURNING *;
+
+-- name: RecordBusyCalendarSync :one
+UPDATE busy_calendars
+SET event_count = $2, skipped_count = $3, next_sync_at = $4, last_synced_at = now(), last_error = NULL
+WHERE calendar_id = $1
+RETURNING *;
+
+-- name: RecordBusyCalendarError :one
+UPDATE busy_calendars
+SET last_error = $2, next_sync_at = $3
+WHERE calendar_id = $1
+RETURNING *;
+
+-- name: ClaimDueBusyCalendars :many
+SELECT * FROM busy_calendars
+WHERE next_sync_at <= now()
+ORDER BY next_sync_at
+LIMIT @batch_size
+FOR UPDATE SKIP LOCKED;
+
+-- name: SetBusyCalendarNextSync :exec
+UPDATE busy_calendars
+SET next_sync_at = $2
+WHERE calendar_id = $1;
+
+-- name: DeleteBusyBlocks :exec
+DELETE FROM busy_blocks
+WHERE calendar_id = $1;
+
+-- name: CreateBusyBlocks :exec
+INSERT INTO busy_blocks (calendar_id, user_id, uid, summary, starts_at, ends_at, all_day)
+SELECT @calendar_id::int, @user_id::int, unnest(@uids::text[]), unnest(@summaries::text[]),
+    unnest(@starts_at::timestamptz[]), unnest(@ends_at::timestamptz[]), unnest(@all_day::boolean[]);
+
+-- name: ListBusyBlocksBetween :many
+SELECT bb.*, bc.name AS calendar_name
+FROM busy_blocks bb
+JOIN busy_calendars bc ON bc.calendar_id = bb.calendar_id
+WHERE bb.user_id = @user_id AND bb.ends_at > @starts_at AND bb.starts_at < @ends_at
+ORDER BY bb.starts_at, bb.busy_block_id;
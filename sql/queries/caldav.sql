@@ -0,0 +1,69 @@
+-- name: CreateAppPassword :one
+INSERT INTO app_passwords (user_id, name, password_hash)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetAppPassword :one
+SELECT * FROM app_passwords
+WHERE app_password_id = $1;
+
+-- name: GetAppPasswordByHash :one
+SELECT * FROM app_passwords
+WHERE password_hash = $1;
+
+-- name: ListAppPasswords :many
+SELECT * FROM app_passwords
+WHERE user_id = $1
+ORDER BY created_at, app_password_id;
+
+-- name: TouchAppPassword :exec
+UPDATE app_passwords
+SET last_used_at = now()
+WHERE app_password_id = $1;
+
+-- name: DeleteAppPassword :exec
+DELETE FROM app_passwords
+WHERE app_password_id = $1;
+
+-- name: GetCaldavObjectByName :one
+SELECT * FROM caldav_objects
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetCaldavObjectByUID :one
+SELECT * FROM caldav_objects
+WHERE user_id = $1 AND uid = $2;
+
+-- name: ListCaldavObjects :many
+SELECT * FROM caldav_objects
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]);
+
+-- name: UpsertCaldavObject :exec
+INSERT INTO caldav_objects (todo_id, user_id, name, uid)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (todo_id) DO UPDATE
+SET name = EXCLUDED.name, uid = EXCLUDED.uid;
+
+-- name: ListCollectionTodos :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND project_id IS NOT DISTINCT FROM sqlc.narg(project_id)::int
+ORDER BY todo_id;
+
+-- name: ListTodosByIDs :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[])
+ORDER BY todo_id;
+
+-- name: GetCollectionSyncToken :one
+SELECT COALESCE(MAX(change_id), 0)::bigint AS sync_token FROM caldav_changes
+WHERE user_id = @user_id AND project_id IS NOT DISTINCT FROM sqlc.narg(project_id)::int;
+
+-- name: ListCollectionChanges :many
+SELECT * FROM caldav_changes
+WHERE user_id = @user_id AND project_id IS NOT DISTINCT FROM sqlc.narg(project_id)::int AND change_id > @after
+ORDER BY change_id;
+
+-- name: ListTodoTagNames :many
+SELECT tt.todo_id, t.name FROM todo_tags tt
+JOIN tags t ON t.tag_id = tt.tag_id
+WHERE tt.todo_id = ANY(@todo_ids::int[])
+ORDER BY tt.todo_id, t.name;
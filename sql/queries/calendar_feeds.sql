@@ -0,0 +1,32 @@
+-- name: CreateCalendarFeed :one
+INSERT INTO calendar_feeds (user_id, name, token_hash, project_id, saved_filter_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetCalendarFeed :one
+SELECT * FROM calendar_feeds
+WHERE feed_id = $1;
+
+-- name: GetCalendarFeedByTokenHash :one
+SELECT * FROM calendar_feeds
+WHERE token_hash = $1;
+
+-- name: ListCalendarFeeds :many
+SELECT * FROM calendar_feeds
+WHERE user_id = $1
+ORDER BY created_at, feed_id;
+
+-- name: RotateCalendarFeedToken :one
+UPDATE calendar_feeds
+SET token_hash = $2
+WHERE feed_id = $1
+RETURNING *;
+
+-- name: TouchCalendarFeed :exec
+UPDATE calendar_feeds
+SET last_accessed_at = now()
+WHERE feed_id = $1;
+
+-- name: DeleteCalendarFeed :exec
+DELETE FROM calendar_feeds
+WHERE feed_id = $1;
@@ -25,4 +25,9 @@ RETURNING *;
 
 -- name: DeleteComment :exec
 DELETE FROM comments
-WHERE comment_id = $1;
\ No newline at end of file
+WHERE comment_id = $1;
+
+-- name: MoveTodoComments :execrows
+UPDATE comments
+SET todo_id = @target_todo_id
+WHERE todo_id = @source_todo_id;
@@ -0,0 +1,34 @@
+-- name: ListDigestSubscriptions :many
+SELECT * FROM digest_subscriptions
+WHERE user_id = $1;
+
+-- name: GetDigestSubscription :one
+SELECT * FROM digest_subscriptions
+WHERE user_id = $1 AND kind = $2;
+
+-- name: UpsertDigestSubscription :one
+INSERT INTO digest_subscriptions (user_id, kind, enabled, send_hour, send_weekday, next_send_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, kind) DO UPDATE
+SET enabled = EXCLUDED.enabled, send_hour = EXCLUDED.send_hour,
+    send_weekday = EXCLUDED.send_weekday, next_send_at = EXCLUDED.next_send_at
+RETURNING *;
+
+-- name: DisableDigestSubscription :execrows
+UPDATE digest_subscriptions
+SET enabled = FALSE, next_send_at = NULL
+WHERE user_id = $1 AND kind = $2 AND enabled;
+
+-- name: ClaimDueDigestSubscriptions :many
+SELECT ds.*, u.timezone AS timezone
+FROM digest_subscriptions ds
+JOIN users u ON u.user_id = ds.user_id
+WHERE ds.enabled AND ds.next_send_at <= now()
+ORDER BY ds.next_send_at
+LIMIT @batch_size
+FOR UPDATE OF ds SKIP LOCKED;
+
+-- name: SetDigestNextSend :exec
+UPDATE digest_subscriptions
+SET next_send_at = $3, last_sent_at = now()
+WHERE user_id = $1 AND kind = $2;
@@ -0,0 +1,31 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (user_id, idempotency_key, method, path, request_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, idempotency_key) DO UPDATE
+SET method = EXCLUDED.method,
+    path = EXCLUDED.path,
+    request_hash = EXCLUDED.request_hash,
+    response_status = NULL,
+    response_content_type = NULL,
+    response_body = NULL,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= NOW()
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND idempotency_key = $2 AND expires_at > NOW();
+
+-- name: SaveIdempotencyKeyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $2, response_content_type = $3, response_body = $4
+WHERE idempotency_key_id = $1;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key_id = $1;
+
+-- name: CleanupExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE expires_at < NOW();
@@ -0,0 +1,72 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (kind, payload, run_at, max_attempts, unique_key)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (unique_key) WHERE status IN ('pending', 'running') DO NOTHING
+RETURNING *;
+
+-- name: ClaimJob :one
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, locked_at = now(), locked_by = @locked_by
+WHERE job_id = (
+    SELECT job_id FROM jobs
+    WHERE status = 'pending' AND run_at <= now() AND kind = ANY(@kinds::text[])
+    ORDER BY run_at, job_id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET status = 'completed', finished_at = now(), locked_at = NULL, locked_by = NULL, last_error = NULL
+WHERE job_id = $1;
+
+-- name: RetryJob :exec
+UPDATE jobs
+SET status = 'pending', run_at = @run_at, last_error = @last_error, locked_at = NULL, locked_by = NULL
+WHERE job_id = @job_id;
+
+-- name: KillJob :exec
+UPDATE jobs
+SET status = 'dead', finished_at = now(), last_error = @last_error, locked_at = NULL, locked_by = NULL
+WHERE job_id = @job_id;
+
+-- name: RescueStuckJobs :execrows
+UPDATE jobs
+SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+    finished_at = CASE WHEN attempts >= max_attempts THEN now() END,
+    last_error = 'worker stopped responding', locked_at = NULL, locked_by = NULL
+WHERE status = 'running' AND locked_at < now() - @stuck_after::interval;
+
+-- name: ListDeadJobs :many
+SELECT * FROM jobs
+WHERE status = 'dead'
+ORDER BY finished_at DESC
+LIMIT $1;
+
+-- name: RequeueDeadJob :execrows
+UPDATE jobs
+SET status = 'pending', attempts = 0, run_at = now(), finished_at = NULL, last_error = NULL
+WHERE job_id = $1 AND status = 'dead';
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs
+WHERE (status = 'completed' AND finished_at < now() - @completed_retention::interval)
+    OR (status = 'dead' AND finished_at < now() - @dead_retention::interval);
+
+-- name: UpsertJobSchedule :exec
+INSERT INTO job_schedules (name, spec, next_run_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET spec = EXCLUDED.spec, next_run_at = EXCLUDED.next_run_at
+WHERE job_schedules.spec <> EXCLUDED.spec;
+
+-- name: ClaimDueJobSchedules :many
+SELECT * FROM job_schedules
+WHERE next_run_at <= now() AND name = ANY(@names::text[])
+FOR UPDATE SKIP LOCKED;
+
+-- name: SetJobScheduleNextRun :exec
+UPDATE job_schedules
+SET next_run_at = $2, last_run_at = now()
+WHERE name = $1;
@@ -0,0 +1,51 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, todo_id, actor_user_id, event, title, body)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetNotification :one
+SELECT * FROM notifications
+WHERE notification_id = $1;
+
+-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read_at = COALESCE(read_at, now())
+WHERE notification_id = $1
+RETURNING *;
+
+-- name: MarkNotificationUnread :one
+UPDATE notifications
+SET read_at = NULL
+WHERE notification_id = $1
+RETURNING *;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = now()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: CountUnreadNotifications :many
+SELECT event, count(*) AS count FROM notifications
+WHERE user_id = $1 AND read_at IS NULL
+GROUP BY event;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, event, channel, enabled)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, event, channel) DO UPDATE
+SET enabled = EXCLUDED.enabled;
+
+-- name: GetNotificationSettings :one
+SELECT * FROM notification_settings
+WHERE user_id = $1;
+
+-- name: UpsertNotificationSettings :one
+INSERT INTO notification_settings (user_id, quiet_hours_start, quiet_hours_end)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET quiet_hours_start = EXCLUDED.quiet_hours_start, quiet_hours_end = EXCLUDED.quiet_hours_end
+RETURNING *;
@@ -0,0 +1,81 @@
+-- name: GetPlanningSettings :one
+SELECT * FROM planning_settings
+WHERE user_id = $1;
+
+-- name: UpsertPlanningSettings :one
+INSERT INTO planning_settings (user_id, rollover_mode)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET rollover_mode = EXCLUDED.rollover_mode
+RETURNING *;
+
+-- name: ListDueRollovers :many
+SELECT u.user_id, u.timezone, COALESCE(ps.rollover_mode, 'flag')::text AS rollover_mode
+FROM users u
+LEFT JOIN planning_settings ps ON ps.user_id = u.user_id
+WHERE ps.next_rollover_at IS NULL OR ps.next_rollover_at <= now()
+ORDER BY u.user_id
+LIMIT @batch_size;
+
+-- name: SetNextRollover :exec
+INSERT INTO planning_settings (user_id, next_rollover_at)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET next_rollover_at = EXCLUDED.next_rollover_at;
+
+-- name: MoveRolledOverTodos :execrows
+UPDATE todos
+SET assigned_date = (@today::date + (assigned_date AT TIME ZONE @timezone::text)::time) AT TIME ZONE @timezone::text,
+    rollover_count = rollover_count + 1, last_rolled_over_at = now()
+WHERE user_id = @user_id AND NOT COALESCE(is_completed, FALSE) AND recurrence_rule IS NULL
+    AND assigned_date < @before AND (last_rolled_over_at IS NULL OR last_rolled_over_at < @before);
+
+-- name: FlagRolledOverTodos :execrows
+UPDATE todos
+SET rollover_count = rollover_count + 1, last_rolled_over_at = now()
+WHERE user_id = @user_id AND NOT COALESCE(is_completed, FALSE) AND recurrence_rule IS NULL
+    AND assigned_date < @before AND (last_rolled_over_at IS NULL OR last_rolled_over_at < @before);
+
+-- name: ListPlanningCandidates :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND NOT COALESCE(is_completed, FALSE)
+    AND (assigned_date IS NULL OR assigned_date < @ends_at)
+ORDER BY COALESCE(priority, 0) DESC, created_at, todo_id
+LIMIT @max_results;
+
+-- name: UpsertDailyPlan :one
+INSERT INTO daily_plans (user_id, plan_date, available_minutes)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, plan_date) DO UPDATE
+SET available_minutes = EXCLUDED.available_minutes, committed_at = now()
+RETURNING *;
+
+-- name: GetDailyPlan :one
+SELECT * FROM daily_plans
+WHERE user_id = $1 AND plan_date = $2;
+
+-- name: ListDailyPlans :many
+SELECT * FROM daily_plans
+WHERE user_id = $1
+ORDER BY plan_date DESC
+LIMIT $2;
+
+-- name: DeleteDailyPlanItems :exec
+DELETE FROM daily_plan_items
+WHERE daily_plan_id = $1;
+
+-- name: CreateDailyPlanItem :exec
+INSERT INTO daily_plan_items (daily_plan_id, todo_id, position, planned_minutes)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListDailyPlanItems :many
+SELECT i.*, t.title AS title, t.assigned_date AS assigned_date,
+    (COALESCE(t.is_completed, FALSE) OR EXISTS (
+        SELECT 1 FROM todo_occurrences o
+        WHERE o.todo_id = i.todo_id AND o.completed_at >= dp.committed_at
+    ))::boolean AS done
+FROM daily_plan_items i
+JOIN daily_plans dp ON dp.daily_plan_id = i.daily_plan_id
+JOIN todos t ON t.todo_id = i.todo_id
+WHERE i.daily_plan_id = @daily_plan_id
+ORDER BY i.position;
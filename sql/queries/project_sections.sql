@@ -0,0 +1,52 @@
+-- name: ListProjectSections :many
+SELECT * FROM project_sections
+WHERE project_id = @project_id
+ORDER BY position, section_id;
+
+-- name: GetProjectSection :one
+SELECT * FROM project_sections
+WHERE section_id = @section_id;
+
+-- name: CreateProjectSection :one
+INSERT INTO project_sections (project_id, user_id, name, position)
+VALUES (@project_id, @user_id, @name, @position)
+RETURNING *;
+
+-- name: UpdateProjectSection :one
+UPDATE project_sections
+SET name = @name, is_collapsed = @is_collapsed
+WHERE section_id = @section_id
+RETURNING *;
+
+-- name: SetProjectSectionPosition :exec
+UPDATE project_sections
+SET position = @position
+WHERE section_id = @section_id;
+
+-- name: DeleteProjectSection :exec
+DELETE FROM project_sections
+WHERE section_id = @section_id;
+
+-- name: MoveProjectSection :one
+UPDATE project_sections
+SET project_id = @project_id, position = @position
+WHERE section_id = @section_id
+RETURNING *;
+
+-- name: MoveSectionTodos :execrows
+WITH RECURSIVE moved AS (
+    SELECT todo_id FROM todos
+    WHERE section_id = @section_id::int
+    UNION
+    SELECT t.todo_id FROM todos t
+    JOIN moved m ON t.parent_todo_id = m.todo_id
+)
+UPDATE todos
+SET project_id = @project_id::int
+WHERE todo_id IN (SELECT todo_id FROM moved);
+
+-- name: SetTodoSection :one
+UPDATE todos
+SET section_id = @section_id, position = @position
+WHERE todo_id = @todo_id
+RETURNING *;
@@ -0,0 +1,64 @@
+-- name: ListProjectStatuses :many
+SELECT * FROM project_statuses
+WHERE project_id = @project_id
+ORDER BY position, status_id;
+
+-- name: GetProjectStatus :one
+SELECT * FROM project_statuses
+WHERE status_id = @status_id;
+
+-- name: CreateProjectStatus :one
+INSERT INTO project_statuses (project_id, user_id, name, category, wip_limit, position)
+VALUES (
+    @project_id, @user_id, @name, @category, @wip_limit,
+    (SELECT COALESCE(MAX(s.position) + 1, 0)::int FROM project_statuses s WHERE s.project_id = @project_id)
+)
+RETURNING *;
+
+-- name: UpdateProjectStatus :one
+UPDATE project_statuses
+SET name = @name, category = @category, wip_limit = @wip_limit
+WHERE status_id = @status_id
+RETURNING *;
+
+-- name: SetProjectStatusPosition :exec
+UPDATE project_statuses
+SET position = @position
+WHERE status_id = @status_id;
+
+-- name: DeleteProjectStatus :exec
+DELETE FROM project_statuses
+WHERE status_id = @status_id;
+
+-- name: CountStatusTodos :one
+SELECT COUNT(*) FROM todos
+WHERE status_id = @status_id;
+
+-- name: MoveStatusTodos :execrows
+UPDATE todos
+SET status_id = @to_status_id
+WHERE status_id = @from_status_id;
+
+-- name: ResyncProjectTodoStatuses :execrows
+-- Clearing the status of todos whose status no longer fits has the
+-- sync_todos_status trigger pick a new one
+UPDATE todos t
+SET status_id = NULL
+WHERE t.project_id = @project_id
+  AND NOT EXISTS (
+      SELECT 1 FROM project_statuses s
+      WHERE s.status_id = t.status_id
+        AND s.project_id = t.project_id
+        AND (s.category = 'done') = COALESCE(t.is_completed, FALSE)
+  );
+
+-- name: SetTodoStatus :one
+UPDATE todos
+SET status_id = @status_id
+WHERE todo_id = @todo_id
+RETURNING *;
+
+-- name: ListProjectTodos :many
+SELECT * FROM todos
+WHERE project_id = @project_id
+ORDER BY position, todo_id;
@@ -1,21 +1,27 @@
 -- name: CreateProject :one
-INSERT INTO projects (user_id, parent_project_id, name, description, color)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO projects (user_id, parent_project_id, name, description, color, position)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetProject :one
 SELECT * FROM projects
 WHERE project_id = $1;
 
+-- name: GetProjectByName :one
+SELECT * FROM projects
+WHERE user_id = $1 AND lower(name) = lower(@name::text)
+ORDER BY created_at ASC
+LIMIT 1;
+
 -- name: ListProjects :many
 SELECT * FROM projects
 WHERE user_id = $1
-ORDER BY created_at DESC;
+ORDER BY position, project_id;
 
 -- name: ListProjectsByParent :many
 SELECT * FROM projects
 WHERE user_id = $1 AND parent_project_id = $2
-ORDER BY created_at DESC;
+ORDER BY position, project_id;
 
 -- name: UpdateProject :one
 UPDATE projects
@@ -25,4 +31,18 @@ RETURNING *;
 
 -- name: DeleteProject :exec
 DELETE FROM projects
-WHERE project_id = $1;
\ No newline at end of file
+WHERE project_id = $1;
+
+-- name: FirstProjectPosition :one
+SELECT COALESCE(MIN(position), '')::text AS position FROM projects
+WHERE user_id = @user_id AND parent_project_id IS NOT DISTINCT FROM sqlc.narg(parent_project_id)::int;
+
+-- name: ListProjectSiblings :many
+SELECT project_id, position FROM projects
+WHERE user_id = @user_id AND parent_project_id IS NOT DISTINCT FROM sqlc.narg(parent_project_id)::int
+ORDER BY position, project_id;
+
+-- name: SetProjectPosition :exec
+UPDATE projects
+SET position = @position
+WHERE project_id = @project_id;
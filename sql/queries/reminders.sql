@@ -0,0 +1,59 @@
+-- name: CreateReminder :one
+INSERT INTO reminders (user_id, todo_id, remind_at, offset_minutes, channel, webhook_url)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetReminder :one
+SELECT * FROM reminders
+WHERE reminder_id = $1;
+
+-- name: ListReminders :many
+SELECT * FROM reminders
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListRemindersByTodo :many
+SELECT * FROM reminders
+WHERE todo_id = $1
+ORDER BY created_at ASC;
+
+-- name: UpdateReminder :one
+UPDATE reminders
+SET remind_at = $2, offset_minutes = $3, channel = $4, webhook_url = $5,
+    fired_for = NULL, attempts = 0, next_attempt_at = NULL, last_error = NULL
+WHERE reminder_id = $1
+RETURNING *;
+
+-- name: DeleteReminder :exec
+DELETE FROM reminders
+WHERE reminder_id = $1;
+
+-- name: ClaimDueReminders :many
+SELECT r.*, t.title AS todo_title, u.email AS user_email,
+    COALESCE(r.remind_at, t.assigned_date - make_interval(mins => r.offset_minutes))::timestamptz AS due_at
+FROM reminders r
+JOIN todos t ON t.todo_id = r.todo_id
+JOIN users u ON u.user_id = r.user_id
+WHERE t.is_completed = false
+    AND (r.next_attempt_at IS NULL OR r.next_attempt_at <= now())
+    AND COALESCE(r.remind_at, t.assigned_date - make_interval(mins => r.offset_minutes)) <= now()
+    AND COALESCE(r.remind_at, t.assigned_date - make_interval(mins => r.offset_minutes)) > now() - @max_lateness::interval
+    AND r.fired_for IS DISTINCT FROM COALESCE(r.remind_at, t.assigned_date - make_interval(mins => r.offset_minutes))
+ORDER BY due_at
+LIMIT @batch_size
+FOR UPDATE OF r SKIP LOCKED;
+
+-- name: LeaseReminders :exec
+UPDATE reminders
+SET next_attempt_at = @lease_until
+WHERE reminder_id = ANY(@reminder_ids::int[]);
+
+-- name: MarkReminderFired :exec
+UPDATE reminders
+SET fired_for = @fired_for, last_fired_at = now(), attempts = 0, next_attempt_at = NULL, last_error = @last_error
+WHERE reminder_id = @reminder_id;
+
+-- name: MarkReminderFailed :exec
+UPDATE reminders
+SET attempts = attempts + 1, last_error = @last_error, next_attempt_at = @next_attempt_at
+WHERE reminder_id = @reminder_id;
@@ -0,0 +1,23 @@
+-- name: CreateSavedFilter :one
+INSERT INTO saved_filters (user_id, name, color, query, sort_by, sort_order, group_by, is_pinned)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetSavedFilter :one
+SELECT * FROM saved_filters
+WHERE saved_filter_id = $1;
+
+-- name: ListSavedFilters :many
+SELECT * FROM saved_filters
+WHERE user_id = $1
+ORDER BY is_pinned DESC, name ASC;
+
+-- name: UpdateSavedFilter :one
+UPDATE saved_filters
+SET name = $2, color = $3, query = $4, sort_by = $5, sort_order = $6, group_by = $7, is_pinned = $8
+WHERE saved_filter_id = $1
+RETURNING *;
+
+-- name: DeleteSavedFilter :exec
+DELETE FROM saved_filters
+WHERE saved_filter_id = $1;
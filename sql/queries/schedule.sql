@@ -0,0 +1,61 @@
+-- name: ListWorkingHours :many
+SELECT * FROM working_hours
+WHERE user_id = $1
+ORDER BY weekday, start_time;
+
+-- name: DeleteWorkingHours :exec
+DELETE FROM working_hours
+WHERE user_id = $1;
+
+-- name: CreateWorkingHours :exec
+INSERT INTO working_hours (user_id, weekday, start_time, end_time)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListScheduleBlocks :many
+SELECT sb.*, t.title AS todo_title, COALESCE(t.is_completed, FALSE)::boolean AS todo_completed
+FROM schedule_blocks sb
+JOIN todos t ON t.todo_id = sb.todo_id
+WHERE sb.user_id = @user_id AND sb.ends_at > @starts_at AND sb.starts_at < @ends_at
+ORDER BY sb.starts_at, sb.block_id;
+
+-- name: ListFixedScheduleBlocks :many
+SELECT sb.*
+FROM schedule_blocks sb
+JOIN todos t ON t.todo_id = sb.todo_id
+WHERE sb.user_id = @user_id AND (sb.status = 'accepted' OR sb.pinned) AND NOT COALESCE(t.is_completed, FALSE)
+ORDER BY sb.starts_at;
+
+-- name: GetScheduleBlock :one
+SELECT * FROM schedule_blocks
+WHERE block_id = $1;
+
+-- name: CreateScheduleBlock :one
+INSERT INTO schedule_blocks (user_id, todo_id, starts_at, ends_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpdateScheduleBlock :one
+UPDATE schedule_blocks
+SET starts_at = $2, ends_at = $3, pinned = $4
+WHERE block_id = $1
+RETURNING *;
+
+-- name: DeleteScheduleBlock :exec
+DELETE FROM schedule_blocks
+WHERE block_id = $1;
+
+-- name: DeleteProposedScheduleBlocks :execrows
+DELETE FROM schedule_blocks
+WHERE user_id = $1 AND status = 'proposed' AND NOT pinned;
+
+-- name: AcceptScheduleBlocks :many
+UPDATE schedule_blocks
+SET status = 'accepted'
+WHERE user_id = @user_id AND status = 'proposed' AND (block_id = ANY(@block_ids::int[]) OR cardinality(@block_ids::int[]) = 0)
+RETURNING *;
+
+-- name: SetTodoAssignedDate :one
+UPDATE todos
+SET assigned_date = @assigned_date
+WHERE todo_id = @todo_id
+RETURNING *;
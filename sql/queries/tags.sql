@@ -14,7 +14,9 @@ ORDER BY name ASC;
 
 -- name: GetTagByName :one
 SELECT * FROM tags
-WHERE user_id = $1 AND name = $2;
+WHERE user_id = $1 AND lower(name) = lower(@name::text)
+ORDER BY created_at ASC, tag_id
+LIMIT 1;
 
 -- name: UpdateTag :one
 UPDATE tags
@@ -0,0 +1,61 @@
+-- name: CreateTodoDependency :execrows
+INSERT INTO todo_dependencies (todo_id, depends_on_id, user_id)
+VALUES (@todo_id, @depends_on_id, @user_id)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTodoDependency :execrows
+DELETE FROM todo_dependencies
+WHERE todo_id = @todo_id AND depends_on_id = @depends_on_id;
+
+-- name: DeleteTodoDependencies :exec
+DELETE FROM todo_dependencies
+WHERE todo_id = @todo_id OR depends_on_id = @todo_id;
+
+-- name: TodoDependsOn :one
+-- Whether todo_id depends on depends_on_id, directly or through other todos
+WITH RECURSIVE prerequisites (todo_id) AS (
+    SELECT d.depends_on_id FROM todo_dependencies d
+    WHERE d.todo_id = @todo_id
+    UNION
+    SELECT d.depends_on_id FROM todo_dependencies d
+    JOIN prerequisites p ON d.todo_id = p.todo_id
+)
+SELECT EXISTS (
+    SELECT 1 FROM prerequisites WHERE prerequisites.todo_id = @depends_on_id::int
+)::boolean AS depends;
+
+-- name: ListTodoPrerequisites :many
+SELECT t.* FROM todos t
+JOIN todo_dependencies d ON d.depends_on_id = t.todo_id
+WHERE d.todo_id = @todo_id
+ORDER BY t.todo_id;
+
+-- name: ListTodoDependents :many
+SELECT t.* FROM todos t
+JOIN todo_dependencies d ON d.todo_id = t.todo_id
+WHERE d.depends_on_id = @todo_id
+ORDER BY t.todo_id;
+
+-- name: ListOpenDependencyEdges :many
+-- The dependencies between open todos that touch any of todo_ids
+SELECT d.todo_id, d.depends_on_id FROM todo_dependencies d
+JOIN todos t ON t.todo_id = d.todo_id
+JOIN todos p ON p.todo_id = d.depends_on_id
+WHERE (d.todo_id = ANY(@todo_ids::int[]) OR d.depends_on_id = ANY(@todo_ids::int[]))
+  AND NOT COALESCE(t.is_completed, FALSE)
+  AND NOT COALESCE(p.is_completed, FALSE)
+ORDER BY d.todo_id, d.depends_on_id;
+
+-- name: ListUserOpenDependencies :many
+SELECT d.todo_id, d.depends_on_id FROM todo_dependencies d
+JOIN todos t ON t.todo_id = d.todo_id
+JOIN todos p ON p.todo_id = d.depends_on_id
+WHERE d.user_id = @user_id
+  AND NOT COALESCE(t.is_completed, FALSE)
+  AND NOT COALESCE(p.is_completed, FALSE)
+ORDER BY d.todo_id, d.depends_on_id;
+
+-- name: ListProjectOpenTodos :many
+SELECT * FROM todos
+WHERE project_id = @project_id AND NOT COALESCE(is_completed, FALSE)
+ORDER BY todo_id;
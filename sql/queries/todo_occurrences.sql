@@ -0,0 +1,16 @@
+-- name: CreateTodoOccurrence :one
+INSERT INTO todo_occurrences (todo_id, user_id, occurrence_date)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListTodoOccurrences :many
+SELECT * FROM todo_occurrences
+WHERE todo_id = $1
+ORDER BY occurrence_date DESC;
+
+-- name: ListTodoOccurrencesBetween :many
+SELECT o.*, t.title AS todo_title, t.duration_min AS duration_min, t.priority AS priority
+FROM todo_occurrences o
+JOIN todos t ON t.todo_id = o.todo_id
+WHERE o.user_id = @user_id AND o.occurrence_date >= @starts_at AND o.occurrence_date < @ends_at
+ORDER BY o.occurrence_date, o.todo_occurrence_id;
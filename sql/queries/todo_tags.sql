@@ -32,4 +32,19 @@ WHERE todo_id = $1;
 
 -- name: DeleteAllTagTodos :exec
 DELETE FROM todo_tags
-WHERE tag_id = $1;
\ No newline at end of file
+WHERE tag_id = $1;
+
+-- name: BulkCreateTodoTags :execrows
+INSERT INTO todo_tags (todo_id, tag_id)
+SELECT unnest(@todo_ids::int[]), @tag_id::int
+ON CONFLICT DO NOTHING;
+
+-- name: BulkDeleteTodoTags :execrows
+DELETE FROM todo_tags
+WHERE tag_id = @tag_id AND todo_id = ANY(@todo_ids::int[]);
+
+-- name: CopyTodoTags :exec
+INSERT INTO todo_tags (todo_id, tag_id)
+SELECT @target_todo_id::int, tag_id FROM todo_tags
+WHERE todo_id = @source_todo_id
+ON CONFLICT DO NOTHING;
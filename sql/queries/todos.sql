@@ -1,6 +1,6 @@
 -- name: CreateTodo :one
-INSERT INTO todos (user_id, project_id, parent_todo_id, title, description, assigned_date, duration_min, priority)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO todos (user_id, project_id, parent_todo_id, title, description, assigned_date, duration_min, priority, deadline, position, section_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: GetTodo :one
@@ -10,17 +10,17 @@ WHERE todo_id = $1;
 -- name: ListTodos :many
 SELECT * FROM todos
 WHERE user_id = $1
-ORDER BY created_at DESC;
+ORDER BY position, todo_id;
 
 -- name: ListTodosByProject :many
 SELECT * FROM todos
 WHERE user_id = $1 AND project_id = $2
-ORDER BY created_at DESC;
+ORDER BY position, todo_id;
 
 -- name: ListTodosByParent :many
 SELECT * FROM todos
 WHERE user_id = $1 AND parent_todo_id = $2
-ORDER BY created_at DESC;
+ORDER BY position, todo_id;
 
 -- name: ListCompletedTodos :many
 SELECT * FROM todos
@@ -30,7 +30,7 @@ ORDER BY completed_at DESC;
 -- name: ListPendingTodos :many
 SELECT * FROM todos
 WHERE user_id = $1 AND is_completed = false
-ORDER BY created_at DESC;
+ORDER BY position, todo_id;
 
 -- name: UpdateTodo :one
 UPDATE todos
@@ -52,4 +52,143 @@ RETURNING *;
 
 -- name: DeleteTodo :exec
 DELETE FROM todos
-WHERE todo_id = $1;
\ No newline at end of file
+WHERE todo_id = $1;
+
+-- name: ListOwnedTodoIDs :many
+SELECT todo_id FROM todos
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]);
+
+-- name: CountTodoAncestorsInSet :one
+WITH RECURSIVE ancestors AS (
+    SELECT todo_id, parent_todo_id FROM todos
+    WHERE todo_id = @todo_id
+    UNION
+    SELECT t.todo_id, t.parent_todo_id FROM todos t
+    JOIN ancestors a ON t.todo_id = a.parent_todo_id
+)
+SELECT count(*) FROM ancestors
+WHERE todo_id = ANY(@todo_ids::int[]);
+
+-- name: BulkCompleteTodos :execrows
+UPDATE todos
+SET is_completed = true
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]) AND is_completed = false;
+
+-- name: BulkUncompleteTodos :execrows
+UPDATE todos
+SET is_completed = false
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]) AND is_completed = true;
+
+-- name: FirstTodoPosition :one
+SELECT COALESCE(MIN(position), '')::text AS position FROM todos
+WHERE user_id = @user_id AND project_id IS NOT DISTINCT FROM sqlc.narg(project_id)::int AND parent_todo_id IS NOT DISTINCT FROM sqlc.narg(parent_todo_id)::int AND section_id IS NOT DISTINCT FROM sqlc.narg(section_id)::int;
+
+-- name: ListTodoSiblings :many
+SELECT todo_id, position FROM todos
+WHERE user_id = @user_id AND project_id IS NOT DISTINCT FROM sqlc.narg(project_id)::int AND parent_todo_id IS NOT DISTINCT FROM sqlc.narg(parent_todo_id)::int AND section_id IS NOT DISTINCT FROM sqlc.narg(section_id)::int
+ORDER BY position, todo_id;
+
+-- name: SetTodoPosition :exec
+UPDATE todos
+SET position = @position
+WHERE todo_id = @todo_id;
+
+-- name: BulkMoveTodos :execrows
+UPDATE todos
+SET project_id = sqlc.narg(project_id), parent_todo_id = sqlc.narg(parent_todo_id)
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]);
+
+-- name: SetDescendantProjects :exec
+WITH RECURSIVE moved AS (
+    SELECT todo_id FROM todos
+    WHERE parent_todo_id = ANY(@todo_ids::int[])
+    UNION
+    SELECT t.todo_id FROM todos t
+    JOIN moved m ON t.parent_todo_id = m.todo_id
+)
+UPDATE todos
+SET project_id = sqlc.narg(project_id)
+WHERE todo_id IN (SELECT todo_id FROM moved);
+
+-- name: BulkShiftTodoAssignedDates :execrows
+UPDATE todos
+SET assigned_date = assigned_date + @shift::interval
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]) AND assigned_date IS NOT NULL;
+
+-- name: BulkSetTodoPriority :execrows
+UPDATE todos
+SET priority = @priority::int
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]);
+
+-- name: BulkDeleteTodos :execrows
+DELETE FROM todos
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]);
+
+-- name: FindSimilarTodos :many
+SELECT t.*, similarity(t.title, @title::text)::real AS similarity
+FROM todos t
+WHERE t.user_id = @user_id AND t.is_completed = false AND t.todo_id <> @exclude_todo_id AND t.title % @title::text
+ORDER BY similarity DESC, t.todo_id
+LIMIT @max_results;
+
+-- name: MoveSubtasks :execrows
+UPDATE todos
+SET parent_todo_id = @target_todo_id::int
+WHERE parent_todo_id = @source_todo_id::int;
+
+-- name: SetTodoRecurrence :one
+UPDATE todos
+SET recurrence_rule = @recurrence_rule, recurrence_mode = @recurrence_mode, recurrence_start = @recurrence_start, recurrence_count = 0, assigned_date = @assigned_date
+WHERE todo_id = @todo_id
+RETURNING *;
+
+-- name: ClearTodoRecurrence :one
+UPDATE todos
+SET recurrence_rule = NULL, recurrence_start = NULL, recurrence_count = 0
+WHERE todo_id = @todo_id
+RETURNING *;
+
+-- name: AdvanceRecurringTodo :one
+UPDATE todos
+SET assigned_date = @assigned_date, recurrence_count = recurrence_count + 1
+WHERE todo_id = @todo_id
+RETURNING *;
+
+-- name: ListRecurringTodosForUpdate :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND todo_id = ANY(@todo_ids::int[]) AND recurrence_rule IS NOT NULL AND is_completed = false
+FOR UPDATE;
+
+-- name: ListOpenTodosBetween :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND NOT COALESCE(is_completed, FALSE)
+    AND assigned_date >= @starts_at AND assigned_date < @ends_at
+ORDER BY assigned_date, COALESCE(priority, 0) DESC, todo_id
+LIMIT @max_results;
+
+-- name: ListOpenTodosBefore :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND NOT COALESCE(is_completed, FALSE)
+    AND assigned_date < @ends_at
+ORDER BY assigned_date, COALESCE(priority, 0) DESC, todo_id
+LIMIT @max_results;
+
+-- name: ListTodosCompletedBetween :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND COALESCE(is_completed, FALSE)
+    AND completed_at >= @starts_at AND completed_at < @ends_at
+ORDER BY completed_at, todo_id
+LIMIT @max_results;
+
+-- name: ListTodosAssignedBetween :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND recurrence_rule IS NULL
+    AND assigned_date >= @starts_at AND assigned_date < @ends_at
+ORDER BY assigned_date, todo_id
+LIMIT @max_results;
+
+-- name: ListRecurringTodosStartedBefore :many
+SELECT * FROM todos
+WHERE user_id = @user_id AND recurrence_rule IS NOT NULL AND NOT COALESCE(is_completed, FALSE)
+    AND recurrence_start < @ends_at
+ORDER BY todo_id;
@@ -27,4 +27,15 @@ RETURNING *;
 
 -- name: DeleteUser :exec
 DELETE FROM users
-WHERE user_id = $1;
\ No newline at end of file
+WHERE user_id = $1;
+
+-- name: UpdateUserTimezone :one
+UPDATE users
+SET timezone = $2
+WHERE user_id = $1
+RETURNING *;
+
+-- name: LockUser :exec
+SELECT user_id FROM users
+WHERE user_id = $1
+FOR UPDATE;
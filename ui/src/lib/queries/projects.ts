@@ -1,13 +1,25 @@
-import type { Project } from "../types";
+import type { Page, Project } from "../types";
 import { listProjectsKeys } from "./keys";
 
 export const projectQueries = {
   listProjects: () => ({
     queryKey: listProjectsKeys,
     queryFn: async () => {
-      return await fetch("/api/projects", {
-        credentials: "include",
-      }).then((res) => res.json() as Promise<Project[]>);
+      // The sidebar needs every project, so follow the cursor to the end
+      const projects: Project[] = [];
+      let cursor: string | null = null;
+      do {
+        const params = new URLSearchParams({ limit: "200" });
+        if (cursor) {
+          params.set("cursor", cursor);
+        }
+        const page = await fetch(`/api/projects?${params}`, {
+          credentials: "include",
+        }).then((res) => res.json() as Promise<Page<Project>>);
+        projects.push(...page.items);
+        cursor = page.next_cursor;
+      } while (cursor);
+      return projects;
     },
   }),
 };
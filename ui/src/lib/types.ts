@@ -11,3 +11,8 @@ export type Project = {
   color: string;
   parent_project_id: number | null;
 };
+
+export type Page<T> = {
+  items: T[];
+  next_cursor: string | null;
+};